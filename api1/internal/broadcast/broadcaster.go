@@ -0,0 +1,155 @@
+// Package broadcast fans committed book changes out to the live
+// subscribers of this process.
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// Config sizes the replay buffer each tenant keeps and the queue of each
+// subscriber. Zero values fall back to the defaults below.
+type Config struct {
+	Replay int
+	Queue  int
+}
+
+const (
+	defaultReplay = 1024
+	defaultQueue  = 64
+)
+
+// Broadcaster is an in-process domain.BookChangePublisher and
+// domain.BookChangeFeed. Publishing never waits for a subscriber: one
+// whose queue is full is dropped and has to reconnect, resuming from the
+// replay buffer if it is not too far behind. Each tenant has its own
+// buffer, so a busy tenant cannot push a quiet one's changes out.
+type Broadcaster struct {
+	mu     sync.Mutex
+	cfg    Config
+	replay map[string][]domain.BookChange
+	subs   map[*subscription]struct{}
+	closed bool
+}
+
+func New(cfg Config) *Broadcaster {
+	if cfg.Replay <= 0 {
+		cfg.Replay = defaultReplay
+	}
+	if cfg.Queue <= 0 {
+		cfg.Queue = defaultQueue
+	}
+	return &Broadcaster{cfg: cfg, replay: make(map[string][]domain.BookChange), subs: make(map[*subscription]struct{})}
+}
+
+func (b *Broadcaster) PublishBookChange(ctx context.Context, c domain.BookChange) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := append(b.replay[c.TenantID], c)
+	if n := len(replay); n > b.cfg.Replay {
+		replay = replay[n-b.cfg.Replay:]
+	}
+	b.replay[c.TenantID] = replay
+	for s := range b.subs {
+		if !s.wants(c) {
+			continue
+		}
+		select {
+		case s.ch <- c:
+		default:
+			s.dropped.Store(true)
+			b.remove(s)
+		}
+	}
+	return nil
+}
+
+// Subscribe replays the buffered changes after lastID before the live
+// ones. If lastID is set but no longer buffered, nothing is replayed and
+// the subscription reports a gap.
+func (b *Broadcaster) Subscribe(ctx context.Context, f domain.BookChangeFilter, lastID string) (domain.BookChangeSubscription, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &subscription{b: b, tenant: tenant, filter: f}
+	var backlog []domain.BookChange
+	if lastID != "" {
+		s.gap = true
+		replay := b.replay[tenant]
+		for i := len(replay) - 1; i >= 0; i-- {
+			if replay[i].ID == lastID {
+				s.gap = false
+				for _, c := range replay[i+1:] {
+					if s.wants(c) {
+						backlog = append(backlog, c)
+					}
+				}
+				break
+			}
+		}
+	}
+	s.ch = make(chan domain.BookChange, b.cfg.Queue+len(backlog))
+	for _, c := range backlog {
+		s.ch <- c
+	}
+	if b.closed {
+		close(s.ch)
+		return s, nil
+	}
+	b.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Close ends every subscription, so streams finish and a server can shut
+// down. Later subscriptions end at once.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+// remove unregisters s and closes its queue. Callers hold b.mu.
+func (b *Broadcaster) remove(s *subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+}
+
+type subscription struct {
+	b       *Broadcaster
+	tenant  string
+	filter  domain.BookChangeFilter
+	ch      chan domain.BookChange
+	gap     bool
+	dropped atomic.Bool
+}
+
+func (s *subscription) wants(c domain.BookChange) bool {
+	return c.TenantID == s.tenant && s.filter.Match(c)
+}
+
+func (s *subscription) Changes() <-chan domain.BookChange { return s.ch }
+func (s *subscription) Gap() bool                         { return s.gap }
+func (s *subscription) Dropped() bool                     { return s.dropped.Load() }
+
+func (s *subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.remove(s)
+}
@@ -0,0 +1,134 @@
+package broadcast_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/broadcast"
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+func change(id, tenant, author string, categories ...string) domain.BookChange {
+	return domain.BookChange{
+		ID:         id,
+		TenantID:   tenant,
+		Type:       domain.EventBookUpdated,
+		BookID:     1,
+		Book:       &domain.Book{ID: 1, Title: "Dune", Author: author},
+		Categories: categories,
+	}
+}
+
+// drain returns the IDs of the changes queued on sub without waiting.
+func drain(sub domain.BookChangeSubscription) []string {
+	var ids []string
+	for {
+		select {
+		case c, ok := <-sub.Changes():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, c.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestBroadcaster_DeliversMatchingChangesOfTenant(t *testing.T) {
+	// Arrange
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	b := broadcast.New(broadcast.Config{})
+	all, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+	scifi, err := b.Subscribe(ctx, domain.BookChangeFilter{CategoryPath: "/1/"}, "")
+	require.NoError(t, err)
+
+	// Act
+	for _, c := range []domain.BookChange{
+		change("a", "acme", "Frank Herbert", "/1/4/"),
+		change("b", "globex", "Frank Herbert", "/1/"),
+		change("c", "acme", "Jane Austen", "/2/"),
+	} {
+		require.NoError(t, b.PublishBookChange(ctx, c))
+	}
+
+	// Assert
+	assert.Equal(t, []string{"a", "c"}, drain(all))
+	assert.Equal(t, []string{"a"}, drain(scifi))
+	assert.False(t, all.Gap())
+}
+
+func TestBroadcaster_ReplaysAfterLastID(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	b := broadcast.New(broadcast.Config{Replay: 2})
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, b.PublishBookChange(ctx, change(id, "acme", "Frank Herbert")))
+	}
+
+	resumed, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "b")
+	require.NoError(t, err)
+	assert.False(t, resumed.Gap())
+	assert.Equal(t, []string{"c"}, drain(resumed))
+
+	// "a" fell out of the buffer, so what came after it is unknown.
+	late, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "a")
+	require.NoError(t, err)
+	assert.True(t, late.Gap())
+	assert.Empty(t, drain(late))
+}
+
+func TestBroadcaster_ReplayIsPerTenant(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	b := broadcast.New(broadcast.Config{Replay: 2})
+	require.NoError(t, b.PublishBookChange(ctx, change("a", "acme", "Frank Herbert")))
+	require.NoError(t, b.PublishBookChange(ctx, change("b", "acme", "Frank Herbert")))
+	for _, id := range []string{"x", "y", "z"} {
+		require.NoError(t, b.PublishBookChange(ctx, change(id, "globex", "Jane Austen")))
+	}
+
+	// globex filled its own buffer, not acme's.
+	resumed, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "a")
+	require.NoError(t, err)
+	assert.False(t, resumed.Gap())
+	assert.Equal(t, []string{"b"}, drain(resumed))
+}
+
+func TestBroadcaster_DropsSlowSubscriber(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	b := broadcast.New(broadcast.Config{Queue: 1})
+	slow, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, b.PublishBookChange(ctx, change("a", "acme", "Frank Herbert")))
+	require.NoError(t, b.PublishBookChange(ctx, change("b", "acme", "Frank Herbert")))
+
+	assert.Equal(t, []string{"a"}, drain(slow))
+	_, open := <-slow.Changes()
+	assert.False(t, open)
+	assert.True(t, slow.Dropped())
+}
+
+func TestBroadcaster_CloseEndsSubscriptions(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	b := broadcast.New(broadcast.Config{})
+	sub, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+
+	b.Close()
+	_, open := <-sub.Changes()
+	assert.False(t, open)
+	assert.False(t, sub.Dropped())
+	sub.Close()
+
+	_, err = b.Subscribe(context.Background(), domain.BookChangeFilter{}, "")
+	assert.Error(t, err, "a tenant is required")
+	after, err := b.Subscribe(ctx, domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+	_, open = <-after.Changes()
+	assert.False(t, open)
+}
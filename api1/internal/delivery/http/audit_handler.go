@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// AuditHandler lets a tenant read its own audit log. Mount it behind
+// WithTenant.
+type AuditHandler struct {
+	uc usecase.AuditUsecase
+}
+
+func NewAuditHandler(uc usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{uc: uc}
+}
+
+func (h *AuditHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/audit", h.ListAudit, readTimeout},
+	}
+}
+
+// ListAudit serves GET /v1/audit?entity=book&id=1&actor=&action=&limit=&offset=
+// with the records of the caller's tenant.
+func (h *AuditHandler) ListAudit(ctx context.Context, req *Request) Response {
+	return listAudit(ctx, h.uc, req)
+}
+
+// AuditAdminHandler lets operators read the audit log of any tenant. Mount
+// it behind RequireAdmin.
+type AuditAdminHandler struct {
+	uc usecase.AuditUsecase
+}
+
+func NewAuditAdminHandler(uc usecase.AuditUsecase) *AuditAdminHandler {
+	return &AuditAdminHandler{uc: uc}
+}
+
+func (h *AuditAdminHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/admin/tenants/{id}/audit", h.ListAudit, readTimeout},
+	}
+}
+
+// ListAudit serves GET /v1/admin/tenants/{id}/audit with the records of
+// that tenant, taking the query of AuditHandler.ListAudit.
+func (h *AuditAdminHandler) ListAudit(ctx context.Context, req *Request) Response {
+	return listAudit(tenancy.WithTenant(ctx, req.Params["id"]), h.uc, req)
+}
+
+// listAudit lists the records of the tenant in ctx that the query of req
+// asks for.
+func listAudit(ctx context.Context, uc usecase.AuditUsecase, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	q := domain.AuditQuery{
+		EntityType: req.Query.Get("entity"),
+		Actor:      req.Query.Get("actor"),
+		Action:     domain.AuditAction(req.Query.Get("action")),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if v := req.Query.Get("id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errorBody(http.StatusBadRequest, "invalid id")
+		}
+		q.EntityID = id
+	}
+
+	records, err := uc.ListAudit(ctx, q)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: records}
+}
@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+func TestListAudit_CallersTenant(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockAuditUsecase(ctrl)
+			uc.EXPECT().
+				ListAudit(gomock.Any(), domain.AuditQuery{EntityType: "book", EntityID: 3}).
+				DoAndReturn(func(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+					tenant, _ := tenancy.FromContext(ctx)
+					assert.Equal(t, "acme", tenant)
+					return []*domain.AuditRecord{{ID: 1, TenantID: tenant, EntityType: "book", EntityID: 3}}, nil
+				})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/audit?entity=book&id=3", nil)
+			res := do(t, signedIn(t, req, "alice", httpdelivery.NewAuditHandler(uc)), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestListAudit_AdminQueryParams(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockAuditUsecase(ctrl)
+			uc.EXPECT().
+				ListAudit(gomock.Any(), domain.AuditQuery{EntityType: "book", EntityID: 3, Action: domain.AuditUpdate, Limit: 20}).
+				DoAndReturn(func(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+					tenant, _ := tenancy.FromContext(ctx)
+					assert.Equal(t, "acme", tenant)
+					return []*domain.AuditRecord{{ID: 1, TenantID: tenant, EntityType: "book", EntityID: 3}}, nil
+				})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/tenants/acme/audit?entity=book&id=3&action=update&limit=20", nil)
+			res := do(t, httpdelivery.NewAuditAdminHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestDeleteBook_PassesRequestMeta(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().DeleteBook(gomock.Any(), int64(5)).DoAndReturn(func(ctx context.Context, id int64) error {
+				meta := requestmeta.FromContext(ctx)
+				assert.Equal(t, "librarian", meta.Actor)
+				assert.Equal(t, "req-42", meta.RequestID)
+				assert.NotEmpty(t, meta.ClientIP)
+				return nil
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/v1/books/5", nil)
+			req.Header.Set("X-Request-ID", "req-42")
+			res := do(t, signedIn(t, req, "librarian", httpdelivery.NewBookHandler(uc)), req)
+
+			assert.Equal(t, http.StatusNoContent, res.StatusCode)
+			assert.Equal(t, "req-42", res.Header.Get("X-Request-ID"))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"fmt"
+	"io"
+
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// MaxBody caps the request bodies of every route but those in fileLimits.
+// They are JSON, and a small document at that.
+const MaxBody = 1 << 20
+
+// fileLimits are the routes that take a whole file as their body, with the
+// most they take. The usecases behind them check the same limits; these
+// stop the adapters reading on regardless.
+var fileLimits = map[string]int64{
+	"POST /v1/imports":         usecase.MaxImportBytes,
+	"PUT /v1/books/{id}/cover": usecase.MaxCoverBytes,
+}
+
+// bodyLimit is the most of a request body rt reads.
+func (rt route) bodyLimit() int64 {
+	if n, ok := fileLimits[rt.method+" "+rt.path]; ok {
+		return n
+	}
+	return MaxBody
+}
+
+// limitedBody reads up to n bytes of r and fails with usecase.ErrTooLarge
+// past them, remembering that it did.
+type limitedBody struct {
+	r    io.Reader
+	n    int64
+	over bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.over {
+		return 0, b.err()
+	}
+	n, err := b.r.Read(p)
+	b.n -= int64(n)
+	if b.n < 0 {
+		b.over = true
+		return 0, b.err()
+	}
+	return n, err
+}
+
+func (b *limitedBody) err() error {
+	return fmt.Errorf("%w: request body is too large", usecase.ErrTooLarge)
+}
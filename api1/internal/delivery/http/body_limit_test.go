@@ -0,0 +1,55 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestRoutes_JSONBodyIsCapped(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			body := `{"title":"` + strings.Repeat("a", httpdelivery.MaxBody) + `","author":"A"}`
+
+			res := do(t, httpdelivery.NewBookHandler(uc), newCreateRequest(body))
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+		})
+	}
+}
+
+func TestStartImport_TakesFilesOverTheJSONCap(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockImportUsecase(ctrl)
+			file := bytes.Repeat([]byte(`{"title":"Dune","author":"Frank Herbert"}`+"\n"), 2*httpdelivery.MaxBody/42)
+			uc.EXPECT().StartImport(gomock.Any(), domain.ImportJSONL, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ domain.ImportFormat, r io.Reader) (*domain.ImportJob, error) {
+					n, err := io.Copy(io.Discard, r)
+					assert.NoError(t, err)
+					assert.EqualValues(t, len(file), n)
+					return &domain.ImportJob{ID: 9, Format: domain.ImportJSONL, Status: domain.ImportQueued}, nil
+				})
+			req := httptest.NewRequest(http.MethodPost, "/v1/imports", bytes.NewReader(file))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+
+			res := do(t, httpdelivery.NewImportHandler(uc), req)
+
+			assert.Equal(t, http.StatusAccepted, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// BookAdminHandler lets operators act on the books of any tenant, named in
+// the path rather than taken from the caller. Mount it behind RequireAdmin.
+type BookAdminHandler struct {
+	uc usecase.BookUsecase
+}
+
+func NewBookAdminHandler(uc usecase.BookUsecase) *BookAdminHandler {
+	return &BookAdminHandler{uc: uc}
+}
+
+func (h *BookAdminHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/admin/tenants/{id}/books", h.ForceCreateBook, writeTimeout},
+		{http.MethodGet, "/v1/admin/tenants/{id}/books/duplicates", h.ListDuplicates, readTimeout},
+	}
+}
+
+// ForceCreateBook creates a book even if the tenant already has one with
+// the same normalized title and author.
+func (h *BookAdminHandler) ForceCreateBook(ctx context.Context, req *Request) Response {
+	var in domain.CreateBookInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+	in.Force = true
+
+	book, err := h.uc.CreateBook(tenancy.WithTenant(ctx, req.Params["id"]), in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: book}
+}
+
+// ListDuplicates serves GET /v1/admin/tenants/{id}/books/duplicates?limit=&offset=.
+func (h *BookAdminHandler) ListDuplicates(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	groups, err := h.uc.ListDuplicates(tenancy.WithTenant(ctx, req.Params["id"]), limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: groups}
+}
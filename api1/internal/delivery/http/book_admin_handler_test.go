@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+func TestCreateBook_Duplicate(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(nil, &domain.DuplicateError{ExistingID: 7})
+
+			res := do(t, httpdelivery.NewBookHandler(uc), newCreateRequest(`{"title":"dune","author":"Frank Herbert"}`))
+
+			assert.Equal(t, http.StatusConflict, res.StatusCode)
+			var body struct {
+				Error      string `json:"error"`
+				ExistingID int64  `json:"existing_id"`
+			}
+			_ = json.NewDecoder(res.Body).Decode(&body)
+			assert.Equal(t, int64(7), body.ExistingID)
+			assert.NotEmpty(t, body.Error)
+		})
+	}
+}
+
+func TestForceCreateBook(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().CreateBook(gomock.Any(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert", Force: true}).
+				DoAndReturn(func(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+					tenant, _ := tenancy.FromContext(ctx)
+					assert.Equal(t, "acme", tenant)
+					return &domain.Book{ID: 8, TenantID: tenant, Title: in.Title, Author: in.Author}, nil
+				})
+			h := httpdelivery.RequireAdmin("secret", httpdelivery.NewBookAdminHandler(uc))
+
+			// "force" in the body is ignored; the admin route is what forces.
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/tenants/acme/books",
+				strings.NewReader(`{"title":"Dune","author":"Frank Herbert","force":false}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Admin-Token", "secret")
+			res := do(t, h, req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+		})
+	}
+}
+
+func TestListDuplicates(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ListDuplicates(gomock.Any(), 10, 0).Return([]*domain.DuplicateGroup{{
+				Key:   "dune/frank herbert",
+				Books: []*domain.Book{{ID: 1}, {ID: 8}},
+			}}, nil)
+			h := httpdelivery.RequireAdmin("secret", httpdelivery.NewBookAdminHandler(uc))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/tenants/acme/books/duplicates?limit=10", nil)
+			req.Header.Set("X-Admin-Token", "secret")
+			res := do(t, h, req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got []domain.DuplicateGroup
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			if assert.Len(t, got, 1) {
+				assert.Len(t, got[0].Books, 2)
+			}
+		})
+	}
+}
+
+func TestListDuplicates_RequiresAdmin(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			h := httpdelivery.RequireAdmin("secret", httpdelivery.NewBookAdminHandler(usecase_mock.NewMockBookUsecase(ctrl)))
+
+			res := do(t, h, httptest.NewRequest(http.MethodGet, "/v1/admin/tenants/acme/books/duplicates", nil))
+
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+const (
+	// sseHeartbeat is how often an idle stream sends a comment, so proxies
+	// keep it open and a dead client is noticed.
+	sseHeartbeat = 15 * time.Second
+	// sseRetry is how long clients wait before reconnecting.
+	sseRetry = 3 * time.Second
+)
+
+type BookEventsHandler struct {
+	uc usecase.BookEventsUsecase
+}
+
+func NewBookEventsHandler(uc usecase.BookEventsUsecase) *BookEventsHandler {
+	return &BookEventsHandler{uc: uc}
+}
+
+// routes must be mounted before the BookHandler, so that routers matching
+// in order do not take "events" for a book ID.
+func (h *BookEventsHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/books/events", h.StreamBookEvents, eventsTimeout},
+	}
+}
+
+// sseChange is the data of one event on the stream.
+type sseChange struct {
+	Type   domain.EventType `json:"type"`
+	BookID int64            `json:"book_id"`
+	Book   *domain.Book     `json:"book,omitempty"`
+	At     time.Time        `json:"at"`
+}
+
+// StreamBookEvents serves GET /v1/books/events?author=&category= as a
+// stream of Server-Sent Events, one per book change, named after the event
+// type. A client that reconnects with Last-Event-ID gets the changes it
+// missed if they are still buffered; otherwise the stream opens with a
+// "reset" event telling it to reload. A client that cannot keep up gets a
+// "dropped" event and the stream ends.
+func (h *BookEventsHandler) StreamBookEvents(ctx context.Context, req *Request) Response {
+	q := domain.BookChangeQuery{Author: req.Query.Get("author"), Category: req.Query.Get("category")}
+	lastID := req.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		// EventSource cannot set headers on the first connection.
+		lastID = req.Query.Get("last_event_id")
+	}
+
+	sub, err := h.uc.SubscribeBookChanges(ctx, q, lastID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	return Response{Status: http.StatusOK, Header: header, Body: StreamBody(func(w io.Writer) error {
+		defer sub.Close()
+		return streamChanges(ctx, w, sub)
+	})}
+}
+
+// streamChanges writes sub to w until ctx ends or the feed lets go of it.
+// The route deadline ending the stream is not an error: the client just
+// reconnects.
+func streamChanges(ctx context.Context, w io.Writer, sub domain.BookChangeSubscription) error {
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return err
+	}
+	if sub.Gap() {
+		if _, err := io.WriteString(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return err
+		}
+	}
+	if err := flush(w); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+		case c, ok := <-sub.Changes():
+			if !ok {
+				if sub.Dropped() {
+					_, err := io.WriteString(w, "event: dropped\ndata: {}\n\n")
+					if err != nil {
+						return err
+					}
+					return flush(w)
+				}
+				return nil
+			}
+			data, err := json.Marshal(sseChange{Type: c.Type, BookID: c.BookID, Book: c.Book, At: c.At})
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", c.ID, c.Type, data); err != nil {
+				return err
+			}
+		}
+		if err := flush(w); err != nil {
+			return err
+		}
+	}
+}
+
+// flush pushes what has been written to w out to the client, for the
+// writers both adapters hand a StreamBody.
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package http_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// fakeSubscription hands out the given changes and then ends, so a stream
+// over it finishes.
+type fakeSubscription struct {
+	ch      chan domain.BookChange
+	gap     bool
+	dropped bool
+}
+
+func newFakeSubscription(gap, dropped bool, changes ...domain.BookChange) *fakeSubscription {
+	ch := make(chan domain.BookChange, len(changes))
+	for _, c := range changes {
+		ch <- c
+	}
+	close(ch)
+	return &fakeSubscription{ch: ch, gap: gap, dropped: dropped}
+}
+
+func (s *fakeSubscription) Changes() <-chan domain.BookChange { return s.ch }
+func (s *fakeSubscription) Gap() bool                         { return s.gap }
+func (s *fakeSubscription) Dropped() bool                     { return s.dropped }
+func (s *fakeSubscription) Close()                            {}
+
+func TestStreamBookEvents(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookEventsUsecase(ctrl)
+			at := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+			sub := newFakeSubscription(false, false,
+				domain.BookChange{ID: "c1", TenantID: "acme", Type: domain.EventBookUpdated, BookID: 7,
+					Book: &domain.Book{ID: 7, Title: "Dune", Author: "Frank Herbert"}, Categories: []string{"/1/"}, At: at},
+				domain.BookChange{ID: "c2", TenantID: "acme", Type: domain.EventBookDeleted, BookID: 8, At: at},
+			)
+			uc.EXPECT().SubscribeBookChanges(gomock.Any(), domain.BookChangeQuery{Author: "Frank Herbert", Category: "sci-fi"}, "c0").
+				Return(sub, nil)
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/events?author=Frank+Herbert&category=sci-fi", nil)
+			req.Header.Set("Last-Event-ID", "c0")
+
+			// Act
+			res := do(t, httpdelivery.NewBookEventsHandler(uc), req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+			assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, "retry: 3000\n\n"+
+				"id: c1\nevent: book.updated\ndata: "+
+				fmt.Sprintf(`{"type":"book.updated","book_id":7,"book":{"id":7,"tenant_id":"","title":"Dune","author":"Frank Herbert","created_at":"0001-01-01T00:00:00Z","rating_avg":0,"rating_count":0},"at":"%s"}`, at.Format(time.RFC3339))+"\n\n"+
+				"id: c2\nevent: book.deleted\ndata: "+
+				fmt.Sprintf(`{"type":"book.deleted","book_id":8,"at":"%s"}`, at.Format(time.RFC3339))+"\n\n", string(body))
+		})
+	}
+}
+
+func TestStreamBookEvents_ResetAndDropped(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookEventsUsecase(ctrl)
+			uc.EXPECT().SubscribeBookChanges(gomock.Any(), domain.BookChangeQuery{}, "gone").
+				Return(newFakeSubscription(true, true), nil)
+
+			// EventSource cannot send Last-Event-ID on the first connection.
+			res := do(t, httpdelivery.NewBookEventsHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/events?last_event_id=gone", nil))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, "retry: 3000\n\nevent: reset\ndata: {}\n\nevent: dropped\ndata: {}\n\n", string(body))
+		})
+	}
+}
+
+func TestStreamBookEvents_UnknownCategory(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookEventsUsecase(ctrl)
+			uc.EXPECT().SubscribeBookChanges(gomock.Any(), gomock.Any(), "").Return(nil, usecase.ErrValidation)
+
+			res := do(t, httpdelivery.NewBookEventsHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/events?category=poetry", nil))
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// exportFormat is one representation of a book export.
+type exportFormat struct {
+	contentType string
+	ext         string
+	encoder     func(w io.Writer) bookEncoder
+}
+
+// exportFormats are the representations on offer, in order of preference.
+var exportFormats = []exportFormat{
+	{"text/csv; charset=utf-8", "csv", newCSVBooks},
+	{"application/x-ndjson", "ndjson", newNDJSONBooks},
+	{"application/xml; charset=utf-8", "xml", newXMLBooks},
+}
+
+// exportTypes maps the media types clients may ask for, including the
+// usual aliases and wildcards, to the preferred format they accept.
+var exportTypes = map[string]int{
+	"*/*":                     0,
+	"text/*":                  0,
+	"text/csv":                0,
+	"application/*":           1,
+	"application/x-ndjson":    1,
+	"application/jsonl":       1,
+	"application/x-jsonlines": 1,
+	"application/xml":         2,
+	"text/xml":                2,
+}
+
+// ExportBooks serves GET /v1/books/export with the filters of ListBooks
+// but no paging: it streams every matching book. The Accept header picks
+// CSV (the default), NDJSON or XML, and Accept-Encoding gzip compresses it.
+func (h *BookHandler) ExportBooks(ctx context.Context, req *Request) Response {
+	format, ok := negotiateExport(req.Header.Get("Accept"))
+	if !ok {
+		return errorBody(http.StatusNotAcceptable, "export is available as text/csv, application/x-ndjson or application/xml")
+	}
+	q, errResp := bookQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	books, err := h.uc.ExportBooks(ctx, q)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	gz := acceptsGzip(req.Header.Get("Accept-Encoding"))
+	header := http.Header{}
+	header.Set("Content-Type", format.contentType)
+	header.Set("Content-Disposition", `attachment; filename="books.`+format.ext+`"`)
+	header.Set("Vary", "Accept, Accept-Encoding")
+	if gz {
+		header.Set("Content-Encoding", "gzip")
+	}
+	return Response{Status: http.StatusOK, Header: header, Body: exportStream(books, format, gz)}
+}
+
+// exportStream encodes books one at a time as they arrive.
+func exportStream(books iter.Seq2[*domain.Book, error], format exportFormat, gz bool) StreamBody {
+	return func(w io.Writer) error {
+		var zw *gzip.Writer
+		if gz {
+			zw = gzip.NewWriter(w)
+			w = zw
+		}
+		enc := format.encoder(w)
+		for b, err := range books {
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(b); err != nil {
+				return err
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if zw != nil {
+			return zw.Close()
+		}
+		return nil
+	}
+}
+
+// negotiateExport picks the format the Accept header rates highest; ties
+// go to the range listed first. No header means CSV.
+func negotiateExport(accept string) (exportFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return exportFormats[0], true
+	}
+	best, bestQ := -1, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if i, ok := exportTypes[mt]; ok && q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if best < 0 {
+		return exportFormat{}, false
+	}
+	return exportFormats[best], true
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err != nil || q == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// bookEncoder writes books in one export format. Close writes whatever
+// ends the document and flushes.
+type bookEncoder interface {
+	Encode(b *domain.Book) error
+	Close() error
+}
+
+type csvBooks struct{ w *csv.Writer }
+
+func newCSVBooks(w io.Writer) bookEncoder {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "title", "author", "created_at", "cover_url", "rating_avg", "rating_count"})
+	return &csvBooks{w: cw}
+}
+
+func (c *csvBooks) Encode(b *domain.Book) error {
+	return c.w.Write([]string{
+		strconv.FormatInt(b.ID, 10),
+		b.Title,
+		b.Author,
+		b.CreatedAt.Format(time.RFC3339),
+		b.CoverURL,
+		strconv.FormatFloat(b.RatingAvg, 'f', -1, 64),
+		strconv.Itoa(b.RatingCount),
+	})
+}
+
+func (c *csvBooks) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonBooks struct{ enc *json.Encoder }
+
+func newNDJSONBooks(w io.Writer) bookEncoder {
+	return &ndjsonBooks{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonBooks) Encode(b *domain.Book) error { return n.enc.Encode(b) }
+
+func (n *ndjsonBooks) Close() error { return nil }
+
+// xmlBook is the XML form of a book, one <book> element per row.
+type xmlBook struct {
+	XMLName     xml.Name  `xml:"book"`
+	ID          int64     `xml:"id,attr"`
+	Title       string    `xml:"title"`
+	Author      string    `xml:"author"`
+	CreatedAt   time.Time `xml:"created_at"`
+	CoverURL    string    `xml:"cover_url,omitempty"`
+	RatingAvg   float64   `xml:"rating_avg"`
+	RatingCount int       `xml:"rating_count"`
+}
+
+type xmlBooks struct {
+	w   io.Writer
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLBooks(w io.Writer) bookEncoder {
+	_, err := io.WriteString(w, xml.Header+"<books>")
+	return &xmlBooks{w: w, enc: xml.NewEncoder(w), err: err}
+}
+
+func (x *xmlBooks) Encode(b *domain.Book) error {
+	if x.err != nil {
+		return x.err
+	}
+	return x.enc.Encode(xmlBook{
+		ID:          b.ID,
+		Title:       b.Title,
+		Author:      b.Author,
+		CreatedAt:   b.CreatedAt,
+		CoverURL:    b.CoverURL,
+		RatingAvg:   b.RatingAvg,
+		RatingCount: b.RatingCount,
+	})
+}
+
+func (x *xmlBooks) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	_, err := io.WriteString(x.w, "</books>\n")
+	return err
+}
@@ -0,0 +1,168 @@
+package http_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+// bookSeq yields books as an export would.
+func bookSeq(books ...*domain.Book) iter.Seq2[*domain.Book, error] {
+	return func(yield func(*domain.Book, error) bool) {
+		for _, b := range books {
+			if !yield(b, nil) {
+				return
+			}
+		}
+	}
+}
+
+var exported = []*domain.Book{
+	{ID: 1, Title: "Dune", Author: "Frank Herbert", CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), RatingAvg: 4.5, RatingCount: 2},
+	{ID: 2, Title: "Emma, Again", Author: "Jane Austen", CreatedAt: time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)},
+}
+
+func exportRequest(accept, encoding string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/v1/books/export?author=Jane+Austen&tags=classic", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	return req
+}
+
+func TestExportBooks_CSVByDefault(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ExportBooks(gomock.Any(), domain.ListBooksQuery{Author: "Jane Austen", Tags: []string{"classic"}}).
+				Return(bookSeq(exported...), nil)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("", ""))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="books.csv"`, res.Header.Get("Content-Disposition"))
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, "id,title,author,created_at,cover_url,rating_avg,rating_count\n"+
+				"1,Dune,Frank Herbert,2025-01-02T03:04:05Z,,4.5,2\n"+
+				"2,\"Emma, Again\",Jane Austen,2025-01-03T00:00:00Z,,0,0\n", string(body))
+		})
+	}
+}
+
+func TestExportBooks_GzippedNDJSON(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ExportBooks(gomock.Any(), gomock.Any()).Return(bookSeq(exported...), nil)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("application/xml;q=0.5, application/x-ndjson", "br, gzip"))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+			assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+			zr, err := gzip.NewReader(res.Body)
+			require.NoError(t, err)
+			body, _ := io.ReadAll(zr)
+			assert.Equal(t,
+				`{"id":1,"tenant_id":"","title":"Dune","author":"Frank Herbert","created_at":"2025-01-02T03:04:05Z","rating_avg":4.5,"rating_count":2}`+"\n"+
+					`{"id":2,"tenant_id":"","title":"Emma, Again","author":"Jane Austen","created_at":"2025-01-03T00:00:00Z","rating_avg":0,"rating_count":0}`+"\n",
+				string(body))
+		})
+	}
+}
+
+func TestExportBooks_XML(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ExportBooks(gomock.Any(), gomock.Any()).Return(bookSeq(exported...), nil)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("text/xml", ""))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var doc struct {
+				Books []struct {
+					ID    int64  `xml:"id,attr"`
+					Title string `xml:"title"`
+				} `xml:"book"`
+			}
+			require.NoError(t, xml.NewDecoder(res.Body).Decode(&doc))
+			require.Len(t, doc.Books, 2)
+			assert.Equal(t, int64(2), doc.Books[1].ID)
+			assert.Equal(t, "Emma, Again", doc.Books[1].Title)
+		})
+	}
+}
+
+func TestExportBooks_NotAcceptable(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("application/json, text/csv;q=0", ""))
+
+			assert.Equal(t, http.StatusNotAcceptable, res.StatusCode)
+		})
+	}
+}
+
+func TestExportBooks_ContextLastsForTheStream(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ExportBooks(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, _ domain.ListBooksQuery) (iter.Seq2[*domain.Book, error], error) {
+					return func(yield func(*domain.Book, error) bool) {
+						if err := ctx.Err(); err != nil {
+							yield(nil, err)
+							return
+						}
+						yield(exported[0], nil)
+					}, nil
+				})
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("application/x-ndjson", ""))
+
+			body, _ := io.ReadAll(res.Body)
+			assert.Contains(t, string(body), `"title":"Dune"`)
+		})
+	}
+}
+
+func TestExportBooks_SetupErrorKeepsItsStatus(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ExportBooks(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+
+			res := do(t, httpdelivery.NewBookHandler(uc), exportRequest("", ""))
+
+			assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		})
+	}
+}
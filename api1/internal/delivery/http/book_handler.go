@@ -1,39 +1,384 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/patch"
+	"unit-test-demo/api1/internal/tenancy"
 	"unit-test-demo/api1/internal/usecase"
-
-	"github.com/gofiber/fiber/v2"
 )
 
+// Request is the framework-agnostic view of an incoming HTTP request.
+// Adapters fill it from Fiber or net/http before calling a handler.
+type Request struct {
+	Body   io.Reader
+	Params map[string]string
+	Query  url.Values
+	Header http.Header
+}
+
+// Response is what a handler wants written back; adapters encode Body as
+// JSON. A nil Body is written as an empty response, an io.Reader is
+// streamed as is (and closed if it is an io.Closer), and a StreamBody is
+// called to write it. Header is copied onto the response first.
+type Response struct {
+	Status int
+	Body   any
+	Header http.Header
+}
+
+// StreamBody writes a response body as it is produced, after the status
+// and headers have gone out. Unlike an io.Reader body it runs within the
+// route's deadline, so it may keep using the handler's context. An error
+// means the body is incomplete; adapters make that visible to the client
+// where they can.
+type StreamBody func(w io.Writer) error
+
+// BookHandler holds the transport logic for books: decode, call the
+// usecase, map errors and pick the response. It knows nothing about the
+// HTTP framework it is mounted in.
 type BookHandler struct {
 	uc usecase.BookUsecase
 }
 
-func NewBookHandler(r fiber.Router, uc usecase.BookUsecase) {
-	h := &BookHandler{uc: uc}
-	v1 := r.Group("/v1")
-	v1.Post("/books", h.CreateBook)
+func NewBookHandler(uc usecase.BookUsecase) *BookHandler {
+	return &BookHandler{uc: uc}
+}
+
+type handlerFunc func(ctx context.Context, req *Request) Response
+
+// RouteSet is implemented by every handler in this package; the adapters
+// mount any number of them.
+type RouteSet interface {
+	routes() []route
+}
+
+// route binds a method and path to a handler. Paths use {param} syntax;
+// adapters translate it to their own router format. The handler gets at
+// most timeout to answer.
+type route struct {
+	method  string
+	path    string
+	handle  handlerFunc
+	timeout time.Duration
+}
+
+// params returns the names of the {param} segments in the route path.
+func (rt route) params() []string {
+	var names []string
+	for _, seg := range strings.Split(rt.path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, seg[1:len(seg)-1])
+		}
+	}
+	return names
+}
+
+func (h *BookHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/books", h.CreateBook, writeTimeout},
+		{http.MethodGet, "/v1/books", h.ListBooks, readTimeout},
+		{http.MethodGet, "/v1/books/export", h.ExportBooks, exportTimeout},
+		{http.MethodGet, "/v1/books/changes", h.SyncBooks, readTimeout},
+		{http.MethodGet, "/v1/books/{id}", h.GetBook, readTimeout},
+		{http.MethodPut, "/v1/books/{id}", h.UpdateBook, writeTimeout},
+		{http.MethodPatch, "/v1/books/{id}", h.PatchBook, writeTimeout},
+		{http.MethodDelete, "/v1/books/{id}", h.DeleteBook, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/restore", h.RestoreBook, writeTimeout},
+		{http.MethodGet, "/v1/books/{id}/revisions", h.ListRevisions, readTimeout},
+		{http.MethodPost, "/v1/books/{id}/revisions/{rev}/revert", h.RevertBook, writeTimeout},
+		{http.MethodPut, "/v1/books/{id}/cover", h.UploadCover, uploadTimeout},
+		{http.MethodGet, "/v1/books/{id}/cover", h.GetCover, readTimeout},
+	}
 }
 
-func (h *BookHandler) CreateBook(c *fiber.Ctx) error {
-	var req domain.CreateBookInput
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid JSON body",
-		})
+func (h *BookHandler) CreateBook(ctx context.Context, req *Request) Response {
+	var in domain.CreateBookInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
 	}
 
-	book, err := h.uc.CreateBook(c.Context(), req)
+	book, err := h.uc.CreateBook(ctx, in)
 	if err != nil {
-		if err == usecase.ErrValidation {
-			return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: book}
+}
+
+func (h *BookHandler) GetBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	sel, selected, errResp := selection(req)
+	if errResp != nil {
+		return *errResp
+	}
+	if selected {
+		if req.Query.Get("as_of") != "" {
+			return errorBody(http.StatusBadRequest, "as_of cannot be combined with fields or include")
 		}
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		view, err := h.uc.GetBookView(ctx, id, sel)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return viewResponse(view)
 	}
 
-	return c.Status(http.StatusCreated).JSON(book)
+	var book *domain.Book
+	var err error
+	if v := req.Query.Get("as_of"); v != "" {
+		at, perr := time.Parse(time.RFC3339, v)
+		if perr != nil {
+			return errorBody(http.StatusBadRequest, "invalid as_of, want RFC 3339")
+		}
+		book, err = h.uc.GetBookAsOf(ctx, id, at)
+	} else {
+		book, err = h.uc.GetBook(ctx, id)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+func (h *BookHandler) UpdateBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.UpdateBookInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	book, err := h.uc.UpdateBook(ctx, id, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+// PatchBook takes either a JSON Merge Patch or a JSON Patch, told apart by
+// the Content-Type of the request.
+func (h *BookHandler) PatchBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || (mt != patch.MergePatchType && mt != patch.JSONPatchType) {
+		return errorBody(http.StatusUnsupportedMediaType,
+			"patch must be "+patch.MergePatchType+" or "+patch.JSONPatchType)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errorBody(http.StatusBadRequest, "invalid body")
+	}
+	p, err := patch.Parse(mt, body)
+	if err != nil {
+		return errorBody(http.StatusBadRequest, err.Error())
+	}
+
+	book, err := h.uc.PatchBook(ctx, id, p)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+func (h *BookHandler) DeleteBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	if err := h.uc.DeleteBook(ctx, id); err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusNoContent}
+}
+
+func (h *BookHandler) RestoreBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	book, err := h.uc.RestoreBook(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+func (h *BookHandler) ListRevisions(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	revs, err := h.uc.ListRevisions(ctx, id, limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: revs}
+}
+
+func (h *BookHandler) RevertBook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	rev, ok := pathID(req, "rev")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid revision")
+	}
+
+	book, err := h.uc.RevertBook(ctx, id, int(rev))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+// ListBooks serves GET /v1/books?author=&category=&tags=a,b&match=any|all.
+func (h *BookHandler) ListBooks(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	q, errResp := bookQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	q.Limit, q.Offset = limit, offset
+	sel, selected, errResp := selection(req)
+	if errResp != nil {
+		return *errResp
+	}
+	if selected {
+		views, err := h.uc.ListBookViews(ctx, q, sel)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return viewsResponse(views)
+	}
+
+	books, err := h.uc.ListBooks(ctx, q)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: books}
+}
+
+// bookQuery reads the filters of a book listing. Tags are comma-separated
+// and match any of them unless match=all.
+func bookQuery(req *Request) (domain.ListBooksQuery, *Response) {
+	q := domain.ListBooksQuery{Author: req.Query.Get("author"), Category: req.Query.Get("category")}
+	if v := req.Query.Get("tags"); v != "" {
+		q.Tags = strings.Split(v, ",")
+	}
+	switch req.Query.Get("match") {
+	case "", "any":
+	case "all":
+		q.AllTags = true
+	default:
+		res := errorBody(http.StatusBadRequest, "invalid match, want any or all")
+		return q, &res
+	}
+	return q, nil
+}
+
+// pathID parses a positive integer path parameter.
+func pathID(req *Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(req.Params[name], 10, 64)
+	return id, err == nil && id > 0
+}
+
+// pageQuery reads the limit and offset query parameters.
+func pageQuery(req *Request) (limit, offset int, errResp *Response) {
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		v := req.Query.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			res := errorBody(http.StatusBadRequest, "invalid "+name)
+			return 0, 0, &res
+		}
+		*dst = n
+	}
+	return limit, offset, nil
+}
+
+// errorResponse maps usecase errors to HTTP statuses. Anything unknown is
+// reported as a 500 without leaking the underlying message.
+func errorResponse(err error) Response {
+	var dup *domain.DuplicateError
+	switch {
+	case errors.As(err, &dup) && dup.ExistingID != 0:
+		return Response{Status: http.StatusConflict, Body: map[string]any{
+			"error":       err.Error(),
+			"existing_id": dup.ExistingID,
+		}}
+	case errors.Is(err, usecase.ErrValidation), errors.Is(err, domain.ErrCategoryCycle), errors.Is(err, patch.ErrPath):
+		return errorBody(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return errorBody(http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrTooLarge):
+		return errorBody(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, usecase.ErrUnsupportedMedia):
+		return errorBody(http.StatusUnsupportedMediaType, "cover must be a JPEG, PNG or WebP image")
+	case errors.Is(err, usecase.ErrForbidden):
+		return errorBody(http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrDuplicate), errors.Is(err, patch.ErrTestFailed),
+		errors.Is(err, domain.ErrCopyUnavailable), errors.Is(err, domain.ErrLoanClosed), errors.Is(err, domain.ErrHoldClosed),
+		errors.Is(err, domain.ErrImportFinished), errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrOutOfStock),
+		errors.Is(err, domain.ErrReservationClosed), errors.Is(err, domain.ErrPaymentState):
+		return errorBody(http.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrCursorExpired):
+		return errorBody(http.StatusGone, "cursor expired, sync again without since")
+	case errors.Is(err, tenancy.ErrNoTenant):
+		return errorBody(http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrTenantSuspended), errors.Is(err, domain.ErrQuotaExceeded):
+		return errorBody(http.StatusForbidden, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return timedOut("request timed out")
+	case errors.Is(err, domain.ErrUnavailable):
+		return errorBody(http.StatusServiceUnavailable, domain.ErrUnavailable.Error())
+	default:
+		return errorBody(http.StatusInternalServerError, "internal error")
+	}
+}
+
+func errorBody(status int, msg string) Response {
+	return Response{Status: status, Body: map[string]string{"error": msg}}
 }
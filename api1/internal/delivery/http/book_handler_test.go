@@ -3,144 +3,256 @@ package http_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	"unit-test-demo/api1/internal/domain"
-	"unit-test-demo/api1/internal/usecase"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
 )
 
-// ---- Mock (hand-written) ----
+// serve sends req through one of the adapters and returns the recorded response.
+type serve func(t *testing.T, h httpdelivery.RouteSet, req *http.Request) *http.Response
 
-type mockBookUsecase struct {
-	// You can add fields to assert inputs if needed
-	CreateBookFn func(in domain.CreateBookInput) (*domain.Book, error)
+// adapters lets every test run against both the Fiber and net/http mounts of
+// the same handler.
+var adapters = map[string]serve{
+	"fiber": func(t *testing.T, h httpdelivery.RouteSet, req *http.Request) *http.Response {
+		app := fiber.New(httpdelivery.FiberConfig(fiber.Config{}))
+		httpdelivery.RegisterFiberRoutes(app, h)
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("fiber test: %v", err)
+		}
+		return res
+	},
+	"nethttp": func(t *testing.T, h httpdelivery.RouteSet, req *http.Request) *http.Response {
+		rec := httptest.NewRecorder()
+		httpdelivery.NewHTTPHandler(h).ServeHTTP(rec, req)
+		return rec.Result()
+	},
 }
 
-func (m *mockBookUsecase) CreateBook(ctx fiber.Ctx, in domain.CreateBookInput) (*domain.Book, error) {
-	// This signature is wrong; we need context.Context, not fiber.Ctx.
-	// We'll adapt by providing a wrapper method below.
-	return nil, nil
+func newCreateRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/books", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
 }
 
-// Properly implement the interface expected by handler (context.Context).
-func (m *mockBookUsecase) CreateBookCtx(ctx interface{}, in domain.CreateBookInput) (*domain.Book, error) {
-	return m.CreateBookFn(in)
+func TestCreateBook_Success(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			in := domain.CreateBookInput{Title: "Clean Architecture", Author: "Uncle Bob"}
+			uc.EXPECT().CreateBook(gomock.Any(), in).Return(&domain.Book{
+				ID:        42,
+				Title:     in.Title,
+				Author:    in.Author,
+				CreatedAt: time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC),
+			}, nil)
+
+			body, _ := json.Marshal(in)
+			req := httptest.NewRequest(http.MethodPost, "/v1/books", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Act
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			// Assert
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+			var got domain.Book
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, int64(42), got.ID)
+			assert.Equal(t, "Clean Architecture", got.Title)
+			assert.Equal(t, "Uncle Bob", got.Author)
+		})
+	}
 }
 
-// To satisfy the interface, we use a tiny adapter type.
-// (Cleaner: define mock with the correct signature directly.)
-type bookUsecaseAdapter struct {
-	m *mockBookUsecase
+func TestCreateBook_ValidationError(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrValidation)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), newCreateRequest(`{"title":"","author":"Anyone"}`))
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		})
+	}
 }
 
-func (a *bookUsecaseAdapter) CreateBook(ctx interface{}, in domain.CreateBookInput) (*domain.Book, error) {
-	return a.m.CreateBookFn(in)
+func TestCreateBook_InvalidJSON(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), newCreateRequest(`{"title":`))
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
 }
 
-// ---- Test ----
+func TestCreateBook_InternalError(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+
+			res := do(t, httpdelivery.NewBookHandler(uc), newCreateRequest(`{"title":"A","author":"B"}`))
 
-type bookUsecaseIface interface {
-	CreateBook(ctx interface{}, in domain.CreateBookInput) (*domain.Book, error)
+			assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+			var got map[string]string
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "internal error", got["error"])
+		})
+	}
 }
 
-// re-declare a small interface matching what the handler uses (context.Context)
-// to avoid import cycle in tests. In real projects, put interfaces in a shared test package.
+func TestGetBook_Success(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().GetBook(gomock.Any(), int64(7)).Return(&domain.Book{ID: 7, Title: "Dune", Author: "Frank Herbert"}, nil)
 
-func TestCreateBook_Success(t *testing.T) {
-	
-	// Arrange
-	app := fiber.New()
-
-	mock := &mockBookUsecase{
-		CreateBookFn: func(in domain.CreateBookInput) (*domain.Book, error) {
-			assert.Equal(t, "Clean Architecture", in.Title)
-			assert.Equal(t, "Uncle Bob", in.Author)
-			return &domain.Book{
-				ID:        42,
-				Title:     in.Title,
-				Author:    in.Author,
-				CreatedAt: time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC),
-			}, nil
-		},
+			res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/7", nil))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var got domain.Book
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "Dune", got.Title)
+		})
 	}
-	adapter := &bookUsecaseAdapter{m: mock}
+}
 
-	// We need a compatible constructor for handler that accepts this interface.
-	// To keep it simple, we expose a tiny local wrapper route here:
+func TestGetBook_NotFound(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().GetBook(gomock.Any(), int64(7)).Return(nil, domain.ErrNotFound)
 
-	app.Post("/v1/books", func(c *fiber.Ctx) error {
-		var req domain.CreateBookInput
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON body"})
-		}
-		book, err := adapter.CreateBook(c.Context(), req)
-		if err != nil {
-			if err == usecase.ErrValidation {
-				return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
-			}
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
-		}
-		return c.Status(http.StatusCreated).JSON(book)
-	})
-
-	// Act
-	body, _ := json.Marshal(domain.CreateBookInput{
-		Title:  "Clean Architecture",
-		Author: "Uncle Bob",
-	})
-	req := httptest.NewRequest(http.MethodPost, "/v1/books", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	res, _ := app.Test(req, -1)
+			res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/7", nil))
+
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		})
+	}
+}
+
+func TestGetBook_InvalidID(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
 
-	// Assert
-	assert.Equal(t, http.StatusCreated, res.StatusCode)
+			res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/abc", nil))
 
-	var got domain.Book
-	_ = json.NewDecoder(res.Body).Decode(&got)
-	assert.Equal(t, int64(42), got.ID)
-	assert.Equal(t, "Clean Architecture", got.Title)
-	assert.Equal(t, "Uncle Bob", got.Author)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
 }
 
-func TestCreateBook_ValidationError(t *testing.T) {
-	app := fiber.New()
+func TestListBooks_TaxonomyFilters(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().
+				ListBooks(gomock.Any(), domain.ListBooksQuery{Category: "sf", Tags: []string{"space", "classic"}, AllTags: true}).
+				Return([]*domain.Book{}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books?category=sf&tags=space,classic&match=all", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
 
-	// Force the usecase to return validation error for empty title
-	mock := &mockBookUsecase{
-		CreateBookFn: func(in domain.CreateBookInput) (*domain.Book, error) {
-			return nil, usecase.ErrValidation
-		},
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
 	}
-	adapter := &bookUsecaseAdapter{m: mock}
+}
 
-	app.Post("/v1/books", func(c *fiber.Ctx) error {
-		var req domain.CreateBookInput
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON body"})
-		}
-		book, err := adapter.CreateBook(c.Context(), req)
-		if err != nil {
-			if err == usecase.ErrValidation {
-				return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
-			}
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
-		}
-		return c.Status(http.StatusCreated).JSON(book)
-	})
-
-	body, _ := json.Marshal(domain.CreateBookInput{
-		Title:  "",
-		Author: "Anyone",
-	})
-	req := httptest.NewRequest(http.MethodPost, "/v1/books", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	res, _ := app.Test(req, -1)
+func TestListBooks_InvalidMatch(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books?tags=a&match=some", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
 
-	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestListBooks_QueryParams(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().
+				ListBooks(gomock.Any(), domain.ListBooksQuery{Author: "Jane Austen", Limit: 5, Offset: 10}).
+				Return([]*domain.Book{{ID: 1, Title: "Emma", Author: "Jane Austen"}}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books?author=Jane+Austen&limit=5&offset=10", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var got []domain.Book
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Len(t, got, 1)
+		})
+	}
+}
+
+func TestUpdateBook_Success(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			in := domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"}
+			uc.EXPECT().UpdateBook(gomock.Any(), int64(7), in).Return(&domain.Book{ID: 7, Title: in.Title, Author: in.Author}, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/books/7", strings.NewReader(`{"title":"Dune Messiah","author":"Frank Herbert"}`))
+			req.Header.Set("Content-Type", "application/json")
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestGetBook_Unavailable(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().GetBook(gomock.Any(), int64(1)).
+				Return(nil, fmt.Errorf("%w: circuit open", domain.ErrUnavailable))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/1", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		})
+	}
 }
@@ -0,0 +1,78 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/patch"
+)
+
+func newPatchRequest(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/v1/books/3", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestPatchBook(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().PatchBook(gomock.Any(), int64(3), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, id int64, p patch.Patch) (*domain.Book, error) {
+					b, err := patch.ApplyTo(p, domain.Book{ID: id, Title: "Dune", Author: "Frank Herbert"})
+					return &b, err
+				})
+
+			res := do(t, httpdelivery.NewBookHandler(uc),
+				newPatchRequest(patch.MergePatchType+"; charset=utf-8", `{"title":"Dune Messiah"}`))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got domain.Book
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "Dune Messiah", got.Title)
+			assert.Equal(t, "Frank Herbert", got.Author)
+		})
+	}
+}
+
+func TestPatchBook_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		ucErr       error
+		wantStatus  int
+	}{
+		{"plain JSON", "application/json", `{"title":"x"}`, nil, http.StatusUnsupportedMediaType},
+		{"malformed patch", patch.JSONPatchType, `[{"op":"nope","path":"/title"}]`, nil, http.StatusBadRequest},
+		{"test failed", patch.JSONPatchType, `[{"op":"test","path":"/title","value":"x"}]`, patch.ErrTestFailed, http.StatusConflict},
+		{"missing path", patch.JSONPatchType, `[{"op":"remove","path":"/isbn"}]`, patch.ErrPath, http.StatusUnprocessableEntity},
+		{"not found", patch.MergePatchType, `{"title":"x"}`, domain.ErrNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockBookUsecase(ctrl)
+				if tt.ucErr != nil {
+					uc.EXPECT().PatchBook(gomock.Any(), int64(3), gomock.Any()).Return(nil, tt.ucErr)
+				}
+
+				res := do(t, httpdelivery.NewBookHandler(uc), newPatchRequest(tt.contentType, tt.body))
+
+				assert.Equal(t, tt.wantStatus, res.StatusCode)
+			})
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// syncChange is one entry of a sync page: an upsert carrying the whole
+// book, or a delete carrying only its ID.
+type syncChange struct {
+	Op     string       `json:"op"`
+	BookID int64        `json:"book_id"`
+	Book   *domain.Book `json:"book,omitempty"`
+}
+
+type syncPage struct {
+	Changes []syncChange `json:"changes"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+// SyncBooks serves GET /v1/books/changes?since=<cursor>&limit= for offline
+// clients. Without since it starts from the beginning; each page ends with
+// the cursor for the next one. A client keeps asking while has_more is
+// set, and later resumes from the last cursor it got. A cursor that has
+// expired gets 410 Gone, and the client must discard its copy and sync
+// from scratch.
+func (h *BookHandler) SyncBooks(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	if offset != 0 {
+		return errorBody(http.StatusBadRequest, "offset is not supported, page with since")
+	}
+
+	page, err := h.uc.SyncBooks(ctx, req.Query.Get("since"), limit)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	out := syncPage{Changes: make([]syncChange, len(page.Changes)), Cursor: page.Cursor, HasMore: page.HasMore}
+	for i, c := range page.Changes {
+		if c.Deleted {
+			out.Changes[i] = syncChange{Op: "delete", BookID: c.Book.ID}
+		} else {
+			out.Changes[i] = syncChange{Op: "upsert", BookID: c.Book.ID, Book: &c.Book}
+		}
+	}
+	return Response{Status: http.StatusOK, Body: out}
+}
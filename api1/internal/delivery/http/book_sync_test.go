@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestSyncBooks(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().SyncBooks(gomock.Any(), "abc", 2).Return(&usecase.BookSyncPage{
+				Changes: []*domain.BookSyncChange{
+					{Position: domain.BookSyncPosition{Seq: 4, BookID: 1}, Book: domain.Book{ID: 1, Title: "Dune", Author: "Frank Herbert"}},
+					{Position: domain.BookSyncPosition{Seq: 5, BookID: 2}, Book: domain.Book{ID: 2, Title: "Emma"}, Deleted: true},
+				},
+				Cursor:  "def",
+				HasMore: true,
+			}, nil)
+
+			// Act
+			res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/changes?since=abc&limit=2", nil))
+
+			// Assert
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var body struct {
+				Changes []struct {
+					Op     string       `json:"op"`
+					BookID int64        `json:"book_id"`
+					Book   *domain.Book `json:"book"`
+				} `json:"changes"`
+				Cursor  string `json:"cursor"`
+				HasMore bool   `json:"has_more"`
+			}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+			require.Len(t, body.Changes, 2)
+			assert.Equal(t, "upsert", body.Changes[0].Op)
+			assert.Equal(t, "Dune", body.Changes[0].Book.Title)
+			assert.Equal(t, "delete", body.Changes[1].Op)
+			assert.Equal(t, int64(2), body.Changes[1].BookID)
+			assert.Nil(t, body.Changes[1].Book, "a tombstone carries only the ID")
+			assert.Equal(t, "def", body.Cursor)
+			assert.True(t, body.HasMore)
+		})
+	}
+}
+
+func TestSyncBooks_ExpiredCursor(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().SyncBooks(gomock.Any(), "old", 0).Return(nil, usecase.ErrCursorExpired)
+
+			res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/changes?since=old", nil))
+
+			assert.Equal(t, http.StatusGone, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// selection reads the comma-separated fields and include query parameters
+// of a book request. selected is false when neither is given, in which
+// case the caller serves whole books as before.
+func selection(req *Request) (sel domain.BookSelection, selected bool, errResp *Response) {
+	sel.Fields = splitList(req.Query.Get("fields"))
+	sel.Include = splitList(req.Query.Get("include"))
+	for _, f := range sel.Fields {
+		if !slices.Contains(domain.BookFields, f) {
+			res := errorBody(http.StatusBadRequest,
+				"unknown field "+f+", valid fields are: "+strings.Join(domain.BookFields, ", "))
+			return sel, false, &res
+		}
+	}
+	for _, rel := range sel.Include {
+		if !slices.Contains(domain.BookIncludes, rel) {
+			res := errorBody(http.StatusBadRequest,
+				"unknown include "+rel+", valid includes are: "+strings.Join(domain.BookIncludes, ", "))
+			return sel, false, &res
+		}
+	}
+	return sel, len(sel.Fields) > 0 || len(sel.Include) > 0, nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func viewResponse(v *domain.BookView) Response {
+	body, err := viewBody(v)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{Status: http.StatusOK, Body: body}
+}
+
+func viewsResponse(views []*domain.BookView) Response {
+	bodies := make([]map[string]any, len(views))
+	for i, v := range views {
+		body, err := viewBody(v)
+		if err != nil {
+			return errorResponse(err)
+		}
+		bodies[i] = body
+	}
+	return Response{Status: http.StatusOK, Body: bodies}
+}
+
+// viewBody is the JSON object of v.Book cut down to v.Fields, with the
+// included relations added under their names.
+func viewBody(v *domain.BookView) (map[string]any, error) {
+	data, err := json.Marshal(v.Book)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers stay as written, so IDs keep every digit.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var body map[string]any
+	if err := dec.Decode(&body); err != nil {
+		return nil, err
+	}
+	if len(v.Fields) > 0 {
+		for k := range body {
+			if !slices.Contains(v.Fields, k) {
+				delete(body, k)
+			}
+		}
+	}
+	if v.Reviews != nil {
+		body["reviews"] = v.Reviews
+	}
+	if v.Copies != nil {
+		body["copies"] = v.Copies
+	}
+	return body, nil
+}
@@ -0,0 +1,94 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestGetBook_SparseFields(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			sel := domain.BookSelection{Fields: []string{"id", "title"}, Include: []string{"reviews"}}
+			uc.EXPECT().GetBookView(gomock.Any(), int64(1), sel).Return(&domain.BookView{
+				Book:    &domain.Book{ID: 1, Title: "Dune", Author: "Frank Herbert", RatingCount: 1},
+				Fields:  sel.Fields,
+				Reviews: []*domain.Review{{ID: 9, BookID: 1, Rating: 5}},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/1?fields=id,title&include=reviews", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got map[string]json.RawMessage
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.ElementsMatch(t, []string{"id", "title", "reviews"}, keys(got))
+			assert.JSONEq(t, `"Dune"`, string(got["title"]))
+		})
+	}
+}
+
+func TestListBooks_SparseFields(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			sel := domain.BookSelection{Fields: []string{"title"}}
+			uc.EXPECT().ListBookViews(gomock.Any(), domain.ListBooksQuery{Limit: 5}, sel).Return([]*domain.BookView{
+				{Book: &domain.Book{ID: 1, Title: "Dune"}, Fields: sel.Fields},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books?limit=5&fields=title", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got []map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, []map[string]any{{"title": "Dune"}}, got)
+		})
+	}
+}
+
+func TestGetBook_SelectionErrors(t *testing.T) {
+	tests := []struct {
+		name, url, wantErr string
+	}{
+		{"unknown field", "/v1/books/1?fields=id,isbn",
+			"unknown field isbn, valid fields are: id, tenant_id, title, author, created_at, cover_url, rating_avg, rating_count"},
+		{"unknown include", "/v1/books/1?include=authors", "unknown include authors, valid includes are: copies, reviews"},
+		{"with as_of", "/v1/books/1?fields=id&as_of=2025-01-01T00:00:00Z", "as_of cannot be combined with fields or include"},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+				res := do(t, httpdelivery.NewBookHandler(uc), httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+				assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+				var body map[string]string
+				_ = json.NewDecoder(res.Body).Decode(&body)
+				assert.Equal(t, tt.wantErr, body["error"])
+			})
+		}
+	}
+}
+
+func keys(m map[string]json.RawMessage) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// CategoryHandler serves the category tree, the categories and tags of
+// each book, and facet counts over book listings.
+type CategoryHandler struct {
+	uc usecase.CategoryUsecase
+}
+
+func NewCategoryHandler(uc usecase.CategoryUsecase) *CategoryHandler {
+	return &CategoryHandler{uc: uc}
+}
+
+// routes include GET /v1/books/facets, which routers that match in order
+// of registration (Fiber) need to see before /v1/books/{id}.
+func (h *CategoryHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/categories", h.CreateCategory, writeTimeout},
+		{http.MethodGet, "/v1/categories", h.ListCategories, readTimeout},
+		{http.MethodGet, "/v1/categories/{id}", h.GetCategory, readTimeout},
+		{http.MethodPut, "/v1/categories/{id}", h.UpdateCategory, writeTimeout},
+		{http.MethodDelete, "/v1/categories/{id}", h.DeleteCategory, writeTimeout},
+		{http.MethodGet, "/v1/books/facets", h.Facets, readTimeout},
+		{http.MethodGet, "/v1/books/{id}/taxonomy", h.GetBookTaxonomy, readTimeout},
+		{http.MethodPut, "/v1/books/{id}/taxonomy", h.SetBookTaxonomy, writeTimeout},
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(ctx context.Context, req *Request) Response {
+	var in domain.CategoryInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	c, err := h.uc.CreateCategory(ctx, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: c}
+}
+
+func (h *CategoryHandler) ListCategories(ctx context.Context, req *Request) Response {
+	cats, err := h.uc.ListCategories(ctx)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: cats}
+}
+
+func (h *CategoryHandler) GetCategory(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid category id")
+	}
+
+	c, err := h.uc.GetCategory(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: c}
+}
+
+func (h *CategoryHandler) UpdateCategory(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid category id")
+	}
+
+	var in domain.CategoryInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	c, err := h.uc.UpdateCategory(ctx, id, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: c}
+}
+
+func (h *CategoryHandler) DeleteCategory(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid category id")
+	}
+
+	if err := h.uc.DeleteCategory(ctx, id); err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusNoContent}
+}
+
+// Facets serves GET /v1/books/facets with the filters of GET /v1/books.
+func (h *CategoryHandler) Facets(ctx context.Context, req *Request) Response {
+	q, errResp := bookQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	f, err := h.uc.Facets(ctx, q)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: f}
+}
+
+func (h *CategoryHandler) GetBookTaxonomy(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	t, err := h.uc.GetBookTaxonomy(ctx, bookID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: t}
+}
+
+func (h *CategoryHandler) SetBookTaxonomy(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.BookTaxonomyInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	t, err := h.uc.SetBookTaxonomy(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: t}
+}
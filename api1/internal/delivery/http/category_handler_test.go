@@ -0,0 +1,93 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestCreateCategory(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockCategoryUsecase(ctrl)
+			parent := int64(1)
+			uc.EXPECT().CreateCategory(gomock.Any(), domain.CategoryInput{ParentID: &parent, Name: "Science Fiction"}).
+				Return(&domain.Category{ID: 2, ParentID: &parent, Slug: "science-fiction", Name: "Science Fiction", Path: "/1/2/"}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/categories", strings.NewReader(`{"parent_id":1,"name":"Science Fiction"}`))
+			res := do(t, httpdelivery.NewCategoryHandler(uc), req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			var got map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "science-fiction", got["slug"])
+			assert.NotContains(t, got, "path")
+		})
+	}
+}
+
+func TestUpdateCategory_Cycle(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockCategoryUsecase(ctrl)
+			uc.EXPECT().UpdateCategory(gomock.Any(), int64(1), gomock.Any()).Return(nil, domain.ErrCategoryCycle)
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/categories/1", strings.NewReader(`{"parent_id":2,"name":"Fiction"}`))
+			res := do(t, httpdelivery.NewCategoryHandler(uc), req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		})
+	}
+}
+
+func TestFacets(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockCategoryUsecase(ctrl)
+			uc.EXPECT().Facets(gomock.Any(), domain.ListBooksQuery{Author: "Frank Herbert", Tags: []string{"space"}}).
+				Return(&domain.Facets{
+					Total:      1,
+					Categories: []*domain.CategoryFacet{{ID: 2, Slug: "science-fiction", Count: 1}},
+					Tags:       []*domain.TagFacet{{Tag: "space", Count: 1}},
+				}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/facets?author=Frank+Herbert&tags=space", nil)
+			res := do(t, httpdelivery.NewCategoryHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got domain.Facets
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, 1, got.Total)
+			assert.Len(t, got.Categories, 1)
+		})
+	}
+}
+
+func TestSetBookTaxonomy(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockCategoryUsecase(ctrl)
+			in := domain.BookTaxonomyInput{CategoryIDs: []int64{2}, Tags: []string{"space"}}
+			uc.EXPECT().SetBookTaxonomy(gomock.Any(), int64(7), in).
+				Return(&domain.BookTaxonomy{BookID: 7, Categories: []*domain.Category{{ID: 2}}, Tags: []string{"space"}}, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/books/7/taxonomy", strings.NewReader(`{"category_ids":[2],"tags":["space"]}`))
+			res := do(t, httpdelivery.NewCategoryHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
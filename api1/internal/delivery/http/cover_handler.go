@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// UploadCover accepts the image either as the raw request body or as the
+// "file" field of a multipart form. The content type the client declares
+// is ignored; the usecase sniffs the bytes.
+func (h *BookHandler) UploadCover(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	body := req.Body
+	if mt, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && mt == "multipart/form-data" {
+		part, err := formFile(multipart.NewReader(req.Body, params["boundary"]), "file")
+		if err != nil {
+			return errorBody(http.StatusBadRequest, err.Error())
+		}
+		defer part.Close()
+		body = part
+	}
+
+	book, err := h.uc.UploadCover(ctx, id, body)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: book}
+}
+
+// GetCover serves GET /v1/books/{id}/cover?size=thumb|original.
+func (h *BookHandler) GetCover(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	var thumb bool
+	switch req.Query.Get("size") {
+	case "", "original":
+	case "thumb":
+		thumb = true
+	default:
+		return errorBody(http.StatusBadRequest, "size must be thumb or original")
+	}
+
+	f, err := h.uc.OpenCover(ctx, id, thumb)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	etag := strconv.Quote(f.ETag)
+	header := http.Header{}
+	header.Set("ETag", etag)
+	// Covers belong to one tenant, so shared caches must not keep them.
+	header.Set("Cache-Control", "private, max-age=86400")
+	if req.Header.Get("If-None-Match") == etag {
+		f.Body.Close()
+		return Response{Status: http.StatusNotModified, Header: header}
+	}
+	header.Set("Content-Type", f.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	return Response{Status: http.StatusOK, Body: f.Body, Header: header}
+}
+
+// formFile returns the first part of the form named field.
+func formFile(mr *multipart.Reader, field string) (*multipart.Part, error) {
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing " + field + " field")
+		}
+		if err != nil {
+			return nil, errors.New("invalid multipart body")
+		}
+		if part.FormName() == field {
+			return part, nil
+		}
+		part.Close()
+	}
+}
@@ -0,0 +1,163 @@
+package http_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// readsBody matches an io.Reader whose contents equal want.
+type readsBody struct{ want []byte }
+
+func (m readsBody) Matches(x any) bool {
+	r, ok := x.(io.Reader)
+	if !ok {
+		return false
+	}
+	got, err := io.ReadAll(r)
+	return err == nil && bytes.Equal(got, m.want)
+}
+
+func (m readsBody) String() string { return "reads " + string(m.want) }
+
+func TestUploadCover_RawBody(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().UploadCover(gomock.Any(), int64(7), readsBody{[]byte("image bytes")}).
+				Return(&domain.Book{ID: 7, CoverURL: "/v1/books/7/cover?v=abc"}, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/books/7/cover", bytes.NewReader([]byte("image bytes")))
+			req.Header.Set("Content-Type", "image/png")
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestUploadCover_Multipart(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().UploadCover(gomock.Any(), int64(7), readsBody{[]byte("image bytes")}).
+				Return(&domain.Book{ID: 7}, nil)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			_ = mw.WriteField("note", "ignored")
+			fw, _ := mw.CreateFormFile("file", "cover.png")
+			_, _ = fw.Write([]byte("image bytes"))
+			require.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/books/7/cover", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestUploadCover_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unsupported", usecase.ErrUnsupportedMedia, http.StatusUnsupportedMediaType},
+		{"too large", usecase.ErrTooLarge, http.StatusRequestEntityTooLarge},
+		{"invalid", usecase.ErrValidation, http.StatusUnprocessableEntity},
+		{"not found", domain.ErrNotFound, http.StatusNotFound},
+	}
+	for name, do := range adapters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockBookUsecase(ctrl)
+				uc.EXPECT().UploadCover(gomock.Any(), int64(7), gomock.Any()).Return(nil, tt.err)
+
+				req := httptest.NewRequest(http.MethodPut, "/v1/books/7/cover", bytes.NewReader([]byte("x")))
+				res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
+
+func TestGetCover(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().OpenCover(gomock.Any(), int64(7), true).Return(&usecase.CoverFile{
+				Body:        io.NopCloser(bytes.NewReader([]byte("thumb"))),
+				ContentType: "image/jpeg",
+				ETag:        "abc",
+				Size:        5,
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/cover?size=thumb", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "image/jpeg", res.Header.Get("Content-Type"))
+			assert.Equal(t, `"abc"`, res.Header.Get("ETag"))
+			assert.Equal(t, "private, max-age=86400", res.Header.Get("Cache-Control"))
+			got, _ := io.ReadAll(res.Body)
+			assert.Equal(t, "thumb", string(got))
+		})
+	}
+}
+
+func TestGetCover_NotModified(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().OpenCover(gomock.Any(), int64(7), false).Return(&usecase.CoverFile{
+				Body:        io.NopCloser(bytes.NewReader([]byte("original"))),
+				ContentType: "image/png",
+				ETag:        "abc",
+				Size:        8,
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/cover", nil)
+			req.Header.Set("If-None-Match", `"abc"`)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusNotModified, res.StatusCode)
+			got, _ := io.ReadAll(res.Body)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+func TestGetCover_InvalidSize(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/cover?size=huge", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Per-route budgets. Reads are expected to be quick; writes get more room
+// because they also append to the outbox and audit log. Uploads of whole
+// files spend most of theirs receiving the body, and exports sending it.
+// Event streams are cut after theirs and clients reconnect.
+const (
+	readTimeout   = 5 * time.Second
+	writeTimeout  = 10 * time.Second
+	uploadTimeout = 5 * time.Minute
+	exportTimeout = time.Hour
+	eventsTimeout = 30 * time.Minute
+)
+
+// MaxRouteTimeout is the longest budget any route gets for database work.
+// It is the natural upper bound for a database statement_timeout; uploads
+// run longer but only to receive the file, and exports only to send theirs,
+// reading it in short chunks.
+const MaxRouteTimeout = writeTimeout
+
+// serve is the middleware shared by both adapters. It runs the handler
+// under the route's timeout, derived from ctx so a client that goes away
+// cancels the work too. When the budget runs out, whatever the handler
+// made of the failure is replaced by a 504 that says so. A StreamBody
+// keeps the context alive until it has written the body. The request body
+// is cut off at the route's bodyLimit; a handler that read past it, and
+// so most likely reports a body it could not decode, is answered with a
+// 413 instead.
+func (rt route) serve(ctx context.Context, req *Request) Response {
+	ctx, cancel := context.WithTimeout(ctx, rt.timeout)
+
+	body := &limitedBody{r: req.Body, n: rt.bodyLimit()}
+	if req.Body != nil {
+		req.Body = body
+	}
+	res := rt.handle(ctx, req)
+	if body.over {
+		cancel()
+		if c, ok := res.Body.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return errorResponse(body.err())
+	}
+	if stream, ok := res.Body.(StreamBody); ok {
+		res.Body = StreamBody(func(w io.Writer) error {
+			defer cancel()
+			return stream(w)
+		})
+		return res
+	}
+	defer cancel()
+
+	if res.Status >= http.StatusInternalServerError && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return timedOut(fmt.Sprintf("request timed out after %s", rt.timeout))
+	}
+	return res
+}
+
+// timedOut is the 504 for a request that ran out of time, as an RFC 9457
+// problem. It keeps the "error" member of every other error body, so
+// clients that only read that one still find the reason.
+func timedOut(detail string) Response {
+	body, _ := json.Marshal(map[string]any{
+		"type":   "about:blank",
+		"title":  http.StatusText(http.StatusGatewayTimeout),
+		"status": http.StatusGatewayTimeout,
+		"detail": detail,
+		"error":  detail,
+	})
+	header := make(http.Header)
+	header.Set("Content-Type", "application/problem+json")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return Response{Status: http.StatusGatewayTimeout, Header: header, Body: bytes.NewReader(body)}
+}
@@ -0,0 +1,95 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestRoutes_CarryDeadline(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().GetBook(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, id int64) (*domain.Book, error) {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok, "usecase calls must be bounded")
+				assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+				return &domain.Book{ID: id}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/1", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestRoutes_DeadlineExceededIs504(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().UpdateBook(gomock.Any(), int64(1), gomock.Any()).
+				Return(nil, fmt.Errorf("update book: %w", context.DeadlineExceeded))
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/books/1", strings.NewReader(`{"title":"T","author":"A"}`))
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+			assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+			var body map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&body)
+			assert.EqualValues(t, http.StatusGatewayTimeout, body["status"])
+			assert.Contains(t, body["detail"], "timed out")
+			assert.Contains(t, body["error"], "timed out")
+		})
+	}
+}
+
+func TestFiber_ClientDisconnectCancelsContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	uc := usecase_mock.NewMockBookUsecase(ctrl)
+	gone := make(chan error, 1)
+	uc.EXPECT().GetBook(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, id int64) (*domain.Book, error) {
+		<-ctx.Done()
+		gone <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	app := fiber.New(httpdelivery.FiberConfig(fiber.Config{}))
+	httpdelivery.RegisterFiberRoutes(app, httpdelivery.NewBookHandler(uc))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("GET /v1/books/1 HTTP/1.1\r\nHost: test\r\n\r\n"))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-gone:
+		assert.ErrorIs(t, err, context.Canceled, "canceled, not timed out")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the handler kept running after the client left")
+	}
+}
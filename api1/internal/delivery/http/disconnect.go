@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// disconnectPoll is how often untilDisconnect looks at the connection.
+const disconnectPoll = 200 * time.Millisecond
+
+// untilDisconnect returns a context derived from parent that is canceled
+// once the client closes conn, for servers that do not report it
+// themselves. done cancels the context and stops watching; it must be
+// called once the request is over.
+func untilDisconnect(parent context.Context, conn net.Conn) (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(parent)
+	if conn == nil {
+		return ctx, cancel
+	}
+	go func() {
+		t := time.NewTicker(disconnectPoll)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if peerClosed(conn) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package http
+
+import "net"
+
+// peerClosed cannot tell on this platform; only the route timeout bounds
+// the work of a client that went away.
+func peerClosed(net.Conn) bool {
+	return false
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"unit-test-demo/api1/internal/requestmeta"
+)
+
+// RegisterFiberRoutes mounts the routes of each handler on a Fiber router.
+// The app should be configured with FiberConfig, so that the bodies of
+// file uploads are streamed rather than held in memory.
+func RegisterFiberRoutes(r fiber.Router, sets ...RouteSet) {
+	for _, set := range sets {
+		for _, rt := range set.routes() {
+			r.Add(rt.method, fiberPath(rt.path), fiberHandler(rt))
+		}
+	}
+}
+
+func fiberHandler(rt route) fiber.Handler {
+	names := rt.params()
+	return func(c *fiber.Ctx) error {
+		params := make(map[string]string, len(names))
+		for _, n := range names {
+			params[n] = c.Params(n)
+		}
+		query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+		header := make(http.Header)
+		c.Request().Header.VisitAll(func(k, v []byte) {
+			header.Add(string(k), string(v))
+		})
+
+		meta := newRequestMeta(c.Get(headerRequestID), c.IP())
+		c.Set(headerRequestID, meta.RequestID)
+		// c.Context() is the fasthttp RequestCtx, which is recycled once the
+		// handler returns and is never cancelled per request; UserContext is
+		// a real context.Context. fasthttp does not report client
+		// disconnects either, so watch the connection for them.
+		ctx, done := untilDisconnect(c.UserContext(), c.Context().Conn())
+		ctx = requestmeta.WithMeta(ctx, meta)
+
+		// With StreamRequestBody the body is only buffered up to the app's
+		// BodyLimit; past that it is read from the connection as the
+		// handler goes, and serve stops it at the route's limit.
+		var body io.Reader = c.Context().RequestBodyStream()
+		if body == nil {
+			body = bytes.NewReader(c.Body())
+		}
+		res := rt.serve(ctx, &Request{
+			Body:   body,
+			Params: params,
+			Query:  query,
+			Header: header,
+		})
+		if stream, ok := res.Body.(StreamBody); ok {
+			res.Body = StreamBody(func(w io.Writer) error {
+				defer done()
+				return stream(w)
+			})
+		} else {
+			defer done()
+		}
+		for k, vs := range res.Header {
+			// Set replaces fasthttp's default Content-Type; Append would
+			// join the two.
+			for i, v := range vs {
+				if i == 0 {
+					c.Set(k, v)
+				} else {
+					c.Append(k, v)
+				}
+			}
+		}
+		switch body := res.Body.(type) {
+		case nil:
+			return c.SendStatus(res.Status)
+		case StreamBody:
+			// fasthttp calls the writer after the handler returns and ends
+			// the chunked body normally whatever happens, so under Fiber a
+			// failed stream only shows as a short body.
+			c.Status(res.Status)
+			c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+				_ = body(w)
+			})
+			return nil
+		case io.Reader:
+			// fasthttp closes the stream once it has been sent. Without a
+			// known length it falls back to chunked encoding.
+			size := -1
+			if n, err := strconv.Atoi(res.Header.Get("Content-Length")); err == nil {
+				size = n
+			}
+			return c.Status(res.Status).SendStream(body, size)
+		default:
+			return c.Status(res.Status).JSON(body)
+		}
+	}
+}
+
+// FiberConfig returns cfg set up for RegisterFiberRoutes: bodies are
+// streamed to the handlers, and only buffered up to MaxBody.
+func FiberConfig(cfg fiber.Config) fiber.Config {
+	cfg.StreamRequestBody = true
+	cfg.BodyLimit = MaxBody
+	return cfg
+}
+
+// fiberPath turns "/v1/books/{id}" into "/v1/books/:id".
+func fiberPath(p string) string {
+	p = strings.ReplaceAll(p, "{", ":")
+	return strings.ReplaceAll(p, "}", "")
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// importFormats maps the accepted upload content types to file formats.
+var importFormats = map[string]domain.ImportFormat{
+	"text/csv":                domain.ImportCSV,
+	"application/x-ndjson":    domain.ImportJSONL,
+	"application/jsonl":       domain.ImportJSONL,
+	"application/x-jsonlines": domain.ImportJSONL,
+}
+
+type ImportHandler struct {
+	uc usecase.ImportUsecase
+}
+
+func NewImportHandler(uc usecase.ImportUsecase) *ImportHandler {
+	return &ImportHandler{uc: uc}
+}
+
+func (h *ImportHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/imports", h.StartImport, uploadTimeout},
+		{http.MethodGet, "/v1/imports/{id}", h.GetImport, readTimeout},
+		{http.MethodGet, "/v1/imports/{id}/errors", h.GetImportErrors, readTimeout},
+		{http.MethodPost, "/v1/imports/{id}/cancel", h.CancelImport, writeTimeout},
+	}
+}
+
+// StartImport serves POST /v1/imports. The body is the file itself, CSV
+// or JSON Lines as its content type says; the books are created in the
+// background and the job can be followed at the Location returned.
+func (h *ImportHandler) StartImport(ctx context.Context, req *Request) Response {
+	mt, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	format, ok := importFormats[mt]
+	if !ok {
+		return errorBody(http.StatusUnsupportedMediaType, "import must be text/csv or application/x-ndjson")
+	}
+
+	job, err := h.uc.StartImport(ctx, format, req.Body)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	header := http.Header{}
+	header.Set("Location", fmt.Sprintf("/v1/imports/%d", job.ID))
+	return Response{Status: http.StatusAccepted, Body: job, Header: header}
+}
+
+// GetImport serves GET /v1/imports/{id}.
+func (h *ImportHandler) GetImport(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid import id")
+	}
+
+	job, err := h.uc.GetImport(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: job}
+}
+
+// GetImportErrors serves GET /v1/imports/{id}/errors: the rows that were
+// not imported so far, as a CSV file to fix up and upload again.
+func (h *ImportHandler) GetImportErrors(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid import id")
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"row", "title", "author", "error"})
+	for offset := 0; ; {
+		page, err := h.uc.ListImportErrors(ctx, id, usecase.MaxImportErrors, offset)
+		if err != nil {
+			return errorResponse(err)
+		}
+		for _, e := range page {
+			_ = w.Write([]string{strconv.Itoa(e.Row), e.Title, e.Author, e.Message})
+		}
+		if len(page) == 0 {
+			break
+		}
+		offset += len(page)
+	}
+	w.Flush()
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/csv; charset=utf-8")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, id))
+	header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	return Response{Status: http.StatusOK, Body: &buf, Header: header}
+}
+
+// CancelImport serves POST /v1/imports/{id}/cancel. A running job stops
+// after its current chunk, so the response may still say running.
+func (h *ImportHandler) CancelImport(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid import id")
+	}
+
+	job, err := h.uc.CancelImport(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusAccepted, Body: job}
+}
@@ -0,0 +1,98 @@
+package http_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestStartImport_Accepted(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockImportUsecase(ctrl)
+			uc.EXPECT().StartImport(gomock.Any(), domain.ImportJSONL, gomock.Any()).
+				DoAndReturn(func(_ any, _ domain.ImportFormat, r io.Reader) (*domain.ImportJob, error) {
+					body, _ := io.ReadAll(r)
+					assert.Equal(t, `{"title":"Dune","author":"Frank Herbert"}`+"\n", string(body))
+					return &domain.ImportJob{ID: 9, Format: domain.ImportJSONL, Status: domain.ImportQueued}, nil
+				})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/imports",
+				strings.NewReader(`{"title":"Dune","author":"Frank Herbert"}`+"\n"))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			res := do(t, httpdelivery.NewImportHandler(uc), req)
+
+			assert.Equal(t, http.StatusAccepted, res.StatusCode)
+			assert.Equal(t, "/v1/imports/9", res.Header.Get("Location"))
+			var got domain.ImportJob
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, domain.ImportQueued, got.Status)
+		})
+	}
+}
+
+func TestStartImport_UnsupportedType(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockImportUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/imports", strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			res := do(t, httpdelivery.NewImportHandler(uc), req)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+		})
+	}
+}
+
+func TestGetImportErrors_CSVReport(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockImportUsecase(ctrl)
+			gomock.InOrder(
+				uc.EXPECT().ListImportErrors(gomock.Any(), int64(3), gomock.Any(), 0).Return([]*domain.ImportRowError{
+					{Row: 2, Author: "Jane Austen", Message: "title and author are required"},
+					{Row: 7, Title: "Dune, Part 2", Author: "Frank Herbert", Message: "duplicate book"},
+				}, nil),
+				uc.EXPECT().ListImportErrors(gomock.Any(), int64(3), gomock.Any(), 2).Return([]*domain.ImportRowError{}, nil),
+			)
+
+			res := do(t, httpdelivery.NewImportHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/imports/3/errors", nil))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+			assert.Contains(t, res.Header.Get("Content-Disposition"), "import-3-errors.csv")
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, "row,title,author,error\n"+
+				"2,,Jane Austen,title and author are required\n"+
+				"7,\"Dune, Part 2\",Frank Herbert,duplicate book\n", string(body))
+		})
+	}
+}
+
+func TestCancelImport_AlreadyFinished(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockImportUsecase(ctrl)
+			uc.EXPECT().CancelImport(gomock.Any(), int64(3)).Return(nil, domain.ErrImportFinished)
+
+			res := do(t, httpdelivery.NewImportHandler(uc), httptest.NewRequest(http.MethodPost, "/v1/imports/3/cancel", nil))
+
+			assert.Equal(t, http.StatusConflict, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var errInvalidToken = errors.New("invalid bearer token")
+
+// jwtClaims are the claims the tenant middleware reads: the tenant the
+// token is for, the user it was issued to and, for library members, their
+// member type.
+type jwtClaims struct {
+	TenantID   string `json:"tenant_id"`
+	Subject    string `json:"sub"`
+	MemberType string `json:"member_type"`
+	Exp        int64  `json:"exp"`
+}
+
+// verifyJWT verifies an HS256 JSON Web Token and returns its claims, which
+// always name a tenant. Only what the tenant middleware needs is
+// supported: no other algorithms, no key IDs.
+func verifyJWT(token string, secret []byte, now time.Time) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtClaims{}, errInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return jwtClaims{}, errInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtClaims{}, errInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return jwtClaims{}, errInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.TenantID == "" {
+		return jwtClaims{}, errInvalidToken
+	}
+	if claims.Exp != 0 && now.Unix() >= claims.Exp {
+		return jwtClaims{}, errors.New("bearer token expired")
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// LoanHandler serves circulation: the copies of a book, their loans and
+// the hold queue for them.
+type LoanHandler struct {
+	uc usecase.LoanUsecase
+}
+
+func NewLoanHandler(uc usecase.LoanUsecase) *LoanHandler {
+	return &LoanHandler{uc: uc}
+}
+
+func (h *LoanHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/books/{id}/copies", h.AddCopy, writeTimeout},
+		{http.MethodGet, "/v1/books/{id}/copies", h.ListCopies, readTimeout},
+		{http.MethodPost, "/v1/loans", h.Checkout, writeTimeout},
+		{http.MethodGet, "/v1/loans", h.ListLoans, readTimeout},
+		{http.MethodGet, "/v1/loans/{id}", h.GetLoan, readTimeout},
+		{http.MethodPost, "/v1/loans/{id}/return", h.Return, writeTimeout},
+		{http.MethodPost, "/v1/loans/{id}/renew", h.Renew, writeTimeout},
+		{http.MethodPost, "/v1/loans/{id}/lost", h.ReportLost, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/holds", h.PlaceHold, writeTimeout},
+		{http.MethodGet, "/v1/books/{id}/holds", h.ListHolds, readTimeout},
+		{http.MethodGet, "/v1/books/{id}/holds/{hold_id}", h.GetHold, readTimeout},
+		{http.MethodDelete, "/v1/books/{id}/holds/{hold_id}", h.CancelHold, writeTimeout},
+	}
+}
+
+func (h *LoanHandler) AddCopy(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.CreateCopyInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	c, err := h.uc.AddCopy(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: c}
+}
+
+func (h *LoanHandler) ListCopies(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	copies, err := h.uc.ListCopies(ctx, bookID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: copies}
+}
+
+func (h *LoanHandler) Checkout(ctx context.Context, req *Request) Response {
+	var in domain.CheckoutInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	l, err := h.uc.Checkout(ctx, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: l}
+}
+
+// ListLoans serves GET /v1/loans?member_id=&copy_id=&open=true&overdue=true.
+func (h *LoanHandler) ListLoans(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	q := domain.ListLoansQuery{MemberID: req.Query.Get("member_id"), Limit: limit, Offset: offset}
+	if v := req.Query.Get("copy_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return errorBody(http.StatusBadRequest, "invalid copy_id")
+		}
+		q.CopyID = id
+	}
+	var overdue bool
+	for name, dst := range map[string]*bool{"open": &q.OpenOnly, "overdue": &overdue} {
+		if v := req.Query.Get(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errorBody(http.StatusBadRequest, "invalid "+name)
+			}
+			*dst = b
+		}
+	}
+
+	loans, err := h.uc.ListLoans(ctx, q, overdue)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: loans}
+}
+
+func (h *LoanHandler) GetLoan(ctx context.Context, req *Request) Response {
+	return h.loanAction(ctx, req, h.uc.GetLoan)
+}
+
+func (h *LoanHandler) Return(ctx context.Context, req *Request) Response {
+	return h.loanAction(ctx, req, h.uc.Return)
+}
+
+func (h *LoanHandler) Renew(ctx context.Context, req *Request) Response {
+	return h.loanAction(ctx, req, h.uc.Renew)
+}
+
+func (h *LoanHandler) ReportLost(ctx context.Context, req *Request) Response {
+	return h.loanAction(ctx, req, h.uc.ReportLost)
+}
+
+// loanAction runs fn on the loan named in the path and responds with the
+// loan as it is afterwards.
+func (h *LoanHandler) loanAction(ctx context.Context, req *Request, fn func(context.Context, int64) (*domain.Loan, error)) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid loan id")
+	}
+
+	l, err := fn(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: l}
+}
+
+func (h *LoanHandler) PlaceHold(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	hold, err := h.uc.PlaceHold(ctx, bookID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: hold}
+}
+
+// ListHolds serves GET /v1/books/{id}/holds?member_id=&active=true, in
+// queue order.
+func (h *LoanHandler) ListHolds(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	q := domain.ListHoldsQuery{BookID: bookID, MemberID: req.Query.Get("member_id"), Limit: limit, Offset: offset}
+	if v := req.Query.Get("active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errorBody(http.StatusBadRequest, "invalid active")
+		}
+		q.ActiveOnly = b
+	}
+
+	holds, err := h.uc.ListHolds(ctx, q)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: holds}
+}
+
+func (h *LoanHandler) GetHold(ctx context.Context, req *Request) Response {
+	return h.holdAction(ctx, req, h.uc.GetHold)
+}
+
+func (h *LoanHandler) CancelHold(ctx context.Context, req *Request) Response {
+	return h.holdAction(ctx, req, h.uc.CancelHold)
+}
+
+// holdAction runs fn on the hold named in the path and responds with the
+// hold as it is afterwards.
+func (h *LoanHandler) holdAction(ctx context.Context, req *Request, fn func(context.Context, int64, int64) (*domain.Hold, error)) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	id, ok := pathID(req, "hold_id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid hold id")
+	}
+
+	hold, err := fn(ctx, bookID, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: hold}
+}
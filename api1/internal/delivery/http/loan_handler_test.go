@@ -0,0 +1,162 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/requestmeta"
+)
+
+func TestCheckout(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().Checkout(gomock.Any(), domain.CheckoutInput{CopyID: 3}).
+				DoAndReturn(func(ctx context.Context, in domain.CheckoutInput) (*domain.Loan, error) {
+					meta := requestmeta.FromContext(ctx)
+					assert.Equal(t, "m-1", meta.Actor)
+					assert.Equal(t, "student", meta.MemberType, "the member type comes from the token")
+					return &domain.Loan{
+						ID: 1, CopyID: 3, Status: domain.LoanOnLoan,
+						DueAt: time.Date(2025, 10, 22, 23, 59, 59, 0, time.UTC), DueDate: "22 October 2025",
+					}, nil
+				})
+			tenants := usecase_mock.NewMockTenantUsecase(ctrl)
+			tenants.EXPECT().ActiveTenant(gomock.Any(), "acme").Return(&domain.Tenant{ID: "acme"}, nil)
+
+			body := `{"copy_id":3,"member_type":"staff"}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/loans", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+signJWT(jwtSecret, `{"tenant_id":"acme","sub":"m-1","member_type":"student"}`))
+			auth := httpdelivery.TenantAuth{JWTSecret: jwtSecret}
+			res := do(t, httpdelivery.WithTenant(auth, tenants, httpdelivery.NewLoanHandler(uc)), req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			var got map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "22 October 2025", got["due_date"])
+		})
+	}
+}
+
+func TestCheckout_CopyUnavailable(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().Checkout(gomock.Any(), gomock.Any()).Return(nil, domain.ErrCopyUnavailable)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/loans", strings.NewReader(`{"copy_id":3}`))
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusConflict, res.StatusCode)
+		})
+	}
+}
+
+func TestListLoans_Filters(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().ListLoans(gomock.Any(), domain.ListLoansQuery{MemberID: "m-1", CopyID: 3, Limit: 5}, true).
+				Return([]*domain.Loan{{ID: 1, Status: domain.LoanOverdue}}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/loans?member_id=m-1&copy_id=3&overdue=true&limit=5", nil)
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestListLoans_InvalidFlag(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/loans?overdue=maybe", nil)
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestReturnLoan_AlreadyClosed(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().Return(gomock.Any(), int64(1)).Return(nil, domain.ErrLoanClosed)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/loans/1/return", nil)
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusConflict, res.StatusCode)
+		})
+	}
+}
+
+func TestPlaceHold(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().PlaceHold(gomock.Any(), int64(7)).Return(&domain.Hold{
+				ID: 2, BookID: 7, MemberID: "m-1", Status: domain.HoldQueued, Position: 3,
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/books/7/holds", nil)
+			res := do(t, signedIn(t, req, "m-1", httpdelivery.NewLoanHandler(uc)), req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			var got map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, float64(3), got["position"])
+		})
+	}
+}
+
+func TestListHolds_Filters(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().ListHolds(gomock.Any(), domain.ListHoldsQuery{BookID: 7, MemberID: "m-1", ActiveOnly: true}).
+				Return([]*domain.Hold{}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/holds?member_id=m-1&active=true", nil)
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestCancelHold_AlreadyClosed(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockLoanUsecase(ctrl)
+			uc.EXPECT().CancelHold(gomock.Any(), int64(7), int64(2)).Return(nil, domain.ErrHoldClosed)
+
+			req := httptest.NewRequest(http.MethodDelete, "/v1/books/7/holds/2", nil)
+			res := do(t, httpdelivery.NewLoanHandler(uc), req)
+
+			assert.Equal(t, http.StatusConflict, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+const (
+	headerTenant     = "X-Tenant-ID"
+	headerAdminToken = "X-Admin-Token"
+)
+
+// wrappedRoutes applies wrap to every handler of sets. It is how
+// middleware is attached without the adapters knowing about it.
+type wrappedRoutes struct {
+	sets []RouteSet
+	wrap func(handlerFunc) handlerFunc
+}
+
+func (w wrappedRoutes) routes() []route {
+	var out []route
+	for _, set := range w.sets {
+		for _, rt := range set.routes() {
+			rt.handle = w.wrap(rt.handle)
+			out = append(out, rt)
+		}
+	}
+	return out
+}
+
+// TenantAuth says where the tenant of a request may come from.
+type TenantAuth struct {
+	// JWTSecret verifies HS256 bearer tokens carrying a tenant_id claim.
+	// Their sub claim, if any, becomes the actor of the request, and their
+	// member_type claim picks the loan policy of that actor.
+	JWTSecret []byte
+	// TrustHeader accepts the X-Tenant-ID header. Only enable it behind a
+	// gateway that sets the header itself and strips it from clients.
+	// Such requests are anonymous.
+	TrustHeader bool
+}
+
+// WithTenant makes every route in sets act for the tenant of the request,
+// and for the user a bearer token was issued to. Requests without a
+// verifiable tenant get 401; those for an unknown or suspended tenant get
+// 403.
+func WithTenant(auth TenantAuth, tenants usecase.TenantUsecase, sets ...RouteSet) RouteSet {
+	return wrappedRoutes{sets: sets, wrap: func(next handlerFunc) handlerFunc {
+		return func(ctx context.Context, req *Request) Response {
+			claims, err := auth.credentialsOf(req.Header, time.Now())
+			if err != nil {
+				return errorBody(http.StatusUnauthorized, err.Error())
+			}
+			if _, err := tenants.ActiveTenant(ctx, claims.TenantID); err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					return errorBody(http.StatusForbidden, "unknown tenant")
+				}
+				return errorResponse(err)
+			}
+			if claims.Subject != "" {
+				ctx = requestmeta.WithActor(ctx, claims.Subject)
+			}
+			if claims.MemberType != "" {
+				ctx = requestmeta.WithMemberType(ctx, claims.MemberType)
+			}
+			return next(tenancy.WithTenant(ctx, claims.TenantID), req)
+		}
+	}}
+}
+
+// credentialsOf returns the tenant of a request and, when a token names
+// one, the user making it.
+func (a TenantAuth) credentialsOf(h http.Header, now time.Time) (jwtClaims, error) {
+	if token, ok := strings.CutPrefix(h.Get("Authorization"), "Bearer "); ok && len(a.JWTSecret) > 0 {
+		return verifyJWT(token, a.JWTSecret, now)
+	}
+	if a.TrustHeader && h.Get(headerTenant) != "" {
+		return jwtClaims{TenantID: h.Get(headerTenant)}, nil
+	}
+	return jwtClaims{}, tenancy.ErrNoTenant
+}
+
+// RequireAdmin guards sets with a shared operator token sent in the
+// X-Admin-Token header. An empty token locks the routes entirely.
+func RequireAdmin(token string, sets ...RouteSet) RouteSet {
+	return wrappedRoutes{sets: sets, wrap: func(next handlerFunc) handlerFunc {
+		return func(ctx context.Context, req *Request) Response {
+			got := req.Header.Get(headerAdminToken)
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return errorBody(http.StatusUnauthorized, "admin token required")
+			}
+			return next(ctx, req)
+		}
+	}}
+}
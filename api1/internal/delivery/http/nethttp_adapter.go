@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+
+	"unit-test-demo/api1/internal/requestmeta"
+)
+
+// NewHTTPHandler returns a net/http handler serving the routes of each
+// handler. It can be mounted in any mux or exercised directly with
+// httptest.NewRecorder.
+func NewHTTPHandler(sets ...RouteSet) http.Handler {
+	mux := http.NewServeMux()
+	for _, set := range sets {
+		for _, rt := range set.routes() {
+			mux.Handle(rt.method+" "+rt.path, netHTTPHandler(rt))
+		}
+	}
+	return mux
+}
+
+func netHTTPHandler(rt route) http.Handler {
+	names := rt.params()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]string, len(names))
+		for _, n := range names {
+			params[n] = r.PathValue(n)
+		}
+
+		meta := newRequestMeta(r.Header.Get(headerRequestID), clientIP(r))
+		w.Header().Set(headerRequestID, meta.RequestID)
+		ctx := requestmeta.WithMeta(r.Context(), meta)
+
+		// r.Context is cancelled when the client disconnects.
+		res := rt.serve(ctx, &Request{
+			Body:   r.Body,
+			Params: params,
+			Query:  r.URL.Query(),
+			Header: r.Header,
+		})
+		writeResponse(w, res)
+	})
+}
+
+func writeResponse(w http.ResponseWriter, res Response) {
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	if stream, ok := res.Body.(StreamBody); ok {
+		w.WriteHeader(res.Status)
+		if err := stream(w); err != nil {
+			// The status is long gone; breaking the connection is the only
+			// way left to tell the client the body is cut short.
+			panic(http.ErrAbortHandler)
+		}
+		return
+	}
+	if r, ok := res.Body.(io.Reader); ok {
+		if c, ok := r.(io.Closer); ok {
+			defer c.Close()
+		}
+		w.WriteHeader(res.Status)
+		_, _ = io.Copy(w, r)
+		return
+	}
+	writeJSON(w, res.Status, res.Body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// clientIP strips the port from RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// OrderHandler serves book prices, the actor's cart and the
+// orders placed from it. The customer is the actor of the request, the
+// subject of its bearer token.
+type OrderHandler struct {
+	uc usecase.OrderUsecase
+}
+
+func NewOrderHandler(uc usecase.OrderUsecase) *OrderHandler {
+	return &OrderHandler{uc: uc}
+}
+
+func (h *OrderHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/books/{id}/inventory", h.GetInventory, readTimeout},
+		{http.MethodPut, "/v1/books/{id}/inventory", h.SetInventory, writeTimeout},
+		{http.MethodGet, "/v1/cart", h.GetCart, readTimeout},
+		{http.MethodDelete, "/v1/cart", h.ClearCart, writeTimeout},
+		{http.MethodPut, "/v1/cart/lines/{book_id}", h.SetCartLine, writeTimeout},
+		{http.MethodPost, "/v1/orders", h.PlaceOrder, writeTimeout},
+		{http.MethodGet, "/v1/orders", h.ListOrders, readTimeout},
+		{http.MethodGet, "/v1/orders/{id}", h.GetOrder, readTimeout},
+	}
+}
+
+func (h *OrderHandler) GetInventory(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	inv, err := h.uc.GetInventory(ctx, bookID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: inv}
+}
+
+// SetInventory serves PUT /v1/books/{id}/inventory with
+// {"price_cents":1999}.
+func (h *OrderHandler) SetInventory(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.InventoryInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	inv, err := h.uc.SetInventory(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: inv}
+}
+
+func (h *OrderHandler) GetCart(ctx context.Context, req *Request) Response {
+	cart, err := h.uc.GetCart(ctx)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: cart}
+}
+
+func (h *OrderHandler) ClearCart(ctx context.Context, req *Request) Response {
+	if err := h.uc.ClearCart(ctx); err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusNoContent}
+}
+
+// SetCartLine serves PUT /v1/cart/lines/{book_id} with {"qty":2}; a qty of
+// 0 takes the book out of the cart.
+func (h *OrderHandler) SetCartLine(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "book_id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.SetCartLineInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	cart, err := h.uc.SetCartLine(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: cart}
+}
+
+// PlaceOrder serves POST /v1/orders, checking out the cart. The body is
+// optional and may carry a coupon and a token for the card to charge:
+// {"coupon":"SUMMER10","payment_token":"tok_visa"}. A paid order comes
+// back paid, cancelled or, while the gateway has yet to say, awaiting
+// payment; GET /v1/orders/{id}/payment follows it.
+func (h *OrderHandler) PlaceOrder(ctx context.Context, req *Request) Response {
+	var in domain.PlaceOrderInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	order, err := h.uc.PlaceOrder(ctx, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: order}
+}
+
+func (h *OrderHandler) ListOrders(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	orders, err := h.uc.ListOrders(ctx, limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: orders}
+}
+
+func (h *OrderHandler) GetOrder(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid order id")
+	}
+
+	order, err := h.uc.GetOrder(ctx, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: order}
+}
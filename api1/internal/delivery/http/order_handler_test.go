@@ -0,0 +1,139 @@
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockOrderUsecase(ctrl)
+			uc.EXPECT().PlaceOrder(gomock.Any(), domain.PlaceOrderInput{Coupon: "SUMMER20"}).
+				Return(&domain.Order{
+					ID:       4,
+					Customer: "alice",
+					Status:   domain.OrderPlaced,
+					Lines:    []domain.OrderLine{{BookID: 7, Title: "Dune", Qty: 2, UnitPriceCents: 1999}},
+					Totals:   domain.OrderTotals{SubtotalCents: 3998, DiscountCents: 800, TaxCents: 320, TotalCents: 3518},
+				}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(`{"coupon":"SUMMER20"}`))
+			res := do(t, signedIn(t, req, "alice", httpdelivery.NewOrderHandler(uc)), req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			var got map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, map[string]any{
+				"subtotal_cents": 3998.0, "discount_cents": 800.0, "tax_cents": 320.0, "total_cents": 3518.0,
+			}, got["totals"])
+		})
+	}
+}
+
+func TestOrderRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(uc *usecase_mock.MockOrderUsecase)
+		want   int
+	}{
+		{
+			name: "order without body", method: http.MethodPost, path: "/v1/orders",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().PlaceOrder(gomock.Any(), domain.PlaceOrderInput{}).Return(&domain.Order{ID: 1}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "out of stock", method: http.MethodPost, path: "/v1/orders",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().PlaceOrder(gomock.Any(), gomock.Any()).Return(nil, domain.ErrOutOfStock)
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "anonymous", method: http.MethodGet, path: "/v1/cart",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().GetCart(gomock.Any()).Return(nil, fmt.Errorf("%w: sign in to shop", usecase.ErrForbidden))
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "set cart line", method: http.MethodPut, path: "/v1/cart/lines/7", body: `{"qty":2}`,
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().SetCartLine(gomock.Any(), int64(7), domain.SetCartLineInput{Qty: 2}).
+					Return(&domain.Cart{Lines: []domain.CartLine{{BookID: 7, Qty: 2}}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "clear cart", method: http.MethodDelete, path: "/v1/cart",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().ClearCart(gomock.Any()).Return(nil)
+			},
+			want: http.StatusNoContent,
+		},
+		{
+			name: "set inventory", method: http.MethodPut, path: "/v1/books/7/inventory", body: `{"price_cents":1999}`,
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().SetInventory(gomock.Any(), int64(7), domain.InventoryInput{PriceCents: 1999}).
+					Return(&domain.Inventory{BookID: 7, PriceCents: 1999}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "not for sale", method: http.MethodGet, path: "/v1/books/7/inventory",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().GetInventory(gomock.Any(), int64(7)).Return(nil, domain.ErrNotFound)
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "list orders", method: http.MethodGet, path: "/v1/orders?limit=5",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {
+				uc.EXPECT().ListOrders(gomock.Any(), 5, 0).Return([]*domain.Order{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "invalid order id", method: http.MethodGet, path: "/v1/orders/abc",
+			expect: func(uc *usecase_mock.MockOrderUsecase) {},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name: "invalid body", method: http.MethodPut, path: "/v1/cart/lines/7", body: `{`,
+			expect: func(uc *usecase_mock.MockOrderUsecase) {},
+			want:   http.StatusBadRequest,
+		},
+	}
+	for name, do := range adapters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockOrderUsecase(ctrl)
+				tt.expect(uc)
+
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				res := do(t, httpdelivery.NewOrderHandler(uc), req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
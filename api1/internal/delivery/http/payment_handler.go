@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+	"unit-test-demo/api1/internal/webhook"
+)
+
+// PaymentHandler serves the payment of one of the actor's orders.
+type PaymentHandler struct {
+	uc usecase.PaymentUsecase
+}
+
+func NewPaymentHandler(uc usecase.PaymentUsecase) *PaymentHandler {
+	return &PaymentHandler{uc: uc}
+}
+
+func (h *PaymentHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/orders/{id}/payment", h.GetOrderPayment, readTimeout},
+	}
+}
+
+func (h *PaymentHandler) GetOrderPayment(ctx context.Context, req *Request) Response {
+	orderID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid order id")
+	}
+
+	p, err := h.uc.GetOrderPayment(ctx, orderID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: p}
+}
+
+// PaymentAdminHandler lets operators refund and void the payments of any
+// tenant's orders. Mount it behind RequireAdmin.
+type PaymentAdminHandler struct {
+	uc usecase.PaymentUsecase
+}
+
+func NewPaymentAdminHandler(uc usecase.PaymentUsecase) *PaymentAdminHandler {
+	return &PaymentAdminHandler{uc: uc}
+}
+
+func (h *PaymentAdminHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/admin/tenants/{id}/orders/{order_id}/payment/refund", h.Refund, writeTimeout},
+		{http.MethodPost, "/v1/admin/tenants/{id}/orders/{order_id}/payment/void", h.Void, writeTimeout},
+	}
+}
+
+func (h *PaymentAdminHandler) Refund(ctx context.Context, req *Request) Response {
+	return h.move(ctx, req, h.uc.Refund)
+}
+
+func (h *PaymentAdminHandler) Void(ctx context.Context, req *Request) Response {
+	return h.move(ctx, req, h.uc.Void)
+}
+
+func (h *PaymentAdminHandler) move(ctx context.Context, req *Request, fn func(context.Context, int64) (*domain.Payment, error)) Response {
+	orderID, ok := pathID(req, "order_id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid order id")
+	}
+
+	p, err := fn(tenancy.WithTenant(ctx, req.Params["id"]), orderID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: p}
+}
+
+// webhookTolerance is how far a payment webhook's timestamp may be from
+// now before it is taken for a replay.
+const webhookTolerance = 5 * time.Minute
+
+// maxWebhookBytes caps the payment webhook bodies read.
+const maxWebhookBytes = 64 << 10
+
+// PaymentWebhookHandler takes the webhooks the payment gateway sends when
+// a payment settles. They come from the gateway, not a tenant, so it is
+// mounted outside WithTenant; each must carry a signature made with
+// secret, as the webhook package signs, or it is turned away with 401.
+type PaymentWebhookHandler struct {
+	uc     usecase.PaymentUsecase
+	secret string
+}
+
+func NewPaymentWebhookHandler(uc usecase.PaymentUsecase, secret string) *PaymentWebhookHandler {
+	return &PaymentWebhookHandler{uc: uc, secret: secret}
+}
+
+func (h *PaymentWebhookHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/payments/webhook", h.Receive, writeTimeout},
+	}
+}
+
+// Receive serves POST /v1/payments/webhook with the payment as the
+// gateway has it, {"ref":"pay_1","reference":"acme:4","status":"captured"}.
+func (h *PaymentWebhookHandler) Receive(ctx context.Context, req *Request) Response {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookBytes))
+	if err != nil {
+		return errorBody(http.StatusBadRequest, "invalid body")
+	}
+	err = webhook.Verify(h.secret, req.Header.Get(webhook.HeaderSignature), req.Header.Get(webhook.HeaderTimestamp),
+		body, webhookTolerance, time.Now())
+	if errors.Is(err, webhook.ErrInvalidSignature) || errors.Is(err, webhook.ErrStaleTimestamp) {
+		return errorBody(http.StatusUnauthorized, err.Error())
+	}
+
+	var gp domain.GatewayPayment
+	if err := json.Unmarshal(body, &gp); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	p, err := h.uc.ApplyGatewayPayment(ctx, gp)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: p}
+}
@@ -0,0 +1,140 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/webhook"
+)
+
+func TestGetOrderPayment(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockPaymentUsecase(ctrl)
+			uc.EXPECT().GetOrderPayment(gomock.Any(), int64(4)).
+				Return(&domain.Payment{ID: 2, OrderID: 4, Status: domain.PaymentCaptured, AmountCents: 4398, GatewayRef: "pay_1"}, nil)
+
+			res := do(t, httpdelivery.NewPaymentHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/orders/4/payment", nil))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got domain.Payment
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, domain.PaymentCaptured, got.Status)
+			assert.EqualValues(t, 4398, got.AmountCents)
+		})
+	}
+}
+
+func TestPaymentAdminRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		expect func(uc *usecase_mock.MockPaymentUsecase)
+		want   int
+	}{
+		{
+			name: "refund", path: "/v1/admin/tenants/acme/orders/4/payment/refund",
+			expect: func(uc *usecase_mock.MockPaymentUsecase) {
+				uc.EXPECT().Refund(gomock.Any(), int64(4)).
+					DoAndReturn(func(ctx context.Context, orderID int64) (*domain.Payment, error) {
+						tenant, _ := tenancy.FromContext(ctx)
+						assert.Equal(t, "acme", tenant)
+						return &domain.Payment{OrderID: orderID, Status: domain.PaymentRefunded}, nil
+					})
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "void captured", path: "/v1/admin/tenants/acme/orders/4/payment/void",
+			expect: func(uc *usecase_mock.MockPaymentUsecase) {
+				uc.EXPECT().Void(gomock.Any(), int64(4)).Return(nil, domain.ErrPaymentState)
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "gateway down", path: "/v1/admin/tenants/acme/orders/4/payment/refund",
+			expect: func(uc *usecase_mock.MockPaymentUsecase) {
+				uc.EXPECT().Refund(gomock.Any(), int64(4)).Return(nil, domain.ErrUnavailable)
+			},
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "bad order id", path: "/v1/admin/tenants/acme/orders/x/payment/void",
+			want: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockPaymentUsecase(ctrl)
+				if tt.expect != nil {
+					tt.expect(uc)
+				}
+				h := httpdelivery.RequireAdmin("secret", httpdelivery.NewPaymentAdminHandler(uc))
+
+				req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+				req.Header.Set("X-Admin-Token", "secret")
+				res := do(t, h, req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	const body = `{"ref":"pay_1","reference":"acme:2","status":"captured"}`
+	sign := func(secret string, at time.Time) func(*http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(at.Unix(), 10))
+			req.Header.Set(webhook.HeaderSignature, webhook.Sign(secret, at, []byte(body)))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		sign   func(*http.Request)
+		expect bool
+		want   int
+	}{
+		{name: "signed", sign: sign("s3cret", time.Now()), expect: true, want: http.StatusOK},
+		{name: "wrong secret", sign: sign("guess", time.Now()), want: http.StatusUnauthorized},
+		{name: "replayed", sign: sign("s3cret", time.Now().Add(-time.Hour)), want: http.StatusUnauthorized},
+		{name: "unsigned", sign: func(*http.Request) {}, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockPaymentUsecase(ctrl)
+				if tt.expect {
+					uc.EXPECT().ApplyGatewayPayment(gomock.Any(),
+						domain.GatewayPayment{Ref: "pay_1", Reference: "acme:2", Status: domain.PaymentCaptured}).
+						Return(&domain.Payment{ID: 2, Status: domain.PaymentCaptured}, nil)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/v1/payments/webhook", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				tt.sign(req)
+				res := do(t, httpdelivery.NewPaymentWebhookHandler(uc, "s3cret"), req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
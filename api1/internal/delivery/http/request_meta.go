@@ -0,0 +1,20 @@
+package http
+
+import (
+	"github.com/google/uuid"
+
+	"unit-test-demo/api1/internal/requestmeta"
+)
+
+const headerRequestID = "X-Request-ID"
+
+// newRequestMeta builds the metadata both adapters put in the request
+// context. Clients may pass their own request ID; otherwise one is made up
+// so every audit record can still be correlated with logs. The actor is
+// left to WithTenant, which takes it from a verified token.
+func newRequestMeta(requestID, clientIP string) requestmeta.Meta {
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	return requestmeta.Meta{RequestID: requestID, ClientIP: clientIP}
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// ReviewHandler serves the reviews of a book. The reviewer is the actor of
+// the request, the subject of its bearer token.
+type ReviewHandler struct {
+	uc usecase.ReviewUsecase
+}
+
+func NewReviewHandler(uc usecase.ReviewUsecase) *ReviewHandler {
+	return &ReviewHandler{uc: uc}
+}
+
+func (h *ReviewHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/books/{id}/reviews", h.CreateReview, writeTimeout},
+		{http.MethodGet, "/v1/books/{id}/reviews", h.ListReviews, readTimeout},
+		{http.MethodGet, "/v1/books/{id}/reviews/{review_id}", h.GetReview, readTimeout},
+		{http.MethodPut, "/v1/books/{id}/reviews/{review_id}", h.UpdateReview, writeTimeout},
+		{http.MethodDelete, "/v1/books/{id}/reviews/{review_id}", h.DeleteReview, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/reviews/{review_id}/helpful", h.MarkHelpful, writeTimeout},
+	}
+}
+
+func (h *ReviewHandler) CreateReview(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.ReviewInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	review, err := h.uc.CreateReview(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: review}
+}
+
+// ListReviews serves GET /v1/books/{id}/reviews?sort=recent|helpful.
+func (h *ReviewHandler) ListReviews(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+	sort := domain.ReviewSort(req.Query.Get("sort"))
+	if sort != "" && sort != domain.ReviewSortRecent && sort != domain.ReviewSortHelpful {
+		return errorBody(http.StatusBadRequest, "sort must be recent or helpful")
+	}
+
+	reviews, err := h.uc.ListReviews(ctx, domain.ListReviewsQuery{BookID: bookID, Sort: sort, Limit: limit, Offset: offset})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: reviews}
+}
+
+func (h *ReviewHandler) GetReview(ctx context.Context, req *Request) Response {
+	bookID, id, errResp := reviewIDs(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	review, err := h.uc.GetReview(ctx, bookID, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: review}
+}
+
+func (h *ReviewHandler) UpdateReview(ctx context.Context, req *Request) Response {
+	bookID, id, errResp := reviewIDs(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	var in domain.ReviewInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	review, err := h.uc.UpdateReview(ctx, bookID, id, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: review}
+}
+
+func (h *ReviewHandler) DeleteReview(ctx context.Context, req *Request) Response {
+	bookID, id, errResp := reviewIDs(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	if err := h.uc.DeleteReview(ctx, bookID, id); err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusNoContent}
+}
+
+func (h *ReviewHandler) MarkHelpful(ctx context.Context, req *Request) Response {
+	bookID, id, errResp := reviewIDs(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	review, err := h.uc.MarkHelpful(ctx, bookID, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: review}
+}
+
+// reviewIDs reads the book and review ids from the path.
+func reviewIDs(req *Request) (bookID, id int64, errResp *Response) {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		res := errorBody(http.StatusBadRequest, "invalid book id")
+		return 0, 0, &res
+	}
+	id, ok = pathID(req, "review_id")
+	if !ok {
+		res := errorBody(http.StatusBadRequest, "invalid review id")
+		return 0, 0, &res
+	}
+	return bookID, id, nil
+}
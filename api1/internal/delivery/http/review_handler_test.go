@@ -0,0 +1,137 @@
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestCreateReview(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockReviewUsecase(ctrl)
+			uc.EXPECT().CreateReview(gomock.Any(), int64(7), domain.ReviewInput{Rating: 5, Text: "Great"}).
+				Return(&domain.Review{ID: 1, BookID: 7, Reviewer: "alice", Rating: 5, Text: "Great"}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/books/7/reviews", strings.NewReader(`{"rating":5,"text":"Great"}`))
+			res := do(t, signedIn(t, req, "alice", httpdelivery.NewReviewHandler(uc)), req)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			var got domain.Review
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "alice", got.Reviewer)
+		})
+	}
+}
+
+func TestListReviews_Sort(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockReviewUsecase(ctrl)
+			uc.EXPECT().ListReviews(gomock.Any(), domain.ListReviewsQuery{BookID: 7, Sort: domain.ReviewSortHelpful, Limit: 10}).
+				Return([]*domain.Review{{ID: 2}, {ID: 1}}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/reviews?sort=helpful&limit=10", nil)
+			res := do(t, httpdelivery.NewReviewHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got []domain.Review
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Len(t, got, 2)
+		})
+	}
+}
+
+func TestListReviews_InvalidSort(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockReviewUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/reviews?sort=stars", nil)
+			res := do(t, httpdelivery.NewReviewHandler(uc), req)
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestReviewRoutes_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(uc *usecase_mock.MockReviewUsecase)
+		want   int
+	}{
+		{
+			name: "update someone else's", method: http.MethodPut, path: "/v1/books/7/reviews/3", body: `{"rating":1}`,
+			expect: func(uc *usecase_mock.MockReviewUsecase) {
+				uc.EXPECT().UpdateReview(gomock.Any(), int64(7), int64(3), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: not your review", usecase.ErrForbidden))
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "second review", method: http.MethodPost, path: "/v1/books/7/reviews", body: `{"rating":1}`,
+			expect: func(uc *usecase_mock.MockReviewUsecase) {
+				uc.EXPECT().CreateReview(gomock.Any(), int64(7), gomock.Any()).Return(nil, domain.ErrConflict)
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "bad rating", method: http.MethodPost, path: "/v1/books/7/reviews", body: `{"rating":9}`,
+			expect: func(uc *usecase_mock.MockReviewUsecase) {
+				uc.EXPECT().CreateReview(gomock.Any(), int64(7), gomock.Any()).Return(nil, usecase.ErrValidation)
+			},
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "delete", method: http.MethodDelete, path: "/v1/books/7/reviews/3",
+			expect: func(uc *usecase_mock.MockReviewUsecase) {
+				uc.EXPECT().DeleteReview(gomock.Any(), int64(7), int64(3)).Return(nil)
+			},
+			want: http.StatusNoContent,
+		},
+		{
+			name: "helpful", method: http.MethodPost, path: "/v1/books/7/reviews/3/helpful",
+			expect: func(uc *usecase_mock.MockReviewUsecase) {
+				uc.EXPECT().MarkHelpful(gomock.Any(), int64(7), int64(3)).Return(&domain.Review{ID: 3, HelpfulCount: 1}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "invalid review id", method: http.MethodGet, path: "/v1/books/7/reviews/abc",
+			expect: func(uc *usecase_mock.MockReviewUsecase) {},
+			want:   http.StatusBadRequest,
+		},
+	}
+	for name, do := range adapters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockReviewUsecase(ctrl)
+				tt.expect(uc)
+
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				res := do(t, httpdelivery.NewReviewHandler(uc), req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+)
+
+func TestGetBook_AsOf(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+			uc.EXPECT().GetBookAsOf(gomock.Any(), int64(7), at).
+				Return(&domain.Book{ID: 7, Title: "Old title"}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7?as_of=2025-03-01T09:30:00Z", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got domain.Book
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, "Old title", got.Title)
+		})
+	}
+}
+
+func TestGetBook_AsOf_Invalid(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7?as_of=yesterday", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestListRevisions(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().ListRevisions(gomock.Any(), int64(7), 10, 0).
+				Return([]*domain.BookRevision{{Revision: 1}, {Revision: 2}}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/books/7/revisions?limit=10", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got []domain.BookRevision
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Len(t, got, 2)
+		})
+	}
+}
+
+func TestRevertBook(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockBookUsecase(ctrl)
+			uc.EXPECT().RevertBook(gomock.Any(), int64(7), 2).
+				Return(&domain.Book{ID: 7, Title: "Restored"}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/books/7/revisions/2/revert", nil)
+			res := do(t, httpdelivery.NewBookHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// StockHandler serves the stock of a book: its level, its ledger of
+// movements and the reservations held against it.
+type StockHandler struct {
+	uc usecase.StockUsecase
+}
+
+func NewStockHandler(uc usecase.StockUsecase) *StockHandler {
+	return &StockHandler{uc: uc}
+}
+
+func (h *StockHandler) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/books/{id}/stock", h.GetStock, readTimeout},
+		{http.MethodGet, "/v1/books/{id}/stock/movements", h.ListMovements, readTimeout},
+		{http.MethodPost, "/v1/books/{id}/stock/receipts", h.ReceiveStock, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/stock/adjustments", h.AdjustStock, writeTimeout},
+		{http.MethodPut, "/v1/books/{id}/stock/threshold", h.SetLowStockThreshold, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/stock/reservations", h.Reserve, writeTimeout},
+		{http.MethodGet, "/v1/books/{id}/stock/reservations/{reservation_id}", h.GetReservation, readTimeout},
+		{http.MethodDelete, "/v1/books/{id}/stock/reservations/{reservation_id}", h.Release, writeTimeout},
+		{http.MethodPost, "/v1/books/{id}/stock/reservations/{reservation_id}/sell", h.SellReservation, writeTimeout},
+	}
+}
+
+func (h *StockHandler) GetStock(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	l, err := h.uc.GetStock(ctx, bookID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: l}
+}
+
+func (h *StockHandler) ListMovements(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	movements, err := h.uc.ListMovements(ctx, bookID, limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: movements}
+}
+
+// ReceiveStock serves POST /v1/books/{id}/stock/receipts with
+// {"qty":12,"note":"delivery 4411"}.
+func (h *StockHandler) ReceiveStock(ctx context.Context, req *Request) Response {
+	return h.record(ctx, req, h.uc.ReceiveStock)
+}
+
+// AdjustStock serves POST /v1/books/{id}/stock/adjustments with
+// {"qty":-2,"note":"damaged"}.
+func (h *StockHandler) AdjustStock(ctx context.Context, req *Request) Response {
+	return h.record(ctx, req, h.uc.AdjustStock)
+}
+
+func (h *StockHandler) record(ctx context.Context, req *Request, fn func(context.Context, int64, domain.StockInput) (*domain.StockLevel, error)) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.StockInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	l, err := fn(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: l}
+}
+
+// SetLowStockThreshold serves PUT /v1/books/{id}/stock/threshold with
+// {"low_stock_threshold":3}.
+func (h *StockHandler) SetLowStockThreshold(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.LowStockInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	l, err := h.uc.SetLowStockThreshold(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: l}
+}
+
+// Reserve serves POST /v1/books/{id}/stock/reservations with
+// {"qty":1,"ttl_seconds":600,"ref":"till 3"}; ttl_seconds may be left out.
+func (h *StockHandler) Reserve(ctx context.Context, req *Request) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+
+	var in domain.ReserveStockInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	res, err := h.uc.Reserve(ctx, bookID, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: res}
+}
+
+func (h *StockHandler) GetReservation(ctx context.Context, req *Request) Response {
+	return h.reservationAction(ctx, req, h.uc.GetReservation)
+}
+
+func (h *StockHandler) Release(ctx context.Context, req *Request) Response {
+	return h.reservationAction(ctx, req, h.uc.Release)
+}
+
+func (h *StockHandler) SellReservation(ctx context.Context, req *Request) Response {
+	return h.reservationAction(ctx, req, h.uc.SellReservation)
+}
+
+// reservationAction runs fn on the reservation named in the path and
+// responds with the reservation as it is afterwards.
+func (h *StockHandler) reservationAction(ctx context.Context, req *Request, fn func(context.Context, int64, int64) (*domain.StockReservation, error)) Response {
+	bookID, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid book id")
+	}
+	id, ok := pathID(req, "reservation_id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid reservation id")
+	}
+
+	res, err := fn(ctx, bookID, id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: res}
+}
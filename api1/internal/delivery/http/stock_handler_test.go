@@ -0,0 +1,141 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestGetStock(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockStockUsecase(ctrl)
+			uc.EXPECT().GetStock(gomock.Any(), int64(7)).
+				Return(&domain.StockLevel{BookID: 7, OnHand: 5, Reserved: 4, Available: 1, LowStockThreshold: 2, Low: true}, nil)
+
+			res := do(t, httpdelivery.NewStockHandler(uc), httptest.NewRequest(http.MethodGet, "/v1/books/7/stock", nil))
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			var got map[string]any
+			_ = json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, map[string]any{
+				"book_id": 7.0, "on_hand": 5.0, "reserved": 4.0, "available": 1.0, "low_stock_threshold": 2.0, "low": true,
+			}, got)
+		})
+	}
+}
+
+func TestStockRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(uc *usecase_mock.MockStockUsecase)
+		want   int
+	}{
+		{
+			name: "movements", method: http.MethodGet, path: "/v1/books/7/stock/movements?limit=10&offset=20",
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().ListMovements(gomock.Any(), int64(7), 10, 20).Return([]*domain.StockMovement{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "receive", method: http.MethodPost, path: "/v1/books/7/stock/receipts", body: `{"qty":12,"note":"delivery 4411"}`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().ReceiveStock(gomock.Any(), int64(7), domain.StockInput{Qty: 12, Note: "delivery 4411"}).
+					Return(&domain.StockLevel{BookID: 7, OnHand: 12, Available: 12}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "adjust below reserved", method: http.MethodPost, path: "/v1/books/7/stock/adjustments", body: `{"qty":-2,"note":"damaged"}`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().AdjustStock(gomock.Any(), int64(7), domain.StockInput{Qty: -2, Note: "damaged"}).
+					Return(nil, domain.ErrOutOfStock)
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "threshold", method: http.MethodPut, path: "/v1/books/7/stock/threshold", body: `{"low_stock_threshold":3}`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().SetLowStockThreshold(gomock.Any(), int64(7), domain.LowStockInput{Threshold: 3}).
+					Return(&domain.StockLevel{BookID: 7, LowStockThreshold: 3, Low: true}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "reserve", method: http.MethodPost, path: "/v1/books/7/stock/reservations", body: `{"qty":1,"ttl_seconds":600,"ref":"till 3"}`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().Reserve(gomock.Any(), int64(7), domain.ReserveStockInput{Qty: 1, TTLSeconds: 600, Ref: "till 3"}).
+					Return(&domain.StockReservation{ID: 9, BookID: 7, Qty: 1, Status: domain.ReservationActive}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "reserve invalid", method: http.MethodPost, path: "/v1/books/7/stock/reservations", body: `{"qty":0}`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().Reserve(gomock.Any(), int64(7), domain.ReserveStockInput{}).Return(nil, usecase.ErrValidation)
+			},
+			want: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "get reservation", method: http.MethodGet, path: "/v1/books/7/stock/reservations/9",
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().GetReservation(gomock.Any(), int64(7), int64(9)).Return(&domain.StockReservation{ID: 9}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "release", method: http.MethodDelete, path: "/v1/books/7/stock/reservations/9",
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().Release(gomock.Any(), int64(7), int64(9)).
+					Return(&domain.StockReservation{ID: 9, Status: domain.ReservationReleased}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "sell closed reservation", method: http.MethodPost, path: "/v1/books/7/stock/reservations/9/sell",
+			expect: func(uc *usecase_mock.MockStockUsecase) {
+				uc.EXPECT().SellReservation(gomock.Any(), int64(7), int64(9)).Return(nil, domain.ErrReservationClosed)
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "invalid reservation id", method: http.MethodGet, path: "/v1/books/7/stock/reservations/abc",
+			expect: func(uc *usecase_mock.MockStockUsecase) {},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name: "invalid body", method: http.MethodPost, path: "/v1/books/7/stock/receipts", body: `{`,
+			expect: func(uc *usecase_mock.MockStockUsecase) {},
+			want:   http.StatusBadRequest,
+		},
+	}
+	for name, do := range adapters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockStockUsecase(ctrl)
+				tt.expect(uc)
+
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				res := do(t, httpdelivery.NewStockHandler(uc), req)
+
+				assert.Equal(t, tt.want, res.StatusCode)
+			})
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// TenantHandler is the admin API for tenants. Mount it behind RequireAdmin.
+type TenantHandler struct {
+	uc usecase.TenantUsecase
+}
+
+func NewTenantHandler(uc usecase.TenantUsecase) *TenantHandler {
+	return &TenantHandler{uc: uc}
+}
+
+func (h *TenantHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/admin/tenants", h.CreateTenant, writeTimeout},
+		{http.MethodGet, "/v1/admin/tenants", h.ListTenants, readTimeout},
+		{http.MethodPost, "/v1/admin/tenants/{id}/suspend", h.SuspendTenant, writeTimeout},
+		{http.MethodPost, "/v1/admin/tenants/{id}/resume", h.ResumeTenant, writeTimeout},
+	}
+}
+
+func (h *TenantHandler) CreateTenant(ctx context.Context, req *Request) Response {
+	var in domain.CreateTenantInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	t, err := h.uc.CreateTenant(ctx, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: t}
+}
+
+func (h *TenantHandler) ListTenants(ctx context.Context, req *Request) Response {
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	tenants, err := h.uc.ListTenants(ctx, limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: tenants}
+}
+
+func (h *TenantHandler) SuspendTenant(ctx context.Context, req *Request) Response {
+	t, err := h.uc.SuspendTenant(ctx, req.Params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: t}
+}
+
+func (h *TenantHandler) ResumeTenant(ctx context.Context, req *Request) Response {
+	t, err := h.uc.ResumeTenant(ctx, req.Params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: t}
+}
@@ -0,0 +1,229 @@
+package http_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+var jwtSecret = []byte("test-secret")
+
+// signJWT builds an HS256 token with the given JSON claims.
+func signJWT(secret []byte, claims string) string {
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+// signedIn mounts sets behind the tenant middleware and signs req in as
+// who, of tenant acme.
+func signedIn(t *testing.T, req *http.Request, who string, sets ...httpdelivery.RouteSet) httpdelivery.RouteSet {
+	tenants := usecase_mock.NewMockTenantUsecase(gomock.NewController(t))
+	tenants.EXPECT().ActiveTenant(gomock.Any(), "acme").Return(&domain.Tenant{ID: "acme"}, nil).AnyTimes()
+	req.Header.Set("Authorization", "Bearer "+signJWT(jwtSecret, `{"tenant_id":"acme","sub":"`+who+`"}`))
+	return httpdelivery.WithTenant(httpdelivery.TenantAuth{JWTSecret: jwtSecret}, tenants, sets...)
+}
+
+// tenantBooks mounts a book handler behind the tenant middleware, expecting
+// GetBook to run for wantTenant when it is not empty.
+func tenantBooks(t *testing.T, auth httpdelivery.TenantAuth, wantTenant string, tenantErr error) httpdelivery.RouteSet {
+	ctrl := gomock.NewController(t)
+	books := usecase_mock.NewMockBookUsecase(ctrl)
+	tenants := usecase_mock.NewMockTenantUsecase(ctrl)
+	if wantTenant != "" {
+		tenants.EXPECT().ActiveTenant(gomock.Any(), wantTenant).Return(&domain.Tenant{ID: wantTenant}, tenantErr)
+		if tenantErr == nil {
+			books.EXPECT().GetBook(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, id int64) (*domain.Book, error) {
+				got, _ := tenancy.FromContext(ctx)
+				assert.Equal(t, wantTenant, got)
+				return &domain.Book{ID: id, TenantID: got}, nil
+			})
+		}
+	}
+	return httpdelivery.WithTenant(auth, tenants, httpdelivery.NewBookHandler(books))
+}
+
+func TestWithTenant(t *testing.T) {
+	expired := time.Now().Add(-time.Minute).Unix()
+	tests := []struct {
+		name       string
+		auth       httpdelivery.TenantAuth
+		header     map[string]string
+		wantTenant string
+		tenantErr  error
+		wantStatus int
+	}{
+		{
+			name:       "no credentials",
+			auth:       httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid JWT",
+			auth:       httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header:     map[string]string{"Authorization": "Bearer " + signJWT(jwtSecret, `{"tenant_id":"acme"}`)},
+			wantTenant: "acme",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "JWT signed with another key",
+			auth:       httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header:     map[string]string{"Authorization": "Bearer " + signJWT([]byte("other"), `{"tenant_id":"acme"}`)},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "expired JWT",
+			auth:       httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header:     map[string]string{"Authorization": "Bearer " + signJWT(jwtSecret, `{"tenant_id":"acme","exp":`+strconv.FormatInt(expired, 10)+`}`)},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "untrusted header is ignored",
+			auth:       httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header:     map[string]string{"X-Tenant-ID": "acme"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "trusted header",
+			auth:       httpdelivery.TenantAuth{TrustHeader: true},
+			header:     map[string]string{"X-Tenant-ID": "acme"},
+			wantTenant: "acme",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "suspended tenant",
+			auth:       httpdelivery.TenantAuth{TrustHeader: true},
+			header:     map[string]string{"X-Tenant-ID": "acme"},
+			wantTenant: "acme",
+			tenantErr:  domain.ErrTenantSuspended,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown tenant",
+			auth:       httpdelivery.TenantAuth{TrustHeader: true},
+			header:     map[string]string{"X-Tenant-ID": "acme"},
+			wantTenant: "acme",
+			tenantErr:  domain.ErrNotFound,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				set := tenantBooks(t, tt.auth, tt.wantTenant, tt.tenantErr)
+				req := httptest.NewRequest(http.MethodGet, "/v1/books/1", nil)
+				for k, v := range tt.header {
+					req.Header.Set(k, v)
+				}
+
+				res := do(t, set, req)
+
+				assert.Equal(t, tt.wantStatus, res.StatusCode)
+			})
+		}
+	}
+}
+
+func TestWithTenant_ActorIsTheTokenSubject(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   httpdelivery.TenantAuth
+		header map[string]string
+		want   string
+	}{
+		{
+			name:   "token subject",
+			auth:   httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header: map[string]string{"Authorization": "Bearer " + signJWT(jwtSecret, `{"tenant_id":"acme","sub":"alice"}`)},
+			want:   "alice",
+		},
+		{
+			name:   "token without subject",
+			auth:   httpdelivery.TenantAuth{JWTSecret: jwtSecret},
+			header: map[string]string{"Authorization": "Bearer " + signJWT(jwtSecret, `{"tenant_id":"acme"}`), "X-Actor": "mallory"},
+			want:   requestmeta.AnonymousActor,
+		},
+		{
+			name:   "trusted header",
+			auth:   httpdelivery.TenantAuth{TrustHeader: true},
+			header: map[string]string{"X-Tenant-ID": "acme", "X-Actor": "mallory"},
+			want:   requestmeta.AnonymousActor,
+		},
+	}
+	for _, tt := range tests {
+		for name, do := range adapters {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				books := usecase_mock.NewMockBookUsecase(ctrl)
+				tenants := usecase_mock.NewMockTenantUsecase(ctrl)
+				tenants.EXPECT().ActiveTenant(gomock.Any(), "acme").Return(&domain.Tenant{ID: "acme"}, nil)
+				books.EXPECT().GetBook(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, id int64) (*domain.Book, error) {
+					assert.Equal(t, tt.want, requestmeta.FromContext(ctx).Actor)
+					return &domain.Book{ID: id}, nil
+				})
+				req := httptest.NewRequest(http.MethodGet, "/v1/books/1", nil)
+				for k, v := range tt.header {
+					req.Header.Set(k, v)
+				}
+
+				res := do(t, httpdelivery.WithTenant(tt.auth, tenants, httpdelivery.NewBookHandler(books)), req)
+
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			})
+		}
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockTenantUsecase(ctrl)
+			uc.EXPECT().CreateTenant(gomock.Any(), domain.CreateTenantInput{ID: "acme", Name: "Acme", BookQuota: 500}).
+				Return(&domain.Tenant{ID: "acme", Name: "Acme", BookQuota: 500}, nil)
+			set := httpdelivery.RequireAdmin("s3cret", httpdelivery.NewTenantHandler(uc))
+			body := `{"id":"acme","name":"Acme","book_quota":500}`
+
+			anon := httptest.NewRequest(http.MethodPost, "/v1/admin/tenants", strings.NewReader(body))
+			assert.Equal(t, http.StatusUnauthorized, do(t, set, anon).StatusCode)
+
+			admin := httptest.NewRequest(http.MethodPost, "/v1/admin/tenants", strings.NewReader(body))
+			admin.Header.Set("X-Admin-Token", "s3cret")
+			assert.Equal(t, http.StatusCreated, do(t, set, admin).StatusCode)
+		})
+	}
+}
+
+func TestSuspendTenant(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockTenantUsecase(ctrl)
+			now := time.Now()
+			uc.EXPECT().SuspendTenant(gomock.Any(), "acme").Return(&domain.Tenant{ID: "acme", SuspendedAt: &now}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/tenants/acme/suspend", nil)
+			res := do(t, httpdelivery.NewTenantHandler(uc), req)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// WebhookHandler lets a tenant manage its own webhook endpoints and read
+// their delivery log. Mount it behind WithTenant.
+type WebhookHandler struct {
+	uc usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(uc usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{uc: uc}
+}
+
+func (h *WebhookHandler) routes() []route {
+	return []route{
+		{http.MethodPost, "/v1/webhooks", h.RegisterWebhook, writeTimeout},
+		{http.MethodGet, "/v1/webhooks", h.ListWebhooks, readTimeout},
+		{http.MethodPost, "/v1/webhooks/{id}/enable", h.EnableWebhook, writeTimeout},
+		{http.MethodGet, "/v1/webhooks/{id}/deliveries", h.ListDeliveries, readTimeout},
+		{http.MethodPost, "/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver", h.Redeliver, writeTimeout},
+	}
+}
+
+func (h *WebhookHandler) RegisterWebhook(ctx context.Context, req *Request) Response {
+	var in domain.CreateWebhookInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return errorBody(http.StatusBadRequest, "invalid JSON body")
+	}
+
+	endpoint, err := h.uc.RegisterWebhook(ctx, in)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusCreated, Body: endpoint}
+}
+
+func (h *WebhookHandler) ListWebhooks(ctx context.Context, req *Request) Response {
+	endpoints, err := h.uc.ListWebhooks(ctx)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: endpoints}
+}
+
+func (h *WebhookHandler) EnableWebhook(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid webhook id")
+	}
+
+	if err := h.uc.EnableWebhook(ctx, id); err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: map[string]bool{"active": true}}
+}
+
+func (h *WebhookHandler) ListDeliveries(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid webhook id")
+	}
+	limit, offset, errResp := pageQuery(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	deliveries, err := h.uc.ListDeliveries(ctx, id, limit, offset)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusOK, Body: deliveries}
+}
+
+func (h *WebhookHandler) Redeliver(ctx context.Context, req *Request) Response {
+	id, ok := pathID(req, "id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid webhook id")
+	}
+	deliveryID, ok := pathID(req, "delivery_id")
+	if !ok {
+		return errorBody(http.StatusBadRequest, "invalid delivery id")
+	}
+
+	delivery, err := h.uc.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{Status: http.StatusAccepted, Body: delivery}
+}
@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	httpdelivery "unit-test-demo/api1/internal/delivery/http"
+	"unit-test-demo/api1/internal/domain"
+	usecase_mock "unit-test-demo/api1/internal/mocks/usecase"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		ucErr      error
+		wantStatus int
+	}{
+		{"created", nil, http.StatusCreated},
+		{"validation", usecase.ErrValidation, http.StatusUnprocessableEntity},
+	}
+
+	for name, do := range adapters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				uc := usecase_mock.NewMockWebhookUsecase(ctrl)
+				in := domain.CreateWebhookInput{
+					URL:        "https://example.com/hooks",
+					EventTypes: []domain.EventType{domain.EventBookCreated},
+					Secret:     "0123456789abcdef",
+				}
+				var out *domain.WebhookEndpoint
+				if tt.ucErr == nil {
+					out = &domain.WebhookEndpoint{ID: 1, URL: in.URL, EventTypes: in.EventTypes, Secret: in.Secret, Active: true}
+				}
+				uc.EXPECT().RegisterWebhook(gomock.Any(), in).Return(out, tt.ucErr)
+
+				body, _ := json.Marshal(in)
+				req := httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(string(body)))
+				req.Header.Set("Content-Type", "application/json")
+				res := do(t, httpdelivery.NewWebhookHandler(uc), req)
+
+				assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+				var got map[string]any
+				_ = json.NewDecoder(res.Body).Decode(&got)
+				assert.NotContains(t, got, "secret", "the secret is never echoed back")
+			})
+		}
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	for name, do := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uc := usecase_mock.NewMockWebhookUsecase(ctrl)
+			uc.EXPECT().Redeliver(gomock.Any(), int64(3), int64(17)).Return(&domain.WebhookDelivery{ID: 18, EndpointID: 3}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/3/deliveries/17/redeliver", nil)
+			res := do(t, httpdelivery.NewWebhookHandler(uc), req)
+
+			assert.Equal(t, http.StatusAccepted, res.StatusCode)
+		})
+	}
+}
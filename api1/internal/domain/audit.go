@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	AuditRevert  AuditAction = "revert"
+)
+
+// FieldChange is one entry of an audit diff. A nil side means the field did
+// not exist before (create) or after (delete).
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditRecord struct {
+	ID         int64                  `json:"id"`
+	TenantID   string                 `json:"tenant_id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int64                  `json:"entity_id"`
+	Action     AuditAction            `json:"action"`
+	Actor      string                 `json:"actor"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	ClientIP   string                 `json:"client_ip,omitempty"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditQuery filters the audit log. Zero fields match everything.
+type AuditQuery struct {
+	EntityType string
+	EntityID   int64
+	Actor      string
+	Action     AuditAction
+	Limit      int
+	Offset     int
+}
+
+// AuditRepository is append-only: records are never updated or deleted.
+// Records belong to the tenant in ctx, which Append stamps and List reads.
+type AuditRepository interface {
+	Append(ctx context.Context, rec AuditRecord) error
+	List(ctx context.Context, q AuditQuery) ([]*AuditRecord, error)
+}
+
+// Diff compares the JSON form of before and after and returns the fields
+// that differ. Either side may be nil.
+func Diff(before, after any) (map[string]FieldChange, error) {
+	b, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(av, bv) {
+			changes[k] = FieldChange{Before: bv, After: a[k]}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = FieldChange{After: av}
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v any) (map[string]any, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return map[string]any{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
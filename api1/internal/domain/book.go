@@ -2,21 +2,86 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable means the storage could not serve the call right now;
+	// the same call may succeed later.
+	ErrUnavailable = errors.New("temporarily unavailable")
+)
+
+// Book belongs to exactly one tenant. TenantID is set by the repository
+// from the request context, never from client input. RatingAvg and
+// RatingCount summarize its reviews; they are kept up to date by every
+// review write rather than computed on read.
 type Book struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Author    string    `json:"author"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	CreatedAt   time.Time `json:"created_at"`
+	CoverURL    string    `json:"cover_url,omitempty"`
+	RatingAvg   float64   `json:"rating_avg"`
+	RatingCount int       `json:"rating_count"`
 }
 
+// CreateBookInput describes a new book. Force stores it even if another
+// live book has the same BookKey; only the admin API sets it.
 type CreateBookInput struct {
 	Title  string `json:"title"`
 	Author string `json:"author"`
+	Force  bool   `json:"-"`
+}
+
+type UpdateBookInput struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// ListBooksQuery filters and pages a book listing. A zero Limit means the
+// repository default.
+//
+// Category is a category slug and matches books filed under it or any of
+// its descendants. Tags matches books with at least one of the tags, or
+// with all of them if AllTags is set.
+type ListBooksQuery struct {
+	Author   string
+	Category string
+	Tags     []string
+	AllTags  bool
+	Limit    int
+	Offset   int
 }
 
+// BookRepository stores books. Delete is a soft delete: the book stops
+// showing up in GetByID and List but can be brought back with Restore.
+//
+// Create, Update and Restore fail with a *DuplicateError when the result
+// would share its BookKey with another live book, unless the input is
+// forced.
+//
+// Every method is scoped to the tenant in the context (see package
+// tenancy): books of other tenants behave as if they did not exist, and a
+// context without a tenant fails with tenancy.ErrNoTenant.
 type BookRepository interface {
 	Create(ctx context.Context, in CreateBookInput) (*Book, error)
+	GetByID(ctx context.Context, id int64) (*Book, error)
+	// GetByIDs returns the live books among ids in one go, keyed by ID.
+	// Books that do not exist or are deleted are absent.
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*Book, error)
+	List(ctx context.Context, q ListBooksQuery) ([]*Book, error)
+	Update(ctx context.Context, id int64, in UpdateBookInput) (*Book, error)
+	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) (*Book, error)
+	// SetCover records c as the cover of book id and returns the book with
+	// its new CoverURL. GetCover returns ErrNotFound if there is none.
+	SetCover(ctx context.Context, id int64, c Cover) (*Book, error)
+	GetCover(ctx context.Context, id int64) (*Cover, error)
+	// AdjustRating adds sum to the total of the book's review ratings and
+	// count to their number. It is called in the transaction of the review
+	// write it accounts for, and does not create a revision.
+	AdjustRating(ctx context.Context, id int64, sum, count int) (*Book, error)
 }
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// BookChange is a committed book event as pushed to live subscribers. ID
+// is opaque and unique across instances, so a client can resume after it
+// on any of them. Book is the book after the change, or before it for a
+// delete. Categories holds the paths of the categories the book is filed
+// under; deletes leave it empty.
+type BookChange struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	Type       EventType `json:"type"`
+	BookID     int64     `json:"book_id"`
+	Book       *Book     `json:"book,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// BookChangeQuery is what a subscriber asks to see: changes to books by
+// Author, or filed under the category with slug Category or one of its
+// descendants. Empty fields do not filter.
+type BookChangeQuery struct {
+	Author   string
+	Category string
+}
+
+// BookChangeFilter is a BookChangeQuery with the category resolved to its
+// Path.
+type BookChangeFilter struct {
+	Author       string
+	CategoryPath string
+}
+
+// Match reports whether c passes f. A delete carries no taxonomy, so it
+// passes any category filter; clients drop deletes of books they do not
+// show.
+func (f BookChangeFilter) Match(c BookChange) bool {
+	if f.Author != "" && (c.Book == nil || c.Book.Author != f.Author) {
+		return false
+	}
+	if f.CategoryPath == "" || c.Type == EventBookDeleted {
+		return true
+	}
+	for _, p := range c.Categories {
+		if strings.HasPrefix(p, f.CategoryPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// BookChangePublisher passes committed book changes on to live
+// subscribers. It must not block on slow ones.
+type BookChangePublisher interface {
+	PublishBookChange(ctx context.Context, c BookChange) error
+}
+
+// BookChangeSubscription delivers the changes a subscriber asked for until
+// Close. Changes is closed when the feed drops the subscriber for falling
+// behind, which Dropped then reports, or shuts down. Gap reports that the
+// changes after the requested last ID were no longer buffered, so some may
+// have been missed.
+type BookChangeSubscription interface {
+	Changes() <-chan BookChange
+	Gap() bool
+	Dropped() bool
+	Close()
+}
+
+// BookChangeFeed subscribes to the changes of the tenant in ctx that match
+// f. With a lastID it first replays the buffered changes after it.
+type BookChangeFeed interface {
+	Subscribe(ctx context.Context, f BookChangeFilter, lastID string) (BookChangeSubscription, error)
+}
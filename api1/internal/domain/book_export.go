@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+// BookExporter reads every live book matching the filters of q in ID
+// order, handing each to fn as it is read instead of collecting them, so
+// memory use does not grow with the catalogue. Limit and Offset are
+// ignored. An error from fn stops the export and is returned as is.
+// Implementations may read in chunks, so the export need not be one
+// snapshot; each book is handed over at most once.
+type BookExporter interface {
+	ExportBooks(ctx context.Context, q ListBooksQuery, fn func(*Book) error) error
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict means a book's event stream moved on after it was
+// read, so events based on the old version were refused.
+var ErrVersionConflict = errors.New("book was changed concurrently")
+
+// BookEventKind names what a stored book event records.
+type BookEventKind string
+
+const (
+	BookEventCreated       BookEventKind = "created"
+	BookEventTitleChanged  BookEventKind = "title_changed"
+	BookEventAuthorChanged BookEventKind = "author_changed"
+	BookEventDeleted       BookEventKind = "deleted"
+	BookEventRestored      BookEventKind = "restored"
+	BookEventCoverSet      BookEventKind = "cover_set"
+	BookEventRated         BookEventKind = "rated"
+)
+
+// BookStreamEvent is one event in the stream of a book. Version numbers
+// the events of a book from 1 without gaps; Position orders the events of
+// all books as they were stored. Data is the payload of the kind as JSON.
+type BookStreamEvent struct {
+	Position int64
+	TenantID string
+	BookID   int64
+	Version  int
+	Kind     BookEventKind
+	Data     json.RawMessage
+	At       time.Time
+}
+
+// BookState is a book as its events leave it: the book itself plus what
+// the repository needs to enforce its rules. Version is that of the last
+// event applied; zero means the book does not exist.
+type BookState struct {
+	Book      Book
+	Version   int
+	Deleted   bool
+	Forced    bool
+	RatingSum int
+	Cover     *Cover
+}
+
+// BookSnapshot is the state of a book at Version, saved so loading it only
+// replays the events after.
+type BookSnapshot struct {
+	BookID  int64
+	Version int
+	State   json.RawMessage
+	At      time.Time
+}
+
+// BookEventStore is the append-only store of book event streams, scoped to
+// the tenant in the context.
+type BookEventStore interface {
+	NextBookID(ctx context.Context) (int64, error)
+	// AppendBookEvents adds events, numbered from expected+1, to the stream
+	// of book id if its last version is still expected, and fails with
+	// ErrVersionConflict otherwise.
+	AppendBookEvents(ctx context.Context, id int64, expected int, events []BookStreamEvent) error
+	// LoadBookEvents returns the events of book id after version, in order.
+	LoadBookEvents(ctx context.Context, id int64, after int) ([]BookStreamEvent, error)
+	// LoadBookSnapshot returns the latest snapshot of book id, or
+	// ErrNotFound.
+	LoadBookSnapshot(ctx context.Context, id int64) (*BookSnapshot, error)
+	SaveBookSnapshot(ctx context.Context, s BookSnapshot) error
+	// ReadBookEvents returns up to limit events of every book after
+	// position, in order, for projections to replay.
+	ReadBookEvents(ctx context.Context, after int64, limit int) ([]BookStreamEvent, error)
+}
+
+// BookReadModel is the table books are projected into from their events
+// and read back from, scoped to the tenant in the context.
+type BookReadModel interface {
+	// PutBookState stores s as the current state of its book, unless the
+	// state stored already has a version at least as new. It fails with a
+	// *DuplicateError if another live, unforced book has its BookKey and s
+	// is neither deleted nor forced.
+	PutBookState(ctx context.Context, s BookState) error
+	// GetBookState returns the projected state of book id, deleted or
+	// not, or ErrNotFound.
+	GetBookState(ctx context.Context, id int64) (*BookState, error)
+	// GetBookStates returns the projected states of those of ids that have
+	// one, deleted or not, keyed by book ID.
+	GetBookStates(ctx context.Context, ids []int64) (map[int64]*BookState, error)
+	// ListBookStates returns live books like BookRepository.List.
+	ListBookStates(ctx context.Context, q ListBooksQuery) ([]*Book, error)
+	// FindBookByKey returns the lowest ID of a live, unforced book other
+	// than except with the given BookKey, or 0.
+	FindBookByKey(ctx context.Context, key string, except int64) (int64, error)
+	// ResetBookStates empties the read model.
+	ResetBookStates(ctx context.Context) error
+	// LockBookStates holds the read model of the tenant until the
+	// transaction in ctx ends: exclusively for a rebuild, shared for a
+	// write projecting into it, so that a rebuild never replaces states
+	// written after it read the events.
+	LockBookStates(ctx context.Context, exclusive bool) error
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+// BookSyncPosition is a point in a tenant's sequence of book changes. Every
+// write to a book gives it the tenant's next sequence number, so the books
+// changed since a position are those after it. Books last written before
+// the sequence existed share Seq 0 and are ordered by BookID.
+type BookSyncPosition struct {
+	Seq    int64
+	BookID int64
+}
+
+// BookSyncChange is the latest state of one book for offline clients: the
+// book as it is now, or a tombstone if it is deleted.
+type BookSyncChange struct {
+	Position BookSyncPosition
+	Book     Book
+	Deleted  bool
+}
+
+// BookSyncRepository reads the tenant's book changes in sequence order. A
+// change becomes visible only once every change numbered before it has,
+// so a client that moves past a position never misses a later commit of
+// an earlier number.
+type BookSyncRepository interface {
+	// BookChangesSince returns up to limit changes after pos, oldest
+	// first, along with the tenant's latest sequence number.
+	BookChangesSince(ctx context.Context, after BookSyncPosition, limit int) ([]*BookSyncChange, int64, error)
+}
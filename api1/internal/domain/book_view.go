@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// BookFields are the JSON names of the Book fields a client may select.
+var BookFields = []string{"id", "tenant_id", "title", "author", "created_at", "cover_url", "rating_avg", "rating_count"}
+
+// BookIncludes are the relations that can be embedded in a book.
+var BookIncludes = []string{"copies", "reviews"}
+
+// BookSelection picks what to return of each book: the fields named in
+// Fields, or all of them when it is empty, and the relations in Include.
+type BookSelection struct {
+	Fields  []string
+	Include []string
+}
+
+// Includes reports whether relation was asked for.
+func (s BookSelection) Includes(relation string) bool {
+	return slices.Contains(s.Include, relation)
+}
+
+// BookView is a book as selected by a BookSelection. Book has at least the
+// selected fields and the ID filled in. A relation that was included is
+// non-nil, even when the book has none of it.
+type BookView struct {
+	Book    *Book
+	Fields  []string
+	Copies  []*Copy
+	Reviews []*Review
+}
+
+// BookFieldRepository reads books with only some of their fields, which
+// lets a store skip the columns nobody asked for. Fields are BookFields
+// names; the ID is always filled in. It is scoped to the tenant in the
+// context like BookRepository.
+type BookFieldRepository interface {
+	GetFields(ctx context.Context, id int64, fields []string) (*Book, error)
+	ListFields(ctx context.Context, q ListBooksQuery, fields []string) ([]*Book, error)
+}
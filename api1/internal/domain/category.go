@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrCategoryCycle means a category was asked to move under itself or one
+// of its own descendants.
+var ErrCategoryCycle = errors.New("category cannot be moved under itself")
+
+// Category is a node in a tenant's category tree. Slugs are unique per
+// tenant, so a slug names a category on its own.
+//
+// Path is the materialized path of IDs from the root down to the category,
+// such as "/1/4/9/". A category's descendants are exactly the categories
+// whose Path starts with its own, which is what filters and facets use.
+type Category struct {
+	ID        int64     `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	ParentID  *int64    `json:"parent_id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Path      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CategoryInput creates or replaces a category. A nil ParentID makes it a
+// root.
+type CategoryInput struct {
+	ParentID *int64 `json:"parent_id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+}
+
+// CategoryPath returns the Path of a category with the given ID under a
+// parent with Path parent ("" for a root).
+func CategoryPath(parent string, id int64) string {
+	if parent == "" {
+		parent = "/"
+	}
+	return parent + strconv.FormatInt(id, 10) + "/"
+}
+
+// BookTaxonomy is how a book is classified: the categories it is filed
+// under and its free-form tags.
+type BookTaxonomy struct {
+	BookID     int64       `json:"book_id"`
+	Categories []*Category `json:"categories"`
+	Tags       []string    `json:"tags"`
+}
+
+type BookTaxonomyInput struct {
+	CategoryIDs []int64  `json:"category_ids"`
+	Tags        []string `json:"tags"`
+}
+
+type CategoryFacet struct {
+	ID       int64  `json:"id"`
+	ParentID *int64 `json:"parent_id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+}
+
+type TagFacet struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Facets counts the books matching a listing filter per category and per
+// tag. A book counts towards every category above the ones it is filed
+// under, once each. Categories come in tree order and tags by count, most
+// used first; neither lists zero counts.
+type Facets struct {
+	Total      int              `json:"total"`
+	Categories []*CategoryFacet `json:"categories"`
+	Tags       []*TagFacet      `json:"tags"`
+}
+
+// CategoryRepository stores the category tree and the taxonomy of books,
+// scoped to the tenant in the context like BookRepository.
+//
+// CreateCategory and UpdateCategory fail with ErrConflict on a taken slug
+// and ErrNotFound on an unknown parent; UpdateCategory moves the whole
+// subtree along and fails with ErrCategoryCycle rather than create a loop.
+// DeleteCategory fails with ErrConflict while the category has children,
+// and unfiles the books that were in it. SetBookTaxonomy replaces both the
+// categories and the tags of a book, and fails with ErrNotFound if one of
+// the categories does not exist.
+//
+// Facets counts over the books List would return for q, ignoring paging.
+type CategoryRepository interface {
+	CreateCategory(ctx context.Context, in CategoryInput) (*Category, error)
+	GetCategory(ctx context.Context, id int64) (*Category, error)
+	ListCategories(ctx context.Context) ([]*Category, error)
+	UpdateCategory(ctx context.Context, id int64, in CategoryInput) (*Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+
+	GetBookTaxonomy(ctx context.Context, bookID int64) (*BookTaxonomy, error)
+	SetBookTaxonomy(ctx context.Context, bookID int64, in BookTaxonomyInput) (*BookTaxonomy, error)
+	Facets(ctx context.Context, q ListBooksQuery) (*Facets, error)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Cover describes the cover image of a book and its thumbnail. The bytes
+// live in a BlobStore under Key and ThumbKey; ETags are content hashes.
+type Cover struct {
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag"`
+	Key         string    `json:"-"`
+	ThumbType   string    `json:"thumb_content_type"`
+	ThumbSize   int64     `json:"thumb_size"`
+	ThumbETag   string    `json:"thumb_etag"`
+	ThumbKey    string    `json:"-"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CoverURL is where the cover of a book is served. The ETag in the query
+// changes with every upload, so clients can cache each URL for good.
+func CoverURL(bookID int64, etag string) string {
+	return fmt.Sprintf("/v1/books/%d/cover?v=%s", bookID, etag)
+}
+
+// BlobStore keeps opaque binary objects under slash-separated keys. It is
+// deliberately as small as an S3 bucket's API so one can back it; the
+// content type is recorded for backends that serve objects directly.
+// Get returns ErrNotFound for unknown keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrDuplicate matches every *DuplicateError.
+var ErrDuplicate = errors.New("duplicate book")
+
+// DuplicateError reports that a book with the same BookKey already exists.
+// ExistingID is that book, or zero when a concurrent write got there first
+// and the book could not be identified.
+type DuplicateError struct {
+	ExistingID int64
+}
+
+func (e *DuplicateError) Error() string {
+	if e.ExistingID == 0 {
+		return ErrDuplicate.Error()
+	}
+	return fmt.Sprintf("%s: same title and author as book %d", ErrDuplicate, e.ExistingID)
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// NormalizeKey reduces s to the form used to compare titles and authors:
+// accents removed and the rest in Unicode NFC, case folded, punctuation
+// and symbols dropped, and runs of whitespace collapsed to one space.
+//
+//	NormalizeKey("  Les Misérables ") == NormalizeKey("les  miserables") == "les miserables"
+func NormalizeKey(s string) string {
+	// Decomposing first splits accented letters into a base letter and
+	// combining marks, which can then be dropped on their own. The
+	// transformers keep state, so each call builds its own chain.
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if out, _, err := transform.String(t, s); err == nil {
+		s = out
+	}
+	s = cases.Fold().String(s)
+
+	var b strings.Builder
+	pendingSpace := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			pendingSpace = b.Len() > 0
+		case unicode.IsPunct(r), unicode.IsSymbol(r):
+		default:
+			if pendingSpace {
+				b.WriteByte(' ')
+				pendingSpace = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// BookKey is the normalized identity of a book: two live books of one
+// tenant may not share it unless the second was forced in by an admin.
+// The separator cannot appear in either half, since NormalizeKey drops
+// punctuation.
+func BookKey(title, author string) string {
+	return NormalizeKey(title) + "/" + NormalizeKey(author)
+}
+
+// DuplicateGroup is a set of live books that share a BookKey.
+type DuplicateGroup struct {
+	Key   string  `json:"key"`
+	Books []*Book `json:"books"`
+}
+
+// DuplicateRepository finds books that already share a BookKey, because
+// they were forced in or predate duplicate detection. Groups come in key
+// order and books by ID; it is scoped to the tenant in the context like
+// BookRepository.
+type DuplicateRepository interface {
+	ListDuplicates(ctx context.Context, limit, offset int) ([]*DuplicateGroup, error)
+}
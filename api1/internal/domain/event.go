@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventBookCreated  EventType = "book.created"
+	EventBookUpdated  EventType = "book.updated"
+	EventBookDeleted  EventType = "book.deleted"
+	EventBookRestored EventType = "book.restored"
+	EventBookLowStock EventType = "book.low_stock"
+)
+
+const AggregateBook = "book"
+
+// Event is a fact about an aggregate that other services may care about.
+type Event interface {
+	EventType() EventType
+	AggregateType() string
+	AggregateID() int64
+}
+
+type BookCreated struct {
+	Book Book `json:"book"`
+}
+
+func (e BookCreated) EventType() EventType  { return EventBookCreated }
+func (e BookCreated) AggregateType() string { return AggregateBook }
+func (e BookCreated) AggregateID() int64    { return e.Book.ID }
+
+type BookUpdated struct {
+	Book Book `json:"book"`
+}
+
+func (e BookUpdated) EventType() EventType  { return EventBookUpdated }
+func (e BookUpdated) AggregateType() string { return AggregateBook }
+func (e BookUpdated) AggregateID() int64    { return e.Book.ID }
+
+type BookDeleted struct {
+	BookID int64 `json:"book_id"`
+}
+
+func (e BookDeleted) EventType() EventType  { return EventBookDeleted }
+func (e BookDeleted) AggregateType() string { return AggregateBook }
+func (e BookDeleted) AggregateID() int64    { return e.BookID }
+
+type BookRestored struct {
+	Book Book `json:"book"`
+}
+
+func (e BookRestored) EventType() EventType  { return EventBookRestored }
+func (e BookRestored) AggregateType() string { return AggregateBook }
+func (e BookRestored) AggregateID() int64    { return e.Book.ID }
+
+// BookLowStock is raised when a book's available stock falls to its
+// low-stock threshold.
+type BookLowStock struct {
+	BookID    int64 `json:"book_id"`
+	Available int64 `json:"available"`
+	Threshold int64 `json:"low_stock_threshold"`
+}
+
+func (e BookLowStock) EventType() EventType  { return EventBookLowStock }
+func (e BookLowStock) AggregateType() string { return AggregateBook }
+func (e BookLowStock) AggregateID() int64    { return e.BookID }
+
+// OutboxMessage is an event as stored in the outbox, waiting to be relayed.
+type OutboxMessage struct {
+	ID            int64           `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     EventType       `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Attempts      int             `json:"attempts"`
+}
+
+// Transactor runs fn so that every repository call made with the context
+// it receives commits or rolls back together. Implementations scope the
+// context with BeginTxHooks, so that AfterCommit callbacks run only once
+// the outermost transaction has committed.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// OutboxRepository stores events next to the data that produced them.
+// Append stamps them with the tenant in ctx; ClaimPending returns the
+// messages of every tenant.
+//
+// ClaimPending returns at most one message per aggregate: the oldest one
+// not yet dispatched, and only if it is due. That keeps delivery ordered
+// per aggregate while a failing message backs off. The messages it
+// returns are not due again until leaseUntil, so that the caller can
+// publish them without holding a transaction open; one neither marked
+// dispatched nor failed by then is handed out again.
+type OutboxRepository interface {
+	Append(ctx context.Context, events ...Event) error
+	ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]OutboxMessage, error)
+	MarkDispatched(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrHoldClosed = errors.New("hold is no longer active")
+
+// HoldStatus is where a hold is in its life:
+//
+//	queued -> ready -> fulfilled
+//	queued -> ready -> expired
+//	queued | ready  -> cancelled
+//
+// A hold becomes ready when a copy is set aside for it, and must be picked
+// up, by checking that copy out, before PickupBy.
+type HoldStatus string
+
+const (
+	HoldQueued    HoldStatus = "queued"
+	HoldReady     HoldStatus = "ready"
+	HoldFulfilled HoldStatus = "fulfilled"
+	HoldExpired   HoldStatus = "expired"
+	HoldCancelled HoldStatus = "cancelled"
+)
+
+// Hold is a member's place in the queue for a book. Position is the
+// 1-based place in the queue while the hold is queued, and zero otherwise.
+// PickupDate is PickupBy in lib.FormatDateLong style.
+type Hold struct {
+	ID         int64      `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	BookID     int64      `json:"book_id"`
+	MemberID   string     `json:"member_id"`
+	Status     HoldStatus `json:"status"`
+	Position   int        `json:"position,omitempty"`
+	CopyID     *int64     `json:"copy_id,omitempty"`
+	PlacedAt   time.Time  `json:"placed_at"`
+	ReadyAt    *time.Time `json:"ready_at,omitempty"`
+	PickupBy   *time.Time `json:"pickup_by,omitempty"`
+	PickupDate string     `json:"pickup_date,omitempty"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+}
+
+// Active reports whether the hold is still waiting or waiting for pickup.
+func (h *Hold) Active() bool {
+	return h.Status == HoldQueued || h.Status == HoldReady
+}
+
+// ListHoldsQuery filters the holds of a book. Holds are listed in queue
+// order, oldest first.
+type ListHoldsQuery struct {
+	BookID     int64
+	MemberID   string
+	ActiveOnly bool
+	Limit      int
+	Offset     int
+}
+
+// HoldRepository keeps the hold queues, scoped to the tenant in the context
+// like BookRepository. It shares the copies of LoanRepository, and every
+// method that moves a copy in or out of on_hold locks it the same way
+// Checkout does.
+//
+// PlaceHold fails with ErrConflict if the member already has an active
+// hold on the book. AllocateCopies hands every available copy of a book to
+// the oldest queued holds, in order, and returns the holds it made ready.
+// ExpireHolds ends the ready holds not picked up by now and puts their
+// copies back to available; the caller allocates them again.
+type HoldRepository interface {
+	PlaceHold(ctx context.Context, bookID int64, memberID string, at time.Time) (*Hold, error)
+	GetHold(ctx context.Context, bookID, id int64) (*Hold, error)
+	ListHolds(ctx context.Context, q ListHoldsQuery) ([]*Hold, error)
+	CancelHold(ctx context.Context, bookID, id int64, at time.Time) (*Hold, error)
+	AllocateCopies(ctx context.Context, bookID int64, at, pickupBy time.Time) ([]*Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time) ([]*Hold, error)
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrImportFinished = errors.New("import already finished")
+
+type ImportFormat string
+
+const (
+	ImportCSV   ImportFormat = "csv"   // header row naming title and author
+	ImportJSONL ImportFormat = "jsonl" // one CreateBookInput object per line
+)
+
+// ImportStatus is where an import job is in its life:
+//
+//	queued -> running -> succeeded
+//	                  -> failed
+//	                  -> cancelled
+//	queued -> cancelled
+//
+// A running job whose worker went away is picked up again from the last
+// committed chunk.
+type ImportStatus string
+
+const (
+	ImportQueued    ImportStatus = "queued"
+	ImportRunning   ImportStatus = "running"
+	ImportSucceeded ImportStatus = "succeeded"
+	ImportFailed    ImportStatus = "failed"
+	ImportCancelled ImportStatus = "cancelled"
+)
+
+// ImportJob is a file of books being created in the background. Processed
+// counts the data rows dealt with so far, each either Created or Failed;
+// they only move when a chunk commits. Error says why a failed job stopped
+// early, as opposed to the per-row errors.
+type ImportJob struct {
+	ID              int64        `json:"id"`
+	TenantID        string       `json:"tenant_id"`
+	Format          ImportFormat `json:"format"`
+	Status          ImportStatus `json:"status"`
+	Processed       int          `json:"processed"`
+	Created         int          `json:"created"`
+	Failed          int          `json:"failed"`
+	Error           string       `json:"error,omitempty"`
+	CancelRequested bool         `json:"cancel_requested"`
+	CreatedBy       string       `json:"created_by"`
+	CreatedAt       time.Time    `json:"created_at"`
+	StartedAt       *time.Time   `json:"started_at,omitempty"`
+	FinishedAt      *time.Time   `json:"finished_at,omitempty"`
+	// BlobKey is where the uploaded file waits until the job finishes.
+	BlobKey string `json:"-"`
+}
+
+// Done reports whether the job has reached a final status.
+func (j *ImportJob) Done() bool {
+	return j.Status == ImportSucceeded || j.Status == ImportFailed || j.Status == ImportCancelled
+}
+
+// ImportRowError is why one data row was not imported. Row counts data
+// rows from 1, leaving out a CSV header.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Title   string `json:"title"`
+	Author  string `json:"author"`
+	Message string `json:"message"`
+}
+
+// ImportProgress is what one chunk adds to a job's counts.
+type ImportProgress struct {
+	Processed int
+	Created   int
+	Failed    int
+}
+
+// ImportRepository stores import jobs and their row errors, scoped to the
+// tenant in the context like BookRepository.
+//
+// Claim hands the oldest queued job to the caller and marks it running,
+// or takes over a running job that has not reported progress since
+// staleBefore. It returns ErrNotFound when there is nothing to do.
+// Progress adds p and errs to a running job and refreshes its heartbeat;
+// called in the transaction of the chunk it accounts for, it makes the
+// chunk and its counts commit together. RequestCancel cancels a queued job
+// on the spot and flags a running one for its worker; on a finished job it
+// fails with ErrImportFinished.
+type ImportRepository interface {
+	Create(ctx context.Context, job ImportJob) (*ImportJob, error)
+	Get(ctx context.Context, id int64) (*ImportJob, error)
+	Claim(ctx context.Context, now, staleBefore time.Time) (*ImportJob, error)
+	Progress(ctx context.Context, id int64, p ImportProgress, errs []ImportRowError, now time.Time) (*ImportJob, error)
+	Finish(ctx context.Context, id int64, status ImportStatus, reason string, now time.Time) (*ImportJob, error)
+	RequestCancel(ctx context.Context, id int64, now time.Time) (*ImportJob, error)
+	ListErrors(ctx context.Context, id int64, limit, offset int) ([]*ImportRowError, error)
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrCopyUnavailable = errors.New("copy is not available")
+	ErrLoanClosed      = errors.New("loan is already closed")
+)
+
+type CopyStatus string
+
+const (
+	CopyAvailable CopyStatus = "available"
+	CopyOnLoan    CopyStatus = "on_loan"
+	CopyOnHold    CopyStatus = "on_hold" // set aside for a ready hold
+	CopyLost      CopyStatus = "lost"
+)
+
+// Copy is one physical copy of a book that can be lent out.
+type Copy struct {
+	ID        int64      `json:"id"`
+	BookID    int64      `json:"book_id"`
+	TenantID  string     `json:"tenant_id"`
+	Barcode   string     `json:"barcode"`
+	Status    CopyStatus `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CreateCopyInput struct {
+	Barcode string `json:"barcode"`
+}
+
+// LoanStatus is where a loan is in its life:
+//
+//	on_loan -> returned
+//	on_loan -> lost
+//
+// Overdue is not stored: an on_loan loan reads as overdue once its due
+// date has passed, and can still be returned, or reported lost, from there.
+type LoanStatus string
+
+const (
+	LoanOnLoan   LoanStatus = "on_loan"
+	LoanOverdue  LoanStatus = "overdue"
+	LoanReturned LoanStatus = "returned"
+	LoanLost     LoanStatus = "lost"
+)
+
+// MemberType decides which LoanPolicy applies to a member's loans.
+type MemberType string
+
+const (
+	MemberStandard MemberType = "standard"
+	MemberStudent  MemberType = "student"
+	MemberStaff    MemberType = "staff"
+)
+
+// LoanPolicy is how long a member type may keep a copy and how often the
+// loan may be renewed.
+type LoanPolicy struct {
+	LoanDays    int `json:"loan_days"`
+	MaxRenewals int `json:"max_renewals"`
+}
+
+// Loan is one checkout of a copy by a member. DueDate is DueAt as shown to
+// members, in lib.FormatDateLong style: "20 October 2025".
+type Loan struct {
+	ID           int64      `json:"id"`
+	TenantID     string     `json:"tenant_id"`
+	CopyID       int64      `json:"copy_id"`
+	BookID       int64      `json:"book_id"`
+	MemberID     string     `json:"member_id"`
+	MemberType   MemberType `json:"member_type"`
+	Status       LoanStatus `json:"status"`
+	Renewals     int        `json:"renewals"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	DueAt        time.Time  `json:"due_at"`
+	DueDate      string     `json:"due_date"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+}
+
+// Open reports whether the copy is still out on this loan.
+func (l *Loan) Open() bool {
+	return l.Status == LoanOnLoan || l.Status == LoanOverdue
+}
+
+// CheckoutInput names the copy to check out. The member is whoever makes
+// the request, and their member type comes with their credentials.
+type CheckoutInput struct {
+	CopyID int64 `json:"copy_id"`
+}
+
+// ListLoansQuery filters loans. Zero fields match everything; DueBefore
+// with OpenOnly finds overdue loans.
+type ListLoansQuery struct {
+	MemberID  string
+	CopyID    int64
+	OpenOnly  bool
+	DueBefore time.Time
+	Limit     int
+	Offset    int
+}
+
+// LoanRepository stores copies and loans, scoped to the tenant in the
+// context like BookRepository.
+//
+// Checkout, Renew and Close are each atomic with respect to the copy and
+// loan they touch: two checkouts of one copy cannot both succeed, and the
+// loser gets ErrCopyUnavailable. A copy on hold can only be checked out by
+// the member of its ready hold, which fulfils the hold. Renew only applies
+// if the loan is open and has been renewed exactly renewals times, and
+// fails with ErrConflict otherwise. Close fails with ErrLoanClosed if the
+// loan already ended.
+type LoanRepository interface {
+	CreateCopy(ctx context.Context, bookID int64, in CreateCopyInput) (*Copy, error)
+	GetCopy(ctx context.Context, id int64) (*Copy, error)
+	ListCopies(ctx context.Context, bookID int64) ([]*Copy, error)
+	// ListCopiesForBooks returns the copies of each of the books in one
+	// go, keyed by book ID. Books without copies are absent.
+	ListCopiesForBooks(ctx context.Context, bookIDs []int64) (map[int64][]*Copy, error)
+
+	Checkout(ctx context.Context, l Loan) (*Loan, error)
+	GetLoan(ctx context.Context, id int64) (*Loan, error)
+	ListLoans(ctx context.Context, q ListLoansQuery) ([]*Loan, error)
+	Renew(ctx context.Context, id int64, renewals int, due time.Time) (*Loan, error)
+	Close(ctx context.Context, id int64, status LoanStatus, at time.Time) (*Loan, error)
+}
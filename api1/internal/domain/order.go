@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"unit-test-demo/unittest3/lib"
+)
+
+// Inventory is what a book sells for. A book without one is not for
+// sale; how many copies there are is up to its stock ledger.
+type Inventory struct {
+	BookID     int64     `json:"book_id"`
+	TenantID   string    `json:"tenant_id"`
+	PriceCents lib.Money `json:"price_cents"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type InventoryInput struct {
+	PriceCents lib.Money `json:"price_cents"`
+}
+
+// CartLine is a book in a cart. Title and UnitPriceCents are filled in
+// when the cart is read, from the book and its current price.
+type CartLine struct {
+	BookID         int64     `json:"book_id"`
+	Qty            int64     `json:"qty"`
+	Title          string    `json:"title,omitempty"`
+	UnitPriceCents lib.Money `json:"unit_price_cents"`
+}
+
+// Cart is what a customer is about to order. Totals is what the order
+// would come to at current prices, with the configured discount.
+type Cart struct {
+	Customer string      `json:"customer"`
+	Lines    []CartLine  `json:"lines"`
+	Totals   OrderTotals `json:"totals"`
+}
+
+type SetCartLineInput struct {
+	Qty int64 `json:"qty"`
+}
+
+// OrderTotals is a lib.Totals as the API shows it.
+type OrderTotals struct {
+	SubtotalCents lib.Money `json:"subtotal_cents"`
+	DiscountCents lib.Money `json:"discount_cents"`
+	TaxCents      lib.Money `json:"tax_cents"`
+	TotalCents    lib.Money `json:"total_cents"`
+}
+
+func NewOrderTotals(t lib.Totals) OrderTotals {
+	return OrderTotals{
+		SubtotalCents: t.Subtotal,
+		DiscountCents: t.Discount,
+		TaxCents:      t.Tax,
+		TotalCents:    t.Total,
+	}
+}
+
+// OrderStatus is where an order stands. Orders without payment are placed
+// and done with; paid orders wait for their payment, and end up paid, or
+// cancelled if it is declined or voided, or refunded.
+type OrderStatus string
+
+const (
+	OrderPlaced          OrderStatus = "placed"
+	OrderAwaitingPayment OrderStatus = "awaiting_payment"
+	OrderPaid            OrderStatus = "paid"
+	OrderCancelled       OrderStatus = "cancelled"
+	OrderRefunded        OrderStatus = "refunded"
+)
+
+// OrderLine is a book as it was ordered: its title and price are copied
+// from the book at checkout and do not follow later changes.
+// ReservationID is the stock reservation its copies were taken from.
+type OrderLine struct {
+	BookID         int64     `json:"book_id"`
+	Title          string    `json:"title"`
+	Qty            int64     `json:"qty"`
+	UnitPriceCents lib.Money `json:"unit_price_cents"`
+	ReservationID  int64     `json:"reservation_id,omitempty"`
+}
+
+// Order is a checked out cart. Its lines, the percentages applied and the
+// Totals worked out from them are fixed once it is placed.
+type Order struct {
+	ID          int64       `json:"id"`
+	TenantID    string      `json:"tenant_id"`
+	Customer    string      `json:"customer"`
+	Status      OrderStatus `json:"status"`
+	Lines       []OrderLine `json:"lines"`
+	Coupon      string      `json:"coupon,omitempty"`
+	DiscountPct int         `json:"discount_pct"`
+	TaxPct      int         `json:"tax_pct"`
+	Totals      OrderTotals `json:"totals"`
+	PlacedAt    time.Time   `json:"placed_at"`
+}
+
+// PlaceOrderInput may carry a coupon, and a PaymentToken standing for the
+// card to charge; without one the order is placed unpaid.
+type PlaceOrderInput struct {
+	Coupon       string `json:"coupon"`
+	PaymentToken string `json:"payment_token"`
+}
+
+// ListOrdersQuery filters orders, newest first. An empty Customer matches
+// every customer.
+type ListOrdersQuery struct {
+	Customer string
+	Limit    int
+	Offset   int
+}
+
+// InventoryRepository keeps the prices of books, scoped to the tenant in
+// the context like BookRepository. SetInventory fails with ErrNotFound for
+// a book that does not exist or is deleted.
+type InventoryRepository interface {
+	GetInventory(ctx context.Context, bookID int64) (*Inventory, error)
+	// GetInventories returns the inventory of each of the books that has
+	// one, keyed by book ID.
+	GetInventories(ctx context.Context, bookIDs []int64) (map[int64]*Inventory, error)
+	SetInventory(ctx context.Context, bookID int64, in InventoryInput, at time.Time) (*Inventory, error)
+}
+
+// CartRepository keeps a cart per customer, scoped to the tenant in the
+// context. Lines are returned in book order; setting a line to zero
+// copies removes it.
+type CartRepository interface {
+	GetCartLines(ctx context.Context, customer string) ([]CartLine, error)
+	SetCartLine(ctx context.Context, customer string, bookID, qty int64) error
+	ClearCart(ctx context.Context, customer string) error
+}
+
+// OrderRepository stores placed orders, scoped to the tenant in the
+// context. Orders are only ever added; nothing changes their lines or
+// totals afterwards, only their status.
+type OrderRepository interface {
+	CreateOrder(ctx context.Context, o Order) (*Order, error)
+	GetOrder(ctx context.Context, id int64) (*Order, error)
+	ListOrders(ctx context.Context, q ListOrdersQuery) ([]*Order, error)
+	SetOrderStatus(ctx context.Context, id int64, status OrderStatus) (*Order, error)
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"unit-test-demo/unittest3/lib"
+)
+
+var ErrPaymentState = errors.New("payment cannot make that transition")
+
+// PaymentStatus is where a payment is in its life:
+//
+//	pending    -> authorized, captured, declined, voided
+//	authorized -> captured, voided
+//	captured   -> refunded
+//
+// A pending payment is one the gateway has not answered for yet: the call
+// timed out, or the gateway settles it later and says so by webhook.
+// Declined, voided and refunded payments are done with.
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentDeclined   PaymentStatus = "declined"
+	PaymentVoided     PaymentStatus = "voided"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+// An authorized payment is refunded, rather than voided, when the capture
+// went through but the order could no longer be filled.
+var paymentMoves = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:    {PaymentAuthorized, PaymentCaptured, PaymentDeclined, PaymentVoided},
+	PaymentAuthorized: {PaymentCaptured, PaymentVoided, PaymentRefunded},
+	PaymentCaptured:   {PaymentRefunded},
+}
+
+// CanMove reports whether a payment in s may move on to next.
+func (s PaymentStatus) CanMove(next PaymentStatus) bool {
+	for _, to := range paymentMoves[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Done reports whether s is a status no payment leaves.
+func (s PaymentStatus) Done() bool {
+	return len(paymentMoves[s]) == 0
+}
+
+// Payment is what an order is paid with. GatewayRef is the gateway's ID
+// for it, known once the gateway has answered; Reason says why it was
+// declined or voided.
+type Payment struct {
+	ID          int64         `json:"id"`
+	TenantID    string        `json:"tenant_id"`
+	OrderID     int64         `json:"order_id"`
+	Status      PaymentStatus `json:"status"`
+	AmountCents lib.Money     `json:"amount_cents"`
+	GatewayRef  string        `json:"gateway_ref,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ListPaymentsQuery finds payments in one of Statuses that have not
+// changed since Before, by ID from after AfterID.
+type ListPaymentsQuery struct {
+	Statuses []PaymentStatus
+	Before   time.Time
+	AfterID  int64
+	Limit    int
+}
+
+// PaymentRepository keeps payments, scoped to the tenant in the context
+// like BookRepository. An order has at most one payment; CreatePayment
+// fails with ErrConflict for a second one.
+//
+// LockPayment returns a payment and holds it until the transaction ends,
+// so that the transitions of one payment are made one at a time.
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, p Payment) (*Payment, error)
+	GetPayment(ctx context.Context, id int64) (*Payment, error)
+	GetPaymentByOrder(ctx context.Context, orderID int64) (*Payment, error)
+	LockPayment(ctx context.Context, id int64) (*Payment, error)
+	// UpdatePayment saves the status, gateway ref, reason and UpdatedAt of p.
+	UpdatePayment(ctx context.Context, p Payment) (*Payment, error)
+	ListPayments(ctx context.Context, q ListPaymentsQuery) ([]*Payment, error)
+}
+
+// AuthorizeRequest asks a gateway to hold AmountCents on the card behind
+// Token. Reference is ours for the payment; a gateway given the same
+// Reference twice answers with the payment it already has, so a call that
+// timed out can be made again safely.
+type AuthorizeRequest struct {
+	Reference   string    `json:"reference"`
+	AmountCents lib.Money `json:"amount_cents"`
+	Token       string    `json:"token"`
+}
+
+// GatewayPayment is a payment as the gateway has it.
+type GatewayPayment struct {
+	Ref       string        `json:"ref"`
+	Reference string        `json:"reference"`
+	Status    PaymentStatus `json:"status"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// PaymentGateway moves money. Calls fail with ErrUnavailable when the
+// gateway cannot be reached or does not answer in time, in which case
+// the outcome is unknown until Lookup says otherwise; with ErrNotFound
+// for a payment the gateway does not know; and with ErrPaymentState for
+// one that cannot make the move asked for.
+//
+// A decline is not an error: Authorize answers with a declined payment.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, req AuthorizeRequest) (*GatewayPayment, error)
+	Capture(ctx context.Context, ref string, amount lib.Money) (*GatewayPayment, error)
+	Refund(ctx context.Context, ref string, amount lib.Money) (*GatewayPayment, error)
+	Void(ctx context.Context, ref string) (*GatewayPayment, error)
+	// Lookup finds a payment by our Reference.
+	Lookup(ctx context.Context, reference string) (*GatewayPayment, error)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Review is one reader's rating of a book. A reviewer has at most one
+// review per book; TenantID and Reviewer are set from the request context.
+type Review struct {
+	ID           int64     `json:"id"`
+	BookID       int64     `json:"book_id"`
+	TenantID     string    `json:"tenant_id"`
+	Reviewer     string    `json:"reviewer"`
+	Rating       int       `json:"rating"`
+	Text         string    `json:"text"`
+	HelpfulCount int       `json:"helpful_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ReviewInput struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+type ReviewSort string
+
+const (
+	ReviewSortRecent  ReviewSort = "recent"
+	ReviewSortHelpful ReviewSort = "helpful"
+)
+
+// ListReviewsQuery pages the reviews of one book. An empty Sort means
+// ReviewSortRecent and a zero Limit the repository default.
+type ListReviewsQuery struct {
+	BookID int64
+	Sort   ReviewSort
+	Limit  int
+	Offset int
+}
+
+// ReviewRepository stores reviews, scoped to the tenant in the context like
+// BookRepository. Create fails with ErrConflict if the reviewer already
+// reviewed the book. MarkHelpful counts at most one vote per voter and
+// returns the review either way.
+type ReviewRepository interface {
+	Create(ctx context.Context, bookID int64, reviewer string, in ReviewInput) (*Review, error)
+	Get(ctx context.Context, bookID, id int64) (*Review, error)
+	List(ctx context.Context, q ListReviewsQuery) ([]*Review, error)
+	Update(ctx context.Context, bookID, id int64, in ReviewInput) (*Review, error)
+	Delete(ctx context.Context, bookID, id int64) error
+	MarkHelpful(ctx context.Context, bookID, id int64, voter string) (*Review, error)
+	// ListForBooks returns the perBook most recent reviews of each of the
+	// books in one go, keyed by book ID. Books without reviews are absent.
+	ListForBooks(ctx context.Context, bookIDs []int64, perBook int) (map[int64][]*Review, error)
+}
+
+// RatingAvg is the mean of count ratings adding up to sum, rounded to two
+// decimals; zero without ratings.
+func RatingAvg(sum, count int) float64 {
+	if count <= 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(count)*100) / 100
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// BookRevision is a full snapshot of a book taken after one of its writes.
+// Revisions are numbered from 1 per book; a delete is a revision too, with
+// Deleted set.
+type BookRevision struct {
+	Revision   int       `json:"revision"`
+	Book       Book      `json:"book"`
+	Deleted    bool      `json:"deleted"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// BookRevisionRepository reads the history a BookRepository keeps of every
+// book it writes. GetAsOf returns the book as it was at the given time, or
+// ErrNotFound if it did not exist or was deleted then.
+type BookRevisionRepository interface {
+	ListRevisions(ctx context.Context, bookID int64, limit, offset int) ([]*BookRevision, error)
+	GetRevision(ctx context.Context, bookID int64, rev int) (*BookRevision, error)
+	GetAsOf(ctx context.Context, bookID int64, at time.Time) (*Book, error)
+}
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrOutOfStock        = errors.New("not enough stock")
+	ErrReservationClosed = errors.New("reservation is no longer active")
+)
+
+// StockMovementKind is what a stock movement records:
+//
+//	received  copies came in
+//	adjusted  a correction after a count, either way
+//	reserved  copies set aside for a buyer
+//	released  a reservation let go, or expired
+//	sold      reserved copies left with their buyer
+type StockMovementKind string
+
+const (
+	StockReceived StockMovementKind = "received"
+	StockAdjusted StockMovementKind = "adjusted"
+	StockReserved StockMovementKind = "reserved"
+	StockReleased StockMovementKind = "released"
+	StockSold     StockMovementKind = "sold"
+)
+
+// StockMovement is one entry in a book's stock ledger, which is only ever
+// appended to. Qty is positive, except that adjustments may be negative.
+// The movements of a reservation carry its ID.
+type StockMovement struct {
+	ID            int64             `json:"id"`
+	TenantID      string            `json:"tenant_id"`
+	BookID        int64             `json:"book_id"`
+	Kind          StockMovementKind `json:"kind"`
+	Qty           int64             `json:"qty"`
+	ReservationID int64             `json:"reservation_id,omitempty"`
+	Note          string            `json:"note,omitempty"`
+	At            time.Time         `json:"at"`
+}
+
+// StockLevel is where a book's stock stands, as its ledger adds up:
+//
+//	on hand   = received + adjusted - sold
+//	reserved  = reserved - released - sold
+//	available = on hand - reserved
+//
+// Low is set once available has fallen to LowStockThreshold; a threshold
+// of zero turns the warning off.
+type StockLevel struct {
+	BookID            int64 `json:"book_id"`
+	OnHand            int64 `json:"on_hand"`
+	Reserved          int64 `json:"reserved"`
+	Available         int64 `json:"available"`
+	LowStockThreshold int64 `json:"low_stock_threshold"`
+	Low               bool  `json:"low"`
+}
+
+// Add folds m into the level. Available and Low follow.
+func (l *StockLevel) Add(m StockMovement) {
+	switch m.Kind {
+	case StockReceived, StockAdjusted:
+		l.OnHand += m.Qty
+	case StockReserved:
+		l.Reserved += m.Qty
+	case StockReleased:
+		l.Reserved -= m.Qty
+	case StockSold:
+		l.OnHand -= m.Qty
+		l.Reserved -= m.Qty
+	}
+	l.settle()
+}
+
+// SetThreshold sets the low-stock threshold. Low follows.
+func (l *StockLevel) SetThreshold(threshold int64) {
+	l.LowStockThreshold = threshold
+	l.settle()
+}
+
+func (l *StockLevel) settle() {
+	l.Available = l.OnHand - l.Reserved
+	l.Low = l.LowStockThreshold > 0 && l.Available <= l.LowStockThreshold
+}
+
+// StockLine is how many copies of a book to set aside.
+type StockLine struct {
+	BookID int64
+	Qty    int64
+}
+
+type StockInput struct {
+	Qty  int64  `json:"qty"`
+	Note string `json:"note"`
+}
+
+type LowStockInput struct {
+	Threshold int64 `json:"low_stock_threshold"`
+}
+
+// ReservationStatus is where a reservation is in its life:
+//
+//	active -> sold
+//	active -> released
+//	active -> expired
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "active"
+	ReservationSold     ReservationStatus = "sold"
+	ReservationReleased ReservationStatus = "released"
+	ReservationExpired  ReservationStatus = "expired"
+)
+
+// StockReservation is a number of copies of a book set aside until
+// ExpiresAt. Ref says what they were set aside for.
+type StockReservation struct {
+	ID        int64             `json:"id"`
+	TenantID  string            `json:"tenant_id"`
+	BookID    int64             `json:"book_id"`
+	Qty       int64             `json:"qty"`
+	Status    ReservationStatus `json:"status"`
+	Ref       string            `json:"ref,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	ClosedAt  *time.Time        `json:"closed_at,omitempty"`
+}
+
+type ReserveStockInput struct {
+	Qty        int64  `json:"qty"`
+	TTLSeconds int    `json:"ttl_seconds"`
+	Ref        string `json:"ref"`
+}
+
+// ListStockMovementsQuery lists a book's ledger, newest first.
+type ListStockMovementsQuery struct {
+	BookID int64
+	Limit  int
+	Offset int
+}
+
+// StockRepository keeps the stock ledgers, scoped to the tenant in the
+// context like BookRepository. Every write locks the stock of the books it
+// touches, so the level it checks is the level it changes: concurrent
+// reservations cannot set aside the same copy twice.
+//
+// RecordStock appends a received or adjusted movement; an adjustment that
+// would leave fewer copies on hand than are reserved fails with
+// ErrOutOfStock. Reserve sets copies aside for every line, in order, or
+// for none and fails with ErrOutOfStock. CloseReservation sells or lets go
+// of an active reservation, and fails with ErrReservationClosed once it is
+// not active. ExpiredReservations lists active reservations past now, at
+// most limit of them.
+type StockRepository interface {
+	GetStockLevel(ctx context.Context, bookID int64) (*StockLevel, error)
+	SetLowStockThreshold(ctx context.Context, bookID, threshold int64) (*StockLevel, error)
+	ListStockMovements(ctx context.Context, q ListStockMovementsQuery) ([]*StockMovement, error)
+	RecordStock(ctx context.Context, m StockMovement) (*StockLevel, error)
+
+	Reserve(ctx context.Context, lines []StockLine, ref string, at, expiresAt time.Time) ([]*StockReservation, error)
+	GetReservation(ctx context.Context, id int64) (*StockReservation, error)
+	CloseReservation(ctx context.Context, id int64, status ReservationStatus, at time.Time) (*StockReservation, error)
+	ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*StockReservation, error)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrConflict        = errors.New("already exists")
+	ErrTenantSuspended = errors.New("tenant suspended")
+	ErrQuotaExceeded   = errors.New("book quota exceeded")
+)
+
+// Tenant is one library sharing the deployment. A BookQuota of zero means
+// no limit.
+type Tenant struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	BookQuota   int        `json:"book_quota"`
+	BookCount   int        `json:"book_count"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (t *Tenant) Suspended() bool {
+	return t.SuspendedAt != nil
+}
+
+type CreateTenantInput struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BookQuota int    `json:"book_quota"`
+}
+
+// TenantRepository stores tenants and their book counts. ReserveBook takes
+// one unit of quota and fails with ErrQuotaExceeded or ErrTenantSuspended;
+// ReleaseBook gives one back. Both must run in the transaction that
+// creates, deletes or restores the book so the count never drifts.
+type TenantRepository interface {
+	Create(ctx context.Context, in CreateTenantInput) (*Tenant, error)
+	Get(ctx context.Context, id string) (*Tenant, error)
+	List(ctx context.Context, limit, offset int) ([]*Tenant, error)
+	SetSuspended(ctx context.Context, id string, suspended bool) (*Tenant, error)
+	ReserveBook(ctx context.Context, id string) error
+	ReleaseBook(ctx context.Context, id string) error
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// txHooks holds the AfterCommit callbacks of one transaction, or of a
+// savepoint within one when parent is set.
+type txHooks struct {
+	parent *txHooks
+
+	mu  sync.Mutex
+	fns []func()
+}
+
+type txHooksKey struct{}
+
+func (h *txHooks) add(fns ...func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fns...)
+}
+
+// BeginTxHooks is for Transactor implementations. It returns ctx with a
+// fresh scope for AfterCommit callbacks, and end, to be called once the
+// transaction or savepoint is over with whether it committed. When the
+// outermost transaction commits, end runs the callbacks in the order they
+// were added. A savepoint that commits hands its callbacks on to the
+// transaction around it, and one that rolls back drops them.
+func BeginTxHooks(ctx context.Context) (context.Context, func(committed bool)) {
+	parent, _ := ctx.Value(txHooksKey{}).(*txHooks)
+	h := &txHooks{parent: parent}
+	return context.WithValue(ctx, txHooksKey{}, h), func(committed bool) {
+		h.mu.Lock()
+		fns := h.fns
+		h.fns = nil
+		h.mu.Unlock()
+
+		switch {
+		case !committed:
+		case h.parent != nil:
+			h.parent.add(fns...)
+		default:
+			for _, fn := range fns {
+				fn()
+			}
+		}
+	}
+}
+
+// AfterCommit runs fn once the transaction in ctx has committed, or never
+// if it rolls back. Outside a transaction fn runs at once.
+func AfterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		fn()
+		return
+	}
+	h.add(fn)
+}
+
+// InTransaction reports whether ctx belongs to a transaction of a
+// Transactor, whose writes others cannot see yet.
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	return ok
+}
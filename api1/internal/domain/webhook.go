@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"time"
+)
+
+type WebhookEndpoint struct {
+	ID                  int64       `json:"id"`
+	TenantID            string      `json:"tenant_id"`
+	URL                 string      `json:"url"`
+	EventTypes          []EventType `json:"event_types"`
+	Secret              string      `json:"-"`
+	Active              bool        `json:"active"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	CreatedAt           time.Time   `json:"created_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of type t.
+func (e *WebhookEndpoint) Subscribes(t EventType) bool {
+	for _, et := range e.EventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which
+// netip does not count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// WebhookAddrAllowed reports whether webhooks may be sent to ip. Loopback,
+// private, link-local, shared, unspecified and multicast addresses are
+// refused, so that a webhook cannot reach into the network the service
+// runs in.
+func WebhookAddrAllowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+type CreateWebhookInput struct {
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	Secret     string      `json:"secret"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event on its way to one endpoint, together with
+// the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	TenantID       string          `json:"tenant_id"`
+	EndpointID     int64           `json:"endpoint_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      EventType       `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	RedeliveryOf   *int64          `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookRepository stores endpoints and their delivery log. Both belong
+// to the tenant in ctx: every method only sees and writes that tenant's.
+//
+// EnqueueDelivery is idempotent per (endpoint, event) for first deliveries,
+// so an outbox message relayed twice does not produce two deliveries;
+// redeliveries are always inserted. ClaimDueDeliveries leases due pending
+// deliveries of active endpoints by pushing their next attempt out by
+// lease, so concurrent workers don't send the same delivery.
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, in CreateWebhookInput) (*WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]*WebhookEndpoint, error)
+	SetEndpointActive(ctx context.Context, id int64, active bool) error
+	IncrementEndpointFailures(ctx context.Context, id int64) (int, error)
+	ResetEndpointFailures(ctx context.Context, id int64) error
+
+	EnqueueDelivery(ctx context.Context, d WebhookDelivery) (*WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, endpointID int64, limit, offset int) ([]*WebhookDelivery, error)
+}
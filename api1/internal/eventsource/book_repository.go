@@ -0,0 +1,383 @@
+// Package eventsource is an event-sourced domain.BookRepository. A book is
+// the fold of its stream of events, which is only ever appended to;
+// queries are answered from a read model the events are projected into as
+// they are written, and which can be rebuilt from the streams at any time.
+//
+// It is an evaluation of the pattern on the book aggregate and keeps
+// nothing but the book: categories, tags and revisions stay with the CRUD
+// repositories, so List matches no book on a category or tag filter.
+package eventsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+const (
+	// DefaultSnapshotEvery is how many events a book gets between
+	// snapshots when Config leaves SnapshotEvery zero.
+	DefaultSnapshotEvery = 50
+	// maxAttempts bounds how often a write is retried after losing a race
+	// for the same book.
+	maxAttempts = 3
+	// rebuildBatch is how many events Rebuild reads at a time.
+	rebuildBatch = 500
+)
+
+// Config tunes a BookRepository. Now defaults to time.Now.
+type Config struct {
+	SnapshotEvery int
+	Now           func() time.Time
+}
+
+// BookRepository is a domain.BookRepository over a domain.BookEventStore,
+// projecting into a domain.BookReadModel. Each write loads the book,
+// decides on its events and appends them against the version it loaded,
+// all in one transaction of tx; a write that loses a race to another is
+// retried on the new state.
+type BookRepository struct {
+	store domain.BookEventStore
+	model domain.BookReadModel
+	tx    domain.Transactor
+	cfg   Config
+}
+
+func NewBookRepository(store domain.BookEventStore, model domain.BookReadModel, tx domain.Transactor, cfg Config) *BookRepository {
+	if cfg.SnapshotEvery <= 0 {
+		cfg.SnapshotEvery = DefaultSnapshotEvery
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &BookRepository{store: store, model: model, tx: tx, cfg: cfg}
+}
+
+// change is an event about to be stored.
+type change struct {
+	kind domain.BookEventKind
+	data any
+}
+
+func (r *BookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	var s domain.BookState
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !in.Force {
+			if err := r.duplicateOf(ctx, 0, in.Title, in.Author); err != nil {
+				return err
+			}
+		}
+		id, err := r.store.NextBookID(ctx)
+		if err != nil {
+			return err
+		}
+		s = domain.BookState{Book: domain.Book{ID: id}}
+		return r.commit(ctx, &s, change{domain.BookEventCreated, created{
+			Title:     in.Title,
+			Author:    in.Author,
+			CreatedAt: r.cfg.Now().UTC().Truncate(time.Second),
+			Forced:    in.Force,
+		}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s.Book, nil
+}
+
+func (r *BookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	s, err := r.model.GetBookState(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Deleted {
+		return nil, domain.ErrNotFound
+	}
+	return &s.Book, nil
+}
+
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	states, err := r.model.GetBookStates(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	books := make(map[int64]*domain.Book, len(states))
+	for id, s := range states {
+		if !s.Deleted {
+			books[id] = &s.Book
+		}
+	}
+	return books, nil
+}
+
+func (r *BookRepository) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	return r.model.ListBookStates(ctx, q)
+}
+
+// Update records only the fields that changed; a book updated to what it
+// already is gets no events.
+func (r *BookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	return r.change(ctx, id, func(ctx context.Context, s *domain.BookState) ([]change, error) {
+		if s.Deleted {
+			return nil, domain.ErrNotFound
+		}
+		if !s.Forced {
+			if err := r.duplicateOf(ctx, id, in.Title, in.Author); err != nil {
+				return nil, err
+			}
+		}
+		var changes []change
+		if in.Title != s.Book.Title {
+			changes = append(changes, change{domain.BookEventTitleChanged, titleChanged{Title: in.Title}})
+		}
+		if in.Author != s.Book.Author {
+			changes = append(changes, change{domain.BookEventAuthorChanged, authorChanged{Author: in.Author}})
+		}
+		return changes, nil
+	})
+}
+
+func (r *BookRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.change(ctx, id, func(ctx context.Context, s *domain.BookState) ([]change, error) {
+		if s.Deleted {
+			return nil, domain.ErrNotFound
+		}
+		return []change{{domain.BookEventDeleted, struct{}{}}}, nil
+	})
+	return err
+}
+
+func (r *BookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	return r.change(ctx, id, func(ctx context.Context, s *domain.BookState) ([]change, error) {
+		if !s.Deleted {
+			return nil, domain.ErrNotFound
+		}
+		if !s.Forced {
+			if err := r.duplicateOf(ctx, id, s.Book.Title, s.Book.Author); err != nil {
+				return nil, err
+			}
+		}
+		return []change{{domain.BookEventRestored, struct{}{}}}, nil
+	})
+}
+
+func (r *BookRepository) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	return r.change(ctx, id, func(ctx context.Context, s *domain.BookState) ([]change, error) {
+		if s.Deleted {
+			return nil, domain.ErrNotFound
+		}
+		c.UpdatedAt = r.cfg.Now().UTC().Truncate(time.Second)
+		return []change{{domain.BookEventCoverSet, coverSet{Cover: cover(c)}}}, nil
+	})
+}
+
+func (r *BookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	s, err := r.model.GetBookState(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Deleted || s.Cover == nil {
+		return nil, domain.ErrNotFound
+	}
+	return s.Cover, nil
+}
+
+func (r *BookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	return r.change(ctx, id, func(ctx context.Context, s *domain.BookState) ([]change, error) {
+		if s.Deleted {
+			return nil, domain.ErrNotFound
+		}
+		return []change{{domain.BookEventRated, rated{Sum: sum, Count: count}}}, nil
+	})
+}
+
+// Rebuild replaces the read model of the tenant in ctx with a fresh
+// projection of its event streams, for when the projection changed or
+// the table was lost. It runs in one transaction, so readers keep seeing
+// the old projection until it is done, and holds the state of every book
+// of the tenant while it replays. Writes to the tenant's books wait for it,
+// and it for those under way, so none is lost to the replay. It returns
+// the number of books.
+func (r *BookRepository) Rebuild(ctx context.Context) (int, error) {
+	if _, err := tenancy.Require(ctx); err != nil {
+		return 0, err
+	}
+
+	var n int
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := r.model.LockBookStates(ctx, true); err != nil {
+			return err
+		}
+		states := make(map[int64]*domain.BookState)
+		for after := int64(0); ; {
+			events, err := r.store.ReadBookEvents(ctx, after, rebuildBatch)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				break
+			}
+			for _, e := range events {
+				s, ok := states[e.BookID]
+				if !ok {
+					s = &domain.BookState{}
+					states[e.BookID] = s
+				}
+				if err := apply(s, e); err != nil {
+					return err
+				}
+			}
+			after = events[len(events)-1].Position
+		}
+
+		if err := r.model.ResetBookStates(ctx); err != nil {
+			return err
+		}
+		ids := make([]int64, 0, len(states))
+		for id := range states {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			if err := r.model.PutBookState(ctx, *states[id]); err != nil {
+				return err
+			}
+		}
+		n = len(ids)
+		return nil
+	})
+	return n, err
+}
+
+// change runs decide on the current state of book id and stores the events
+// it returns, retrying from a fresh load if another write got in first.
+func (r *BookRepository) change(ctx context.Context, id int64, decide func(ctx context.Context, s *domain.BookState) ([]change, error)) (*domain.Book, error) {
+	for attempt := 1; ; attempt++ {
+		var s *domain.BookState
+		err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			if s, err = r.load(ctx, id); err != nil {
+				return err
+			}
+			changes, err := decide(ctx, s)
+			if err != nil {
+				return err
+			}
+			return r.commit(ctx, s, changes...)
+		})
+		if errors.Is(err, domain.ErrVersionConflict) && attempt < maxAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &s.Book, nil
+	}
+}
+
+// load rebuilds the state of book id from its latest snapshot and the
+// events after it.
+func (r *BookRepository) load(ctx context.Context, id int64) (*domain.BookState, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &domain.BookState{}
+	snap, err := r.store.LoadBookSnapshot(ctx, id)
+	switch {
+	case err == nil:
+		if *s, err = decodeSnapshot(tenant, *snap); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	events, err := r.store.LoadBookEvents(ctx, id, s.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if err := apply(s, e); err != nil {
+			return nil, err
+		}
+	}
+	if s.Version == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return s, nil
+}
+
+// commit appends changes to the stream of s against its version, applies
+// them to s and projects the result. It snapshots s whenever the stream
+// crosses a multiple of SnapshotEvery.
+func (r *BookRepository) commit(ctx context.Context, s *domain.BookState, changes ...change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := r.model.LockBookStates(ctx, false); err != nil {
+		return err
+	}
+	at := r.cfg.Now().UTC()
+	expected := s.Version
+	events := make([]domain.BookStreamEvent, len(changes))
+	for i, c := range changes {
+		data, err := json.Marshal(c.data)
+		if err != nil {
+			return err
+		}
+		events[i] = domain.BookStreamEvent{
+			TenantID: tenant,
+			BookID:   s.Book.ID,
+			Version:  expected + i + 1,
+			Kind:     c.kind,
+			Data:     data,
+			At:       at,
+		}
+	}
+	if err := r.store.AppendBookEvents(ctx, s.Book.ID, expected, events); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := apply(s, e); err != nil {
+			return err
+		}
+	}
+	if err := r.model.PutBookState(ctx, *s); err != nil {
+		return err
+	}
+
+	if s.Version/r.cfg.SnapshotEvery > expected/r.cfg.SnapshotEvery {
+		snap, err := encodeSnapshot(*s)
+		if err != nil {
+			return err
+		}
+		snap.At = at
+		return r.store.SaveBookSnapshot(ctx, snap)
+	}
+	return nil
+}
+
+// duplicateOf fails with a *domain.DuplicateError if a live, unforced book
+// other than id has the BookKey of title and author.
+func (r *BookRepository) duplicateOf(ctx context.Context, id int64, title, author string) error {
+	existing, err := r.model.FindBookByKey(ctx, domain.BookKey(title, author), id)
+	if err != nil {
+		return err
+	}
+	if existing != 0 {
+		return &domain.DuplicateError{ExistingID: existing}
+	}
+	return nil
+}
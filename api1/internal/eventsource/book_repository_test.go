@@ -0,0 +1,158 @@
+package eventsource_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/eventsource"
+	"unit-test-demo/api1/internal/infrastructure/booktest"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+func acme() context.Context {
+	return tenancy.WithTenant(context.Background(), "acme")
+}
+
+func newRepo(store *memory.BookStreamStore, snapshotEvery int) *eventsource.BookRepository {
+	return eventsource.NewBookRepository(store, store, memory.NewTransactor(), eventsource.Config{SnapshotEvery: snapshotEvery})
+}
+
+func TestBookRepository(t *testing.T) {
+	booktest.Run(t, func(t *testing.T) domain.BookRepository {
+		return newRepo(memory.NewBookStreamStore(), 0)
+	})
+}
+
+func TestBookRepository_RecordsEvents(t *testing.T) {
+	// Arrange
+	ctx := acme()
+	store := memory.NewBookStreamStore()
+	repo := newRepo(store, 0)
+	b, err := repo.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	// Act
+	_, err = repo.Update(ctx, b.ID, domain.UpdateBookInput{Title: "Dune", Author: "F. Herbert"})
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, b.ID, domain.UpdateBookInput{Title: "Dune", Author: "F. Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, b.ID))
+
+	// Assert: only what changed is recorded.
+	events, err := store.LoadBookEvents(ctx, b.ID, 0)
+	require.NoError(t, err)
+	var kinds []domain.BookEventKind
+	for i, e := range events {
+		assert.Equal(t, i+1, e.Version)
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []domain.BookEventKind{domain.BookEventCreated, domain.BookEventAuthorChanged, domain.BookEventDeleted}, kinds)
+}
+
+func TestBookRepository_Snapshots(t *testing.T) {
+	ctx := acme()
+	store := memory.NewBookStreamStore()
+	repo := newRepo(store, 3)
+	b, err := repo.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err = repo.AdjustRating(ctx, b.ID, 4, 1)
+		require.NoError(t, err)
+	}
+
+	snap, err := store.LoadBookSnapshot(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, snap.Version)
+
+	// A write after the snapshot replays only the events since, and lands
+	// on the same state as the whole stream.
+	_, err = repo.SetCover(ctx, b.ID, domain.Cover{ETag: "abc", Key: "covers/1"})
+	require.NoError(t, err)
+	got, err := repo.AdjustRating(ctx, b.ID, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, got.RatingCount)
+	assert.Equal(t, 3.4, got.RatingAvg)
+	assert.Equal(t, domain.CoverURL(b.ID, "abc"), got.CoverURL)
+
+	snap, err = store.LoadBookSnapshot(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, snap.Version)
+}
+
+// racingStore lets another write in between a load and the append that
+// follows it, once.
+type racingStore struct {
+	*memory.BookStreamStore
+	once  sync.Once
+	other func()
+}
+
+func (s *racingStore) AppendBookEvents(ctx context.Context, id int64, expected int, events []domain.BookStreamEvent) error {
+	s.once.Do(s.other)
+	return s.BookStreamStore.AppendBookEvents(ctx, id, expected, events)
+}
+
+func TestBookRepository_RetriesOnVersionConflict(t *testing.T) {
+	ctx := acme()
+	mem := memory.NewBookStreamStore()
+	plain := newRepo(mem, 0)
+	b, err := plain.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	racing := &racingStore{BookStreamStore: mem, other: func() {
+		_, err := plain.AdjustRating(ctx, b.ID, 5, 1)
+		require.NoError(t, err)
+	}}
+	repo := eventsource.NewBookRepository(racing, mem, noTx{}, eventsource.Config{})
+
+	got, err := repo.AdjustRating(ctx, b.ID, 3, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.RatingCount, "the retry applies on top of the write that won")
+	assert.Equal(t, 4.0, got.RatingAvg)
+}
+
+func TestBookRepository_Rebuild(t *testing.T) {
+	ctx := acme()
+	store := memory.NewBookStreamStore()
+	repo := newRepo(store, 2)
+	dune, err := repo.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	emma, err := repo.Create(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, dune.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, emma.ID))
+	other, err := repo.Create(tenancy.WithTenant(context.Background(), "globex"), domain.CreateBookInput{Title: "Beloved", Author: "Toni Morrison"})
+	require.NoError(t, err)
+	before, err := repo.List(ctx, domain.ListBooksQuery{})
+	require.NoError(t, err)
+
+	// The read model is lost.
+	require.NoError(t, store.ResetBookStates(ctx))
+	_, err = repo.GetByID(ctx, dune.ID)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	n, err := repo.Rebuild(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "deleted books are projected too")
+	after, err := repo.List(ctx, domain.ListBooksQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	_, err = repo.Restore(ctx, emma.ID)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(tenancy.WithTenant(context.Background(), "globex"), other.ID)
+	assert.NoError(t, err, "other tenants are left alone")
+}
+
+// noTx runs fn as is, so the racing write is not held up by a lock.
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
@@ -0,0 +1,165 @@
+package eventsource
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// The payloads of the event kinds. They are stored, so fields may be added
+// but not renamed or removed.
+type (
+	created struct {
+		Title     string    `json:"title"`
+		Author    string    `json:"author"`
+		CreatedAt time.Time `json:"created_at"`
+		Forced    bool      `json:"forced,omitempty"`
+	}
+	titleChanged struct {
+		Title string `json:"title"`
+	}
+	authorChanged struct {
+		Author string `json:"author"`
+	}
+	coverSet struct {
+		Cover cover `json:"cover"`
+	}
+	rated struct {
+		Sum   int `json:"sum"`
+		Count int `json:"count"`
+	}
+)
+
+// cover is domain.Cover with the blob keys, which its JSON leaves out.
+type cover struct {
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag"`
+	Key         string    `json:"key"`
+	ThumbType   string    `json:"thumb_content_type"`
+	ThumbSize   int64     `json:"thumb_size"`
+	ThumbETag   string    `json:"thumb_etag"`
+	ThumbKey    string    `json:"thumb_key"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (c cover) domain() *domain.Cover {
+	d := domain.Cover(c)
+	return &d
+}
+
+// snapshot is how a domain.BookState is saved in a domain.BookSnapshot.
+type snapshot struct {
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	CreatedAt   time.Time `json:"created_at"`
+	Deleted     bool      `json:"deleted,omitempty"`
+	Forced      bool      `json:"forced,omitempty"`
+	RatingSum   int       `json:"rating_sum"`
+	RatingCount int       `json:"rating_count"`
+	Cover       *cover    `json:"cover,omitempty"`
+}
+
+// apply folds e into s.
+func apply(s *domain.BookState, e domain.BookStreamEvent) error {
+	if e.Version != s.Version+1 {
+		return fmt.Errorf("book %d: event version %d follows %d", e.BookID, e.Version, s.Version)
+	}
+	var err error
+	switch e.Kind {
+	case domain.BookEventCreated:
+		var p created
+		if err = json.Unmarshal(e.Data, &p); err == nil {
+			*s = domain.BookState{
+				Book:   domain.Book{ID: e.BookID, TenantID: e.TenantID, Title: p.Title, Author: p.Author, CreatedAt: p.CreatedAt},
+				Forced: p.Forced,
+			}
+		}
+	case domain.BookEventTitleChanged:
+		var p titleChanged
+		if err = json.Unmarshal(e.Data, &p); err == nil {
+			s.Book.Title = p.Title
+		}
+	case domain.BookEventAuthorChanged:
+		var p authorChanged
+		if err = json.Unmarshal(e.Data, &p); err == nil {
+			s.Book.Author = p.Author
+		}
+	case domain.BookEventDeleted:
+		s.Deleted = true
+	case domain.BookEventRestored:
+		s.Deleted = false
+	case domain.BookEventCoverSet:
+		var p coverSet
+		if err = json.Unmarshal(e.Data, &p); err == nil {
+			s.Cover = p.Cover.domain()
+			s.Book.CoverURL = domain.CoverURL(e.BookID, p.Cover.ETag)
+		}
+	case domain.BookEventRated:
+		var p rated
+		if err = json.Unmarshal(e.Data, &p); err == nil {
+			s.RatingSum += p.Sum
+			s.Book.RatingCount += p.Count
+			s.Book.RatingAvg = domain.RatingAvg(s.RatingSum, s.Book.RatingCount)
+		}
+	default:
+		err = fmt.Errorf("unknown event kind %q", e.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("book %d version %d: %w", e.BookID, e.Version, err)
+	}
+	s.Version = e.Version
+	return nil
+}
+
+func encodeSnapshot(s domain.BookState) (domain.BookSnapshot, error) {
+	snap := snapshot{
+		Title:       s.Book.Title,
+		Author:      s.Book.Author,
+		CreatedAt:   s.Book.CreatedAt,
+		Deleted:     s.Deleted,
+		Forced:      s.Forced,
+		RatingSum:   s.RatingSum,
+		RatingCount: s.Book.RatingCount,
+	}
+	if s.Cover != nil {
+		c := cover(*s.Cover)
+		snap.Cover = &c
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return domain.BookSnapshot{}, err
+	}
+	return domain.BookSnapshot{BookID: s.Book.ID, Version: s.Version, State: data}, nil
+}
+
+func decodeSnapshot(tenant string, in domain.BookSnapshot) (domain.BookState, error) {
+	var snap snapshot
+	if err := json.Unmarshal(in.State, &snap); err != nil {
+		return domain.BookState{}, fmt.Errorf("book %d snapshot %d: %w", in.BookID, in.Version, err)
+	}
+	s := domain.BookState{
+		Book: domain.Book{
+			ID:          in.BookID,
+			TenantID:    tenant,
+			Title:       snap.Title,
+			Author:      snap.Author,
+			CreatedAt:   snap.CreatedAt,
+			RatingAvg:   domain.RatingAvg(snap.RatingSum, snap.RatingCount),
+			RatingCount: snap.RatingCount,
+		},
+		Version:   in.Version,
+		Deleted:   snap.Deleted,
+		Forced:    snap.Forced,
+		RatingSum: snap.RatingSum,
+	}
+	if snap.Cover != nil {
+		s.Cover = snap.Cover.domain()
+		s.Book.CoverURL = domain.CoverURL(in.BookID, snap.Cover.ETag)
+	}
+	return s, nil
+}
@@ -0,0 +1,92 @@
+// Package imaging validates uploaded images and makes thumbnails. JPEG and
+// PNG use the standard library decoders; WebP is decoded with x/image,
+// which registers itself with package image the same way.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// formats maps sniffed content types to the name package image knows the
+// decoder by.
+var formats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// Info describes an image without holding its pixels.
+type Info struct {
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Inspect sniffs the content type from the bytes themselves, ignoring
+// whatever the client claimed, and reads the dimensions from the header
+// without decoding the pixels.
+func Inspect(data []byte) (Info, error) {
+	ct := http.DetectContentType(data)
+	want, ok := formats[ct]
+	if !ok {
+		return Info{}, ErrUnsupportedType
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != want {
+		return Info{}, ErrInvalidImage
+	}
+	return Info{ContentType: ct, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Thumbnail decodes data fully, which also proves the pixel data is sound,
+// and scales it to fit in a maxSide square. PNGs stay PNG to keep
+// transparency; everything else becomes JPEG, since the standard library
+// cannot encode WebP.
+func Thumbnail(data []byte, maxSide int) ([]byte, string, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+
+	b := src.Bounds()
+	w, h := fit(b.Dx(), b.Dy(), maxSide)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// fit scales w×h down to fit in a maxSide square, keeping the aspect
+// ratio. Images that already fit are left as they are.
+func fit(w, h, maxSide int) (int, int) {
+	if w <= maxSide && h <= maxSide {
+		return w, h
+	}
+	if w >= h {
+		return maxSide, max(1, h*maxSide/w)
+	}
+	return max(1, w*maxSide/h), maxSide
+}
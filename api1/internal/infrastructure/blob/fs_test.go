@@ -0,0 +1,58 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/blob"
+)
+
+func TestFSStore_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, err := blob.NewFSStore(root)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put(ctx, "covers/acme/1/abc.png", strings.NewReader("png"), 3, "image/png"))
+
+	rc, err := s.Get(ctx, "covers/acme/1/abc.png")
+	require.NoError(t, err)
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "png", string(got))
+
+	require.NoError(t, s.Delete(ctx, "covers/acme/1/abc.png"))
+	_, err = s.Get(ctx, "covers/acme/1/abc.png")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, s.Delete(ctx, "covers/acme/1/abc.png"), "deleting twice is fine")
+}
+
+func TestFSStore_ShortWriteLeavesNothing(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, _ := blob.NewFSStore(root)
+
+	err := s.Put(ctx, "a/b.png", strings.NewReader("pn"), 3, "image/png")
+
+	assert.Error(t, err)
+	entries, _ := os.ReadDir(filepath.Join(root, "a"))
+	assert.Empty(t, entries, "the temporary file is removed")
+}
+
+func TestFSStore_RejectsEscapingKeys(t *testing.T) {
+	ctx := context.Background()
+	s, _ := blob.NewFSStore(t.TempDir())
+
+	for _, key := range []string{"", "../etc/passwd", "/etc/passwd", "a/../../b", "a//b", "a/./b"} {
+		err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain")
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,212 @@
+// Package booktest is the behaviour every domain.BookRepository must share,
+// as a suite each implementation runs from its own tests.
+package booktest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// Run runs the suite against fresh repositories made by newRepo.
+func Run(t *testing.T, newRepo func(t *testing.T) domain.BookRepository) {
+	RunShared(t, func(t *testing.T) (domain.BookRepository, context.Context, context.Context) {
+		return newRepo(t), tenancy.WithTenant(context.Background(), "acme"), tenancy.WithTenant(context.Background(), "globex")
+	})
+}
+
+// RunShared runs the suite against a repository that is not fresh, such as
+// one on a database other tests write to as well. newRepo returns it along
+// with two tenants that have no books yet.
+func RunShared(t *testing.T, newRepo func(t *testing.T) (repo domain.BookRepository, acme, globex context.Context)) {
+	create := func(t *testing.T, repo domain.BookRepository, ctx context.Context, title, author string) *domain.Book {
+		t.Helper()
+		b, err := repo.Create(ctx, domain.CreateBookInput{Title: title, Author: author})
+		require.NoError(t, err)
+		return b
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+
+		assert.Positive(t, b.ID)
+		tenant, _ := tenancy.FromContext(acme)
+		assert.Equal(t, tenant, b.TenantID)
+		assert.False(t, b.CreatedAt.IsZero())
+		got, err := repo.GetByID(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, b, got)
+	})
+
+	t.Run("RequiresTenant", func(t *testing.T) {
+		repo, _, _ := newRepo(t)
+
+		_, err := repo.Create(context.Background(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+		assert.ErrorIs(t, err, tenancy.ErrNoTenant)
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		repo, acme, globex := newRepo(t)
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+
+		_, err := repo.GetByID(globex, b.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = repo.Update(globex, b.ID, domain.UpdateBookInput{Title: "Mine", Author: "Me"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.ErrorIs(t, repo.Delete(globex, b.ID), domain.ErrNotFound)
+		books, err := repo.List(globex, domain.ListBooksQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, books)
+		// Another tenant may use the same title and author.
+		create(t, repo, globex, "Dune", "Frank Herbert")
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		dune := create(t, repo, acme, "Dune", "Frank Herbert")
+		emma := create(t, repo, acme, "Emma", "Jane Austen")
+		messiah := create(t, repo, acme, "Dune Messiah", "Frank Herbert")
+		gone := create(t, repo, acme, "Beloved", "Toni Morrison")
+		require.NoError(t, repo.Delete(acme, gone.ID))
+
+		all, err := repo.List(acme, domain.ListBooksQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{dune.ID, emma.ID, messiah.ID}, ids(all))
+
+		byAuthor, err := repo.List(acme, domain.ListBooksQuery{Author: "Frank Herbert"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{dune.ID, messiah.ID}, ids(byAuthor))
+
+		page, err := repo.List(acme, domain.ListBooksQuery{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{emma.ID}, ids(page))
+
+		none, err := repo.List(acme, domain.ListBooksQuery{Category: "no-such-category"})
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+
+		updated, err := repo.Update(acme, b.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "F. Herbert"})
+		require.NoError(t, err)
+		assert.Equal(t, "Dune Messiah", updated.Title)
+		assert.Equal(t, "F. Herbert", updated.Author)
+		assert.Equal(t, b.CreatedAt, updated.CreatedAt)
+
+		got, err := repo.GetByID(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updated, got)
+
+		_, err = repo.Update(acme, 9999, domain.UpdateBookInput{Title: "X", Author: "Y"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+
+		require.NoError(t, repo.Delete(acme, b.ID))
+		_, err := repo.GetByID(acme, b.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.ErrorIs(t, repo.Delete(acme, b.ID), domain.ErrNotFound)
+		_, err = repo.Update(acme, b.ID, domain.UpdateBookInput{Title: "X", Author: "Y"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		restored, err := repo.Restore(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, b.Title, restored.Title)
+		_, err = repo.GetByID(acme, b.ID)
+		assert.NoError(t, err)
+
+		_, err = repo.Restore(acme, b.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound, "only deleted books can be restored")
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		dune := create(t, repo, acme, "Dune", "Frank Herbert")
+		emma := create(t, repo, acme, "Emma", "Jane Austen")
+
+		var dup *domain.DuplicateError
+		_, err := repo.Create(acme, domain.CreateBookInput{Title: "  DUNE ", Author: "frank herbert"})
+		require.ErrorAs(t, err, &dup)
+		assert.Equal(t, dune.ID, dup.ExistingID)
+
+		_, err = repo.Update(acme, emma.ID, domain.UpdateBookInput{Title: "Dune", Author: "Frank Herbert"})
+		require.ErrorAs(t, err, &dup)
+		assert.Equal(t, dune.ID, dup.ExistingID)
+
+		forced, err := repo.Create(acme, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert", Force: true})
+		require.NoError(t, err)
+		assert.NotEqual(t, dune.ID, forced.ID)
+
+		// A deleted book does not block; restoring it again would clash.
+		require.NoError(t, repo.Delete(acme, dune.ID))
+		again := create(t, repo, acme, "Dune", "Frank Herbert")
+		_, err = repo.Restore(acme, dune.ID)
+		require.ErrorAs(t, err, &dup)
+		assert.Equal(t, again.ID, dup.ExistingID)
+	})
+
+	t.Run("Cover", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+		_, err := repo.GetCover(acme, b.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		withCover, err := repo.SetCover(acme, b.ID, domain.Cover{ContentType: "image/png", Width: 10, Height: 20, Size: 300, ETag: "abc", Key: "covers/1"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.CoverURL(b.ID, "abc"), withCover.CoverURL)
+
+		c, err := repo.GetCover(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "covers/1", c.Key)
+		assert.Equal(t, int64(300), c.Size)
+		got, err := repo.GetByID(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, withCover.CoverURL, got.CoverURL)
+
+		require.NoError(t, repo.Delete(acme, b.ID))
+		_, err = repo.GetCover(acme, b.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = repo.SetCover(acme, b.ID, domain.Cover{ETag: "def"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("AdjustRating", func(t *testing.T) {
+		repo, acme, _ := newRepo(t)
+		b := create(t, repo, acme, "Dune", "Frank Herbert")
+
+		_, err := repo.AdjustRating(acme, b.ID, 5, 1)
+		require.NoError(t, err)
+		rated, err := repo.AdjustRating(acme, b.ID, 4, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, rated.RatingCount)
+		assert.Equal(t, 4.5, rated.RatingAvg)
+		got, err := repo.GetByID(acme, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rated, got)
+
+		_, err = repo.AdjustRating(acme, 9999, 1, 1)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func ids(books []*domain.Book) []int64 {
+	out := []int64{}
+	for _, b := range books {
+		out = append(out, b.ID)
+	}
+	return out
+}
@@ -0,0 +1,301 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// Options tunes a CachedBookRepository.
+type Options struct {
+	// TTL bounds how long a found book or list page is served from cache.
+	TTL time.Duration
+	// NegativeTTL bounds how long a not-found result is remembered. Zero
+	// disables negative caching.
+	NegativeTTL time.Duration
+	// LoadTimeout bounds a read shared by concurrent misses, which outlives
+	// the caller that started it. Zero means 5s.
+	LoadTimeout time.Duration
+}
+
+// Stats counts cache lookups since the repository was created.
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// CachedBookRepository is a read-through cache in front of any
+// domain.BookRepository. Reads by ID and list queries are cached; every
+// successful write invalidates what it could have changed.
+//
+// Keys include the tenant from the context, since book IDs alone would let
+// one tenant's cached rows answer another tenant's reads. Calls without a
+// tenant bypass the cache and are left to the wrapped repository to reject.
+//
+// List pages are keyed by a write generation that is bumped on each write,
+// so a write makes all earlier pages unreachable at once. The generation is
+// local to the process: with a shared Store, other instances keep serving
+// their pages until TTL expires.
+//
+// Writes made in a transaction invalidate once it commits: done earlier, a
+// concurrent read could cache the row as it was before, or one that then
+// rolls back. Reads in a transaction skip the cache both ways, since they
+// must see the transaction's own writes and must not cache them.
+type CachedBookRepository struct {
+	next  domain.BookRepository
+	store Store
+	opts  Options
+
+	flights group
+	gen     atomic.Uint64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func NewCachedBookRepository(next domain.BookRepository, store Store, opts Options) *CachedBookRepository {
+	return &CachedBookRepository{next: next, store: store, opts: opts, flights: group{timeout: opts.LoadTimeout}}
+}
+
+// entry is what gets serialized into the Store.
+type entry struct {
+	NotFound bool           `json:"not_found,omitempty"`
+	Book     *domain.Book   `json:"book,omitempty"`
+	Books    []*domain.Book `json:"books,omitempty"`
+}
+
+func (c *CachedBookRepository) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+func (c *CachedBookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	b, err := c.next.Create(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	// The ID may have a negative entry from an earlier lookup.
+	c.invalidate(ctx, b.TenantID, b.ID)
+	return b, nil
+}
+
+func (c *CachedBookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	tenant, ok := tenancy.FromContext(ctx)
+	if !ok || domain.InTransaction(ctx) {
+		return c.next.GetByID(ctx, id)
+	}
+	key := bookKey(tenant, id)
+	if e, ok := c.lookup(ctx, key); ok {
+		if e.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return copyBook(e.Book), nil
+	}
+
+	gen := c.gen.Load()
+	v, err, _ := c.flights.Do(ctx, key, func(ctx context.Context) (any, error) {
+		b, err := c.next.GetByID(ctx, id)
+		switch {
+		case err == nil:
+			c.fill(ctx, gen, key, entry{Book: b}, c.opts.TTL)
+		case errors.Is(err, domain.ErrNotFound) && c.opts.NegativeTTL > 0:
+			c.fill(ctx, gen, key, entry{NotFound: true}, c.opts.NegativeTTL)
+		}
+		return b, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyBook(v.(*domain.Book)), nil
+}
+
+// GetByIDs answers what it can from the entries GetByID keeps and reads
+// the rest from the wrapped repository in one call, caching what it finds.
+func (c *CachedBookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	tenant, ok := tenancy.FromContext(ctx)
+	if !ok || domain.InTransaction(ctx) {
+		return c.next.GetByIDs(ctx, ids)
+	}
+	books := make(map[int64]*domain.Book, len(ids))
+	var missing []int64
+	for _, id := range ids {
+		e, ok := c.lookup(ctx, bookKey(tenant, id))
+		switch {
+		case !ok:
+			missing = append(missing, id)
+		case !e.NotFound:
+			books[id] = copyBook(e.Book)
+		}
+	}
+	if len(missing) == 0 {
+		return books, nil
+	}
+
+	gen := c.gen.Load()
+	found, err := c.next.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range missing {
+		key := bookKey(tenant, id)
+		if b, ok := found[id]; ok {
+			c.fill(ctx, gen, key, entry{Book: b}, c.opts.TTL)
+			books[id] = copyBook(b)
+		} else if c.opts.NegativeTTL > 0 {
+			c.fill(ctx, gen, key, entry{NotFound: true}, c.opts.NegativeTTL)
+		}
+	}
+	return books, nil
+}
+
+func (c *CachedBookRepository) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	tenant, ok := tenancy.FromContext(ctx)
+	// Filing a book under a category or tagging it does not go through
+	// this repository, so nothing would invalidate those pages.
+	if !ok || q.Category != "" || len(q.Tags) > 0 || domain.InTransaction(ctx) {
+		return c.next.List(ctx, q)
+	}
+	gen := c.gen.Load()
+	key := listKey(tenant, gen, q)
+	if e, ok := c.lookup(ctx, key); ok {
+		return copyBooks(e.Books), nil
+	}
+
+	v, err, _ := c.flights.Do(ctx, key, func(ctx context.Context) (any, error) {
+		books, err := c.next.List(ctx, q)
+		if err == nil {
+			c.fill(ctx, gen, key, entry{Books: books}, c.opts.TTL)
+		}
+		return books, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyBooks(v.([]*domain.Book)), nil
+}
+
+func (c *CachedBookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	b, err := c.next.Update(ctx, id, in)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, b.TenantID, id)
+	return b, nil
+}
+
+func (c *CachedBookRepository) Delete(ctx context.Context, id int64) error {
+	if err := c.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	// A successful delete implies ctx carried the tenant.
+	tenant, _ := tenancy.FromContext(ctx)
+	c.invalidate(ctx, tenant, id)
+	return nil
+}
+
+func (c *CachedBookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	b, err := c.next.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, b.TenantID, id)
+	return b, nil
+}
+
+func (c *CachedBookRepository) SetCover(ctx context.Context, id int64, cover domain.Cover) (*domain.Book, error) {
+	b, err := c.next.SetCover(ctx, id, cover)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, b.TenantID, id)
+	return b, nil
+}
+
+func (c *CachedBookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	b, err := c.next.AdjustRating(ctx, id, sum, count)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, b.TenantID, id)
+	return b, nil
+}
+
+// GetCover is not cached: it is only read when serving the image, and the
+// bytes themselves come from the blob store.
+func (c *CachedBookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	return c.next.GetCover(ctx, id)
+}
+
+// lookup reads and decodes key, counting the hit or miss. Store failures
+// are treated as misses so the cache never makes a read fail.
+func (c *CachedBookRepository) lookup(ctx context.Context, key string) (entry, bool) {
+	raw, ok, err := c.store.Get(ctx, key)
+	var e entry
+	if err == nil && ok && json.Unmarshal(raw, &e) == nil {
+		c.hits.Add(1)
+		return e, true
+	}
+	c.misses.Add(1)
+	return entry{}, false
+}
+
+// fill stores e unless a write happened since the load started, in which
+// case the loaded value may already be stale.
+func (c *CachedBookRepository) fill(ctx context.Context, gen uint64, key string, e entry, ttl time.Duration) {
+	if c.gen.Load() != gen {
+		return
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if c.store.Set(ctx, key, raw, ttl) != nil {
+		return
+	}
+	// A write committing between the check above and Set may have deleted
+	// key before e was stored. invalidate bumps the generation before it
+	// deletes, so if the generation is still gen here, any such delete is
+	// yet to come.
+	if c.gen.Load() != gen {
+		_ = c.store.Delete(ctx, key)
+	}
+}
+
+// invalidate drops the book and every list page once the write is
+// committed.
+func (c *CachedBookRepository) invalidate(ctx context.Context, tenant string, id int64) {
+	domain.AfterCommit(ctx, func() {
+		c.gen.Add(1)
+		_ = c.store.Delete(context.WithoutCancel(ctx), bookKey(tenant, id))
+	})
+}
+
+func bookKey(tenant string, id int64) string {
+	return fmt.Sprintf("book:%q:%d", tenant, id)
+}
+
+func listKey(tenant string, gen uint64, q domain.ListBooksQuery) string {
+	return fmt.Sprintf("books:%q:%d:%q:%d:%d", tenant, gen, q.Author, q.Limit, q.Offset)
+}
+
+// copyBook hands each caller its own value so callers sharing a flight (or
+// mutating the result, as the usecase does) can't affect one another.
+func copyBook(b *domain.Book) *domain.Book {
+	if b == nil {
+		return nil
+	}
+	cp := *b
+	return &cp
+}
+
+func copyBooks(books []*domain.Book) []*domain.Book {
+	out := make([]*domain.Book, len(books))
+	for i, b := range books {
+		out[i] = copyBook(b)
+	}
+	return out
+}
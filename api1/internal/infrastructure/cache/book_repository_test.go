@@ -0,0 +1,347 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/cache"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// countingRepo wraps the in-memory repository and counts read calls so
+// tests can tell cache hits from pass-through reads.
+type countingRepo struct {
+	*memory.BookRepository
+	gets  atomic.Int64
+	lists atomic.Int64
+
+	// if set, GetByID blocks until the channel is closed
+	block chan struct{}
+}
+
+func (r *countingRepo) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	r.gets.Add(1)
+	if r.block != nil {
+		<-r.block
+	}
+	return r.BookRepository.GetByID(ctx, id)
+}
+
+func (r *countingRepo) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	r.lists.Add(1)
+	return r.BookRepository.List(ctx, q)
+}
+
+func newCached(t *testing.T) (*cache.CachedBookRepository, *countingRepo) {
+	t.Helper()
+	repo := &countingRepo{BookRepository: memory.NewBookRepository()}
+	c := cache.NewCachedBookRepository(repo, cache.NewLRUStore(100), cache.Options{
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	})
+	return c, repo
+}
+
+func TestCachedBookRepository_GetByID_ReadThrough(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	b, err := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	first, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+	second, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Dune", first.Title)
+	assert.Equal(t, first, second)
+	assert.NotSame(t, first, second, "each caller gets its own copy")
+	assert.Equal(t, int64(1), repo.gets.Load())
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1}, c.Stats())
+}
+
+func TestCachedBookRepository_NegativeCaching(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+
+	_, err := c.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = c.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Equal(t, int64(1), repo.gets.Load())
+
+	// Creating the book must clear the remembered miss.
+	b, err := c.Create(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), b.ID)
+
+	got, err := c.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Emma", got.Title)
+}
+
+func TestCachedBookRepository_InvalidatesOnUpdateAndDelete(t *testing.T) {
+	ctx := tenantCtx()
+	c, _ := newCached(t)
+	b, _ := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	_, _ = c.GetByID(ctx, b.ID)
+
+	_, err := c.Update(ctx, b.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	got, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", got.Title)
+
+	require.NoError(t, c.Delete(ctx, b.ID))
+	_, err = c.GetByID(ctx, b.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestCachedBookRepository_InvalidatesAfterCommit(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	b, _ := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	_, _ = c.GetByID(ctx, b.ID)
+	tx := memory.NewTransactor()
+
+	err := tx.WithinTx(ctx, func(txCtx context.Context) error {
+		_, err := c.Update(txCtx, b.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+		require.NoError(t, err)
+
+		// The transaction reads its own write, past the cache...
+		got, err := c.GetByID(txCtx, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Dune Messiah", got.Title)
+		// ...while others are served the committed book until it commits.
+		got, err = c.GetByID(ctx, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Dune", got.Title)
+		return nil
+	})
+	require.NoError(t, err)
+
+	got, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", got.Title)
+	assert.Equal(t, int64(3), repo.gets.Load())
+}
+
+func TestCachedBookRepository_RollbackKeepsTheCache(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	b, _ := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	_, _ = c.GetByID(ctx, b.ID)
+
+	err := memory.NewTransactor().WithinTx(ctx, func(txCtx context.Context) error {
+		_, err := c.Update(txCtx, b.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+		require.NoError(t, err)
+		return errors.New("boom")
+	})
+	require.Error(t, err)
+
+	// The in-memory store cannot undo the write; the cache shows it was
+	// never invalidated.
+	got, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", got.Title)
+	assert.Equal(t, int64(1), repo.gets.Load())
+}
+
+func TestCachedBookRepository_ListInvalidatedByWrites(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	_, _ = c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+
+	q := domain.ListBooksQuery{Limit: 10}
+	got, _ := c.List(ctx, q)
+	assert.Len(t, got, 1)
+	got, _ = c.List(ctx, q)
+	assert.Len(t, got, 1)
+	assert.Equal(t, int64(1), repo.lists.Load())
+
+	_, _ = c.Create(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	got, _ = c.List(ctx, q)
+	assert.Len(t, got, 2)
+	assert.Equal(t, int64(2), repo.lists.Load())
+}
+
+func TestCachedBookRepository_TaxonomyFiltersBypassTheCache(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	b, _ := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+
+	q := domain.ListBooksQuery{Tags: []string{"classic"}}
+	got, _ := c.List(ctx, q)
+	assert.Empty(t, got)
+
+	// Tagging goes straight to the repository, past the cache.
+	_, err := repo.SetBookTaxonomy(ctx, b.ID, domain.BookTaxonomyInput{Tags: []string{"classic"}})
+	require.NoError(t, err)
+	got, _ = c.List(ctx, q)
+	assert.Len(t, got, 1)
+	assert.Equal(t, int64(2), repo.lists.Load())
+}
+
+func TestCachedBookRepository_SingleflightConcurrentMisses(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	b, _ := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	repo.block = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := c.GetByID(ctx, b.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "Dune", got.Title)
+		}()
+	}
+
+	// Give the goroutines a moment to pile up behind the first load.
+	time.Sleep(20 * time.Millisecond)
+	close(repo.block)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), repo.gets.Load())
+}
+
+func TestCachedBookRepository_CallerGivingUpLeavesTheSharedLoad(t *testing.T) {
+	c, repo := newCached(t)
+	b, _ := c.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	repo.block = make(chan struct{})
+
+	first, cancel := context.WithCancel(tenantCtx())
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.GetByID(first, b.ID)
+		firstErr <- err
+	}()
+	// Let the first caller start the load before the second joins it.
+	time.Sleep(20 * time.Millisecond)
+	second := make(chan *domain.Book)
+	go func() {
+		got, err := c.GetByID(tenantCtx(), b.ID)
+		assert.NoError(t, err)
+		second <- got
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(repo.block)
+	assert.Equal(t, "Dune", (<-second).Title)
+	assert.Equal(t, int64(1), repo.gets.Load())
+}
+
+// panickyRepo panics on every read by ID.
+type panickyRepo struct {
+	*memory.BookRepository
+}
+
+func (panickyRepo) GetByID(context.Context, int64) (*domain.Book, error) {
+	panic("boom")
+}
+
+func TestCachedBookRepository_PanickingLoadFailsEveryCaller(t *testing.T) {
+	c := cache.NewCachedBookRepository(panickyRepo{memory.NewBookRepository()}, cache.NewLRUStore(100), cache.Options{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetByID(tenantCtx(), 1)
+			assert.ErrorContains(t, err, "panicked: boom")
+		}()
+	}
+	wg.Wait()
+}
+
+// racingStore runs beforeSet ahead of the first Set it is asked for, as a
+// write committing while a load is being stored would.
+type racingStore struct {
+	*cache.LRUStore
+	once      sync.Once
+	beforeSet func()
+}
+
+func (s *racingStore) Set(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	s.once.Do(s.beforeSet)
+	return s.LRUStore.Set(ctx, key, val, ttl)
+}
+
+func TestCachedBookRepository_WriteDuringFillDropsTheEntry(t *testing.T) {
+	ctx := tenantCtx()
+	repo := memory.NewBookRepository()
+	b, err := repo.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	store := &racingStore{LRUStore: cache.NewLRUStore(100)}
+	c := cache.NewCachedBookRepository(repo, store, cache.Options{TTL: time.Minute})
+	store.beforeSet = func() {
+		_, err := c.Update(ctx, b.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+		assert.NoError(t, err)
+	}
+
+	_, err = c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+
+	got, err := c.GetByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", got.Title, "the entry loaded before the update must not stay cached")
+}
+
+// tenantCtx is the context of a request acting for the test tenant; the
+// memory repository refuses to work without one.
+func tenantCtx() context.Context {
+	return tenancy.WithTenant(context.Background(), "acme")
+}
+
+func TestCachedBookRepository_KeysAreTenantScoped(t *testing.T) {
+	c, _ := newCached(t)
+	b, err := c.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = c.GetByID(tenantCtx(), b.ID)
+	require.NoError(t, err)
+
+	other := tenancy.WithTenant(context.Background(), "globex")
+	_, err = c.GetByID(other, b.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "a cached book must not leak to another tenant")
+
+	books, err := c.List(other, domain.ListBooksQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, books)
+}
+
+func TestCachedBookRepository_GetByIDs_ReadsOnlyMisses(t *testing.T) {
+	ctx := tenantCtx()
+	c, repo := newCached(t)
+	dune, err := c.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	emma, err := c.Create(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	_, err = c.GetByID(ctx, dune.ID)
+	require.NoError(t, err)
+
+	first, err := c.GetByIDs(ctx, []int64{dune.ID, emma.ID, 99})
+	require.NoError(t, err)
+	second, err := c.GetByIDs(ctx, []int64{dune.ID, emma.ID, 99})
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Len(t, second, 2, "the missing book is absent")
+	assert.Equal(t, "Emma", second[emma.ID].Title)
+	assert.Equal(t, int64(1), repo.gets.Load(), "only the first GetByID went through")
+	// 1 miss for GetByID, then 2 misses and 1 hit, then 3 hits.
+	assert.Equal(t, cache.Stats{Hits: 4, Misses: 3}, c.Stats())
+}
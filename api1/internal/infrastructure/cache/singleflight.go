@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultLoadTimeout bounds a shared load when Options.LoadTimeout is zero.
+const defaultLoadTimeout = 5 * time.Second
+
+// group de-duplicates concurrent loads of the same key: the first caller
+// starts fn, everyone else arriving before it finishes waits and shares the
+// result.
+//
+// fn serves every waiter, so it runs detached from the context of the
+// caller that started it, bounded by timeout instead: one caller giving up
+// must not fail the others. Each caller still stops waiting when its own
+// context ends.
+type group struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+func (g *group) Do(ctx context.Context, key string, fn func(context.Context) (any, error)) (v any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(ctx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err, shared
+	case <-ctx.Done():
+		return nil, ctx.Err(), shared
+	}
+}
+
+// run calls fn for c and releases its waiters however fn returns; a panic
+// becomes the error every waiter gets.
+func (g *group) run(ctx context.Context, key string, c *call, fn func(context.Context) (any, error)) {
+	timeout := g.timeout
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.val, c.err = nil, fmt.Errorf("cache: load of %s panicked: %v", key, r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn(ctx)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewLRUStore(2)
+
+	_ = s.Set(ctx, "a", []byte("1"), 0)
+	_ = s.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = s.Get(ctx, "a") // a is now most recent
+	_ = s.Set(ctx, "c", []byte("3"), 0)
+
+	_, ok, _ := s.Get(ctx, "b")
+	assert.False(t, ok, "b should have been evicted")
+	_, ok, _ = s.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestLRUStore_TTLExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	s := NewLRUStore(10)
+	s.now = func() time.Time { return now }
+
+	_ = s.Set(ctx, "k", []byte("v"), time.Second)
+	_, ok, _ := s.Get(ctx, "k")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = s.Get(ctx, "k")
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Len())
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// AuditRepository is an in-memory, append-only domain.AuditRepository.
+type AuditRepository struct {
+	mu      sync.Mutex
+	records []domain.AuditRecord
+	now     func() time.Time
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{now: time.Now}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, rec domain.AuditRecord) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec.TenantID = tenant
+	rec.ID = int64(len(r.records) + 1)
+	rec.CreatedAt = r.now().UTC()
+	r.records = append(r.records, rec)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	out := []*domain.AuditRecord{}
+	skipped := 0
+	for i := range r.records {
+		rec := r.records[i]
+		if rec.TenantID != tenant ||
+			(q.EntityType != "" && rec.EntityType != q.EntityType) ||
+			(q.EntityID != 0 && rec.EntityID != q.EntityID) ||
+			(q.Actor != "" && rec.Actor != q.Actor) ||
+			(q.Action != "" && rec.Action != q.Action) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		if len(out) >= limit {
+			break
+		}
+		out = append(out, &rec)
+	}
+	return out, nil
+}
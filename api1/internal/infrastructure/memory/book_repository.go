@@ -0,0 +1,463 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+const defaultListLimit = 50
+
+// BookRepository is an in-memory domain.BookRepository,
+// domain.BookRevisionRepository, domain.CategoryRepository,
+// domain.DuplicateRepository, domain.BookFieldRepository,
+// domain.BookExporter and domain.BookSyncRepository. It is safe for concurrent use and is meant for tests
+// and local runs without Postgres.
+type BookRepository struct {
+	mu             sync.RWMutex
+	books          map[int64]domain.Book
+	deleted        map[int64]domain.Book
+	history        map[int64][]domain.BookRevision
+	covers         map[int64]domain.Cover
+	ratings        map[int64]int // sum of review ratings per book
+	forced         map[int64]bool
+	categories     map[int64]domain.Category
+	filed          map[int64][]int64 // category IDs per book
+	tags           map[int64][]string
+	changeSeq      map[int64]int64  // sequence number of each book's last write
+	tenantSeq      map[string]int64 // latest sequence number per tenant
+	nextID         int64
+	nextCategoryID int64
+	now            func() time.Time
+}
+
+func NewBookRepository() *BookRepository {
+	return &BookRepository{
+		books:      make(map[int64]domain.Book),
+		deleted:    make(map[int64]domain.Book),
+		history:    make(map[int64][]domain.BookRevision),
+		covers:     make(map[int64]domain.Cover),
+		ratings:    make(map[int64]int),
+		forced:     make(map[int64]bool),
+		categories: make(map[int64]domain.Category),
+		filed:      make(map[int64][]int64),
+		tags:       make(map[int64][]string),
+		changeSeq:  make(map[int64]int64),
+		tenantSeq:  make(map[string]int64),
+		now:        time.Now,
+	}
+}
+
+// SetClock replaces the time source, so tests can control when revisions
+// are recorded.
+func (r *BookRepository) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = now
+}
+
+func (r *BookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !in.Force {
+		if err := r.duplicateOf(tenant, 0, in.Title, in.Author); err != nil {
+			return nil, err
+		}
+	}
+	now := r.now().UTC()
+	r.nextID++
+	b := domain.Book{
+		ID:        r.nextID,
+		TenantID:  tenant,
+		Title:     in.Title,
+		Author:    in.Author,
+		CreatedAt: now.Truncate(time.Second),
+	}
+	r.books[b.ID] = b
+	r.forced[b.ID] = in.Force
+	r.snapshot(b, false, now)
+	r.touch(b)
+	return &b, nil
+}
+
+func (r *BookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := find(r.books, tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &b, nil
+}
+
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := make(map[int64]*domain.Book, len(ids))
+	for _, id := range ids {
+		if b, ok := find(r.books, tenant, id); ok {
+			books[id] = &b
+		}
+	}
+	return books, nil
+}
+
+func (r *BookRepository) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	ids := r.matching(tenant, q)
+	books := []*domain.Book{}
+	for i := q.Offset; i < len(ids) && len(books) < limit; i++ {
+		b := r.books[ids[i]]
+		books = append(books, &b)
+	}
+	return books, nil
+}
+
+// GetFields returns the whole book; with everything in memory there is
+// nothing to save by leaving fields out.
+func (r *BookRepository) GetFields(ctx context.Context, id int64, fields []string) (*domain.Book, error) {
+	return r.GetByID(ctx, id)
+}
+
+// ListFields returns whole books, like GetFields.
+func (r *BookRepository) ListFields(ctx context.Context, q domain.ListBooksQuery, fields []string) ([]*domain.Book, error) {
+	return r.List(ctx, q)
+}
+
+// ExportBooks copies the matching books under the lock and hands them to
+// fn after releasing it, so fn may call back into the repository.
+func (r *BookRepository) ExportBooks(ctx context.Context, q domain.ListBooksQuery, fn func(*domain.Book) error) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	ids := r.matching(tenant, q)
+	books := make([]domain.Book, len(ids))
+	for i, id := range ids {
+		books[i] = r.books[id]
+	}
+	r.mu.RUnlock()
+
+	for i := range books {
+		if err := fn(&books[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := find(r.books, tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if err := r.duplicateOf(tenant, id, in.Title, in.Author); err != nil {
+		return nil, err
+	}
+	b.Title = in.Title
+	b.Author = in.Author
+	r.books[id] = b
+	r.snapshot(b, false, r.now())
+	r.touch(b)
+	return &b, nil
+}
+
+func (r *BookRepository) Delete(ctx context.Context, id int64) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := find(r.books, tenant, id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.books, id)
+	r.deleted[id] = b
+	r.snapshot(b, true, r.now())
+	r.touch(b)
+	return nil
+}
+
+func (r *BookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := find(r.deleted, tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if err := r.duplicateOf(tenant, id, b.Title, b.Author); err != nil {
+		return nil, err
+	}
+	delete(r.deleted, id)
+	r.books[id] = b
+	r.snapshot(b, false, r.now())
+	r.touch(b)
+	return &b, nil
+}
+
+func (r *BookRepository) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := find(r.books, tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	c.UpdatedAt = r.now().UTC().Truncate(time.Second)
+	r.covers[id] = c
+	b.CoverURL = domain.CoverURL(id, c.ETag)
+	r.books[id] = b
+	r.touch(b)
+	return &b, nil
+}
+
+func (r *BookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := find(r.books, tenant, id); !ok {
+		return nil, domain.ErrNotFound
+	}
+	c, ok := r.covers[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *BookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := find(r.books, tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	r.ratings[id] += sum
+	b.RatingCount += count
+	b.RatingAvg = domain.RatingAvg(r.ratings[id], b.RatingCount)
+	r.books[id] = b
+	r.touch(b)
+	return &b, nil
+}
+
+func (r *BookRepository) ListRevisions(ctx context.Context, bookID int64, limit, offset int) ([]*domain.BookRevision, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	hist := r.historyOf(tenant, bookID)
+	revs := []*domain.BookRevision{}
+	for i := offset; i < len(hist) && len(revs) < limit; i++ {
+		rev := hist[i]
+		revs = append(revs, &rev)
+	}
+	return revs, nil
+}
+
+func (r *BookRepository) GetRevision(ctx context.Context, bookID int64, rev int) (*domain.BookRevision, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hist := r.historyOf(tenant, bookID)
+	if rev < 1 || rev > len(hist) {
+		return nil, domain.ErrNotFound
+	}
+	out := hist[rev-1]
+	return &out, nil
+}
+
+func (r *BookRepository) GetAsOf(ctx context.Context, bookID int64, at time.Time) (*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hist := r.historyOf(tenant, bookID)
+	for i := len(hist) - 1; i >= 0; i-- {
+		if hist[i].RecordedAt.After(at) {
+			continue
+		}
+		if hist[i].Deleted {
+			return nil, domain.ErrNotFound
+		}
+		b := hist[i].Book
+		return &b, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// ListDuplicates groups the live books of the tenant by BookKey.
+func (r *BookRepository) ListDuplicates(ctx context.Context, limit, offset int) ([]*domain.DuplicateGroup, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	byKey := make(map[string][]*domain.Book)
+	for _, b := range r.books {
+		if b.TenantID == tenant {
+			key := domain.BookKey(b.Title, b.Author)
+			byKey[key] = append(byKey[key], &b)
+		}
+	}
+	var keys []string
+	for key, books := range byKey {
+		if len(books) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	groups := []*domain.DuplicateGroup{}
+	for i := offset; i < len(keys) && len(groups) < limit; i++ {
+		books := byKey[keys[i]]
+		slices.SortFunc(books, func(a, b *domain.Book) int { return cmp.Compare(a.ID, b.ID) })
+		groups = append(groups, &domain.DuplicateGroup{Key: keys[i], Books: books})
+	}
+	return groups, nil
+}
+
+// duplicateOf fails with a *domain.DuplicateError if a live book of tenant
+// other than id has the BookKey of title and author. Forced books neither
+// block others nor are blocked. Callers hold r.mu.
+func (r *BookRepository) duplicateOf(tenant string, id int64, title, author string) error {
+	if r.forced[id] {
+		return nil
+	}
+	key := domain.BookKey(title, author)
+	var existing int64
+	for oid, b := range r.books {
+		if oid != id && b.TenantID == tenant && !r.forced[oid] && domain.BookKey(b.Title, b.Author) == key {
+			if existing == 0 || oid < existing {
+				existing = oid
+			}
+		}
+	}
+	if existing != 0 {
+		return &domain.DuplicateError{ExistingID: existing}
+	}
+	return nil
+}
+
+// find returns book id from m if it belongs to tenant.
+func find(m map[int64]domain.Book, tenant string, id int64) (domain.Book, bool) {
+	b, ok := m[id]
+	if !ok || b.TenantID != tenant {
+		return domain.Book{}, false
+	}
+	return b, true
+}
+
+// historyOf returns the revisions of book id if it belongs to tenant.
+// Callers hold r.mu.
+func (r *BookRepository) historyOf(tenant string, id int64) []domain.BookRevision {
+	hist := r.history[id]
+	if len(hist) == 0 || hist[0].Book.TenantID != tenant {
+		return nil
+	}
+	return hist
+}
+
+// snapshot appends the next revision of b, recorded at at. Callers hold r.mu.
+func (r *BookRepository) snapshot(b domain.Book, deleted bool, at time.Time) {
+	r.history[b.ID] = append(r.history[b.ID], domain.BookRevision{
+		Revision:   len(r.history[b.ID]) + 1,
+		Book:       b,
+		Deleted:    deleted,
+		RecordedAt: at.UTC(),
+	})
+}
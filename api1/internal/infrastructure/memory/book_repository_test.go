@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/booktest"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+)
+
+func TestBookRepository(t *testing.T) {
+	booktest.Run(t, func(t *testing.T) domain.BookRepository {
+		return memory.NewBookRepository()
+	})
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// BookStreamStore is an in-memory domain.BookEventStore and
+// domain.BookReadModel. Like the other in-memory stores it cannot roll
+// back, so a failed write may leave its events without their projection.
+type BookStreamStore struct {
+	mu        sync.RWMutex
+	events    []domain.BookStreamEvent
+	streams   map[int64][]int // indexes into events per book
+	snapshots map[int64]domain.BookSnapshot
+	states    map[int64]domain.BookState
+	nextID    int64
+}
+
+func NewBookStreamStore() *BookStreamStore {
+	return &BookStreamStore{
+		streams:   make(map[int64][]int),
+		snapshots: make(map[int64]domain.BookSnapshot),
+		states:    make(map[int64]domain.BookState),
+	}
+}
+
+func (s *BookStreamStore) NextBookID(ctx context.Context) (int64, error) {
+	if _, err := tenancy.Require(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return s.nextID, nil
+}
+
+func (s *BookStreamStore) AppendBookEvents(ctx context.Context, id int64, expected int, events []domain.BookStreamEvent) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.streams[id]
+	if len(stream) > 0 && s.events[stream[0]].TenantID != tenant {
+		return domain.ErrNotFound
+	}
+	if len(stream) != expected {
+		return domain.ErrVersionConflict
+	}
+	for _, e := range events {
+		e.TenantID = tenant
+		e.Position = int64(len(s.events) + 1)
+		s.streams[id] = append(s.streams[id], len(s.events))
+		s.events = append(s.events, e)
+	}
+	return nil
+}
+
+func (s *BookStreamStore) LoadBookEvents(ctx context.Context, id int64, after int) ([]domain.BookStreamEvent, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []domain.BookStreamEvent
+	for _, i := range s.streams[id] {
+		if e := s.events[i]; e.TenantID == tenant && e.Version > after {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *BookStreamStore) LoadBookSnapshot(ctx context.Context, id int64) (*domain.BookSnapshot, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap, ok := s.snapshots[id]
+	if !ok || !s.owns(tenant, id) {
+		return nil, domain.ErrNotFound
+	}
+	return &snap, nil
+}
+
+func (s *BookStreamStore) SaveBookSnapshot(ctx context.Context, snap domain.BookSnapshot) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.owns(tenant, snap.BookID) {
+		return domain.ErrNotFound
+	}
+	s.snapshots[snap.BookID] = snap
+	return nil
+}
+
+func (s *BookStreamStore) ReadBookEvents(ctx context.Context, after int64, limit int) ([]domain.BookStreamEvent, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []domain.BookStreamEvent
+	for i := int(max(after, 0)); i < len(s.events) && len(out) < limit; i++ {
+		if s.events[i].TenantID == tenant {
+			out = append(out, s.events[i])
+		}
+	}
+	return out, nil
+}
+
+func (s *BookStreamStore) PutBookState(ctx context.Context, st domain.BookState) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, ok := s.states[st.Book.ID]; ok && cur.Version >= st.Version {
+		return nil
+	}
+	if !st.Deleted && !st.Forced {
+		if existing := s.findByKey(tenant, domain.BookKey(st.Book.Title, st.Book.Author), st.Book.ID); existing != 0 {
+			return &domain.DuplicateError{ExistingID: existing}
+		}
+	}
+	st.Book.TenantID = tenant
+	s.states[st.Book.ID] = st
+	return nil
+}
+
+func (s *BookStreamStore) GetBookState(ctx context.Context, id int64) (*domain.BookState, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.states[id]
+	if !ok || st.Book.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return &st, nil
+}
+
+func (s *BookStreamStore) GetBookStates(ctx context.Context, ids []int64) (map[int64]*domain.BookState, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make(map[int64]*domain.BookState, len(ids))
+	for _, id := range ids {
+		if st, ok := s.states[id]; ok && st.Book.TenantID == tenant {
+			states[id] = &st
+		}
+	}
+	return states, nil
+}
+
+// ListBookStates filters on the author only: nothing in a book's stream
+// files it under a category or tags it, so those filters match nothing.
+func (s *BookStreamStore) ListBookStates(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	books := []*domain.Book{}
+	if q.Category != "" || len(q.Tags) > 0 {
+		return books, nil
+	}
+	for _, st := range s.states {
+		if st.Book.TenantID == tenant && !st.Deleted && (q.Author == "" || st.Book.Author == q.Author) {
+			b := st.Book
+			books = append(books, &b)
+		}
+	}
+	slices.SortFunc(books, func(a, b *domain.Book) int { return cmp.Compare(a.ID, b.ID) })
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	books = books[min(q.Offset, len(books)):]
+	return books[:min(limit, len(books))], nil
+}
+
+func (s *BookStreamStore) FindBookByKey(ctx context.Context, key string, except int64) (int64, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.findByKey(tenant, key, except), nil
+}
+
+func (s *BookStreamStore) ResetBookStates(ctx context.Context) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, st := range s.states {
+		if st.Book.TenantID == tenant {
+			delete(s.states, id)
+		}
+	}
+	return nil
+}
+
+// owns reports whether book id has a stream of tenant. Callers hold s.mu.
+func (s *BookStreamStore) owns(tenant string, id int64) bool {
+	stream := s.streams[id]
+	return len(stream) > 0 && s.events[stream[0]].TenantID == tenant
+}
+
+// findByKey is FindBookByKey for callers holding s.mu.
+func (s *BookStreamStore) findByKey(tenant, key string, except int64) int64 {
+	var existing int64
+	for id, st := range s.states {
+		if id == except || st.Book.TenantID != tenant || st.Deleted || st.Forced {
+			continue
+		}
+		if domain.BookKey(st.Book.Title, st.Book.Author) == key && (existing == 0 || id < existing) {
+			existing = id
+		}
+	}
+	return existing
+}
+
+// LockBookStates has nothing to do: the in-memory Transactor already runs
+// one transaction at a time.
+func (s *BookStreamStore) LockBookStates(ctx context.Context, exclusive bool) error {
+	_, err := tenancy.Require(ctx)
+	return err
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// BookChangesSince reads the sequence numbers kept by touch. Writes take
+// the lock, so numbers are visible in the order they were handed out.
+func (r *BookRepository) BookChangesSince(ctx context.Context, after domain.BookSyncPosition, limit int) ([]*domain.BookSyncChange, int64, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := []*domain.BookSyncChange{}
+	collect := func(m map[int64]domain.Book, deleted bool) {
+		for id, b := range m {
+			pos := domain.BookSyncPosition{Seq: r.changeSeq[id], BookID: id}
+			if b.TenantID != tenant || comparePositions(pos, after) <= 0 {
+				continue
+			}
+			changes = append(changes, &domain.BookSyncChange{Position: pos, Book: b, Deleted: deleted})
+		}
+	}
+	collect(r.books, false)
+	collect(r.deleted, true)
+	slices.SortFunc(changes, func(a, b *domain.BookSyncChange) int {
+		return comparePositions(a.Position, b.Position)
+	})
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, r.tenantSeq[tenant], nil
+}
+
+// touch gives b the next sequence number of its tenant. Callers hold r.mu.
+func (r *BookRepository) touch(b domain.Book) {
+	r.tenantSeq[b.TenantID]++
+	r.changeSeq[b.ID] = r.tenantSeq[b.TenantID]
+}
+
+func comparePositions(a, b domain.BookSyncPosition) int {
+	if c := cmp.Compare(a.Seq, b.Seq); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.BookID, b.BookID)
+}
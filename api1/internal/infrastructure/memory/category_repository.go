@@ -0,0 +1,345 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// The domain.CategoryRepository half of BookRepository. Categories keep a
+// materialized path just like the Postgres table, so subtree checks are
+// prefix checks here too.
+
+func (r *BookRepository) CreateCategory(ctx context.Context, in domain.CategoryInput) (*domain.Category, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parentPath, err := r.parentPath(tenant, in.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if r.slugTaken(tenant, in.Slug, 0) {
+		return nil, domain.ErrConflict
+	}
+	r.nextCategoryID++
+	c := domain.Category{
+		ID:        r.nextCategoryID,
+		TenantID:  tenant,
+		ParentID:  in.ParentID,
+		Slug:      in.Slug,
+		Name:      in.Name,
+		Path:      domain.CategoryPath(parentPath, r.nextCategoryID),
+		CreatedAt: r.now().UTC().Truncate(time.Second),
+	}
+	r.categories[c.ID] = c
+	return &c, nil
+}
+
+func (r *BookRepository) GetCategory(ctx context.Context, id int64) (*domain.Category, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.findCategory(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *BookRepository) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.tree(tenant), nil
+}
+
+func (r *BookRepository) UpdateCategory(ctx context.Context, id int64, in domain.CategoryInput) (*domain.Category, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.findCategory(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	parentPath, err := r.parentPath(tenant, in.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(parentPath, c.Path) {
+		return nil, domain.ErrCategoryCycle
+	}
+	if r.slugTaken(tenant, in.Slug, id) {
+		return nil, domain.ErrConflict
+	}
+
+	// Re-root the subtree, this category included, under its new path.
+	oldPath, newPath := c.Path, domain.CategoryPath(parentPath, id)
+	for cid, d := range r.categories {
+		if d.TenantID == tenant && strings.HasPrefix(d.Path, oldPath) {
+			d.Path = newPath + strings.TrimPrefix(d.Path, oldPath)
+			r.categories[cid] = d
+		}
+	}
+	c = r.categories[id]
+	c.ParentID = in.ParentID
+	c.Slug = in.Slug
+	c.Name = in.Name
+	r.categories[id] = c
+	return &c, nil
+}
+
+func (r *BookRepository) DeleteCategory(ctx context.Context, id int64) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.findCategory(tenant, id); !ok {
+		return domain.ErrNotFound
+	}
+	for _, c := range r.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return domain.ErrConflict
+		}
+	}
+	delete(r.categories, id)
+	for bookID, ids := range r.filed {
+		r.filed[bookID] = slices.DeleteFunc(ids, func(cid int64) bool { return cid == id })
+	}
+	return nil
+}
+
+func (r *BookRepository) GetBookTaxonomy(ctx context.Context, bookID int64) (*domain.BookTaxonomy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := find(r.books, tenant, bookID); !ok {
+		return nil, domain.ErrNotFound
+	}
+	return r.taxonomy(bookID), nil
+}
+
+func (r *BookRepository) SetBookTaxonomy(ctx context.Context, bookID int64, in domain.BookTaxonomyInput) (*domain.BookTaxonomy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := find(r.books, tenant, bookID); !ok {
+		return nil, domain.ErrNotFound
+	}
+	for _, id := range in.CategoryIDs {
+		if _, ok := r.findCategory(tenant, id); !ok {
+			return nil, domain.ErrNotFound
+		}
+	}
+	r.filed[bookID] = slices.Clone(in.CategoryIDs)
+	r.tags[bookID] = slices.Clone(in.Tags)
+	return r.taxonomy(bookID), nil
+}
+
+func (r *BookRepository) Facets(ctx context.Context, q domain.ListBooksQuery) (*domain.Facets, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := r.matching(tenant, q)
+	categoryCounts := make(map[int64]int)
+	tagCounts := make(map[string]int)
+	for _, id := range ids {
+		// Each book counts once per category, however many of its
+		// categories sit below it.
+		under := make(map[int64]bool)
+		for _, cid := range r.filed[id] {
+			for _, a := range r.ancestors(tenant, cid) {
+				under[a] = true
+			}
+		}
+		for a := range under {
+			categoryCounts[a]++
+		}
+		for _, t := range r.tags[id] {
+			tagCounts[t]++
+		}
+	}
+
+	f := &domain.Facets{Total: len(ids), Categories: []*domain.CategoryFacet{}, Tags: []*domain.TagFacet{}}
+	for _, c := range r.tree(tenant) {
+		if n := categoryCounts[c.ID]; n > 0 {
+			f.Categories = append(f.Categories, &domain.CategoryFacet{
+				ID: c.ID, ParentID: c.ParentID, Slug: c.Slug, Name: c.Name, Count: n,
+			})
+		}
+	}
+	for t, n := range tagCounts {
+		f.Tags = append(f.Tags, &domain.TagFacet{Tag: t, Count: n})
+	}
+	sort.Slice(f.Tags, func(i, j int) bool {
+		if f.Tags[i].Count != f.Tags[j].Count {
+			return f.Tags[i].Count > f.Tags[j].Count
+		}
+		return f.Tags[i].Tag < f.Tags[j].Tag
+	})
+	return f, nil
+}
+
+// matching returns the IDs of the live books of tenant that pass the
+// filters of q, in ID order. Callers hold r.mu.
+func (r *BookRepository) matching(tenant string, q domain.ListBooksQuery) []int64 {
+	var within string
+	if q.Category != "" {
+		for _, c := range r.categories {
+			if c.TenantID == tenant && c.Slug == q.Category {
+				within = c.Path
+			}
+		}
+		if within == "" {
+			return nil
+		}
+	}
+
+	var ids []int64
+	for id, b := range r.books {
+		switch {
+		case b.TenantID != tenant,
+			q.Author != "" && b.Author != q.Author,
+			within != "" && !slices.ContainsFunc(r.filed[id], func(cid int64) bool {
+				return strings.HasPrefix(r.categories[cid].Path, within)
+			}),
+			len(q.Tags) > 0 && !hasTags(r.tags[id], q.Tags, q.AllTags):
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func hasTags(have, want []string, all bool) bool {
+	for _, t := range want {
+		found := slices.Contains(have, t)
+		if found && !all {
+			return true
+		}
+		if !found && all {
+			return false
+		}
+	}
+	return all
+}
+
+// ancestors returns the IDs on the path of category id, itself included.
+// Callers hold r.mu.
+func (r *BookRepository) ancestors(tenant string, id int64) []int64 {
+	c, ok := r.findCategory(tenant, id)
+	if !ok {
+		return nil
+	}
+	var ids []int64
+	for _, a := range r.categories {
+		if a.TenantID == tenant && strings.HasPrefix(c.Path, a.Path) {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
+
+// tree returns the categories of tenant in depth-first order. Callers hold
+// r.mu.
+func (r *BookRepository) tree(tenant string) []*domain.Category {
+	cats := []*domain.Category{}
+	for _, c := range r.categories {
+		if c.TenantID == tenant {
+			cats = append(cats, &c)
+		}
+	}
+	sort.Slice(cats, func(i, j int) bool { return cats[i].Path < cats[j].Path })
+	return cats
+}
+
+// taxonomy returns how book bookID is classified. Callers hold r.mu.
+func (r *BookRepository) taxonomy(bookID int64) *domain.BookTaxonomy {
+	t := &domain.BookTaxonomy{BookID: bookID, Categories: []*domain.Category{}, Tags: []string{}}
+	for _, id := range r.filed[bookID] {
+		c := r.categories[id]
+		t.Categories = append(t.Categories, &c)
+	}
+	sort.Slice(t.Categories, func(i, j int) bool { return t.Categories[i].Path < t.Categories[j].Path })
+	t.Tags = append(t.Tags, r.tags[bookID]...)
+	sort.Strings(t.Tags)
+	return t
+}
+
+// parentPath returns the Path of category parent, or "" for no parent.
+// Callers hold r.mu.
+func (r *BookRepository) parentPath(tenant string, parent *int64) (string, error) {
+	if parent == nil {
+		return "", nil
+	}
+	p, ok := r.findCategory(tenant, *parent)
+	if !ok {
+		return "", domain.ErrNotFound
+	}
+	return p.Path, nil
+}
+
+// slugTaken reports whether another category than except uses slug.
+// Callers hold r.mu.
+func (r *BookRepository) slugTaken(tenant, slug string, except int64) bool {
+	for _, c := range r.categories {
+		if c.TenantID == tenant && c.Slug == slug && c.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// findCategory returns category id if it belongs to tenant. Callers hold
+// r.mu.
+func (r *BookRepository) findCategory(tenant string, id int64) (domain.Category, bool) {
+	c, ok := r.categories[id]
+	if !ok || c.TenantID != tenant {
+		return domain.Category{}, false
+	}
+	return c, true
+}
@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// The domain.HoldRepository half of LoanRepository. Holds live next to the
+// copies they claim, under the same mutex.
+
+func (r *LoanRepository) PlaceHold(ctx context.Context, bookID int64, memberID string, at time.Time) (*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, h := range r.holds {
+		if h.TenantID == tenant && h.BookID == bookID && h.MemberID == memberID && h.Active() {
+			return nil, domain.ErrConflict
+		}
+	}
+	r.nextHoldID++
+	h := domain.Hold{
+		ID:       r.nextHoldID,
+		TenantID: tenant,
+		BookID:   bookID,
+		MemberID: memberID,
+		Status:   domain.HoldQueued,
+		PlacedAt: at,
+	}
+	r.holds[h.ID] = h
+	return r.withPosition(h), nil
+}
+
+func (r *LoanRepository) GetHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.findHold(tenant, bookID, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return r.withPosition(h), nil
+}
+
+func (r *LoanRepository) ListHolds(ctx context.Context, q domain.ListHoldsQuery) ([]*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	queue := r.queue(tenant, q.BookID, func(h domain.Hold) bool {
+		return (q.MemberID == "" || h.MemberID == q.MemberID) && (!q.ActiveOnly || h.Active())
+	})
+	holds := []*domain.Hold{}
+	for i := q.Offset; i < len(queue) && len(holds) < limit; i++ {
+		holds = append(holds, r.withPosition(queue[i]))
+	}
+	return holds, nil
+}
+
+func (r *LoanRepository) CancelHold(ctx context.Context, bookID, id int64, at time.Time) (*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.findHold(tenant, bookID, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !h.Active() {
+		return nil, domain.ErrHoldClosed
+	}
+	r.release(&h)
+	h.Status = domain.HoldCancelled
+	h.ClosedAt = &at
+	r.holds[id] = h
+	return &h, nil
+}
+
+func (r *LoanRepository) AllocateCopies(ctx context.Context, bookID int64, at, pickupBy time.Time) ([]*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var free []domain.Copy
+	for _, c := range r.copies {
+		if c.TenantID == tenant && c.BookID == bookID && c.Status == domain.CopyAvailable {
+			free = append(free, c)
+		}
+	}
+	sort.Slice(free, func(i, j int) bool { return free[i].ID < free[j].ID })
+	queued := r.queue(tenant, bookID, func(h domain.Hold) bool { return h.Status == domain.HoldQueued })
+
+	ready := []*domain.Hold{}
+	for i := 0; i < len(free) && i < len(queued); i++ {
+		c, h := free[i], queued[i]
+		c.Status = domain.CopyOnHold
+		r.copies[c.ID] = c
+
+		copyID := c.ID
+		h.Status = domain.HoldReady
+		h.CopyID = &copyID
+		h.ReadyAt = &at
+		h.PickupBy = &pickupBy
+		r.holds[h.ID] = h
+		ready = append(ready, &h)
+	}
+	return ready, nil
+}
+
+func (r *LoanRepository) ExpireHolds(ctx context.Context, now time.Time) ([]*domain.Hold, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := []*domain.Hold{}
+	for id, h := range r.holds {
+		if h.TenantID != tenant || h.Status != domain.HoldReady || !h.PickupBy.Before(now) {
+			continue
+		}
+		r.release(&h)
+		h.Status = domain.HoldExpired
+		h.ClosedAt = &now
+		r.holds[id] = h
+		expired = append(expired, &h)
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+	return expired, nil
+}
+
+// fulfil closes the ready hold of member on copy copyID, reporting whether
+// there was one. Callers hold r.mu.
+func (r *LoanRepository) fulfil(copyID int64, member string, at time.Time) bool {
+	for id, h := range r.holds {
+		if h.Status == domain.HoldReady && h.CopyID != nil && *h.CopyID == copyID && h.MemberID == member {
+			h.Status = domain.HoldFulfilled
+			h.ClosedAt = &at
+			r.holds[id] = h
+			return true
+		}
+	}
+	return false
+}
+
+// release puts the copy set aside for h back to available. Callers hold
+// r.mu.
+func (r *LoanRepository) release(h *domain.Hold) {
+	if h.Status != domain.HoldReady || h.CopyID == nil {
+		return
+	}
+	if c, ok := r.copies[*h.CopyID]; ok && c.Status == domain.CopyOnHold {
+		c.Status = domain.CopyAvailable
+		r.copies[c.ID] = c
+	}
+}
+
+// queue returns the holds of book bookID that match keep, oldest first.
+// Callers hold r.mu.
+func (r *LoanRepository) queue(tenant string, bookID int64, keep func(domain.Hold) bool) []domain.Hold {
+	var out []domain.Hold
+	for _, h := range r.holds {
+		if h.TenantID == tenant && h.BookID == bookID && keep(h) {
+			out = append(out, h)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// withPosition returns h with its place in the queue filled in. Callers
+// hold r.mu.
+func (r *LoanRepository) withPosition(h domain.Hold) *domain.Hold {
+	if h.Status == domain.HoldQueued {
+		h.Position = len(r.queue(h.TenantID, h.BookID, func(o domain.Hold) bool {
+			return o.Status == domain.HoldQueued && o.ID <= h.ID
+		}))
+	}
+	return &h
+}
+
+// findHold returns hold id of book bookID if it belongs to tenant. Callers
+// hold r.mu.
+func (r *LoanRepository) findHold(tenant string, bookID, id int64) (domain.Hold, bool) {
+	h, ok := r.holds[id]
+	if !ok || h.TenantID != tenant || h.BookID != bookID {
+		return domain.Hold{}, false
+	}
+	return h, true
+}
@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// ImportRepository is an in-memory domain.ImportRepository.
+type ImportRepository struct {
+	mu        sync.Mutex
+	jobs      map[int64]domain.ImportJob
+	heartbeat map[int64]time.Time
+	errors    map[int64][]domain.ImportRowError
+	nextID    int64
+	now       func() time.Time
+}
+
+func NewImportRepository() *ImportRepository {
+	return &ImportRepository{
+		jobs:      make(map[int64]domain.ImportJob),
+		heartbeat: make(map[int64]time.Time),
+		errors:    make(map[int64][]domain.ImportRowError),
+		now:       time.Now,
+	}
+}
+
+func (r *ImportRepository) Create(ctx context.Context, job domain.ImportJob) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	j := domain.ImportJob{
+		ID:        r.nextID,
+		TenantID:  tenant,
+		Format:    job.Format,
+		Status:    domain.ImportQueued,
+		CreatedBy: job.CreatedBy,
+		CreatedAt: r.now().UTC(),
+		BlobKey:   job.BlobKey,
+	}
+	r.jobs[j.ID] = j
+	return &j, nil
+}
+
+func (r *ImportRepository) Get(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.find(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &j, nil
+}
+
+func (r *ImportRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int64, 0, len(r.jobs))
+	for id, j := range r.jobs {
+		if j.TenantID != tenant {
+			continue
+		}
+		if j.Status == domain.ImportQueued ||
+			(j.Status == domain.ImportRunning && r.heartbeat[id].Before(staleBefore)) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	j := r.jobs[ids[0]]
+	j.Status = domain.ImportRunning
+	if j.StartedAt == nil {
+		j.StartedAt = &now
+	}
+	r.jobs[j.ID] = j
+	r.heartbeat[j.ID] = now
+	return &j, nil
+}
+
+func (r *ImportRepository) Progress(ctx context.Context, id int64, p domain.ImportProgress, errs []domain.ImportRowError, now time.Time) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.find(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if j.Status != domain.ImportRunning {
+		return nil, domain.ErrImportFinished
+	}
+	j.Processed += p.Processed
+	j.Created += p.Created
+	j.Failed += p.Failed
+	r.jobs[id] = j
+	r.heartbeat[id] = now
+	r.errors[id] = append(r.errors[id], errs...)
+	return &j, nil
+}
+
+func (r *ImportRepository) Finish(ctx context.Context, id int64, status domain.ImportStatus, reason string, now time.Time) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.find(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if j.Done() {
+		return nil, domain.ErrImportFinished
+	}
+	j.Status = status
+	j.Error = reason
+	j.FinishedAt = &now
+	r.jobs[id] = j
+	return &j, nil
+}
+
+func (r *ImportRepository) RequestCancel(ctx context.Context, id int64, now time.Time) (*domain.ImportJob, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.find(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	switch j.Status {
+	case domain.ImportQueued:
+		j.Status = domain.ImportCancelled
+		j.FinishedAt = &now
+	case domain.ImportRunning:
+	default:
+		return nil, domain.ErrImportFinished
+	}
+	j.CancelRequested = true
+	r.jobs[id] = j
+	return &j, nil
+}
+
+func (r *ImportRepository) ListErrors(ctx context.Context, id int64, limit, offset int) ([]*domain.ImportRowError, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	errs := []*domain.ImportRowError{}
+	if _, ok := r.find(tenant, id); !ok {
+		return errs, nil
+	}
+	all := r.errors[id]
+	for i := offset; i < len(all) && len(errs) < limit; i++ {
+		e := all[i]
+		errs = append(errs, &e)
+	}
+	return errs, nil
+}
+
+// find returns job id if it belongs to tenant. Callers hold r.mu.
+func (r *ImportRepository) find(tenant string, id int64) (domain.ImportJob, bool) {
+	j, ok := r.jobs[id]
+	if !ok || j.TenantID != tenant {
+		return domain.ImportJob{}, false
+	}
+	return j, true
+}
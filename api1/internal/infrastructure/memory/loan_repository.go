@@ -0,0 +1,268 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// LoanRepository is an in-memory domain.LoanRepository and
+// domain.HoldRepository. One mutex guards copies, loans and holds together,
+// which is what makes a checkout and the copy status change it implies a
+// single step.
+type LoanRepository struct {
+	mu         sync.Mutex
+	copies     map[int64]domain.Copy
+	loans      map[int64]domain.Loan
+	holds      map[int64]domain.Hold
+	nextCopyID int64
+	nextLoanID int64
+	nextHoldID int64
+	now        func() time.Time
+}
+
+func NewLoanRepository() *LoanRepository {
+	return &LoanRepository{
+		copies: make(map[int64]domain.Copy),
+		loans:  make(map[int64]domain.Loan),
+		holds:  make(map[int64]domain.Hold),
+		now:    time.Now,
+	}
+}
+
+func (r *LoanRepository) CreateCopy(ctx context.Context, bookID int64, in domain.CreateCopyInput) (*domain.Copy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.copies {
+		if c.TenantID == tenant && c.Barcode == in.Barcode {
+			return nil, domain.ErrConflict
+		}
+	}
+	r.nextCopyID++
+	c := domain.Copy{
+		ID:        r.nextCopyID,
+		BookID:    bookID,
+		TenantID:  tenant,
+		Barcode:   in.Barcode,
+		Status:    domain.CopyAvailable,
+		CreatedAt: r.now().UTC().Truncate(time.Second),
+	}
+	r.copies[c.ID] = c
+	return &c, nil
+}
+
+func (r *LoanRepository) GetCopy(ctx context.Context, id int64) (*domain.Copy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.copies[id]
+	if !ok || c.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *LoanRepository) ListCopies(ctx context.Context, bookID int64) ([]*domain.Copy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copies := []*domain.Copy{}
+	for _, c := range r.copies {
+		if c.TenantID == tenant && c.BookID == bookID {
+			copies = append(copies, &c)
+		}
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].ID < copies[j].ID })
+	return copies, nil
+}
+
+func (r *LoanRepository) ListCopiesForBooks(ctx context.Context, bookIDs []int64) (map[int64][]*domain.Copy, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[int64]bool, len(bookIDs))
+	for _, id := range bookIDs {
+		wanted[id] = true
+	}
+	out := make(map[int64][]*domain.Copy)
+	for _, c := range r.copies {
+		if c.TenantID == tenant && wanted[c.BookID] {
+			out[c.BookID] = append(out[c.BookID], &c)
+		}
+	}
+	for _, copies := range out {
+		sort.Slice(copies, func(i, j int) bool { return copies[i].ID < copies[j].ID })
+	}
+	return out, nil
+}
+
+func (r *LoanRepository) Checkout(ctx context.Context, l domain.Loan) (*domain.Loan, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.copies[l.CopyID]
+	if !ok || c.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	switch c.Status {
+	case domain.CopyAvailable:
+	case domain.CopyOnHold:
+		if !r.fulfil(c.ID, l.MemberID, l.CheckedOutAt) {
+			return nil, domain.ErrCopyUnavailable
+		}
+	default:
+		return nil, domain.ErrCopyUnavailable
+	}
+	c.Status = domain.CopyOnLoan
+	r.copies[c.ID] = c
+
+	r.nextLoanID++
+	l.ID = r.nextLoanID
+	l.TenantID = tenant
+	l.BookID = c.BookID
+	l.Status = domain.LoanOnLoan
+	r.loans[l.ID] = l
+	return &l, nil
+}
+
+func (r *LoanRepository) GetLoan(ctx context.Context, id int64) (*domain.Loan, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.findLoan(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &l, nil
+}
+
+func (r *LoanRepository) ListLoans(ctx context.Context, q domain.ListLoansQuery) ([]*domain.Loan, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	ids := make([]int64, 0, len(r.loans))
+	for id, l := range r.loans {
+		switch {
+		case l.TenantID != tenant,
+			q.MemberID != "" && l.MemberID != q.MemberID,
+			q.CopyID != 0 && l.CopyID != q.CopyID,
+			q.OpenOnly && !l.Open(),
+			!q.DueBefore.IsZero() && !l.DueAt.Before(q.DueBefore):
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	loans := []*domain.Loan{}
+	for i := q.Offset; i < len(ids) && len(loans) < limit; i++ {
+		l := r.loans[ids[i]]
+		loans = append(loans, &l)
+	}
+	return loans, nil
+}
+
+func (r *LoanRepository) Renew(ctx context.Context, id int64, renewals int, due time.Time) (*domain.Loan, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.findLoan(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !l.Open() || l.Renewals != renewals {
+		return nil, domain.ErrConflict
+	}
+	l.Renewals++
+	l.DueAt = due
+	r.loans[id] = l
+	return &l, nil
+}
+
+func (r *LoanRepository) Close(ctx context.Context, id int64, status domain.LoanStatus, at time.Time) (*domain.Loan, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.findLoan(tenant, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !l.Open() {
+		return nil, domain.ErrLoanClosed
+	}
+	l.Status = status
+	l.ClosedAt = &at
+	r.loans[id] = l
+
+	c := r.copies[l.CopyID]
+	c.Status = domain.CopyAvailable
+	if status == domain.LoanLost {
+		c.Status = domain.CopyLost
+	}
+	r.copies[c.ID] = c
+	return &l, nil
+}
+
+// findLoan returns loan id if it belongs to tenant. Callers hold r.mu.
+func (r *LoanRepository) findLoan(tenant string, id int64) (domain.Loan, bool) {
+	l, ok := r.loans[id]
+	if !ok || l.TenantID != tenant {
+		return domain.Loan{}, false
+	}
+	return l, true
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// OrderRepository is an in-memory domain.InventoryRepository,
+// domain.CartRepository and domain.OrderRepository. It does not know about
+// books, so it takes the caller's word that a book exists.
+type OrderRepository struct {
+	mu          sync.Mutex
+	inventory   map[int64]domain.Inventory
+	carts       map[cartKey]map[int64]int64 // book ID to qty
+	orders      map[int64]domain.Order
+	nextOrderID int64
+}
+
+type cartKey struct {
+	tenant   string
+	customer string
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{
+		inventory: make(map[int64]domain.Inventory),
+		carts:     make(map[cartKey]map[int64]int64),
+		orders:    make(map[int64]domain.Order),
+	}
+}
+
+func (r *OrderRepository) GetInventory(ctx context.Context, bookID int64) (*domain.Inventory, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.inventory[bookID]
+	if !ok || inv.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return &inv, nil
+}
+
+func (r *OrderRepository) GetInventories(ctx context.Context, bookIDs []int64) (map[int64]*domain.Inventory, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[int64]*domain.Inventory)
+	for _, id := range bookIDs {
+		if inv, ok := r.inventory[id]; ok && inv.TenantID == tenant {
+			out[id] = &inv
+		}
+	}
+	return out, nil
+}
+
+func (r *OrderRepository) SetInventory(ctx context.Context, bookID int64, in domain.InventoryInput, at time.Time) (*domain.Inventory, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inv, ok := r.inventory[bookID]; ok && inv.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	inv := domain.Inventory{
+		BookID:     bookID,
+		TenantID:   tenant,
+		PriceCents: in.PriceCents,
+		UpdatedAt:  at.UTC().Truncate(time.Second),
+	}
+	r.inventory[bookID] = inv
+	return &inv, nil
+}
+
+func (r *OrderRepository) GetCartLines(ctx context.Context, customer string) ([]domain.CartLine, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := []domain.CartLine{}
+	for id, qty := range r.carts[cartKey{tenant, customer}] {
+		lines = append(lines, domain.CartLine{BookID: id, Qty: qty})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].BookID < lines[j].BookID })
+	return lines, nil
+}
+
+func (r *OrderRepository) SetCartLine(ctx context.Context, customer string, bookID, qty int64) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := cartKey{tenant, customer}
+	cart := r.carts[key]
+	if qty <= 0 {
+		delete(cart, bookID)
+		return nil
+	}
+	if cart == nil {
+		cart = make(map[int64]int64)
+		r.carts[key] = cart
+	}
+	cart[bookID] = qty
+	return nil
+}
+
+func (r *OrderRepository) ClearCart(ctx context.Context, customer string) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.carts, cartKey{tenant, customer})
+	return nil
+}
+
+func (r *OrderRepository) CreateOrder(ctx context.Context, o domain.Order) (*domain.Order, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextOrderID++
+	o.ID = r.nextOrderID
+	o.TenantID = tenant
+	o.PlacedAt = o.PlacedAt.UTC().Truncate(time.Second)
+	o.Lines = slices.Clone(o.Lines)
+	r.orders[o.ID] = o
+	return cloneOrder(o), nil
+}
+
+func (r *OrderRepository) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok || o.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return cloneOrder(o), nil
+}
+
+func (r *OrderRepository) ListOrders(ctx context.Context, q domain.ListOrdersQuery) ([]*domain.Order, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := []*domain.Order{}
+	for _, o := range r.orders {
+		if o.TenantID == tenant && (q.Customer == "" || o.Customer == q.Customer) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	orders = orders[min(q.Offset, len(orders)):]
+	return orders[:min(limit, len(orders))], nil
+}
+
+func (r *OrderRepository) SetOrderStatus(ctx context.Context, id int64, status domain.OrderStatus) (*domain.Order, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok || o.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	o.Status = status
+	r.orders[id] = o
+	return cloneOrder(o), nil
+}
+
+// cloneOrder copies o so that callers cannot change a stored order
+// through its lines.
+func cloneOrder(o domain.Order) *domain.Order {
+	o.Lines = slices.Clone(o.Lines)
+	return &o
+}
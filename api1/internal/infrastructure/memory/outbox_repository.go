@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+type outboxRow struct {
+	msg          domain.OutboxMessage
+	nextAttempt  time.Time
+	lastError    string
+	dispatchedAt time.Time
+}
+
+// OutboxRepository is an in-memory domain.OutboxRepository.
+type OutboxRepository struct {
+	mu     sync.Mutex
+	rows   []*outboxRow
+	nextID int64
+	now    func() time.Time
+}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{now: time.Now}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, events ...domain.Event) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		r.nextID++
+		now := r.now().UTC()
+		r.rows = append(r.rows, &outboxRow{
+			msg: domain.OutboxMessage{
+				ID:            r.nextID,
+				TenantID:      tenant,
+				AggregateType: e.AggregateType(),
+				AggregateID:   e.AggregateID(),
+				EventType:     e.EventType(),
+				Payload:       payload,
+				OccurredAt:    now,
+			},
+			nextAttempt: now,
+		})
+	}
+	return nil
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type aggregate struct {
+		typ string
+		id  int64
+	}
+	seen := make(map[aggregate]bool)
+
+	var msgs []domain.OutboxMessage
+	for _, row := range r.rows {
+		if len(msgs) >= limit {
+			break
+		}
+		if !row.dispatchedAt.IsZero() {
+			continue
+		}
+		key := aggregate{row.msg.AggregateType, row.msg.AggregateID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if row.nextAttempt.After(now) {
+			continue
+		}
+		row.nextAttempt = leaseUntil
+		msgs = append(msgs, row.msg)
+	}
+	return msgs, nil
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if row := r.find(id); row != nil {
+		row.dispatchedAt = at
+		row.lastError = ""
+	}
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if row := r.find(id); row != nil {
+		row.msg.Attempts++
+		row.nextAttempt = nextAttempt
+		row.lastError = reason
+	}
+	return nil
+}
+
+// Pending returns the number of messages not yet dispatched.
+func (r *OutboxRepository) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, row := range r.rows {
+		if row.dispatchedAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *OutboxRepository) find(id int64) *outboxRow {
+	for _, row := range r.rows {
+		if row.msg.ID == id {
+			return row
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// PaymentRepository is an in-memory domain.PaymentRepository. LockPayment
+// locks nothing: the in-memory Transactor already runs one transaction at
+// a time.
+type PaymentRepository struct {
+	mu       sync.Mutex
+	payments map[int64]domain.Payment
+	nextID   int64
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{payments: make(map[int64]domain.Payment)}
+}
+
+func (r *PaymentRepository) CreatePayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.payments {
+		if other.TenantID == tenant && other.OrderID == p.OrderID {
+			return nil, domain.ErrConflict
+		}
+	}
+	r.nextID++
+	p.ID = r.nextID
+	p.TenantID = tenant
+	p.CreatedAt = p.CreatedAt.UTC().Truncate(time.Second)
+	p.UpdatedAt = p.CreatedAt
+	r.payments[p.ID] = p
+	return &p, nil
+}
+
+func (r *PaymentRepository) GetPayment(ctx context.Context, id int64) (*domain.Payment, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.payments[id]
+	if !ok || p.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *PaymentRepository) GetPaymentByOrder(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.payments {
+		if p.TenantID == tenant && p.OrderID == orderID {
+			return &p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *PaymentRepository) LockPayment(ctx context.Context, id int64) (*domain.Payment, error) {
+	return r.GetPayment(ctx, id)
+}
+
+func (r *PaymentRepository) UpdatePayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.payments[p.ID]
+	if !ok || stored.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	stored.Status = p.Status
+	stored.GatewayRef = p.GatewayRef
+	stored.Reason = p.Reason
+	stored.UpdatedAt = p.UpdatedAt.UTC().Truncate(time.Second)
+	r.payments[p.ID] = stored
+	return &stored, nil
+}
+
+func (r *PaymentRepository) ListPayments(ctx context.Context, q domain.ListPaymentsQuery) ([]*domain.Payment, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := []*domain.Payment{}
+	for _, p := range r.payments {
+		if p.TenantID == tenant && slices.Contains(q.Statuses, p.Status) && p.UpdatedAt.Before(q.Before) && p.ID > q.AfterID {
+			out = append(out, &p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	return out[:min(limit, len(out))], nil
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// ReviewRepository is an in-memory domain.ReviewRepository. It does not
+// check that the book exists; the usecase does that.
+type ReviewRepository struct {
+	mu      sync.RWMutex
+	reviews map[int64]domain.Review
+	votes   map[int64]map[string]bool
+	nextID  int64
+	now     func() time.Time
+}
+
+func NewReviewRepository() *ReviewRepository {
+	return &ReviewRepository{
+		reviews: make(map[int64]domain.Review),
+		votes:   make(map[int64]map[string]bool),
+		now:     time.Now,
+	}
+}
+
+// SetClock replaces the time source, so tests can order reviews by time.
+func (r *ReviewRepository) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = now
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, bookID int64, reviewer string, in domain.ReviewInput) (*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rv := range r.reviews {
+		if rv.TenantID == tenant && rv.BookID == bookID && rv.Reviewer == reviewer {
+			return nil, domain.ErrConflict
+		}
+	}
+
+	now := r.now().UTC().Truncate(time.Second)
+	r.nextID++
+	rv := domain.Review{
+		ID:        r.nextID,
+		BookID:    bookID,
+		TenantID:  tenant,
+		Reviewer:  reviewer,
+		Rating:    in.Rating,
+		Text:      in.Text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.reviews[rv.ID] = rv
+	return &rv, nil
+}
+
+func (r *ReviewRepository) Get(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rv, ok := r.find(tenant, bookID, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &rv, nil
+}
+
+func (r *ReviewRepository) List(ctx context.Context, q domain.ListReviewsQuery) ([]*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var matched []domain.Review
+	for _, rv := range r.reviews {
+		if rv.TenantID == tenant && rv.BookID == q.BookID {
+			matched = append(matched, rv)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if q.Sort == domain.ReviewSortHelpful && a.HelpfulCount != b.HelpfulCount {
+			return a.HelpfulCount > b.HelpfulCount
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	reviews := []*domain.Review{}
+	for i := q.Offset; i < len(matched) && len(reviews) < limit; i++ {
+		rv := matched[i]
+		reviews = append(reviews, &rv)
+	}
+	return reviews, nil
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, bookID, id int64, in domain.ReviewInput) (*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rv, ok := r.find(tenant, bookID, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	rv.Rating = in.Rating
+	rv.Text = in.Text
+	rv.UpdatedAt = r.now().UTC().Truncate(time.Second)
+	r.reviews[id] = rv
+	return &rv, nil
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, bookID, id int64) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(tenant, bookID, id); !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.reviews, id)
+	delete(r.votes, id)
+	return nil
+}
+
+func (r *ReviewRepository) MarkHelpful(ctx context.Context, bookID, id int64, voter string) (*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rv, ok := r.find(tenant, bookID, id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if r.votes[id] == nil {
+		r.votes[id] = make(map[string]bool)
+	}
+	if !r.votes[id][voter] {
+		r.votes[id][voter] = true
+		rv.HelpfulCount++
+		r.reviews[id] = rv
+	}
+	return &rv, nil
+}
+
+// find returns review id of book bookID if it belongs to tenant. Callers
+// hold r.mu.
+func (r *ReviewRepository) find(tenant string, bookID, id int64) (domain.Review, bool) {
+	rv, ok := r.reviews[id]
+	if !ok || rv.TenantID != tenant || rv.BookID != bookID {
+		return domain.Review{}, false
+	}
+	return rv, true
+}
+
+func (r *ReviewRepository) ListForBooks(ctx context.Context, bookIDs []int64, perBook int) (map[int64][]*domain.Review, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[int64]bool, len(bookIDs))
+	for _, id := range bookIDs {
+		wanted[id] = true
+	}
+	var matched []domain.Review
+	for _, rv := range r.reviews {
+		if rv.TenantID == tenant && wanted[rv.BookID] {
+			matched = append(matched, rv)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	out := make(map[int64][]*domain.Review)
+	for _, rv := range matched {
+		if len(out[rv.BookID]) < perBook {
+			out[rv.BookID] = append(out[rv.BookID], &rv)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// StockRepository is an in-memory domain.StockRepository. One mutex guards
+// every ledger, so a write checks and changes the stock in one step. Like
+// OrderRepository it takes the caller's word that a book exists.
+type StockRepository struct {
+	mu           sync.Mutex
+	movements    []domain.StockMovement
+	reservations map[int64]domain.StockReservation
+	thresholds   map[stockKey]int64
+	nextResID    int64
+}
+
+type stockKey struct {
+	tenant string
+	bookID int64
+}
+
+func NewStockRepository() *StockRepository {
+	return &StockRepository{
+		reservations: make(map[int64]domain.StockReservation),
+		thresholds:   make(map[stockKey]int64),
+	}
+}
+
+func (r *StockRepository) GetStockLevel(ctx context.Context, bookID int64) (*domain.StockLevel, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.level(tenant, bookID)
+	return &l, nil
+}
+
+func (r *StockRepository) SetLowStockThreshold(ctx context.Context, bookID, threshold int64) (*domain.StockLevel, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.thresholds[stockKey{tenant, bookID}] = threshold
+	l := r.level(tenant, bookID)
+	return &l, nil
+}
+
+func (r *StockRepository) ListStockMovements(ctx context.Context, q domain.ListStockMovementsQuery) ([]*domain.StockMovement, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := []*domain.StockMovement{}
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if m := r.movements[i]; m.TenantID == tenant && m.BookID == q.BookID {
+			out = append(out, &m)
+		}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	out = out[min(q.Offset, len(out)):]
+	return out[:min(limit, len(out))], nil
+}
+
+func (r *StockRepository) RecordStock(ctx context.Context, m domain.StockMovement) (*domain.StockLevel, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.level(tenant, m.BookID)
+	if l.OnHand+m.Qty < l.Reserved {
+		return nil, domain.ErrOutOfStock
+	}
+	m.TenantID = tenant
+	r.append(m)
+	l.Add(m)
+	return &l, nil
+}
+
+func (r *StockRepository) Reserve(ctx context.Context, lines []domain.StockLine, ref string, at, expiresAt time.Time) ([]*domain.StockReservation, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The same book may appear on several lines; check what they add up to.
+	want := make(map[int64]int64)
+	for _, l := range lines {
+		want[l.BookID] += l.Qty
+	}
+	for id, qty := range want {
+		if r.level(tenant, id).Available < qty {
+			return nil, domain.ErrOutOfStock
+		}
+	}
+
+	at = at.UTC().Truncate(time.Second)
+	out := make([]*domain.StockReservation, len(lines))
+	for i, l := range lines {
+		r.nextResID++
+		res := domain.StockReservation{
+			ID:        r.nextResID,
+			TenantID:  tenant,
+			BookID:    l.BookID,
+			Qty:       l.Qty,
+			Status:    domain.ReservationActive,
+			Ref:       ref,
+			ExpiresAt: expiresAt.UTC().Truncate(time.Second),
+			CreatedAt: at,
+		}
+		r.reservations[res.ID] = res
+		r.append(domain.StockMovement{
+			TenantID:      tenant,
+			BookID:        l.BookID,
+			Kind:          domain.StockReserved,
+			Qty:           l.Qty,
+			ReservationID: res.ID,
+			At:            at,
+		})
+		out[i] = &res
+	}
+	return out, nil
+}
+
+func (r *StockRepository) GetReservation(ctx context.Context, id int64) (*domain.StockReservation, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.reservations[id]
+	if !ok || res.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return &res, nil
+}
+
+func (r *StockRepository) CloseReservation(ctx context.Context, id int64, status domain.ReservationStatus, at time.Time) (*domain.StockReservation, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.reservations[id]
+	if !ok || res.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	if res.Status != domain.ReservationActive {
+		return nil, domain.ErrReservationClosed
+	}
+	at = at.UTC().Truncate(time.Second)
+	res.Status = status
+	res.ClosedAt = &at
+	r.reservations[id] = res
+
+	kind := domain.StockReleased
+	if status == domain.ReservationSold {
+		kind = domain.StockSold
+	}
+	r.append(domain.StockMovement{
+		TenantID:      tenant,
+		BookID:        res.BookID,
+		Kind:          kind,
+		Qty:           res.Qty,
+		ReservationID: res.ID,
+		At:            at,
+	})
+	return &res, nil
+}
+
+func (r *StockRepository) ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*domain.StockReservation, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := []*domain.StockReservation{}
+	for _, res := range r.reservations {
+		if res.TenantID == tenant && res.Status == domain.ReservationActive && res.ExpiresAt.Before(now) {
+			out = append(out, &res)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out[:min(limit, len(out))], nil
+}
+
+// level adds up the ledger of a book. Callers hold r.mu.
+func (r *StockRepository) level(tenant string, bookID int64) domain.StockLevel {
+	l := domain.StockLevel{BookID: bookID}
+	for _, m := range r.movements {
+		if m.TenantID == tenant && m.BookID == bookID {
+			l.Add(m)
+		}
+	}
+	l.SetThreshold(r.thresholds[stockKey{tenant, bookID}])
+	return l
+}
+
+// append numbers m and adds it to the ledger. Callers hold r.mu.
+func (r *StockRepository) append(m domain.StockMovement) {
+	m.ID = int64(len(r.movements) + 1)
+	m.At = m.At.UTC().Truncate(time.Second)
+	r.movements = append(r.movements, m)
+}
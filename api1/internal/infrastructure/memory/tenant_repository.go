@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// TenantRepository is an in-memory domain.TenantRepository.
+type TenantRepository struct {
+	mu      sync.Mutex
+	tenants map[string]domain.Tenant
+	now     func() time.Time
+}
+
+func NewTenantRepository() *TenantRepository {
+	return &TenantRepository{tenants: make(map[string]domain.Tenant), now: time.Now}
+}
+
+func (r *TenantRepository) Create(ctx context.Context, in domain.CreateTenantInput) (*domain.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[in.ID]; ok {
+		return nil, domain.ErrConflict
+	}
+	t := domain.Tenant{
+		ID:        in.ID,
+		Name:      in.Name,
+		BookQuota: in.BookQuota,
+		CreatedAt: r.now().UTC().Truncate(time.Second),
+	}
+	r.tenants[t.ID] = t
+	return &t, nil
+}
+
+func (r *TenantRepository) Get(ctx context.Context, id string) (*domain.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *TenantRepository) List(ctx context.Context, limit, offset int) ([]*domain.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := []*domain.Tenant{}
+	for i := offset; i < len(ids) && len(out) < limit; i++ {
+		t := r.tenants[ids[i]]
+		out = append(out, &t)
+	}
+	return out, nil
+}
+
+func (r *TenantRepository) SetSuspended(ctx context.Context, id string, suspended bool) (*domain.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	switch {
+	case suspended && t.SuspendedAt == nil:
+		at := r.now().UTC().Truncate(time.Second)
+		t.SuspendedAt = &at
+	case !suspended:
+		t.SuspendedAt = nil
+	}
+	r.tenants[id] = t
+	return &t, nil
+}
+
+func (r *TenantRepository) ReserveBook(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tenants[id]
+	switch {
+	case !ok:
+		return domain.ErrNotFound
+	case t.Suspended():
+		return domain.ErrTenantSuspended
+	case t.BookQuota > 0 && t.BookCount >= t.BookQuota:
+		return domain.ErrQuotaExceeded
+	}
+	t.BookCount++
+	r.tenants[id] = t
+	return nil
+}
+
+func (r *TenantRepository) ReleaseBook(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if t.BookCount > 0 {
+		t.BookCount--
+	}
+	r.tenants[id] = t
+	return nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+type txKey struct{}
+
+// Transactor serializes transactions behind one mutex so that work done in
+// fn is not interleaved with another transaction. The in-memory stores
+// cannot undo writes, so a failing fn does not roll anything back, but
+// its AfterCommit callbacks are dropped as they would be on a rollback.
+type Transactor struct {
+	mu sync.Mutex
+}
+
+func NewTransactor() *Transactor {
+	return &Transactor{}
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		hctx, end := domain.BeginTxHooks(ctx)
+		err := fn(hctx)
+		end(err == nil)
+		return err
+	}
+
+	t.mu.Lock()
+	hctx, end := domain.BeginTxHooks(ctx)
+	err := fn(context.WithValue(hctx, txKey{}, true))
+	t.mu.Unlock()
+	// Callbacks run outside the lock; they may start transactions of
+	// their own.
+	end(err == nil)
+	return err
+}
@@ -0,0 +1,268 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// WebhookRepository is an in-memory domain.WebhookRepository. It keeps
+// the endpoints and deliveries of every tenant, each seeing only its own.
+type WebhookRepository struct {
+	mu             sync.Mutex
+	endpoints      map[int64]*domain.WebhookEndpoint
+	deliveries     []*domain.WebhookDelivery
+	nextEndpointID int64
+	nextDeliveryID int64
+	now            func() time.Time
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		endpoints: make(map[int64]*domain.WebhookEndpoint),
+		now:       time.Now,
+	}
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, in domain.CreateWebhookInput) (*domain.WebhookEndpoint, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextEndpointID++
+	e := &domain.WebhookEndpoint{
+		ID:         r.nextEndpointID,
+		TenantID:   tenant,
+		URL:        in.URL,
+		EventTypes: append([]domain.EventType(nil), in.EventTypes...),
+		Secret:     in.Secret,
+		Active:     true,
+		CreatedAt:  r.now().UTC().Truncate(time.Second),
+	}
+	r.endpoints[e.ID] = e
+	return copyEndpoint(e), nil
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.endpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return copyEndpoint(e), nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context) ([]*domain.WebhookEndpoint, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := []*domain.WebhookEndpoint{}
+	for _, e := range r.endpoints {
+		if e.TenantID == tenant {
+			out = append(out, copyEndpoint(e))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *WebhookRepository) SetEndpointActive(ctx context.Context, id int64, active bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.endpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+	e.Active = active
+	if active {
+		e.ConsecutiveFailures = 0
+	}
+	return nil
+}
+
+func (r *WebhookRepository) IncrementEndpointFailures(ctx context.Context, id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.endpoint(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	e.ConsecutiveFailures++
+	return e.ConsecutiveFailures, nil
+}
+
+func (r *WebhookRepository) ResetEndpointFailures(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.endpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+	e.ConsecutiveFailures = 0
+	return nil
+}
+
+func (r *WebhookRepository) EnqueueDelivery(ctx context.Context, d domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.endpoint(ctx, d.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.RedeliveryOf == nil {
+		for _, existing := range r.deliveries {
+			if existing.RedeliveryOf == nil && existing.EndpointID == d.EndpointID && existing.EventID == d.EventID {
+				return copyDelivery(existing), nil
+			}
+		}
+	}
+
+	r.nextDeliveryID++
+	d.ID = r.nextDeliveryID
+	d.TenantID = e.TenantID
+	d.Status = domain.DeliveryPending
+	d.CreatedAt = r.now().UTC()
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = d.CreatedAt
+	}
+	r.deliveries = append(r.deliveries, copyDelivery(&d))
+	return copyDelivery(&d), nil
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []*domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if len(out) >= limit {
+			break
+		}
+		if d.TenantID != tenant || d.Status != domain.DeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		if e, ok := r.endpoints[d.EndpointID]; !ok || !e.Active {
+			continue
+		}
+		d.NextAttemptAt = now.Add(lease)
+		out = append(out, copyDelivery(d))
+	}
+	return out, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.deliveries {
+		if existing.ID == d.ID && existing.TenantID == tenant {
+			cp := copyDelivery(d)
+			cp.TenantID = tenant
+			r.deliveries[i] = cp
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.ID == id && d.TenantID == tenant {
+			return copyDelivery(d), nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID int64, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	// Newest first, like the Postgres implementation.
+	out := []*domain.WebhookDelivery{}
+	skipped := 0
+	for i := len(r.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		d := r.deliveries[i]
+		if d.TenantID != tenant || d.EndpointID != endpointID {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		out = append(out, copyDelivery(d))
+	}
+	return out, nil
+}
+
+// endpoint returns the endpoint id of the tenant in ctx. Callers hold r.mu.
+func (r *WebhookRepository) endpoint(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := r.endpoints[id]
+	if !ok || e.TenantID != tenant {
+		return nil, domain.ErrNotFound
+	}
+	return e, nil
+}
+
+func copyEndpoint(e *domain.WebhookEndpoint) *domain.WebhookEndpoint {
+	cp := *e
+	cp.EventTypes = append([]domain.EventType(nil), e.EventTypes...)
+	return &cp
+}
+
+func copyDelivery(d *domain.WebhookDelivery) *domain.WebhookDelivery {
+	cp := *d
+	cp.Payload = append([]byte(nil), d.Payload...)
+	return &cp
+}
@@ -0,0 +1,127 @@
+// Package payment talks to a card payment gateway over HTTP, and has a
+// fake of one to talk to in development and tests.
+//
+// The gateway API is JSON throughout:
+//
+//	POST /authorizations              {"reference","amount_cents","token"}
+//	POST /payments/{ref}/capture      {"amount_cents"}
+//	POST /payments/{ref}/refund       {"amount_cents"}
+//	POST /payments/{ref}/void
+//	GET  /payments?reference={reference}
+//
+// Each answers with the payment as a domain.GatewayPayment.
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/unittest3/lib"
+)
+
+// ClientConfig says where the gateway is. Timeout bounds each call and
+// defaults to 10s; past it the outcome of the call is unknown.
+type ClientConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+}
+
+const defaultTimeout = 10 * time.Second
+
+// Client is a domain.PaymentGateway over the gateway's HTTP API.
+type Client struct {
+	base   string
+	key    string
+	client *http.Client
+}
+
+func NewClient(cfg ClientConfig, client *http.Client) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	c := *client
+	c.Timeout = cfg.Timeout
+	return &Client{base: strings.TrimRight(cfg.BaseURL, "/"), key: cfg.APIKey, client: &c}
+}
+
+type amountBody struct {
+	AmountCents lib.Money `json:"amount_cents"`
+}
+
+func (c *Client) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.GatewayPayment, error) {
+	return c.do(ctx, http.MethodPost, "/authorizations", req)
+}
+
+func (c *Client) Capture(ctx context.Context, ref string, amount lib.Money) (*domain.GatewayPayment, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(ref)+"/capture", amountBody{amount})
+}
+
+func (c *Client) Refund(ctx context.Context, ref string, amount lib.Money) (*domain.GatewayPayment, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(ref)+"/refund", amountBody{amount})
+}
+
+func (c *Client) Void(ctx context.Context, ref string) (*domain.GatewayPayment, error) {
+	return c.do(ctx, http.MethodPost, "/payments/"+url.PathEscape(ref)+"/void", nil)
+}
+
+func (c *Client) Lookup(ctx context.Context, reference string) (*domain.GatewayPayment, error) {
+	return c.do(ctx, http.MethodGet, "/payments?reference="+url.QueryEscape(reference), nil)
+}
+
+// do makes one call. Anything that leaves the outcome unknown, from a
+// timeout to a 5xx, is domain.ErrUnavailable.
+func (c *Client) do(ctx context.Context, method, path string, body any) (*domain.GatewayPayment, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payment gateway: %v", domain.ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, domain.ErrNotFound
+	case res.StatusCode == http.StatusConflict:
+		return nil, domain.ErrPaymentState
+	case res.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: payment gateway: %s", domain.ErrUnavailable, res.Status)
+	case res.StatusCode >= 300:
+		return nil, fmt.Errorf("payment gateway: %s", res.Status)
+	}
+
+	var p domain.GatewayPayment
+	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: payment gateway: %v", domain.ErrUnavailable, err)
+	}
+	return &p, nil
+}
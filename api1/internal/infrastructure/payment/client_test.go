@@ -0,0 +1,119 @@
+package payment_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/payment"
+	"unit-test-demo/api1/internal/webhook"
+)
+
+func newGateway(t *testing.T, cfg payment.FakeConfig) (*payment.FakeGateway, *payment.Client) {
+	t.Helper()
+	g := payment.NewFakeGateway(cfg)
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+	return g, payment.NewClient(payment.ClientConfig{BaseURL: srv.URL, APIKey: cfg.APIKey, Timeout: 200 * time.Millisecond}, srv.Client())
+}
+
+func TestClient_AuthorizeCaptureRefund(t *testing.T) {
+	_, c := newGateway(t, payment.FakeConfig{APIKey: "key"})
+	ctx := context.Background()
+	req := domain.AuthorizeRequest{Reference: "acme:1", AmountCents: 4398, Token: "tok_visa"}
+
+	p, err := c.Authorize(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentAuthorized, p.Status)
+	again, err := c.Authorize(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, p, again, "the same reference is the same payment")
+
+	_, err = c.Capture(ctx, p.Ref, 100)
+	assert.ErrorIs(t, err, domain.ErrPaymentState, "only the whole amount is captured")
+	p, err = c.Capture(ctx, p.Ref, 4398)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentCaptured, p.Status)
+	_, err = c.Void(ctx, p.Ref)
+	assert.ErrorIs(t, err, domain.ErrPaymentState)
+	p, err = c.Refund(ctx, p.Ref, 4398)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRefunded, p.Status)
+
+	found, err := c.Lookup(ctx, "acme:1")
+	require.NoError(t, err)
+	assert.Equal(t, p, found)
+	_, err = c.Lookup(ctx, "acme:2")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = c.Capture(ctx, "pay_404", 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestClient_DeclineAndTimeout(t *testing.T) {
+	g, c := newGateway(t, payment.FakeConfig{})
+	ctx := context.Background()
+
+	p, err := c.Authorize(ctx, domain.AuthorizeRequest{Reference: "acme:1", AmountCents: 100, Token: payment.TokenDecline})
+	require.NoError(t, err, "a decline is an answer, not an error")
+	assert.Equal(t, domain.PaymentDeclined, p.Status)
+	assert.Equal(t, "card_declined", p.Reason)
+
+	req := domain.AuthorizeRequest{Reference: "acme:2", AmountCents: 100, Token: payment.TokenTimeout}
+	_, err = c.Authorize(ctx, req)
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, domain.PaymentAuthorized, g.Status("acme:2"), "the gateway went ahead all the same")
+	p, err = c.Authorize(ctx, req)
+	require.NoError(t, err, "asking again gets the answer that was lost")
+	assert.Equal(t, domain.PaymentAuthorized, p.Status)
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	g := payment.NewFakeGateway(payment.FakeConfig{APIKey: "key"})
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+	c := payment.NewClient(payment.ClientConfig{BaseURL: srv.URL, APIKey: "wrong"}, srv.Client())
+
+	_, err := c.Lookup(context.Background(), "acme:1")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrUnavailable)
+}
+
+func TestFakeGateway_SettleSendsSignedWebhook(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan delivery, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- delivery{r.Header, body}
+	}))
+	defer hook.Close()
+
+	g, c := newGateway(t, payment.FakeConfig{WebhookURL: hook.URL, WebhookSecret: "s3cret"})
+	ctx := context.Background()
+	p, err := c.Authorize(ctx, domain.AuthorizeRequest{Reference: "acme:1", AmountCents: 100, Token: payment.TokenAsync})
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentPending, p.Status)
+	_, err = c.Capture(ctx, p.Ref, 100)
+	assert.ErrorIs(t, err, domain.ErrPaymentState, "async payments settle by themselves")
+
+	require.NoError(t, g.Settle(ctx))
+
+	d := <-got
+	assert.Equal(t, payment.EventPaymentSettled, d.header.Get(webhook.HeaderEvent))
+	assert.NoError(t, webhook.Verify("s3cret", d.header.Get(webhook.HeaderSignature), d.header.Get(webhook.HeaderTimestamp),
+		d.body, time.Minute, time.Now()))
+	var settled domain.GatewayPayment
+	require.NoError(t, json.Unmarshal(d.body, &settled))
+	assert.Equal(t, domain.GatewayPayment{Ref: p.Ref, Reference: "acme:1", Status: domain.PaymentCaptured}, settled)
+	assert.Equal(t, domain.PaymentCaptured, g.Status("acme:1"))
+}
@@ -0,0 +1,290 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/webhook"
+	"unit-test-demo/unittest3/lib"
+)
+
+// Tokens the fake gateway knows. Any other token authorizes.
+const (
+	// TokenDecline is declined as card_declined.
+	TokenDecline = "tok_decline"
+	// TokenTimeout authorizes, but the answer does not come until the
+	// caller has given up, as when a response is lost on the way.
+	TokenTimeout = "tok_timeout"
+	// TokenAsync answers pending; the payment is captured when it
+	// settles, and the gateway says so by webhook.
+	TokenAsync = "tok_async"
+	// TokenInvalid is refused with 400 Bad Request, as a malformed or
+	// expired token is; no payment is made.
+	TokenInvalid = "tok_invalid"
+)
+
+// EventPaymentSettled is the X-Webhook-Event of settlement webhooks.
+const EventPaymentSettled = "payment.settled"
+
+// FakeConfig sets up a FakeGateway. Settlement webhooks go to WebhookURL,
+// signed with WebhookSecret as the webhook package signs; without a URL
+// they are not sent. SettleAfter is how long async payments stay pending;
+// at zero they stay so until Settle is called. APIKey, when set, is
+// required of callers. Now defaults to time.Now.
+type FakeConfig struct {
+	APIKey        string
+	WebhookURL    string
+	WebhookSecret string
+	SettleAfter   time.Duration
+	Now           func() time.Time
+}
+
+// FakeGateway is an http.Handler that behaves like the payment gateway
+// Client talks to, with the card behind the token deciding what happens.
+// Serve it with httptest.NewServer in tests, or on a local port.
+type FakeGateway struct {
+	cfg    FakeConfig
+	client *http.Client
+	mux    *http.ServeMux
+
+	mu          sync.Mutex
+	payments    map[string]*fakePayment // by ref
+	byReference map[string]string       // reference to ref
+	nextRef     int
+}
+
+type fakePayment struct {
+	domain.GatewayPayment
+	amount lib.Money
+	async  bool
+}
+
+func NewFakeGateway(cfg FakeConfig) *FakeGateway {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	g := &FakeGateway{
+		cfg:         cfg,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		mux:         http.NewServeMux(),
+		payments:    make(map[string]*fakePayment),
+		byReference: make(map[string]string),
+	}
+	g.mux.HandleFunc("POST /authorizations", g.authorize)
+	g.mux.HandleFunc("POST /payments/{ref}/capture", g.capture)
+	g.mux.HandleFunc("POST /payments/{ref}/refund", g.refund)
+	g.mux.HandleFunc("POST /payments/{ref}/void", g.void)
+	g.mux.HandleFunc("GET /payments", g.lookup)
+	return g
+}
+
+func (g *FakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.cfg.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+g.cfg.APIKey {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *FakeGateway) authorize(w http.ResponseWriter, r *http.Request) {
+	var req domain.AuthorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reference == "" || req.AmountCents <= 0 {
+		writeFake(w, http.StatusBadRequest, map[string]string{"error": "invalid authorization"})
+		return
+	}
+
+	if req.Token == TokenInvalid {
+		writeFake(w, http.StatusBadRequest, map[string]string{"error": "invalid token"})
+		return
+	}
+
+	g.mu.Lock()
+	p, seen := g.find(req.Reference)
+	if !seen {
+		g.nextRef++
+		p = &fakePayment{
+			GatewayPayment: domain.GatewayPayment{
+				Ref:       fmt.Sprintf("pay_%d", g.nextRef),
+				Reference: req.Reference,
+				Status:    domain.PaymentAuthorized,
+			},
+			amount: req.AmountCents,
+		}
+		switch req.Token {
+		case TokenDecline:
+			p.Status, p.Reason = domain.PaymentDeclined, "card_declined"
+		case TokenAsync:
+			p.Status, p.async = domain.PaymentPending, true
+		}
+		g.payments[p.Ref] = p
+		g.byReference[p.Reference] = p.Ref
+	}
+	out := p.GatewayPayment
+	g.mu.Unlock()
+
+	if !seen && p.async && g.cfg.SettleAfter > 0 {
+		time.AfterFunc(g.cfg.SettleAfter, func() { _ = g.settle(context.Background(), out.Ref) })
+	}
+	if req.Token == TokenTimeout && !seen {
+		<-r.Context().Done()
+		return
+	}
+	writeFake(w, http.StatusOK, out)
+}
+
+func (g *FakeGateway) capture(w http.ResponseWriter, r *http.Request) {
+	var body amountBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFake(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	g.move(w, r.PathValue("ref"), domain.PaymentCaptured, body.AmountCents)
+}
+
+func (g *FakeGateway) refund(w http.ResponseWriter, r *http.Request) {
+	var body amountBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFake(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	g.move(w, r.PathValue("ref"), domain.PaymentRefunded, body.AmountCents)
+}
+
+func (g *FakeGateway) void(w http.ResponseWriter, r *http.Request) {
+	g.move(w, r.PathValue("ref"), domain.PaymentVoided, 0)
+}
+
+// move takes a payment to status, as the gateway's own state machine
+// allows. Asking again for the status it is in answers as before. Amounts
+// must be the whole amount authorized; the fake does not split payments.
+func (g *FakeGateway) move(w http.ResponseWriter, ref string, status domain.PaymentStatus, amount lib.Money) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[ref]
+	if !ok {
+		writeFake(w, http.StatusNotFound, map[string]string{"error": "no such payment"})
+		return
+	}
+	if status != domain.PaymentVoided && amount != p.amount {
+		writeFake(w, http.StatusConflict, map[string]string{"error": "amount must be the amount authorized"})
+		return
+	}
+	if p.Status != status {
+		// Async payments settle by themselves; they cannot be captured.
+		if !p.Status.CanMove(status) || p.async && status == domain.PaymentCaptured {
+			writeFake(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("payment is %s", p.Status)})
+			return
+		}
+		p.Status = status
+	}
+	writeFake(w, http.StatusOK, p.GatewayPayment)
+}
+
+func (g *FakeGateway) lookup(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	p, ok := g.find(r.URL.Query().Get("reference"))
+	var out domain.GatewayPayment
+	if ok {
+		out = p.GatewayPayment
+	}
+	g.mu.Unlock()
+
+	if !ok {
+		writeFake(w, http.StatusNotFound, map[string]string{"error": "no such payment"})
+		return
+	}
+	writeFake(w, http.StatusOK, out)
+}
+
+// find looks a payment up by reference. Callers hold g.mu.
+func (g *FakeGateway) find(reference string) (*fakePayment, bool) {
+	ref, ok := g.byReference[reference]
+	if !ok {
+		return nil, false
+	}
+	return g.payments[ref], true
+}
+
+// Settle captures every async payment still pending and sends a webhook
+// for each. It stops at the first webhook that is not taken with a 2xx.
+func (g *FakeGateway) Settle(ctx context.Context) error {
+	g.mu.Lock()
+	var refs []string
+	for ref, p := range g.payments {
+		if p.async && p.Status == domain.PaymentPending {
+			refs = append(refs, ref)
+		}
+	}
+	g.mu.Unlock()
+
+	for _, ref := range refs {
+		if err := g.settle(ctx, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *FakeGateway) settle(ctx context.Context, ref string) error {
+	g.mu.Lock()
+	p := g.payments[ref]
+	if p.Status != domain.PaymentPending {
+		g.mu.Unlock()
+		return nil
+	}
+	p.Status = domain.PaymentCaptured
+	out := p.GatewayPayment
+	g.mu.Unlock()
+
+	if g.cfg.WebhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, EventPaymentSettled)
+	ts := g.cfg.Now()
+	req.Header.Set(webhook.HeaderTimestamp, fmt.Sprint(ts.Unix()))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(g.cfg.WebhookSecret, ts, body))
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("settlement webhook for %s: %s", ref, res.Status)
+	}
+	return nil
+}
+
+// Status reports what the gateway holds of the payment for reference,
+// for tests to check against.
+func (g *FakeGateway) Status(reference string) domain.PaymentStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if p, ok := g.find(reference); ok {
+		return p.Status
+	}
+	return ""
+}
+
+func writeFake(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
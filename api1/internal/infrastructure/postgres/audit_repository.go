@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// AuditRepository is the Postgres domain.AuditRepository. Records are
+// filtered by tenant and guarded by row-level security.
+type AuditRepository struct {
+	db DB
+}
+
+func NewAuditRepository(db DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, rec domain.AuditRecord) error {
+	changes, err := json.Marshal(rec.Changes)
+	if err != nil {
+		return err
+	}
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx,
+			`INSERT INTO audit_log (tenant_id, entity_type, entity_id, action, actor, request_id, client_ip, changes)
+             VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)`,
+			tenant, rec.EntityType, rec.EntityID, string(rec.Action), rec.Actor, rec.RequestID, rec.ClientIP, changes,
+		)
+		return err
+	})
+}
+
+func (r *AuditRepository) List(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	out := []*domain.AuditRecord{}
+	err := scoped(ctx, r.db, func(db DBTX, tenant string) error {
+		rows, err := db.Query(ctx,
+			`SELECT id, tenant_id, entity_type, entity_id, action, actor,
+                    COALESCE(request_id, ''), COALESCE(client_ip, ''), changes, created_at
+             FROM audit_log
+             WHERE tenant_id = $1
+               AND ($2 = '' OR entity_type = $2)
+               AND ($3 = 0 OR entity_id = $3)
+               AND ($4 = '' OR actor = $4)
+               AND ($5 = '' OR action = $5)
+             ORDER BY id
+             LIMIT $6 OFFSET $7`,
+			tenant, q.EntityType, q.EntityID, q.Actor, string(q.Action), limit, q.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var rec domain.AuditRecord
+			var action string
+			var changes []byte
+			if err := rows.Scan(&rec.ID, &rec.TenantID, &rec.EntityType, &rec.EntityID, &action, &rec.Actor,
+				&rec.RequestID, &rec.ClientIP, &changes, &rec.CreatedAt); err != nil {
+				return err
+			}
+			rec.Action = domain.AuditAction(action)
+			if err := json.Unmarshal(changes, &rec.Changes); err != nil {
+				return err
+			}
+			rec.CreatedAt = rec.CreatedAt.UTC()
+			out = append(out, &rec)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
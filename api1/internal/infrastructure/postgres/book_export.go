@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// Exports are read in chunks of exportChunk books, each in a short
+// transaction of its own given at most exportChunkTimeout, so a slow client
+// holds neither a connection nor a snapshot while it reads.
+const (
+	exportChunk        = 500
+	exportChunkTimeout = 10 * time.Second
+)
+
+// ExportBooks pages through the matching books by ID, handing each chunk to
+// fn once its transaction is over. Only one chunk is held in memory at a
+// time. The export is not one snapshot: a book changed while it runs shows
+// as it was when its chunk was read, and each book shows at most once.
+func (r *BookRepository) ExportBooks(ctx context.Context, lq domain.ListBooksQuery, fn func(*domain.Book) error) error {
+	var after int64
+	for {
+		chunk, err := r.exportChunk(ctx, lq, after)
+		if err != nil {
+			return err
+		}
+		for _, b := range chunk {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		if len(chunk) < exportChunk {
+			return nil
+		}
+		after = chunk[len(chunk)-1].ID
+	}
+}
+
+// exportChunk reads the next matching books after the ID after.
+func (r *BookRepository) exportChunk(ctx context.Context, lq domain.ListBooksQuery, after int64) ([]*domain.Book, error) {
+	ctx, cancel := context.WithTimeout(ctx, exportChunkTimeout)
+	defer cancel()
+
+	books := make([]*domain.Book, 0, exportChunk)
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+bookColumns+`
+             FROM books b
+             WHERE `+bookFilter+` AND b.id > $6
+             ORDER BY id
+             LIMIT $7`,
+			tenant, lq.Author, lq.Category, lq.Tags, lq.AllTags, after, exportChunk,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			b, err := scanBook(rows)
+			if err != nil {
+				return err
+			}
+			books = append(books, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
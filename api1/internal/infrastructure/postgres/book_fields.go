@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// The domain.BookFieldRepository half of BookRepository.
+
+// fieldColumns lists the columns each selectable field is computed from.
+// The id is always read, for the includes and for cover_url.
+var fieldColumns = map[string][]string{
+	"id":           {"id"},
+	"tenant_id":    {"tenant_id"},
+	"title":        {"title"},
+	"author":       {"author"},
+	"created_at":   {"created_at"},
+	"cover_url":    {"cover_etag"},
+	"rating_avg":   {"rating_sum", "rating_count"},
+	"rating_count": {"rating_count"},
+}
+
+// bookProjection is the column list and scanner for a set of fields.
+// Columns come in the order of bookColumns, each once.
+type bookProjection struct {
+	columns []string
+}
+
+func projectBook(fields []string) bookProjection {
+	want := map[string]bool{"id": true}
+	if len(fields) == 0 {
+		fields = domain.BookFields
+	}
+	for _, f := range fields {
+		for _, c := range fieldColumns[f] {
+			want[c] = true
+		}
+	}
+	var p bookProjection
+	for _, c := range strings.Split(bookColumns, ", ") {
+		if want[c] {
+			p.columns = append(p.columns, c)
+		}
+	}
+	return p
+}
+
+// list is the SELECT list, with each column qualified by the books alias b.
+func (p bookProjection) list() string {
+	qualified := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		qualified[i] = "b." + c
+	}
+	return strings.Join(qualified, ", ")
+}
+
+func (p bookProjection) scan(row pgx.Row) (*domain.Book, error) {
+	var b domain.Book
+	var coverETag *string
+	var ratingSum int
+	targets := map[string]any{
+		"id":           &b.ID,
+		"tenant_id":    &b.TenantID,
+		"title":        &b.Title,
+		"author":       &b.Author,
+		"created_at":   &b.CreatedAt,
+		"cover_etag":   &coverETag,
+		"rating_sum":   &ratingSum,
+		"rating_count": &b.RatingCount,
+	}
+	dest := make([]any, len(p.columns))
+	for i, c := range p.columns {
+		dest[i] = targets[c]
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(p.columns, "rating_sum") {
+		b.RatingAvg = domain.RatingAvg(ratingSum, b.RatingCount)
+	}
+	b.CreatedAt = b.CreatedAt.UTC().Truncate(time.Second)
+	if coverETag != nil {
+		b.CoverURL = domain.CoverURL(b.ID, *coverETag)
+	}
+	return &b, nil
+}
+
+func (r *BookRepository) GetFields(ctx context.Context, id int64, fields []string) (*domain.Book, error) {
+	p := projectBook(fields)
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		b, err = p.scan(q.QueryRow(ctx,
+			`SELECT `+p.list()+`
+             FROM books b
+             WHERE b.tenant_id = $1 AND b.id = $2 AND b.deleted_at IS NULL`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return b, nil
+}
+
+func (r *BookRepository) ListFields(ctx context.Context, lq domain.ListBooksQuery, fields []string) ([]*domain.Book, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	p := projectBook(fields)
+
+	books := []*domain.Book{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+p.list()+`
+             FROM books b
+             WHERE `+bookFilter+`
+             ORDER BY b.id
+             LIMIT $6 OFFSET $7`,
+			tenant, lq.Author, lq.Category, lq.Tags, lq.AllTags, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			b, err := p.scan(rows)
+			if err != nil {
+				return err
+			}
+			books = append(books, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
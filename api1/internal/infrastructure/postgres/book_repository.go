@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"unit-test-demo/api1/internal/domain"
@@ -9,27 +10,407 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+const defaultListLimit = 50
+
+// BookRepository is the Postgres domain.BookRepository,
+// domain.BookRevisionRepository and domain.CategoryRepository. Every query
+// filters on the tenant from the context and also runs under the row-level
+// security policies of migration 0007, so a bug in one of the two still
+// cannot leak another tenant's rows.
 type BookRepository struct {
-	conn *pgx.Conn
+	db DB
 }
 
-func NewBookRepository(conn *pgx.Conn) *BookRepository {
-	return &BookRepository{conn: conn}
+func NewBookRepository(db DB) *BookRepository {
+	return &BookRepository{db: db}
 }
 
-func (r *BookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+const bookColumns = `id, tenant_id, title, author, created_at, cover_etag, rating_sum, rating_count`
+
+func scanBook(row pgx.Row) (*domain.Book, error) {
 	var b domain.Book
-	err := r.conn.QueryRow(ctx,
-		`INSERT INTO books (title, author) 
-         VALUES ($1, $2)
-         RETURNING id, title, author, created_at`,
-		in.Title, in.Author,
-	).Scan(&b.ID, &b.Title, &b.Author, &b.CreatedAt)
-	if err != nil {
+	var coverETag *string
+	var ratingSum int
+	if err := row.Scan(&b.ID, &b.TenantID, &b.Title, &b.Author, &b.CreatedAt, &coverETag, &ratingSum, &b.RatingCount); err != nil {
 		return nil, err
 	}
-
+	b.RatingAvg = domain.RatingAvg(ratingSum, b.RatingCount)
 	// Normalize timezone if needed
 	b.CreatedAt = b.CreatedAt.UTC().Truncate(time.Second)
+	if coverETag != nil {
+		b.CoverURL = domain.CoverURL(b.ID, *coverETag)
+	}
 	return &b, nil
 }
+
+// createAttempts bounds how often Create tries again when its insert hit a
+// duplicate that was gone by the time it looked for it.
+const createAttempts = 3
+
+// Create skips the insert rather than fail on a duplicate key, which would
+// abort the caller's transaction before the existing book could be named.
+// If the book in the way was deleted or renamed in the meantime the insert
+// is tried again; should that keep happening, Create gives up with a
+// *domain.DuplicateError that cannot name the other book.
+func (r *BookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	key := domain.BookKey(in.Title, in.Author)
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		for range createAttempts {
+			b, err = scanBook(q.QueryRow(ctx,
+				`WITH b AS (
+                     INSERT INTO books (tenant_id, title, author, norm_key, allow_duplicate)
+                     VALUES ($1, $2, $3, $4, $5)
+                     ON CONFLICT (tenant_id, norm_key) WHERE deleted_at IS NULL AND NOT allow_duplicate DO NOTHING
+                     RETURNING `+bookColumns+`, revision, false AS deleted
+                 ), h AS (`+recordRevision+`)
+                 SELECT `+bookColumns+` FROM b`,
+				tenant, in.Title, in.Author, key, in.Force,
+			))
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			if err := duplicateOf(ctx, q, tenant, 0, key); err != nil {
+				return err
+			}
+		}
+		return &domain.DuplicateError{}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *BookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		b, err = scanBook(q.QueryRow(ctx,
+			`SELECT `+bookColumns+`
+             FROM books
+             WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return b, nil
+}
+
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	books := make(map[int64]*domain.Book, len(ids))
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+bookColumns+`
+             FROM books
+             WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL`,
+			tenant, ids,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			b, err := scanBook(rows)
+			if err != nil {
+				return err
+			}
+			books[b.ID] = b
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (r *BookRepository) List(ctx context.Context, lq domain.ListBooksQuery) ([]*domain.Book, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	books := []*domain.Book{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+bookColumns+`
+             FROM books b
+             WHERE `+bookFilter+`
+             ORDER BY id
+             LIMIT $6 OFFSET $7`,
+			tenant, lq.Author, lq.Category, lq.Tags, lq.AllTags, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			b, err := scanBook(rows)
+			if err != nil {
+				return err
+			}
+			books = append(books, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (r *BookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	key := domain.BookKey(in.Title, in.Author)
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		if err := duplicateOf(ctx, q, tenant, id, key); err != nil {
+			return err
+		}
+		b, err = scanBook(q.QueryRow(ctx,
+			`WITH b AS (
+                 UPDATE books
+                 SET title = $3, author = $4, norm_key = $5, revision = revision + 1
+                 WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+                 RETURNING `+bookColumns+`, revision, false AS deleted
+             ), h AS (`+recordRevision+`)
+             SELECT `+bookColumns+` FROM b`,
+			tenant, id, in.Title, in.Author, key,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(duplicateErr(err))
+	}
+	return b, nil
+}
+
+func (r *BookRepository) Delete(ctx context.Context, id int64) error {
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var deletedID int64
+		return q.QueryRow(ctx,
+			`WITH b AS (
+                 UPDATE books
+                 SET deleted_at = now(), revision = revision + 1
+                 WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+                 RETURNING `+bookColumns+`, revision, true AS deleted
+             ), h AS (`+recordRevision+`)
+             SELECT id FROM b`,
+			tenant, id,
+		).Scan(&deletedID)
+	})
+	return notFound(err)
+}
+
+func (r *BookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		var key string
+		err = q.QueryRow(ctx,
+			`SELECT coalesce(norm_key, '') FROM books WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NOT NULL`,
+			tenant, id,
+		).Scan(&key)
+		if err != nil {
+			return err
+		}
+		if err := duplicateOf(ctx, q, tenant, id, key); err != nil {
+			return err
+		}
+		b, err = scanBook(q.QueryRow(ctx,
+			`WITH b AS (
+                 UPDATE books
+                 SET deleted_at = NULL, revision = revision + 1
+                 WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NOT NULL
+                 RETURNING `+bookColumns+`, revision, false AS deleted
+             ), h AS (`+recordRevision+`)
+             SELECT `+bookColumns+` FROM b`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(duplicateErr(err))
+	}
+	return b, nil
+}
+
+// SetCover upserts the cover row and mirrors its ETag onto the book, which
+// is all List and GetByID need to build the cover URL.
+func (r *BookRepository) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		b, err = scanBook(q.QueryRow(ctx,
+			`WITH c AS (
+                 INSERT INTO book_covers (book_id, tenant_id, content_type, width, height, size, etag, key,
+                                          thumb_content_type, thumb_size, thumb_etag, thumb_key)
+                 SELECT id, tenant_id, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+                 FROM books
+                 WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+                 ON CONFLICT (book_id) DO UPDATE SET
+                     content_type = EXCLUDED.content_type, width = EXCLUDED.width,
+                     height = EXCLUDED.height, size = EXCLUDED.size, etag = EXCLUDED.etag,
+                     key = EXCLUDED.key, thumb_content_type = EXCLUDED.thumb_content_type,
+                     thumb_size = EXCLUDED.thumb_size, thumb_etag = EXCLUDED.thumb_etag,
+                     thumb_key = EXCLUDED.thumb_key, updated_at = now()
+                 RETURNING book_id
+             )
+             UPDATE books SET cover_etag = $7
+             WHERE id IN (SELECT book_id FROM c)
+             RETURNING `+bookColumns,
+			tenant, id, c.ContentType, c.Width, c.Height, c.Size, c.ETag, c.Key,
+			c.ThumbType, c.ThumbSize, c.ThumbETag, c.ThumbKey,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return b, nil
+}
+
+func (r *BookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	var c domain.Cover
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		return q.QueryRow(ctx,
+			`SELECT c.content_type, c.width, c.height, c.size, c.etag, c.key,
+                    c.thumb_content_type, c.thumb_size, c.thumb_etag, c.thumb_key, c.updated_at
+             FROM book_covers c
+             JOIN books b ON b.id = c.book_id
+             WHERE c.tenant_id = $1 AND c.book_id = $2 AND b.deleted_at IS NULL`,
+			tenant, id,
+		).Scan(&c.ContentType, &c.Width, &c.Height, &c.Size, &c.ETag, &c.Key,
+			&c.ThumbType, &c.ThumbSize, &c.ThumbETag, &c.ThumbKey, &c.UpdatedAt)
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	c.UpdatedAt = c.UpdatedAt.UTC().Truncate(time.Second)
+	return &c, nil
+}
+
+// AdjustRating updates the running totals in place, so concurrent reviews
+// of one book serialize on its row lock instead of recounting.
+func (r *BookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	var b *domain.Book
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		b, err = scanBook(q.QueryRow(ctx,
+			`UPDATE books
+             SET rating_sum = rating_sum + $3, rating_count = rating_count + $4
+             WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+             RETURNING `+bookColumns,
+			tenant, id, sum, count,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return b, nil
+}
+
+func (r *BookRepository) ListRevisions(ctx context.Context, bookID int64, limit, offset int) ([]*domain.BookRevision, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	revs := []*domain.BookRevision{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+revisionColumns+`
+             FROM book_revisions
+             WHERE tenant_id = $1 AND book_id = $2
+             ORDER BY revision
+             LIMIT $3 OFFSET $4`,
+			tenant, bookID, limit, offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			rev, err := scanRevision(rows)
+			if err != nil {
+				return err
+			}
+			revs = append(revs, rev)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+func (r *BookRepository) GetRevision(ctx context.Context, bookID int64, rev int) (*domain.BookRevision, error) {
+	var out *domain.BookRevision
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		out, err = scanRevision(q.QueryRow(ctx,
+			`SELECT `+revisionColumns+`
+             FROM book_revisions
+             WHERE tenant_id = $1 AND book_id = $2 AND revision = $3`,
+			tenant, bookID, rev,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return out, nil
+}
+
+func (r *BookRepository) GetAsOf(ctx context.Context, bookID int64, at time.Time) (*domain.Book, error) {
+	var rev *domain.BookRevision
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		rev, err = scanRevision(q.QueryRow(ctx,
+			`SELECT `+revisionColumns+`
+             FROM book_revisions
+             WHERE tenant_id = $1 AND book_id = $2 AND recorded_at <= $3
+             ORDER BY revision DESC
+             LIMIT 1`,
+			tenant, bookID, at,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if rev.Deleted {
+		return nil, domain.ErrNotFound
+	}
+	return &rev.Book, nil
+}
+
+// recordRevision is the history half of every write: it expects a CTE
+// named b returning the written row plus its revision and deleted flag, so
+// the book and its snapshot are stored by one statement.
+const recordRevision = `
+                 INSERT INTO book_revisions (book_id, tenant_id, revision, title, author, created_at, deleted)
+                 SELECT id, tenant_id, revision, title, author, created_at, deleted FROM b`
+
+const revisionColumns = `revision, book_id, tenant_id, title, author, created_at, deleted, recorded_at`
+
+func scanRevision(row pgx.Row) (*domain.BookRevision, error) {
+	var rev domain.BookRevision
+	err := row.Scan(&rev.Revision, &rev.Book.ID, &rev.Book.TenantID, &rev.Book.Title, &rev.Book.Author,
+		&rev.Book.CreatedAt, &rev.Deleted, &rev.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+	rev.Book.CreatedAt = rev.Book.CreatedAt.UTC().Truncate(time.Second)
+	rev.RecordedAt = rev.RecordedAt.UTC()
+	return &rev, nil
+}
+
+// notFound translates pgx.ErrNoRows into domain.ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	return err
+}
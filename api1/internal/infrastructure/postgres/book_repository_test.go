@@ -0,0 +1,17 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/booktest"
+	"unit-test-demo/api1/internal/infrastructure/postgres"
+)
+
+func TestBookRepository(t *testing.T) {
+	booktest.RunShared(t, func(t *testing.T) (domain.BookRepository, context.Context, context.Context) {
+		pool := testDB(t)
+		return postgres.NewBookRepository(pool), newTenant(t, pool), newTenant(t, pool)
+	})
+}
@@ -0,0 +1,334 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// BookStreamStore is the Postgres domain.BookEventStore and
+// domain.BookReadModel, on the tables of migration 0016. Appends and
+// projections made in one Transactor transaction commit together.
+type BookStreamStore struct {
+	db DB
+}
+
+func NewBookStreamStore(db DB) *BookStreamStore {
+	return &BookStreamStore{db: db}
+}
+
+func (s *BookStreamStore) NextBookID(ctx context.Context) (int64, error) {
+	var id int64
+	err := scoped(ctx, s.db, func(q DBTX, _ string) error {
+		return q.QueryRow(ctx, `SELECT nextval('book_stream_ids')`).Scan(&id)
+	})
+	return id, err
+}
+
+// AppendBookEvents relies on the unique (book_id, version) constraint: a
+// writer that read an old version collides with the event that superseded
+// it. The version is checked first so that the usual conflict does not
+// abort the caller's transaction.
+func (s *BookStreamStore) AppendBookEvents(ctx context.Context, id int64, expected int, events []domain.BookStreamEvent) error {
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		var current int
+		err := q.QueryRow(ctx,
+			`SELECT coalesce(max(version), 0) FROM book_streams WHERE tenant_id = $1 AND book_id = $2`,
+			tenant, id,
+		).Scan(&current)
+		if err != nil {
+			return err
+		}
+		if current != expected {
+			return domain.ErrVersionConflict
+		}
+
+		for _, e := range events {
+			_, err := q.Exec(ctx,
+				`INSERT INTO book_streams (tenant_id, book_id, version, kind, data, at)
+                 VALUES ($1, $2, $3, $4, $5, $6)`,
+				tenant, id, e.Version, e.Kind, e.Data, e.At,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "book_streams_version_unique" {
+		return domain.ErrVersionConflict
+	}
+	return err
+}
+
+func (s *BookStreamStore) LoadBookEvents(ctx context.Context, id int64, after int) ([]domain.BookStreamEvent, error) {
+	var events []domain.BookStreamEvent
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) (err error) {
+		events, err = queryStreamEvents(ctx, q,
+			`SELECT position, tenant_id, book_id, version, kind, data, at
+             FROM book_streams
+             WHERE tenant_id = $1 AND book_id = $2 AND version > $3
+             ORDER BY version`,
+			tenant, id, after,
+		)
+		return err
+	})
+	return events, err
+}
+
+func (s *BookStreamStore) LoadBookSnapshot(ctx context.Context, id int64) (*domain.BookSnapshot, error) {
+	snap := domain.BookSnapshot{BookID: id}
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		return q.QueryRow(ctx,
+			`SELECT version, state, at FROM book_stream_snapshots WHERE tenant_id = $1 AND book_id = $2`,
+			tenant, id,
+		).Scan(&snap.Version, &snap.State, &snap.At)
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &snap, nil
+}
+
+func (s *BookStreamStore) SaveBookSnapshot(ctx context.Context, snap domain.BookSnapshot) error {
+	return scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx,
+			`INSERT INTO book_stream_snapshots (book_id, tenant_id, version, state, at)
+             VALUES ($1, $2, $3, $4, $5)
+             ON CONFLICT (book_id) DO UPDATE SET
+                 version = EXCLUDED.version, state = EXCLUDED.state, at = EXCLUDED.at
+             WHERE book_stream_snapshots.version < EXCLUDED.version`,
+			snap.BookID, tenant, snap.Version, snap.State, snap.At,
+		)
+		return err
+	})
+}
+
+func (s *BookStreamStore) ReadBookEvents(ctx context.Context, after int64, limit int) ([]domain.BookStreamEvent, error) {
+	var events []domain.BookStreamEvent
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) (err error) {
+		events, err = queryStreamEvents(ctx, q,
+			`SELECT position, tenant_id, book_id, version, kind, data, at
+             FROM book_streams
+             WHERE tenant_id = $1 AND position > $2
+             ORDER BY position
+             LIMIT $3`,
+			tenant, after, limit,
+		)
+		return err
+	})
+	return events, err
+}
+
+// streamCover is how the read model keeps a domain.Cover, whose JSON
+// leaves out the blob keys.
+type streamCover struct {
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag"`
+	Key         string    `json:"key"`
+	ThumbType   string    `json:"thumb_content_type"`
+	ThumbSize   int64     `json:"thumb_size"`
+	ThumbETag   string    `json:"thumb_etag"`
+	ThumbKey    string    `json:"thumb_key"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (s *BookStreamStore) PutBookState(ctx context.Context, st domain.BookState) error {
+	var cover []byte
+	if st.Cover != nil {
+		var err error
+		if cover, err = json.Marshal(streamCover(*st.Cover)); err != nil {
+			return err
+		}
+	}
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx,
+			`INSERT INTO book_stream_states (id, tenant_id, title, author, norm_key, created_at, cover,
+                                             rating_sum, rating_count, deleted, forced, version)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+             ON CONFLICT (id) DO UPDATE SET
+                 title = EXCLUDED.title, author = EXCLUDED.author, norm_key = EXCLUDED.norm_key,
+                 cover = EXCLUDED.cover, rating_sum = EXCLUDED.rating_sum,
+                 rating_count = EXCLUDED.rating_count, deleted = EXCLUDED.deleted,
+                 forced = EXCLUDED.forced, version = EXCLUDED.version
+             WHERE book_stream_states.version < EXCLUDED.version`,
+			st.Book.ID, tenant, st.Book.Title, st.Book.Author, domain.BookKey(st.Book.Title, st.Book.Author),
+			st.Book.CreatedAt, cover, st.RatingSum, st.Book.RatingCount, st.Deleted, st.Forced, st.Version,
+		)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "book_stream_states_norm_key_unique" {
+		return &domain.DuplicateError{}
+	}
+	return err
+}
+
+const bookStateColumns = `id, tenant_id, title, author, created_at, cover, rating_sum, rating_count, deleted, forced, version`
+
+func scanBookState(row pgx.Row) (*domain.BookState, error) {
+	var st domain.BookState
+	var cover []byte
+	err := row.Scan(&st.Book.ID, &st.Book.TenantID, &st.Book.Title, &st.Book.Author, &st.Book.CreatedAt,
+		&cover, &st.RatingSum, &st.Book.RatingCount, &st.Deleted, &st.Forced, &st.Version)
+	if err != nil {
+		return nil, err
+	}
+	st.Book.CreatedAt = st.Book.CreatedAt.UTC().Truncate(time.Second)
+	st.Book.RatingAvg = domain.RatingAvg(st.RatingSum, st.Book.RatingCount)
+	if cover != nil {
+		var c streamCover
+		if err := json.Unmarshal(cover, &c); err != nil {
+			return nil, err
+		}
+		dc := domain.Cover(c)
+		st.Cover = &dc
+		st.Book.CoverURL = domain.CoverURL(st.Book.ID, c.ETag)
+	}
+	return &st, nil
+}
+
+func (s *BookStreamStore) GetBookState(ctx context.Context, id int64) (*domain.BookState, error) {
+	var st *domain.BookState
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) (err error) {
+		st, err = scanBookState(q.QueryRow(ctx,
+			`SELECT `+bookStateColumns+` FROM book_stream_states WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return st, nil
+}
+
+func (s *BookStreamStore) GetBookStates(ctx context.Context, ids []int64) (map[int64]*domain.BookState, error) {
+	states := make(map[int64]*domain.BookState, len(ids))
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+bookStateColumns+` FROM book_stream_states WHERE tenant_id = $1 AND id = ANY($2)`,
+			tenant, ids,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			st, err := scanBookState(rows)
+			if err != nil {
+				return err
+			}
+			states[st.Book.ID] = st
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// ListBookStates filters on the author only: nothing in a book's stream
+// files it under a category or tags it, so those filters match nothing.
+func (s *BookStreamStore) ListBookStates(ctx context.Context, lq domain.ListBooksQuery) ([]*domain.Book, error) {
+	books := []*domain.Book{}
+	if lq.Category != "" || len(lq.Tags) > 0 {
+		return books, nil
+	}
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+bookStateColumns+`
+             FROM book_stream_states
+             WHERE tenant_id = $1 AND NOT deleted AND ($2 = '' OR author = $2)
+             ORDER BY id
+             LIMIT $3 OFFSET $4`,
+			tenant, lq.Author, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			st, err := scanBookState(rows)
+			if err != nil {
+				return err
+			}
+			books = append(books, &st.Book)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (s *BookStreamStore) FindBookByKey(ctx context.Context, key string, except int64) (int64, error) {
+	var id int64
+	err := scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		return q.QueryRow(ctx,
+			`SELECT coalesce(min(id), 0)
+             FROM book_stream_states
+             WHERE tenant_id = $1 AND norm_key = $2 AND id <> $3 AND NOT deleted AND NOT forced`,
+			tenant, key, except,
+		).Scan(&id)
+	})
+	return id, err
+}
+
+func (s *BookStreamStore) ResetBookStates(ctx context.Context) error {
+	return scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx, `DELETE FROM book_stream_states WHERE tenant_id = $1`, tenant)
+		return err
+	})
+}
+
+// LockBookStates takes a transaction-level advisory lock on the tenant's
+// read model. Outside a Transactor transaction it is let go at once.
+func (s *BookStreamStore) LockBookStates(ctx context.Context, exclusive bool) error {
+	lock := `SELECT pg_advisory_xact_lock_shared(hashtext('book_stream_states:' || $1))`
+	if exclusive {
+		lock = `SELECT pg_advisory_xact_lock(hashtext('book_stream_states:' || $1))`
+	}
+	return scoped(ctx, s.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx, lock, tenant)
+		return err
+	})
+}
+
+func queryStreamEvents(ctx context.Context, q DBTX, sql string, args ...any) ([]domain.BookStreamEvent, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.BookStreamEvent
+	for rows.Next() {
+		var e domain.BookStreamEvent
+		if err := rows.Scan(&e.Position, &e.TenantID, &e.BookID, &e.Version, &e.Kind, &e.Data, &e.At); err != nil {
+			return nil, err
+		}
+		e.At = e.At.UTC()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
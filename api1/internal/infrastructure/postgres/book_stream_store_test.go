@@ -0,0 +1,159 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/eventsource"
+	"unit-test-demo/api1/internal/infrastructure/booktest"
+	"unit-test-demo/api1/internal/infrastructure/postgres"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// testDB connects to the database in TEST_DATABASE_URL and brings it up to
+// the latest migration. Without the variable the test is skipped.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob("../../../migrations/*.sql")
+	require.NoError(t, err)
+	slices.Sort(files)
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		require.NoError(t, err)
+		_, err = pool.Exec(ctx, string(sql))
+		require.NoError(t, err, f)
+	}
+	return pool
+}
+
+var tenantSeq atomic.Int64
+
+// newTenant creates a tenant of its own for the test and returns a
+// context for it.
+func newTenant(t *testing.T, pool *pgxpool.Pool) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	tenant := fmt.Sprintf("test-%d-%d", time.Now().UnixNano(), tenantSeq.Add(1))
+	_, err := postgres.NewTenantRepository(pool).Create(ctx, domain.CreateTenantInput{ID: tenant, Name: tenant})
+	require.NoError(t, err)
+	return tenancy.WithTenant(ctx, tenant)
+}
+
+// testTenant is testDB with a tenant of its own.
+func testTenant(t *testing.T) (*pgxpool.Pool, context.Context) {
+	t.Helper()
+	pool := testDB(t)
+	return pool, newTenant(t, pool)
+}
+
+func TestBookStreamStore_BookRepository(t *testing.T) {
+	booktest.RunShared(t, func(t *testing.T) (domain.BookRepository, context.Context, context.Context) {
+		pool := testDB(t)
+		store := postgres.NewBookStreamStore(pool)
+		repo := eventsource.NewBookRepository(store, store, postgres.NewTransactor(pool), eventsource.Config{})
+		return repo, newTenant(t, pool), newTenant(t, pool)
+	})
+}
+
+func TestBookStreamStore_AppendChecksVersion(t *testing.T) {
+	pool, ctx := testTenant(t)
+	store := postgres.NewBookStreamStore(pool)
+	id, err := store.NextBookID(ctx)
+	require.NoError(t, err)
+	event := func(version int) []domain.BookStreamEvent {
+		return []domain.BookStreamEvent{{BookID: id, Version: version, Kind: domain.BookEventCreated, Data: []byte(`{}`), At: time.Now()}}
+	}
+
+	require.NoError(t, store.AppendBookEvents(ctx, id, 0, event(1)))
+	err = store.AppendBookEvents(ctx, id, 0, event(1))
+
+	assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	events, err := store.LoadBookEvents(ctx, id, 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestBookStreamStore_PutBookStateKeepsNewest(t *testing.T) {
+	pool, ctx := testTenant(t)
+	store := postgres.NewBookStreamStore(pool)
+	id, err := store.NextBookID(ctx)
+	require.NoError(t, err)
+	state := func(title string, version int) domain.BookState {
+		return domain.BookState{
+			Book:    domain.Book{ID: id, Title: title, Author: "Frank Herbert", CreatedAt: time.Now()},
+			Version: version,
+		}
+	}
+
+	require.NoError(t, store.PutBookState(ctx, state("Dune Messiah", 2)))
+	// A projection that read version 1 lands late.
+	require.NoError(t, store.PutBookState(ctx, state("Dune", 1)))
+
+	got, err := store.GetBookState(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", got.Book.Title)
+	assert.Equal(t, 2, got.Version)
+}
+
+func TestBookStreamStore_RebuildWaitsForWrites(t *testing.T) {
+	pool, ctx := testTenant(t)
+	store := postgres.NewBookStreamStore(pool)
+	tx := postgres.NewTransactor(pool)
+	repo := eventsource.NewBookRepository(store, store, tx, eventsource.Config{})
+	dune, err := repo.Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	// A write is under way when the rebuild starts.
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	wrote := make(chan error, 1)
+	go func() {
+		wrote <- tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := store.LockBookStates(ctx, false); err != nil {
+				return err
+			}
+			close(writing)
+			<-release
+			return nil
+		})
+	}()
+	<-writing
+	rebuilt := make(chan error, 1)
+	go func() {
+		_, err := repo.Rebuild(ctx)
+		rebuilt <- err
+	}()
+
+	select {
+	case err := <-rebuilt:
+		t.Fatalf("rebuild did not wait for the write: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-wrote)
+	require.NoError(t, <-rebuilt)
+
+	got, err := repo.GetByID(ctx, dune.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", got.Title)
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+
+	"unit-test-demo/api1/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BookChangesSince reads the change_seq kept by the trigger of migrations
+// 0015 and 0022, which numbers a write as its transaction commits. Books
+// deleted before 0015 have no number and are left out: no client can hold
+// a cursor from before their deletion.
+func (r *BookRepository) BookChangesSince(ctx context.Context, after domain.BookSyncPosition, limit int) ([]*domain.BookSyncChange, int64, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	changes := []*domain.BookSyncChange{}
+	var head int64
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		// Read the head first: rows committed after it only make the page
+		// newer than the head it is returned with.
+		err := q.QueryRow(ctx,
+			`SELECT coalesce((SELECT seq FROM book_change_seqs WHERE tenant_id = $1), 0)`,
+			tenant,
+		).Scan(&head)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.Query(ctx,
+			`SELECT `+bookColumns+`, change_seq, deleted_at IS NOT NULL
+             FROM books
+             WHERE tenant_id = $1 AND (change_seq, id) > ($2, $3)
+               AND (deleted_at IS NULL OR change_seq > 0)
+             ORDER BY change_seq, id
+             LIMIT $4`,
+			tenant, after.Seq, after.BookID, limit,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c domain.BookSyncChange
+			b, err := scanBook(trailing{rows, []any{&c.Position.Seq, &c.Deleted}})
+			if err != nil {
+				return err
+			}
+			c.Book = *b
+			c.Position.BookID = b.ID
+			changes = append(changes, &c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return changes, head, nil
+}
+
+// trailing scans the columns a query selects after bookColumns into extra,
+// so scanBook can read the rest of the row.
+type trailing struct {
+	pgx.Row
+	extra []any
+}
+
+func (t trailing) Scan(dest ...any) error {
+	return t.Row.Scan(append(dest, t.extra...)...)
+}
@@ -0,0 +1,381 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// The domain.CategoryRepository half of BookRepository. Categories store a
+// materialized path (see migration 0012), so subtrees are found with a
+// prefix match instead of a recursive query.
+
+// bookFilter narrows books b to the live books of tenant $1 that pass the
+// filters of a domain.ListBooksQuery: $2 author, $3 category slug, $4
+// tags and $5 whether all of the tags must match.
+const bookFilter = `b.tenant_id = $1 AND b.deleted_at IS NULL
+               AND ($2 = '' OR b.author = $2)
+               AND ($3 = '' OR EXISTS (
+                   SELECT 1
+                   FROM book_categories bc
+                   JOIN categories c ON c.id = bc.category_id
+                   JOIN categories f ON f.tenant_id = $1 AND f.slug = $3
+                   WHERE bc.book_id = b.id AND c.path LIKE f.path || '%'
+               ))
+               AND (coalesce(cardinality($4::text[]), 0) = 0 OR (
+                   SELECT count(*) FROM book_tags t WHERE t.book_id = b.id AND t.tag = ANY($4)
+               ) >= CASE WHEN $5 THEN cardinality($4::text[]) ELSE 1 END)`
+
+const categoryColumns = `c.id, c.tenant_id, c.parent_id, c.slug, c.name, c.path, c.created_at`
+
+func scanCategory(row pgx.Row) (*domain.Category, error) {
+	var c domain.Category
+	if err := row.Scan(&c.ID, &c.TenantID, &c.ParentID, &c.Slug, &c.Name, &c.Path, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = c.CreatedAt.UTC().Truncate(time.Second)
+	return &c, nil
+}
+
+func (r *BookRepository) CreateCategory(ctx context.Context, in domain.CategoryInput) (*domain.Category, error) {
+	var c *domain.Category
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		parentPath, err := lockTree(ctx, q, tenant, in.ParentID)
+		if err != nil {
+			return err
+		}
+		var id int64
+		err = q.QueryRow(ctx,
+			`INSERT INTO categories (tenant_id, parent_id, slug, name) VALUES ($1, $2, $3, $4) RETURNING id`,
+			tenant, in.ParentID, in.Slug, in.Name,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+		c, err = scanCategory(q.QueryRow(ctx,
+			`UPDATE categories c SET path = $2 WHERE c.id = $1 RETURNING `+categoryColumns,
+			id, domain.CategoryPath(parentPath, id),
+		))
+		return err
+	})
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	return c, nil
+}
+
+func (r *BookRepository) GetCategory(ctx context.Context, id int64) (*domain.Category, error) {
+	var c *domain.Category
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		c, err = scanCategory(q.QueryRow(ctx,
+			`SELECT `+categoryColumns+` FROM categories c WHERE c.tenant_id = $1 AND c.id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return c, nil
+}
+
+func (r *BookRepository) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	cats := []*domain.Category{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+categoryColumns+` FROM categories c WHERE c.tenant_id = $1 ORDER BY c.path`,
+			tenant,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			c, err := scanCategory(rows)
+			if err != nil {
+				return err
+			}
+			cats = append(cats, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cats, nil
+}
+
+func (r *BookRepository) UpdateCategory(ctx context.Context, id int64, in domain.CategoryInput) (*domain.Category, error) {
+	var c *domain.Category
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		parentPath, err := lockTree(ctx, q, tenant, in.ParentID)
+		if err != nil {
+			return err
+		}
+		var oldPath string
+		err = q.QueryRow(ctx,
+			`SELECT path FROM categories WHERE tenant_id = $1 AND id = $2`, tenant, id,
+		).Scan(&oldPath)
+		if err != nil {
+			return notFound(err)
+		}
+		if strings.HasPrefix(parentPath, oldPath) {
+			return domain.ErrCategoryCycle
+		}
+
+		newPath := domain.CategoryPath(parentPath, id)
+		if newPath != oldPath {
+			_, err := q.Exec(ctx,
+				`UPDATE categories SET path = $3 || substr(path, length($2) + 1)
+                 WHERE tenant_id = $1 AND path LIKE $2 || '%'`,
+				tenant, oldPath, newPath,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		c, err = scanCategory(q.QueryRow(ctx,
+			`UPDATE categories c SET parent_id = $2, slug = $3, name = $4
+             WHERE c.id = $1
+             RETURNING `+categoryColumns,
+			id, in.ParentID, in.Slug, in.Name,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	return c, nil
+}
+
+func (r *BookRepository) DeleteCategory(ctx context.Context, id int64) error {
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		if _, err := lockTree(ctx, q, tenant, nil); err != nil {
+			return err
+		}
+		var hasChildren bool
+		err := q.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = c.id)
+             FROM categories c WHERE c.tenant_id = $1 AND c.id = $2`,
+			tenant, id,
+		).Scan(&hasChildren)
+		if err != nil {
+			return notFound(err)
+		}
+		if hasChildren {
+			return domain.ErrConflict
+		}
+		_, err = q.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+		return err
+	})
+	return err
+}
+
+func (r *BookRepository) GetBookTaxonomy(ctx context.Context, bookID int64) (*domain.BookTaxonomy, error) {
+	var t *domain.BookTaxonomy
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		if err := liveBook(ctx, q, tenant, bookID); err != nil {
+			return err
+		}
+		var err error
+		t, err = getTaxonomy(ctx, q, bookID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *BookRepository) SetBookTaxonomy(ctx context.Context, bookID int64, in domain.BookTaxonomyInput) (*domain.BookTaxonomy, error) {
+	var t *domain.BookTaxonomy
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		if err := liveBook(ctx, q, tenant, bookID); err != nil {
+			return err
+		}
+
+		if _, err := q.Exec(ctx, `DELETE FROM book_categories WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+		ids := slices.Compact(slices.Sorted(slices.Values(in.CategoryIDs)))
+		tag, err := q.Exec(ctx,
+			`INSERT INTO book_categories (book_id, category_id, tenant_id)
+             SELECT $2, id, tenant_id FROM categories WHERE tenant_id = $1 AND id = ANY($3)`,
+			tenant, bookID, ids,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() != int64(len(ids)) {
+			return domain.ErrNotFound
+		}
+
+		if _, err := q.Exec(ctx, `DELETE FROM book_tags WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+		_, err = q.Exec(ctx,
+			`INSERT INTO book_tags (book_id, tag, tenant_id)
+             SELECT DISTINCT $2::bigint, unnest($3::text[]), $1`,
+			tenant, bookID, in.Tags,
+		)
+		if err != nil {
+			return err
+		}
+
+		t, err = getTaxonomy(ctx, q, bookID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *BookRepository) Facets(ctx context.Context, lq domain.ListBooksQuery) (*domain.Facets, error) {
+	f := &domain.Facets{Categories: []*domain.CategoryFacet{}, Tags: []*domain.TagFacet{}}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		args := []any{tenant, lq.Author, lq.Category, lq.Tags, lq.AllTags}
+		matched := `WITH matched AS (SELECT b.id FROM books b WHERE ` + bookFilter + `) `
+
+		if err := q.QueryRow(ctx, matched+`SELECT count(*) FROM matched`, args...).Scan(&f.Total); err != nil {
+			return err
+		}
+
+		// d is every category at or below c, so a book filed anywhere in
+		// c's subtree counts once towards c.
+		rows, err := q.Query(ctx, matched+`
+             SELECT c.id, c.parent_id, c.slug, c.name, count(DISTINCT m.id)
+             FROM categories c
+             JOIN categories d ON d.tenant_id = c.tenant_id AND d.path LIKE c.path || '%'
+             JOIN book_categories bc ON bc.category_id = d.id
+             JOIN matched m ON m.id = bc.book_id
+             WHERE c.tenant_id = $1
+             GROUP BY c.id
+             ORDER BY c.path`,
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var cf domain.CategoryFacet
+			if err := rows.Scan(&cf.ID, &cf.ParentID, &cf.Slug, &cf.Name, &cf.Count); err != nil {
+				rows.Close()
+				return err
+			}
+			f.Categories = append(f.Categories, &cf)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = q.Query(ctx, matched+`
+             SELECT t.tag, count(*)
+             FROM book_tags t JOIN matched m ON m.id = t.book_id
+             GROUP BY t.tag
+             ORDER BY count(*) DESC, t.tag`,
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var tf domain.TagFacet
+			if err := rows.Scan(&tf.Tag, &tf.Count); err != nil {
+				return err
+			}
+			f.Tags = append(f.Tags, &tf)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// lockTree serializes changes to the category tree of tenant, so two moves
+// cannot each pass the cycle check against the other, and returns the path
+// of parent ("" for none).
+func lockTree(ctx context.Context, q DBTX, tenant string, parent *int64) (string, error) {
+	if _, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('categories:' || $1))`, tenant); err != nil {
+		return "", err
+	}
+	if parent == nil {
+		return "", nil
+	}
+	var path string
+	err := q.QueryRow(ctx,
+		`SELECT path FROM categories WHERE tenant_id = $1 AND id = $2`, tenant, *parent,
+	).Scan(&path)
+	if err != nil {
+		return "", notFound(err)
+	}
+	return path, nil
+}
+
+// liveBook fails with domain.ErrNotFound unless book id of tenant exists
+// and is not deleted.
+func liveBook(ctx context.Context, q DBTX, tenant string, id int64) error {
+	var found int64
+	err := q.QueryRow(ctx,
+		`SELECT id FROM books WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL`, tenant, id,
+	).Scan(&found)
+	return notFound(err)
+}
+
+func getTaxonomy(ctx context.Context, q DBTX, bookID int64) (*domain.BookTaxonomy, error) {
+	t := &domain.BookTaxonomy{BookID: bookID, Categories: []*domain.Category{}, Tags: []string{}}
+	rows, err := q.Query(ctx,
+		`SELECT `+categoryColumns+`
+         FROM categories c JOIN book_categories bc ON bc.category_id = c.id
+         WHERE bc.book_id = $1
+         ORDER BY c.path`,
+		bookID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t.Categories = append(t.Categories, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT tag FROM book_tags WHERE book_id = $1 ORDER BY tag`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	t.Tags = append(t.Tags, tags...)
+	return t, nil
+}
+
+// categoryError maps a taken slug to domain.ErrConflict.
+func categoryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return domain.ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+const (
+	// changeChannel is the NOTIFY channel book changes travel on.
+	changeChannel = "book_changes"
+	// maxNotifyPayload keeps a notification under the 8000 byte limit of
+	// Postgres.
+	maxNotifyPayload = 7900
+	// bridgeRetry is how long Run waits before listening again.
+	bridgeRetry = 5 * time.Second
+)
+
+// ChangeBridge is a domain.BookChangePublisher that sends changes through
+// Postgres NOTIFY instead of handing them over directly. Run listens on
+// every instance, the sending one included, and passes what arrives to
+// local, so all instances stream the same changes in commit order.
+type ChangeBridge struct {
+	pool  *pgxpool.Pool
+	local domain.BookChangePublisher
+}
+
+func NewChangeBridge(pool *pgxpool.Pool, local domain.BookChangePublisher) *ChangeBridge {
+	return &ChangeBridge{pool: pool, local: local}
+}
+
+// PublishBookChange leaves the book out of a change too large to notify;
+// subscribers still learn which book changed.
+func (b *ChangeBridge) PublishBookChange(ctx context.Context, c domain.BookChange) error {
+	payload, err := json.Marshal(c)
+	if err == nil && len(payload) > maxNotifyPayload {
+		c.Book = nil
+		payload, err = json.Marshal(c)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, changeChannel, string(payload))
+	return err
+}
+
+// Run listens until ctx is done, starting over on a new connection when
+// one is lost. Changes notified while no connection listens do not reach
+// this instance.
+func (b *ChangeBridge) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("change bridge: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(bridgeRetry):
+		}
+	}
+}
+
+// listen takes a connection out of the pool for good, since one that has
+// run LISTEN must not serve anything else, and relays its notifications.
+func (b *ChangeBridge) listen(ctx context.Context) error {
+	pc, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pc.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+changeChannel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var c domain.BookChange
+		if err := json.Unmarshal([]byte(n.Payload), &c); err != nil {
+			log.Printf("change bridge: bad payload: %v", err)
+			continue
+		}
+		_ = b.local.PublishBookChange(ctx, c)
+	}
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// The domain.DuplicateRepository half of BookRepository, and the key
+// upkeep behind the unique index of migration 0013.
+
+func (r *BookRepository) ListDuplicates(ctx context.Context, limit, offset int) ([]*domain.DuplicateGroup, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	groups := []*domain.DuplicateGroup{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`WITH keys AS (
+                 SELECT norm_key FROM books
+                 WHERE tenant_id = $1 AND deleted_at IS NULL AND norm_key IS NOT NULL
+                 GROUP BY norm_key
+                 HAVING count(*) > 1
+                 ORDER BY norm_key
+                 LIMIT $2 OFFSET $3
+             )
+             SELECT b.norm_key, `+bookColumns+`
+             FROM books b JOIN keys k USING (norm_key)
+             WHERE b.tenant_id = $1 AND b.deleted_at IS NULL
+             ORDER BY b.norm_key, b.id`,
+			tenant, limit, offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var key string
+			b, err := scanBook(keyedRow{Row: rows, key: &key})
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 || groups[len(groups)-1].Key != key {
+				groups = append(groups, &domain.DuplicateGroup{Key: key})
+			}
+			g := groups[len(groups)-1]
+			g.Books = append(g.Books, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// BackfillKeys fills in norm_key for the books of the tenant in ctx that
+// predate it, and reports how many it filled. A live book whose key is
+// already taken is marked allow_duplicate instead of failing, so it shows
+// up in ListDuplicates for someone to sort out.
+func (r *BookRepository) BackfillKeys(ctx context.Context) (int, error) {
+	n := 0
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT id, title, author FROM books WHERE tenant_id = $1 AND norm_key IS NULL ORDER BY id FOR UPDATE`,
+			tenant,
+		)
+		if err != nil {
+			return err
+		}
+		type pending struct {
+			id            int64
+			title, author string
+		}
+		todo, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pending, error) {
+			var p pending
+			err := row.Scan(&p.id, &p.title, &p.author)
+			return p, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range todo {
+			key := domain.BookKey(p.title, p.author)
+			taken := false
+			if err := duplicateOf(ctx, q, tenant, p.id, key); errors.Is(err, domain.ErrDuplicate) {
+				taken = true
+			} else if err != nil {
+				return err
+			}
+			_, err := q.Exec(ctx,
+				`UPDATE books SET norm_key = $2, allow_duplicate = allow_duplicate OR ($3 AND deleted_at IS NULL)
+                 WHERE id = $1`,
+				p.id, key, taken,
+			)
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// duplicateOf fails with a *domain.DuplicateError if a live book of tenant
+// other than id has key and would collide with it in the unique index.
+// An id of 0 stands for a book yet to be created.
+func duplicateOf(ctx context.Context, q DBTX, tenant string, id int64, key string) error {
+	if key == "" {
+		return nil
+	}
+	var existing int64
+	err := q.QueryRow(ctx,
+		`SELECT o.id FROM books o
+         WHERE o.tenant_id = $1 AND o.norm_key = $3 AND o.id <> $2
+           AND o.deleted_at IS NULL AND NOT o.allow_duplicate
+           AND NOT EXISTS (SELECT 1 FROM books b WHERE b.id = $2 AND b.allow_duplicate)
+         ORDER BY o.id
+         LIMIT 1`,
+		tenant, id, key,
+	).Scan(&existing)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &domain.DuplicateError{ExistingID: existing}
+}
+
+// duplicateErr turns a violation of the unique key index, which a
+// concurrent write can still cause after duplicateOf passed, into a
+// *domain.DuplicateError. The other book is not known at that point.
+func duplicateErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "books_norm_key_unique" {
+		return &domain.DuplicateError{}
+	}
+	return err
+}
+
+// keyedRow reads a leading norm_key column into key before handing the
+// rest of the row to the scanner it is passed to.
+type keyedRow struct {
+	pgx.Row
+	key *string
+}
+
+func (r keyedRow) Scan(dest ...any) error {
+	return r.Row.Scan(append([]any{r.key}, dest...)...)
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// The domain.HoldRepository half of LoanRepository. To stay clear of
+// deadlocks with Checkout, every method here locks copies before holds.
+
+const holdColumns = `h.id, h.tenant_id, h.book_id, h.member_id, h.status, h.copy_id,
+                     h.placed_at, h.ready_at, h.pickup_by, h.closed_at`
+
+// holdPosition counts the queued holds of the same book up to h.
+const holdPosition = `CASE WHEN h.status = 'queued' THEN (
+                         SELECT count(*) FROM holds o
+                         WHERE o.book_id = h.book_id AND o.status = 'queued' AND o.id <= h.id
+                     ) ELSE 0 END`
+
+func scanHold(row pgx.Row) (*domain.Hold, error) {
+	var h domain.Hold
+	err := row.Scan(&h.ID, &h.TenantID, &h.BookID, &h.MemberID, &h.Status, &h.CopyID,
+		&h.PlacedAt, &h.ReadyAt, &h.PickupBy, &h.ClosedAt, &h.Position)
+	if err != nil {
+		return nil, err
+	}
+	h.PlacedAt = h.PlacedAt.UTC()
+	for _, t := range []**time.Time{&h.ReadyAt, &h.PickupBy, &h.ClosedAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+	return &h, nil
+}
+
+func (r *LoanRepository) PlaceHold(ctx context.Context, bookID int64, memberID string, at time.Time) (*domain.Hold, error) {
+	var h *domain.Hold
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var id int64
+		err := q.QueryRow(ctx,
+			`INSERT INTO holds (tenant_id, book_id, member_id, placed_at)
+             VALUES ($1, $2, $3, $4)
+             RETURNING id`,
+			tenant, bookID, memberID, at,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+		h, err = getHold(ctx, q, tenant, bookID, id)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (r *LoanRepository) GetHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	var h *domain.Hold
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		h, err = getHold(ctx, q, tenant, bookID, id)
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return h, nil
+}
+
+func (r *LoanRepository) ListHolds(ctx context.Context, hq domain.ListHoldsQuery) ([]*domain.Hold, error) {
+	limit := hq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	holds := []*domain.Hold{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+holdColumns+`, `+holdPosition+`
+             FROM holds h
+             WHERE h.tenant_id = $1 AND h.book_id = $2
+               AND ($3 = '' OR h.member_id = $3)
+               AND (NOT $4 OR h.status IN ('queued', 'ready'))
+             ORDER BY h.id
+             LIMIT $5 OFFSET $6`,
+			tenant, hq.BookID, hq.MemberID, hq.ActiveOnly, limit, hq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			h, err := scanHold(rows)
+			if err != nil {
+				return err
+			}
+			holds = append(holds, h)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *LoanRepository) CancelHold(ctx context.Context, bookID, id int64, at time.Time) (*domain.Hold, error) {
+	var h *domain.Hold
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var copyID *int64
+		err := q.QueryRow(ctx,
+			`SELECT copy_id FROM holds WHERE tenant_id = $1 AND book_id = $2 AND id = $3`,
+			tenant, bookID, id,
+		).Scan(&copyID)
+		if err != nil {
+			return notFound(err)
+		}
+		if copyID != nil {
+			if _, err := q.Exec(ctx, `SELECT 1 FROM copies WHERE id = $1 FOR UPDATE`, *copyID); err != nil {
+				return err
+			}
+		}
+
+		var status domain.HoldStatus
+		if err := q.QueryRow(ctx,
+			`SELECT status, copy_id FROM holds WHERE id = $1 FOR UPDATE`, id,
+		).Scan(&status, &copyID); err != nil {
+			return err
+		}
+		if status != domain.HoldQueued && status != domain.HoldReady {
+			return domain.ErrHoldClosed
+		}
+		if status == domain.HoldReady && copyID != nil {
+			if _, err := q.Exec(ctx,
+				`UPDATE copies SET status = 'available' WHERE id = $1 AND status = 'on_hold'`, *copyID,
+			); err != nil {
+				return err
+			}
+		}
+		h, err = scanHold(q.QueryRow(ctx,
+			`UPDATE holds h SET status = 'cancelled', closed_at = $2
+             WHERE h.id = $1
+             RETURNING `+holdColumns+`, 0`,
+			id, at,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// AllocateCopies locks the book's available copies and then its oldest
+// queued holds, and pairs them up in order.
+func (r *LoanRepository) AllocateCopies(ctx context.Context, bookID int64, at, pickupBy time.Time) ([]*domain.Hold, error) {
+	ready := []*domain.Hold{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		copies, err := collectIDs(q.Query(ctx,
+			`SELECT id FROM copies
+             WHERE tenant_id = $1 AND book_id = $2 AND status = 'available'
+             ORDER BY id
+             FOR UPDATE`,
+			tenant, bookID,
+		))
+		if err != nil || len(copies) == 0 {
+			return err
+		}
+		holds, err := collectIDs(q.Query(ctx,
+			`SELECT id FROM holds
+             WHERE tenant_id = $1 AND book_id = $2 AND status = 'queued'
+             ORDER BY id
+             LIMIT $3
+             FOR UPDATE`,
+			tenant, bookID, len(copies),
+		))
+		if err != nil {
+			return err
+		}
+
+		for i, holdID := range holds {
+			if _, err := q.Exec(ctx, `UPDATE copies SET status = 'on_hold' WHERE id = $1`, copies[i]); err != nil {
+				return err
+			}
+			h, err := scanHold(q.QueryRow(ctx,
+				`UPDATE holds h
+                 SET status = 'ready', copy_id = $2, ready_at = $3, pickup_by = $4
+                 WHERE h.id = $1
+                 RETURNING `+holdColumns+`, 0`,
+				holdID, copies[i], at, pickupBy,
+			))
+			if err != nil {
+				return err
+			}
+			ready = append(ready, h)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ready, nil
+}
+
+func (r *LoanRepository) ExpireHolds(ctx context.Context, now time.Time) ([]*domain.Hold, error) {
+	expired := []*domain.Hold{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		copies, err := collectIDs(q.Query(ctx,
+			`SELECT c.id FROM copies c
+             JOIN holds h ON h.copy_id = c.id
+             WHERE h.tenant_id = $1 AND h.status = 'ready' AND h.pickup_by < $2
+             ORDER BY c.id
+             FOR UPDATE OF c`,
+			tenant, now,
+		))
+		if err != nil || len(copies) == 0 {
+			return err
+		}
+
+		rows, err := q.Query(ctx,
+			`UPDATE holds h SET status = 'expired', closed_at = $3
+             WHERE h.tenant_id = $1 AND h.status = 'ready' AND h.copy_id = ANY($2)
+             RETURNING `+holdColumns+`, 0`,
+			tenant, copies, now,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			h, err := scanHold(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			expired = append(expired, h)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = q.Exec(ctx,
+			`UPDATE copies SET status = 'available' WHERE id = ANY($1) AND status = 'on_hold'`, copies)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+func getHold(ctx context.Context, q DBTX, tenant string, bookID, id int64) (*domain.Hold, error) {
+	return scanHold(q.QueryRow(ctx,
+		`SELECT `+holdColumns+`, `+holdPosition+`
+         FROM holds h
+         WHERE h.tenant_id = $1 AND h.book_id = $2 AND h.id = $3`,
+		tenant, bookID, id,
+	))
+}
+
+// collectIDs reads a single bigint column from rows.
+func collectIDs(rows pgx.Rows, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// ImportRepository is the Postgres domain.ImportRepository. Claim uses
+// SKIP LOCKED so that several workers can poll one tenant side by side.
+type ImportRepository struct {
+	db DB
+}
+
+func NewImportRepository(db DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+const importColumns = `id, tenant_id, format, status, processed, created, failed, error, cancel_requested,
+                       created_by, created_at, started_at, finished_at, blob_key`
+
+func scanImport(row pgx.Row) (*domain.ImportJob, error) {
+	var j domain.ImportJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Format, &j.Status, &j.Processed, &j.Created, &j.Failed, &j.Error,
+		&j.CancelRequested, &j.CreatedBy, &j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.BlobKey)
+	if err != nil {
+		return nil, err
+	}
+	j.CreatedAt = j.CreatedAt.UTC()
+	for _, t := range []**time.Time{&j.StartedAt, &j.FinishedAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+	return &j, nil
+}
+
+func (r *ImportRepository) Create(ctx context.Context, job domain.ImportJob) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		j, err = scanImport(q.QueryRow(ctx,
+			`INSERT INTO import_jobs (tenant_id, format, blob_key, created_by)
+             VALUES ($1, $2, $3, $4)
+             RETURNING `+importColumns,
+			tenant, job.Format, job.BlobKey, job.CreatedBy,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) Get(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		j, err = scanImport(q.QueryRow(ctx,
+			`SELECT `+importColumns+` FROM import_jobs WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		j, err = scanImport(q.QueryRow(ctx,
+			`UPDATE import_jobs
+             SET status = 'running', started_at = coalesce(started_at, $2), heartbeat_at = $2
+             WHERE id = (
+                 SELECT id FROM import_jobs
+                 WHERE tenant_id = $1
+                   AND (status = 'queued' OR (status = 'running' AND heartbeat_at < $3))
+                 ORDER BY id
+                 LIMIT 1
+                 FOR UPDATE SKIP LOCKED
+             )
+             RETURNING `+importColumns,
+			tenant, now, staleBefore,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) Progress(ctx context.Context, id int64, p domain.ImportProgress, errs []domain.ImportRowError, now time.Time) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		if len(errs) > 0 {
+			rowNos := make([]int, len(errs))
+			titles := make([]string, len(errs))
+			authors := make([]string, len(errs))
+			messages := make([]string, len(errs))
+			for i, e := range errs {
+				rowNos[i], titles[i], authors[i], messages[i] = e.Row, e.Title, e.Author, e.Message
+			}
+			if _, err := q.Exec(ctx,
+				`INSERT INTO import_errors (job_id, tenant_id, row_no, title, author, message)
+                 SELECT $2, $1, e.* FROM unnest($3::int[], $4::text[], $5::text[], $6::text[]) AS e`,
+				tenant, id, rowNos, titles, authors, messages,
+			); err != nil {
+				return err
+			}
+		}
+		j, err = scanImport(q.QueryRow(ctx,
+			`UPDATE import_jobs
+             SET processed = processed + $3, created = created + $4, failed = failed + $5, heartbeat_at = $6
+             WHERE tenant_id = $1 AND id = $2 AND status = 'running'
+             RETURNING `+importColumns,
+			tenant, id, p.Processed, p.Created, p.Failed, now,
+		))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrImportFinished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) Finish(ctx context.Context, id int64, status domain.ImportStatus, reason string, now time.Time) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		j, err = scanImport(q.QueryRow(ctx,
+			`UPDATE import_jobs SET status = $3, error = $4, finished_at = $5
+             WHERE tenant_id = $1 AND id = $2 AND status IN ('queued', 'running')
+             RETURNING `+importColumns,
+			tenant, id, status, reason, now,
+		))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrImportFinished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) RequestCancel(ctx context.Context, id int64, now time.Time) (*domain.ImportJob, error) {
+	var j *domain.ImportJob
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var status domain.ImportStatus
+		err := q.QueryRow(ctx,
+			`SELECT status FROM import_jobs WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+			tenant, id,
+		).Scan(&status)
+		if err != nil {
+			return notFound(err)
+		}
+		switch status {
+		case domain.ImportQueued:
+			j, err = scanImport(q.QueryRow(ctx,
+				`UPDATE import_jobs SET status = 'cancelled', cancel_requested = true, finished_at = $2
+                 WHERE id = $1
+                 RETURNING `+importColumns,
+				id, now,
+			))
+		case domain.ImportRunning:
+			j, err = scanImport(q.QueryRow(ctx,
+				`UPDATE import_jobs SET cancel_requested = true WHERE id = $1 RETURNING `+importColumns,
+				id,
+			))
+		default:
+			return domain.ErrImportFinished
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (r *ImportRepository) ListErrors(ctx context.Context, id int64, limit, offset int) ([]*domain.ImportRowError, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	errs := []*domain.ImportRowError{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT row_no, title, author, message
+             FROM import_errors
+             WHERE tenant_id = $1 AND job_id = $2
+             ORDER BY row_no
+             LIMIT $3 OFFSET $4`,
+			tenant, id, limit, offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e domain.ImportRowError
+			if err := rows.Scan(&e.Row, &e.Title, &e.Author, &e.Message); err != nil {
+				return err
+			}
+			errs = append(errs, &e)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
@@ -0,0 +1,321 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// LoanRepository is the Postgres domain.LoanRepository and
+// domain.HoldRepository. Every write that changes a copy's status locks
+// the copy row with SELECT ... FOR UPDATE first, so concurrent requests for
+// one copy queue up instead of racing; partial unique indexes on open
+// loans and ready holds back that up.
+type LoanRepository struct {
+	db DB
+}
+
+func NewLoanRepository(db DB) *LoanRepository {
+	return &LoanRepository{db: db}
+}
+
+const copyColumns = `id, book_id, tenant_id, barcode, status, created_at`
+
+func scanCopy(row pgx.Row) (*domain.Copy, error) {
+	var c domain.Copy
+	if err := row.Scan(&c.ID, &c.BookID, &c.TenantID, &c.Barcode, &c.Status, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = c.CreatedAt.UTC().Truncate(time.Second)
+	return &c, nil
+}
+
+const loanColumns = `id, tenant_id, copy_id, book_id, member_id, member_type, status, renewals,
+                     checked_out_at, due_at, closed_at`
+
+func scanLoan(row pgx.Row) (*domain.Loan, error) {
+	var l domain.Loan
+	err := row.Scan(&l.ID, &l.TenantID, &l.CopyID, &l.BookID, &l.MemberID, &l.MemberType, &l.Status,
+		&l.Renewals, &l.CheckedOutAt, &l.DueAt, &l.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	l.CheckedOutAt = l.CheckedOutAt.UTC()
+	l.DueAt = l.DueAt.UTC()
+	if l.ClosedAt != nil {
+		at := l.ClosedAt.UTC()
+		l.ClosedAt = &at
+	}
+	return &l, nil
+}
+
+func (r *LoanRepository) CreateCopy(ctx context.Context, bookID int64, in domain.CreateCopyInput) (*domain.Copy, error) {
+	var c *domain.Copy
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		c, err = scanCopy(q.QueryRow(ctx,
+			`INSERT INTO copies (book_id, tenant_id, barcode)
+             SELECT id, tenant_id, $3 FROM books
+             WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+             RETURNING `+copyColumns,
+			tenant, bookID, in.Barcode,
+		))
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrConflict
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return c, nil
+}
+
+func (r *LoanRepository) GetCopy(ctx context.Context, id int64) (*domain.Copy, error) {
+	var c *domain.Copy
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		c, err = scanCopy(q.QueryRow(ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return c, nil
+}
+
+func (r *LoanRepository) ListCopies(ctx context.Context, bookID int64) ([]*domain.Copy, error) {
+	copies := []*domain.Copy{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE tenant_id = $1 AND book_id = $2 ORDER BY id`,
+			tenant, bookID,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			c, err := scanCopy(rows)
+			if err != nil {
+				return err
+			}
+			copies = append(copies, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+func (r *LoanRepository) ListCopiesForBooks(ctx context.Context, bookIDs []int64) (map[int64][]*domain.Copy, error) {
+	out := make(map[int64][]*domain.Copy)
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE tenant_id = $1 AND book_id = ANY($2) ORDER BY book_id, id`,
+			tenant, bookIDs,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			c, err := scanCopy(rows)
+			if err != nil {
+				return err
+			}
+			out[c.BookID] = append(out[c.BookID], c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *LoanRepository) Checkout(ctx context.Context, l domain.Loan) (*domain.Loan, error) {
+	var out *domain.Loan
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var bookID int64
+		var status domain.CopyStatus
+		err := q.QueryRow(ctx,
+			`SELECT book_id, status FROM copies WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+			tenant, l.CopyID,
+		).Scan(&bookID, &status)
+		if err != nil {
+			return notFound(err)
+		}
+		switch status {
+		case domain.CopyAvailable:
+		case domain.CopyOnHold:
+			// Only the member the copy is set aside for may take it.
+			tag, err := q.Exec(ctx,
+				`UPDATE holds SET status = 'fulfilled', closed_at = $3
+                 WHERE copy_id = $1 AND member_id = $2 AND status = 'ready'`,
+				l.CopyID, l.MemberID, l.CheckedOutAt,
+			)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return domain.ErrCopyUnavailable
+			}
+		default:
+			return domain.ErrCopyUnavailable
+		}
+
+		if _, err := q.Exec(ctx, `UPDATE copies SET status = 'on_loan' WHERE id = $1`, l.CopyID); err != nil {
+			return err
+		}
+		out, err = scanLoan(q.QueryRow(ctx,
+			`INSERT INTO loans (tenant_id, copy_id, book_id, member_id, member_type, checked_out_at, due_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7)
+             RETURNING `+loanColumns,
+			tenant, l.CopyID, bookID, l.MemberID, l.MemberType, l.CheckedOutAt, l.DueAt,
+		))
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrCopyUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *LoanRepository) GetLoan(ctx context.Context, id int64) (*domain.Loan, error) {
+	var l *domain.Loan
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		l, err = scanLoan(q.QueryRow(ctx,
+			`SELECT `+loanColumns+` FROM loans WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return l, nil
+}
+
+func (r *LoanRepository) ListLoans(ctx context.Context, lq domain.ListLoansQuery) ([]*domain.Loan, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	var dueBefore *time.Time
+	if !lq.DueBefore.IsZero() {
+		dueBefore = &lq.DueBefore
+	}
+
+	loans := []*domain.Loan{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+loanColumns+`
+             FROM loans
+             WHERE tenant_id = $1
+               AND ($2 = '' OR member_id = $2)
+               AND ($3 = 0 OR copy_id = $3)
+               AND (NOT $4 OR status = 'on_loan')
+               AND ($5::timestamptz IS NULL OR due_at < $5)
+             ORDER BY id
+             LIMIT $6 OFFSET $7`,
+			tenant, lq.MemberID, lq.CopyID, lq.OpenOnly, dueBefore, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			l, err := scanLoan(rows)
+			if err != nil {
+				return err
+			}
+			loans = append(loans, l)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+func (r *LoanRepository) Renew(ctx context.Context, id int64, renewals int, due time.Time) (*domain.Loan, error) {
+	var l *domain.Loan
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		l, err = scanLoan(q.QueryRow(ctx,
+			`UPDATE loans
+             SET renewals = renewals + 1, due_at = $4
+             WHERE tenant_id = $1 AND id = $2 AND status = 'on_loan' AND renewals = $3
+             RETURNING `+loanColumns,
+			tenant, id, renewals, due,
+		))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either there is no such loan or the guard failed.
+		if _, gerr := r.GetLoan(ctx, id); gerr != nil {
+			return nil, gerr
+		}
+		return nil, domain.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (r *LoanRepository) Close(ctx context.Context, id int64, status domain.LoanStatus, at time.Time) (*domain.Loan, error) {
+	copyStatus := domain.CopyAvailable
+	if status == domain.LoanLost {
+		copyStatus = domain.CopyLost
+	}
+
+	var l *domain.Loan
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var copyID int64
+		var current domain.LoanStatus
+		err := q.QueryRow(ctx,
+			`SELECT l.copy_id, l.status
+             FROM loans l JOIN copies c ON c.id = l.copy_id
+             WHERE l.tenant_id = $1 AND l.id = $2
+             FOR UPDATE`,
+			tenant, id,
+		).Scan(&copyID, &current)
+		if err != nil {
+			return notFound(err)
+		}
+		if current != domain.LoanOnLoan {
+			return domain.ErrLoanClosed
+		}
+
+		if _, err := q.Exec(ctx, `UPDATE copies SET status = $2 WHERE id = $1`, copyID, copyStatus); err != nil {
+			return err
+		}
+		l, err = scanLoan(q.QueryRow(ctx,
+			`UPDATE loans SET status = $2, closed_at = $3 WHERE id = $1 RETURNING `+loanColumns,
+			id, status, at,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// OrderRepository is the Postgres domain.InventoryRepository,
+// domain.CartRepository and domain.OrderRepository, on the tables of
+// migrations 0017 and 0018. The stock column of book_inventory is left to
+// the triggers of 0023, which keep it in step with the stock ledger.
+type OrderRepository struct {
+	db DB
+}
+
+func NewOrderRepository(db DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+const inventoryColumns = `book_id, tenant_id, price_cents, updated_at`
+
+func scanInventory(row pgx.Row) (*domain.Inventory, error) {
+	var inv domain.Inventory
+	if err := row.Scan(&inv.BookID, &inv.TenantID, &inv.PriceCents, &inv.UpdatedAt); err != nil {
+		return nil, err
+	}
+	inv.UpdatedAt = inv.UpdatedAt.UTC().Truncate(time.Second)
+	return &inv, nil
+}
+
+func (r *OrderRepository) GetInventory(ctx context.Context, bookID int64) (*domain.Inventory, error) {
+	var inv *domain.Inventory
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		inv, err = scanInventory(q.QueryRow(ctx,
+			`SELECT `+inventoryColumns+` FROM book_inventory WHERE tenant_id = $1 AND book_id = $2`,
+			tenant, bookID,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return inv, nil
+}
+
+func (r *OrderRepository) GetInventories(ctx context.Context, bookIDs []int64) (map[int64]*domain.Inventory, error) {
+	out := make(map[int64]*domain.Inventory)
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+inventoryColumns+` FROM book_inventory WHERE tenant_id = $1 AND book_id = ANY($2)`,
+			tenant, bookIDs,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			inv, err := scanInventory(rows)
+			if err != nil {
+				return err
+			}
+			out[inv.BookID] = inv
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *OrderRepository) SetInventory(ctx context.Context, bookID int64, in domain.InventoryInput, at time.Time) (*domain.Inventory, error) {
+	var inv *domain.Inventory
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		inv, err = scanInventory(q.QueryRow(ctx,
+			`INSERT INTO book_inventory (book_id, tenant_id, price_cents, updated_at)
+             SELECT id, tenant_id, $3, $4 FROM books
+             WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+             ON CONFLICT (book_id) DO UPDATE SET
+                 price_cents = EXCLUDED.price_cents, updated_at = EXCLUDED.updated_at
+             RETURNING `+inventoryColumns,
+			tenant, bookID, in.PriceCents, at,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return inv, nil
+}
+
+func (r *OrderRepository) GetCartLines(ctx context.Context, customer string) ([]domain.CartLine, error) {
+	lines := []domain.CartLine{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT book_id, qty FROM cart_lines WHERE tenant_id = $1 AND customer = $2 ORDER BY book_id`,
+			tenant, customer,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var l domain.CartLine
+			if err := rows.Scan(&l.BookID, &l.Qty); err != nil {
+				return err
+			}
+			lines = append(lines, l)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (r *OrderRepository) SetCartLine(ctx context.Context, customer string, bookID, qty int64) error {
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		if qty <= 0 {
+			_, err := q.Exec(ctx,
+				`DELETE FROM cart_lines WHERE tenant_id = $1 AND customer = $2 AND book_id = $3`,
+				tenant, customer, bookID,
+			)
+			return err
+		}
+		_, err := q.Exec(ctx,
+			`INSERT INTO cart_lines (tenant_id, customer, book_id, qty)
+             VALUES ($1, $2, $3, $4)
+             ON CONFLICT (tenant_id, customer, book_id) DO UPDATE SET qty = EXCLUDED.qty, updated_at = now()`,
+			tenant, customer, bookID, qty,
+		)
+		return err
+	})
+}
+
+func (r *OrderRepository) ClearCart(ctx context.Context, customer string) error {
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx, `DELETE FROM cart_lines WHERE tenant_id = $1 AND customer = $2`, tenant, customer)
+		return err
+	})
+}
+
+func (r *OrderRepository) CreateOrder(ctx context.Context, o domain.Order) (*domain.Order, error) {
+	o.PlacedAt = o.PlacedAt.UTC().Truncate(time.Second)
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		o.TenantID = tenant
+		err := q.QueryRow(ctx,
+			`INSERT INTO orders (tenant_id, customer, status, coupon, discount_pct, tax_pct,
+                                 subtotal_cents, discount_cents, tax_cents, total_cents, placed_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+             RETURNING id`,
+			tenant, o.Customer, o.Status, o.Coupon, o.DiscountPct, o.TaxPct,
+			o.Totals.SubtotalCents, o.Totals.DiscountCents, o.Totals.TaxCents, o.Totals.TotalCents, o.PlacedAt,
+		).Scan(&o.ID)
+		if err != nil {
+			return err
+		}
+		for _, l := range o.Lines {
+			_, err := q.Exec(ctx,
+				`INSERT INTO order_lines (order_id, tenant_id, book_id, title, qty, unit_price_cents, reservation_id)
+                 VALUES ($1, $2, $3, $4, $5, $6, nullif($7::bigint, 0))`,
+				o.ID, tenant, l.BookID, l.Title, l.Qty, l.UnitPriceCents, l.ReservationID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	o.Lines = slices.Clone(o.Lines)
+	return &o, nil
+}
+
+const orderColumns = `id, tenant_id, customer, status, coupon, discount_pct, tax_pct,
+                      subtotal_cents, discount_cents, tax_cents, total_cents, placed_at`
+
+func scanOrder(row pgx.Row) (*domain.Order, error) {
+	o := domain.Order{Lines: []domain.OrderLine{}}
+	err := row.Scan(&o.ID, &o.TenantID, &o.Customer, &o.Status, &o.Coupon, &o.DiscountPct, &o.TaxPct,
+		&o.Totals.SubtotalCents, &o.Totals.DiscountCents, &o.Totals.TaxCents, &o.Totals.TotalCents, &o.PlacedAt)
+	if err != nil {
+		return nil, err
+	}
+	o.PlacedAt = o.PlacedAt.UTC()
+	return &o, nil
+}
+
+func (r *OrderRepository) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	var o *domain.Order
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		o, err = scanOrder(q.QueryRow(ctx,
+			`SELECT `+orderColumns+` FROM orders WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		if err != nil {
+			return err
+		}
+		return loadOrderLines(ctx, q, tenant, []*domain.Order{o})
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return o, nil
+}
+
+func (r *OrderRepository) ListOrders(ctx context.Context, lq domain.ListOrdersQuery) ([]*domain.Order, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	orders := []*domain.Order{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+orderColumns+`
+             FROM orders
+             WHERE tenant_id = $1 AND ($2 = '' OR customer = $2)
+             ORDER BY id DESC
+             LIMIT $3 OFFSET $4`,
+			tenant, lq.Customer, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			o, err := scanOrder(rows)
+			if err != nil {
+				return err
+			}
+			orders = append(orders, o)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return loadOrderLines(ctx, q, tenant, orders)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *OrderRepository) SetOrderStatus(ctx context.Context, id int64, status domain.OrderStatus) (*domain.Order, error) {
+	var o *domain.Order
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		o, err = scanOrder(q.QueryRow(ctx,
+			`UPDATE orders SET status = $3 WHERE tenant_id = $1 AND id = $2 RETURNING `+orderColumns,
+			tenant, id, status,
+		))
+		if err != nil {
+			return err
+		}
+		return loadOrderLines(ctx, q, tenant, []*domain.Order{o})
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return o, nil
+}
+
+// loadOrderLines fills in the lines of orders in one query.
+func loadOrderLines(ctx context.Context, q DBTX, tenant string, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*domain.Order, len(orders))
+	ids := make([]int64, len(orders))
+	for i, o := range orders {
+		byID[o.ID] = o
+		ids[i] = o.ID
+	}
+
+	rows, err := q.Query(ctx,
+		`SELECT order_id, book_id, title, qty, unit_price_cents, coalesce(reservation_id, 0)
+         FROM order_lines
+         WHERE tenant_id = $1 AND order_id = ANY($2)
+         ORDER BY order_id, book_id`,
+		tenant, ids,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int64
+		var l domain.OrderLine
+		if err := rows.Scan(&orderID, &l.BookID, &l.Title, &l.Qty, &l.UnitPriceCents, &l.ReservationID); err != nil {
+			return err
+		}
+		byID[orderID].Lines = append(byID[orderID].Lines, l)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+type OutboxRepository struct {
+	db DB
+}
+
+func NewOutboxRepository(db DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, events ...domain.Event) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+	q := conn(ctx, r.db)
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = q.Exec(ctx,
+			`INSERT INTO outbox (tenant_id, aggregate_type, aggregate_id, event_type, payload)
+             VALUES ($1, $2, $3, $4, $5)`,
+			tenant, e.AggregateType(), e.AggregateID(), string(e.EventType()), payload,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimPending leases the head message of each aggregate in a single
+// statement, so the rows are only locked while it runs. SKIP LOCKED lets
+// several relays poll side by side; the NOT EXISTS guard stops a relay
+// from picking a later message while an earlier one is still pending,
+// leased or not.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.OutboxMessage, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`UPDATE outbox SET next_attempt_at = $2
+         WHERE id IN (
+             SELECT o.id
+             FROM outbox o
+             WHERE o.dispatched_at IS NULL
+               AND o.next_attempt_at <= $1
+               AND NOT EXISTS (
+                   SELECT 1 FROM outbox p
+                   WHERE p.aggregate_type = o.aggregate_type
+                     AND p.aggregate_id = o.aggregate_id
+                     AND p.dispatched_at IS NULL
+                     AND p.id < o.id)
+             ORDER BY o.id
+             LIMIT $3
+             FOR UPDATE SKIP LOCKED)
+         RETURNING id, tenant_id, aggregate_type, aggregate_id, event_type, payload, occurred_at, attempts`,
+		now, leaseUntil, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		var eventType string
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.AggregateType, &m.AggregateID, &eventType, &m.Payload, &m.OccurredAt, &m.Attempts); err != nil {
+			return nil, err
+		}
+		m.EventType = domain.EventType(eventType)
+		m.OccurredAt = m.OccurredAt.UTC()
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING keeps no order.
+	slices.SortFunc(msgs, func(a, b domain.OutboxMessage) int { return cmp.Compare(a.ID, b.ID) })
+	return msgs, nil
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox SET dispatched_at = $2, last_error = NULL WHERE id = $1`,
+		id, at,
+	)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox
+         SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+         WHERE id = $1`,
+		id, nextAttempt, reason,
+	)
+	return err
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// PaymentRepository is the Postgres domain.PaymentRepository, on the
+// payments table of migration 0019.
+type PaymentRepository struct {
+	db DB
+}
+
+func NewPaymentRepository(db DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentColumns = `id, tenant_id, order_id, status, amount_cents, gateway_ref, reason, created_at, updated_at`
+
+func scanPayment(row pgx.Row) (*domain.Payment, error) {
+	var p domain.Payment
+	err := row.Scan(&p.ID, &p.TenantID, &p.OrderID, &p.Status, &p.AmountCents, &p.GatewayRef, &p.Reason,
+		&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.CreatedAt = p.CreatedAt.UTC()
+	p.UpdatedAt = p.UpdatedAt.UTC()
+	return &p, nil
+}
+
+func (r *PaymentRepository) CreatePayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	at := p.CreatedAt.UTC().Truncate(time.Second)
+	var out *domain.Payment
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		out, err = scanPayment(q.QueryRow(ctx,
+			`INSERT INTO payments (tenant_id, order_id, status, amount_cents, gateway_ref, reason, created_at, updated_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+             RETURNING `+paymentColumns,
+			tenant, p.OrderID, p.Status, p.AmountCents, p.GatewayRef, p.Reason, at,
+		))
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *PaymentRepository) GetPayment(ctx context.Context, id int64) (*domain.Payment, error) {
+	return r.get(ctx, `id = $2`, id)
+}
+
+func (r *PaymentRepository) GetPaymentByOrder(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	return r.get(ctx, `order_id = $2`, orderID)
+}
+
+func (r *PaymentRepository) LockPayment(ctx context.Context, id int64) (*domain.Payment, error) {
+	return r.get(ctx, `id = $2 FOR UPDATE`, id)
+}
+
+func (r *PaymentRepository) get(ctx context.Context, where string, arg int64) (*domain.Payment, error) {
+	var p *domain.Payment
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		p, err = scanPayment(q.QueryRow(ctx,
+			`SELECT `+paymentColumns+` FROM payments WHERE tenant_id = $1 AND `+where,
+			tenant, arg,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return p, nil
+}
+
+func (r *PaymentRepository) UpdatePayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	var out *domain.Payment
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		out, err = scanPayment(q.QueryRow(ctx,
+			`UPDATE payments SET status = $3, gateway_ref = $4, reason = $5, updated_at = $6
+             WHERE tenant_id = $1 AND id = $2
+             RETURNING `+paymentColumns,
+			tenant, p.ID, p.Status, p.GatewayRef, p.Reason, p.UpdatedAt.UTC().Truncate(time.Second),
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return out, nil
+}
+
+func (r *PaymentRepository) ListPayments(ctx context.Context, lq domain.ListPaymentsQuery) ([]*domain.Payment, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	statuses := make([]string, len(lq.Statuses))
+	for i, s := range lq.Statuses {
+		statuses[i] = string(s)
+	}
+
+	payments := []*domain.Payment{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+paymentColumns+`
+             FROM payments
+             WHERE tenant_id = $1 AND status = ANY($2) AND updated_at < $3 AND id > $4
+             ORDER BY id
+             LIMIT $5`,
+			tenant, statuses, lq.Before, lq.AfterID, limit,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			p, err := scanPayment(rows)
+			if err != nil {
+				return err
+			}
+			payments = append(payments, p)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// ReviewRepository is the Postgres domain.ReviewRepository. Like books,
+// reviews are filtered by tenant and guarded by row-level security.
+type ReviewRepository struct {
+	db DB
+}
+
+func NewReviewRepository(db DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+const reviewColumns = `id, book_id, tenant_id, reviewer, rating, text, helpful_count, created_at, updated_at`
+
+func scanReview(row pgx.Row) (*domain.Review, error) {
+	var rv domain.Review
+	err := row.Scan(&rv.ID, &rv.BookID, &rv.TenantID, &rv.Reviewer, &rv.Rating, &rv.Text,
+		&rv.HelpfulCount, &rv.CreatedAt, &rv.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rv.CreatedAt = rv.CreatedAt.UTC().Truncate(time.Second)
+	rv.UpdatedAt = rv.UpdatedAt.UTC().Truncate(time.Second)
+	return &rv, nil
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, bookID int64, reviewer string, in domain.ReviewInput) (*domain.Review, error) {
+	var rv *domain.Review
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		rv, err = scanReview(q.QueryRow(ctx,
+			`INSERT INTO reviews (book_id, tenant_id, reviewer, rating, text)
+             VALUES ($2, $1, $3, $4, $5)
+             RETURNING `+reviewColumns,
+			tenant, bookID, reviewer, in.Rating, in.Text,
+		))
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (r *ReviewRepository) Get(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	var rv *domain.Review
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		rv, err = scanReview(q.QueryRow(ctx,
+			`SELECT `+reviewColumns+`
+             FROM reviews
+             WHERE tenant_id = $1 AND book_id = $2 AND id = $3`,
+			tenant, bookID, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return rv, nil
+}
+
+func (r *ReviewRepository) List(ctx context.Context, lq domain.ListReviewsQuery) ([]*domain.Review, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	order := `created_at DESC, id DESC`
+	if lq.Sort == domain.ReviewSortHelpful {
+		order = `helpful_count DESC, ` + order
+	}
+
+	reviews := []*domain.Review{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+reviewColumns+`
+             FROM reviews
+             WHERE tenant_id = $1 AND book_id = $2
+             ORDER BY `+order+`
+             LIMIT $3 OFFSET $4`,
+			tenant, lq.BookID, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			rv, err := scanReview(rows)
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, rv)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, bookID, id int64, in domain.ReviewInput) (*domain.Review, error) {
+	var rv *domain.Review
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		rv, err = scanReview(q.QueryRow(ctx,
+			`UPDATE reviews
+             SET rating = $4, text = $5, updated_at = now()
+             WHERE tenant_id = $1 AND book_id = $2 AND id = $3
+             RETURNING `+reviewColumns,
+			tenant, bookID, id, in.Rating, in.Text,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return rv, nil
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, bookID, id int64) error {
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		tag, err := q.Exec(ctx,
+			`DELETE FROM reviews WHERE tenant_id = $1 AND book_id = $2 AND id = $3`,
+			tenant, bookID, id,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// MarkHelpful inserts the vote and bumps the counter only if the vote is
+// new, in one statement; the outer SELECT sees the review as it was before
+// that statement, so the bump is added back in.
+func (r *ReviewRepository) MarkHelpful(ctx context.Context, bookID, id int64, voter string) (*domain.Review, error) {
+	var rv *domain.Review
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		rv, err = scanReview(q.QueryRow(ctx,
+			`WITH v AS (
+                 INSERT INTO review_votes (review_id, tenant_id, voter)
+                 SELECT id, tenant_id, $4 FROM reviews
+                 WHERE tenant_id = $1 AND book_id = $2 AND id = $3
+                 ON CONFLICT DO NOTHING
+                 RETURNING review_id
+             ), u AS (
+                 UPDATE reviews SET helpful_count = helpful_count + 1
+                 WHERE id IN (SELECT review_id FROM v)
+                 RETURNING id
+             )
+             SELECT id, book_id, tenant_id, reviewer, rating, text,
+                    helpful_count + (SELECT count(*) FROM u)::int, created_at, updated_at
+             FROM reviews
+             WHERE tenant_id = $1 AND book_id = $2 AND id = $3`,
+			tenant, bookID, id, voter,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return rv, nil
+}
+
+// ListForBooks numbers the reviews of each book newest first and keeps
+// the first perBook, all in one query.
+func (r *ReviewRepository) ListForBooks(ctx context.Context, bookIDs []int64, perBook int) (map[int64][]*domain.Review, error) {
+	out := make(map[int64][]*domain.Review)
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+reviewColumns+`
+             FROM (
+                 SELECT *, row_number() OVER (PARTITION BY book_id ORDER BY created_at DESC, id DESC) AS n
+                 FROM reviews
+                 WHERE tenant_id = $1 AND book_id = ANY($2)
+             ) r
+             WHERE n <= $3
+             ORDER BY book_id, n`,
+			tenant, bookIDs, perBook,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			rv, err := scanReview(rows)
+			if err != nil {
+				return err
+			}
+			out[rv.BookID] = append(out[rv.BookID], rv)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,354 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// StockRepository is the Postgres domain.StockRepository, on the tables of
+// migration 0018. Levels are summed from the ledger each time; every write
+// first locks the book's book_stock row with SELECT ... FOR UPDATE, so
+// writers to one book queue up and each sees the movements of the last.
+// Triggers from migration 0023 keep book_inventory.stock at the available
+// copies as each movement lands.
+type StockRepository struct {
+	db DB
+}
+
+func NewStockRepository(db DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// stockLevelSQL adds up the ledger of book $2 as domain.StockLevel does.
+const stockLevelSQL = `
+SELECT coalesce(sum(CASE WHEN kind IN ('received', 'adjusted') THEN qty
+                         WHEN kind = 'sold' THEN -qty ELSE 0 END), 0)::bigint,
+       coalesce(sum(CASE kind WHEN 'reserved' THEN qty
+                              WHEN 'released' THEN -qty
+                              WHEN 'sold' THEN -qty ELSE 0 END), 0)::bigint
+FROM stock_movements
+WHERE tenant_id = $1 AND book_id = $2`
+
+func stockLevel(ctx context.Context, q DBTX, tenant string, bookID, threshold int64) (*domain.StockLevel, error) {
+	l := domain.StockLevel{BookID: bookID}
+	if err := q.QueryRow(ctx, stockLevelSQL, tenant, bookID).Scan(&l.OnHand, &l.Reserved); err != nil {
+		return nil, err
+	}
+	l.SetThreshold(threshold)
+	return &l, nil
+}
+
+// lockStock locks the stock of a book until the transaction ends, creating
+// its book_stock row on first use, and returns its low-stock threshold.
+func lockStock(ctx context.Context, q DBTX, tenant string, bookID int64) (int64, error) {
+	_, err := q.Exec(ctx,
+		`INSERT INTO book_stock (book_id, tenant_id)
+         SELECT id, tenant_id FROM books WHERE tenant_id = $1 AND id = $2
+         ON CONFLICT (book_id) DO NOTHING`,
+		tenant, bookID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var threshold int64
+	err = q.QueryRow(ctx,
+		`SELECT low_stock_threshold FROM book_stock WHERE tenant_id = $1 AND book_id = $2 FOR UPDATE`,
+		tenant, bookID,
+	).Scan(&threshold)
+	return threshold, notFound(err)
+}
+
+func (r *StockRepository) GetStockLevel(ctx context.Context, bookID int64) (*domain.StockLevel, error) {
+	var l *domain.StockLevel
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var threshold int64
+		err := q.QueryRow(ctx,
+			`SELECT low_stock_threshold FROM book_stock WHERE tenant_id = $1 AND book_id = $2`,
+			tenant, bookID,
+		).Scan(&threshold)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		l, err = stockLevel(ctx, q, tenant, bookID, threshold)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (r *StockRepository) SetLowStockThreshold(ctx context.Context, bookID, threshold int64) (*domain.StockLevel, error) {
+	var l *domain.StockLevel
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		if _, err := lockStock(ctx, q, tenant, bookID); err != nil {
+			return err
+		}
+		_, err := q.Exec(ctx,
+			`UPDATE book_stock SET low_stock_threshold = $3 WHERE tenant_id = $1 AND book_id = $2`,
+			tenant, bookID, threshold,
+		)
+		if err != nil {
+			return err
+		}
+		l, err = stockLevel(ctx, q, tenant, bookID, threshold)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+const stockMovementColumns = `id, tenant_id, book_id, kind, qty, coalesce(reservation_id, 0), note, at`
+
+func (r *StockRepository) ListStockMovements(ctx context.Context, lq domain.ListStockMovementsQuery) ([]*domain.StockMovement, error) {
+	limit := lq.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	movements := []*domain.StockMovement{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+stockMovementColumns+`
+             FROM stock_movements
+             WHERE tenant_id = $1 AND book_id = $2
+             ORDER BY id DESC
+             LIMIT $3 OFFSET $4`,
+			tenant, lq.BookID, limit, lq.Offset,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var m domain.StockMovement
+			if err := rows.Scan(&m.ID, &m.TenantID, &m.BookID, &m.Kind, &m.Qty, &m.ReservationID, &m.Note, &m.At); err != nil {
+				return err
+			}
+			m.At = m.At.UTC()
+			movements = append(movements, &m)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (r *StockRepository) RecordStock(ctx context.Context, m domain.StockMovement) (*domain.StockLevel, error) {
+	var l *domain.StockLevel
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		threshold, err := lockStock(ctx, q, tenant, m.BookID)
+		if err != nil {
+			return err
+		}
+		if l, err = stockLevel(ctx, q, tenant, m.BookID, threshold); err != nil {
+			return err
+		}
+		if l.OnHand+m.Qty < l.Reserved {
+			return domain.ErrOutOfStock
+		}
+		if err := appendMovement(ctx, q, tenant, m); err != nil {
+			return err
+		}
+		l.Add(m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reserve locks the books in ID order, so that two reservations sharing
+// books cannot each hold one the other waits for.
+func (r *StockRepository) Reserve(ctx context.Context, lines []domain.StockLine, ref string, at, expiresAt time.Time) ([]*domain.StockReservation, error) {
+	want := make(map[int64]int64)
+	for _, l := range lines {
+		want[l.BookID] += l.Qty
+	}
+	ids := make([]int64, 0, len(want))
+	for id := range want {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	at = at.UTC().Truncate(time.Second)
+	expiresAt = expiresAt.UTC().Truncate(time.Second)
+
+	out := make([]*domain.StockReservation, len(lines))
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		for _, id := range ids {
+			threshold, err := lockStock(ctx, q, tenant, id)
+			if err != nil {
+				return err
+			}
+			l, err := stockLevel(ctx, q, tenant, id, threshold)
+			if err != nil {
+				return err
+			}
+			if l.Available < want[id] {
+				return domain.ErrOutOfStock
+			}
+		}
+
+		for i, l := range lines {
+			res, err := scanReservation(q.QueryRow(ctx,
+				`INSERT INTO stock_reservations (tenant_id, book_id, qty, ref, expires_at, created_at)
+                 VALUES ($1, $2, $3, $4, $5, $6)
+                 RETURNING `+reservationColumns,
+				tenant, l.BookID, l.Qty, ref, expiresAt, at,
+			))
+			if err != nil {
+				return err
+			}
+			err = appendMovement(ctx, q, tenant, domain.StockMovement{
+				BookID:        l.BookID,
+				Kind:          domain.StockReserved,
+				Qty:           l.Qty,
+				ReservationID: res.ID,
+				At:            at,
+			})
+			if err != nil {
+				return err
+			}
+			out[i] = res
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const reservationColumns = `id, tenant_id, book_id, qty, status, ref, expires_at, created_at, closed_at`
+
+func scanReservation(row pgx.Row) (*domain.StockReservation, error) {
+	var res domain.StockReservation
+	err := row.Scan(&res.ID, &res.TenantID, &res.BookID, &res.Qty, &res.Status, &res.Ref,
+		&res.ExpiresAt, &res.CreatedAt, &res.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	res.ExpiresAt = res.ExpiresAt.UTC()
+	res.CreatedAt = res.CreatedAt.UTC()
+	if res.ClosedAt != nil {
+		at := res.ClosedAt.UTC()
+		res.ClosedAt = &at
+	}
+	return &res, nil
+}
+
+func (r *StockRepository) GetReservation(ctx context.Context, id int64) (*domain.StockReservation, error) {
+	var res *domain.StockReservation
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		res, err = scanReservation(q.QueryRow(ctx,
+			`SELECT `+reservationColumns+` FROM stock_reservations WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return res, nil
+}
+
+// CloseReservation locks the book's stock before the reservation, the
+// same order Reserve takes them in.
+func (r *StockRepository) CloseReservation(ctx context.Context, id int64, status domain.ReservationStatus, at time.Time) (*domain.StockReservation, error) {
+	at = at.UTC().Truncate(time.Second)
+	var res *domain.StockReservation
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		var bookID int64
+		err := q.QueryRow(ctx,
+			`SELECT book_id FROM stock_reservations WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		).Scan(&bookID)
+		if err != nil {
+			return notFound(err)
+		}
+		if _, err := lockStock(ctx, q, tenant, bookID); err != nil {
+			return err
+		}
+
+		res, err = scanReservation(q.QueryRow(ctx,
+			`UPDATE stock_reservations SET status = $3, closed_at = $4
+             WHERE tenant_id = $1 AND id = $2 AND status = 'active'
+             RETURNING `+reservationColumns,
+			tenant, id, status, at,
+		))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrReservationClosed
+		}
+		if err != nil {
+			return err
+		}
+
+		kind := domain.StockReleased
+		if status == domain.ReservationSold {
+			kind = domain.StockSold
+		}
+		return appendMovement(ctx, q, tenant, domain.StockMovement{
+			BookID:        bookID,
+			Kind:          kind,
+			Qty:           res.Qty,
+			ReservationID: id,
+			At:            at,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *StockRepository) ExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*domain.StockReservation, error) {
+	out := []*domain.StockReservation{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+reservationColumns+`
+             FROM stock_reservations
+             WHERE tenant_id = $1 AND status = 'active' AND expires_at < $2
+             ORDER BY id
+             LIMIT $3`,
+			tenant, now, limit,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			res, err := scanReservation(rows)
+			if err != nil {
+				return err
+			}
+			out = append(out, res)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func appendMovement(ctx context.Context, q DBTX, tenant string, m domain.StockMovement) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO stock_movements (tenant_id, book_id, kind, qty, reservation_id, note, at)
+         VALUES ($1, $2, $3, $4, nullif($5::bigint, 0), $6, $7)`,
+		tenant, m.BookID, m.Kind, m.Qty, m.ReservationID, m.Note, m.At.UTC().Truncate(time.Second),
+	)
+	return err
+}
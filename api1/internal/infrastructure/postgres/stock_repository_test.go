@@ -0,0 +1,59 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/postgres"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// inventoryStock reads book_inventory.stock of a book.
+func inventoryStock(t *testing.T, ctx context.Context, pool *pgxpool.Pool, bookID int64) int64 {
+	t.Helper()
+	tenant, err := tenancy.Require(ctx)
+	require.NoError(t, err)
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+	_, err = tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenant)
+	require.NoError(t, err)
+	var stock int64
+	err = tx.QueryRow(ctx, `SELECT stock FROM book_inventory WHERE book_id = $1`, bookID).Scan(&stock)
+	require.NoError(t, err)
+	return stock
+}
+
+func TestStockRepository_InventoryStockFollowsTheLedger(t *testing.T) {
+	pool, ctx := testTenant(t)
+	book, err := postgres.NewBookRepository(pool).Create(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	stock := postgres.NewStockRepository(pool)
+	orders := postgres.NewOrderRepository(pool)
+	now := time.Now()
+
+	_, err = stock.RecordStock(ctx, domain.StockMovement{BookID: book.ID, Kind: domain.StockReceived, Qty: 5, At: now})
+	require.NoError(t, err)
+	_, err = orders.SetInventory(ctx, book.ID, domain.InventoryInput{PriceCents: 999}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), inventoryStock(t, ctx, pool, book.ID), "a new row starts from the ledger")
+
+	reserved, err := stock.Reserve(ctx, []domain.StockLine{{BookID: book.ID, Qty: 2}}, "test", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), inventoryStock(t, ctx, pool, book.ID))
+	_, err = stock.CloseReservation(ctx, reserved[0].ID, domain.ReservationSold, now)
+	require.NoError(t, err)
+	_, err = stock.RecordStock(ctx, domain.StockMovement{BookID: book.ID, Kind: domain.StockAdjusted, Qty: -1, At: now})
+	require.NoError(t, err)
+
+	level, err := stock.GetStockLevel(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, level.Available, inventoryStock(t, ctx, pool, book.ID))
+	assert.Equal(t, int64(2), level.Available)
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// scoped runs fn for the tenant in ctx on a connection where the row-level
+// security policies see that tenant too. Inside a Transactor transaction
+// the setting was usually made when it began, and is switched if ctx names
+// another tenant, as the outbox relay's does message by message; otherwise
+// fn gets a short transaction of its own, because set_config is only
+// reliable when it is local to one, bounded by the deadline of ctx like
+// those of the Transactor.
+func scoped(ctx context.Context, db DB, fn func(q DBTX, tenant string) error) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		if err := switchTenant(ctx, tx, tenant); err != nil {
+			return err
+		}
+		return fn(tx, tenant)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	if err := matchDeadline(ctx, tx); err != nil {
+		return err
+	}
+	if err := setTenant(ctx, tx, tenant); err != nil {
+		return err
+	}
+	if err := fn(tx, tenant); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// switchTenant points the policies of the Transactor transaction tx at
+// tenant, unless they already are.
+func switchTenant(ctx context.Context, tx pgx.Tx, tenant string) error {
+	cur, ok := ctx.Value(txTenantKey{}).(*txTenant)
+	if ok && cur.id == tenant {
+		return nil
+	}
+	if err := setTenant(ctx, tx, tenant); err != nil {
+		return err
+	}
+	if ok {
+		cur.id = tenant
+	}
+	return nil
+}
+
+// setTenant makes the row-level security policies of the rest of tx apply
+// to tenant.
+func setTenant(ctx context.Context, tx pgx.Tx, tenant string) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenant)
+	return err
+}
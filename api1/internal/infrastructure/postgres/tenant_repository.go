@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// TenantRepository stores tenants. The tenants table is not subject to
+// row-level security: it is only reached through the admin API and the
+// quota checks, which already know which tenant they mean.
+type TenantRepository struct {
+	db DB
+}
+
+func NewTenantRepository(db DB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+const tenantColumns = `id, name, book_quota, book_count, suspended_at, created_at`
+
+func scanTenant(row pgx.Row) (*domain.Tenant, error) {
+	var t domain.Tenant
+	if err := row.Scan(&t.ID, &t.Name, &t.BookQuota, &t.BookCount, &t.SuspendedAt, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	t.CreatedAt = t.CreatedAt.UTC().Truncate(time.Second)
+	if t.SuspendedAt != nil {
+		at := t.SuspendedAt.UTC().Truncate(time.Second)
+		t.SuspendedAt = &at
+	}
+	return &t, nil
+}
+
+func (r *TenantRepository) Create(ctx context.Context, in domain.CreateTenantInput) (*domain.Tenant, error) {
+	t, err := scanTenant(conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO tenants (id, name, book_quota)
+         VALUES ($1, $2, $3)
+         RETURNING `+tenantColumns,
+		in.ID, in.Name, in.BookQuota,
+	))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, domain.ErrConflict
+	}
+	return t, err
+}
+
+func (r *TenantRepository) Get(ctx context.Context, id string) (*domain.Tenant, error) {
+	t, err := scanTenant(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return t, nil
+}
+
+func (r *TenantRepository) List(ctx context.Context, limit, offset int) ([]*domain.Tenant, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+tenantColumns+` FROM tenants ORDER BY id LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*domain.Tenant{}
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *TenantRepository) SetSuspended(ctx context.Context, id string, suspended bool) (*domain.Tenant, error) {
+	t, err := scanTenant(conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE tenants
+         SET suspended_at = CASE WHEN $2 THEN COALESCE(suspended_at, now()) END
+         WHERE id = $1
+         RETURNING `+tenantColumns,
+		id, suspended,
+	))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return t, nil
+}
+
+// ReserveBook bumps the count in one conditional UPDATE, so concurrent
+// creates cannot both squeeze past the quota.
+func (r *TenantRepository) ReserveBook(ctx context.Context, id string) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE tenants
+         SET book_count = book_count + 1
+         WHERE id = $1 AND suspended_at IS NULL
+           AND (book_quota = 0 OR book_count < book_quota)`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 1 {
+		return nil
+	}
+
+	// Nothing updated: find out why.
+	t, err := r.Get(ctx, id)
+	switch {
+	case err != nil:
+		return err
+	case t.Suspended():
+		return domain.ErrTenantSuspended
+	default:
+		return domain.ErrQuotaExceeded
+	}
+}
+
+func (r *TenantRepository) ReleaseBook(ctx context.Context, id string) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE tenants SET book_count = GREATEST(book_count - 1, 0) WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// DBTX is the query surface shared by a connection, a pool and a
+// transaction.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// DB is what the repositories are built on. Both *pgx.Conn and
+// *pgxpool.Pool satisfy it.
+type DB interface {
+	DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// txTenant is the tenant the row-level security policies of a transaction
+// apply to at the moment, so that scoped only switches them when it must.
+type txTenant struct{ id string }
+
+type txTenantKey struct{}
+
+// Transactor runs functions inside a database transaction. Repositories
+// built on the same DB pick the transaction up from the context, so
+// everything fn does commits or rolls back together.
+type Transactor struct {
+	db DB
+}
+
+func NewTransactor(db DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn in a transaction, committing if it returns nil. Nested
+// calls run in a savepoint of the outer transaction, so a failing fn undoes
+// only its own work and the caller may carry on with the rest.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		sp, err := outer.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = sp.Rollback(context.WithoutCancel(ctx)) }()
+
+		// A rolled back savepoint takes its tenant switches with it.
+		parent, _ := ctx.Value(txTenantKey{}).(*txTenant)
+		cur := &txTenant{}
+		if parent != nil {
+			cur.id = parent.id
+		}
+		hctx, end := domain.BeginTxHooks(context.WithValue(ctx, txTenantKey{}, cur))
+		err = fn(context.WithValue(hctx, txKey{}, sp))
+		if err == nil {
+			err = sp.Commit(ctx)
+		}
+		if err == nil && parent != nil {
+			parent.id = cur.id
+		}
+		end(err == nil)
+		return err
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	if err := matchDeadline(ctx, tx); err != nil {
+		return err
+	}
+	// Without a tenant the policies hide every tenant-owned row until a
+	// scoped call names one, so nothing leaks either way.
+	cur := &txTenant{}
+	if tenant, ok := tenancy.FromContext(ctx); ok {
+		if err := setTenant(ctx, tx, tenant); err != nil {
+			return err
+		}
+		cur.id = tenant
+	}
+
+	hctx, end := domain.BeginTxHooks(context.WithValue(ctx, txTenantKey{}, cur))
+	err = fn(context.WithValue(hctx, txKey{}, tx))
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	end(err == nil)
+	return err
+}
+
+// matchDeadline sets statement_timeout for the rest of tx to whatever is
+// left of the context deadline, so the server gives up on a statement at
+// the same moment the caller does instead of running it to completion.
+func matchDeadline(ctx context.Context, tx pgx.Tx) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	ms := time.Until(deadline).Milliseconds()
+	if ms < 1 {
+		return context.DeadlineExceeded
+	}
+	_, err := tx.Exec(ctx, `SELECT set_config('statement_timeout', $1, true)`, strconv.FormatInt(ms, 10))
+	return err
+}
+
+// InTx reports whether ctx carries a transaction started by a Transactor.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return ok
+}
+
+// conn returns the transaction carried by ctx, or db when there is none.
+func conn(ctx context.Context, db DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	endpointColumns = `id, tenant_id, url, event_types, secret, active, consecutive_failures, created_at`
+	deliveryColumns = `id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+                       COALESCE(last_status_code, 0), COALESCE(last_error, ''), redelivery_of, created_at, delivered_at`
+)
+
+// WebhookRepository is the Postgres domain.WebhookRepository. Like books,
+// endpoints and deliveries are filtered by tenant and guarded by row-level
+// security.
+type WebhookRepository struct {
+	db DB
+}
+
+func NewWebhookRepository(db DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, in domain.CreateWebhookInput) (*domain.WebhookEndpoint, error) {
+	var e *domain.WebhookEndpoint
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		e, err = scanEndpoint(q.QueryRow(ctx,
+			`INSERT INTO webhook_endpoints (tenant_id, url, event_types, secret)
+             VALUES ($1, $2, $3, $4)
+             RETURNING `+endpointColumns,
+			tenant, in.URL, eventTypeStrings(in.EventTypes), in.Secret,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	var e *domain.WebhookEndpoint
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		e, err = scanEndpoint(q.QueryRow(ctx,
+			`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2`,
+			tenant, id,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return e, nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context) ([]*domain.WebhookEndpoint, error) {
+	out := []*domain.WebhookEndpoint{}
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 ORDER BY id`, tenant)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			e, err := scanEndpoint(rows)
+			if err != nil {
+				return err
+			}
+			out = append(out, e)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *WebhookRepository) SetEndpointActive(ctx context.Context, id int64, active bool) error {
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		tag, err := q.Exec(ctx,
+			`UPDATE webhook_endpoints
+             SET active = $3,
+                 consecutive_failures = CASE WHEN $3 THEN 0 ELSE consecutive_failures END
+             WHERE tenant_id = $1 AND id = $2`,
+			tenant, id, active,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) IncrementEndpointFailures(ctx context.Context, id int64) (int, error) {
+	var n int
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		return q.QueryRow(ctx,
+			`UPDATE webhook_endpoints
+             SET consecutive_failures = consecutive_failures + 1
+             WHERE tenant_id = $1 AND id = $2
+             RETURNING consecutive_failures`,
+			tenant, id,
+		).Scan(&n)
+	})
+	if err != nil {
+		return 0, notFound(err)
+	}
+	return n, nil
+}
+
+func (r *WebhookRepository) ResetEndpointFailures(ctx context.Context, id int64) error {
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		_, err := q.Exec(ctx,
+			`UPDATE webhook_endpoints SET consecutive_failures = 0 WHERE tenant_id = $1 AND id = $2`, tenant, id)
+		return err
+	})
+}
+
+// EnqueueDelivery queues d for an endpoint of the tenant; an endpoint of
+// another tenant is not found.
+func (r *WebhookRepository) EnqueueDelivery(ctx context.Context, d domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	var out *domain.WebhookDelivery
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		out, err = scanDelivery(q.QueryRow(ctx,
+			`INSERT INTO webhook_deliveries (tenant_id, endpoint_id, event_id, event_type, payload, redelivery_of)
+             SELECT e.tenant_id, e.id, $3, $4, $5, $6
+             FROM webhook_endpoints e
+             WHERE e.tenant_id = $1 AND e.id = $2
+             ON CONFLICT (endpoint_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+             RETURNING `+deliveryColumns,
+			tenant, d.EndpointID, d.EventID, string(d.EventType), d.Payload, d.RedeliveryOf,
+		))
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		// Already enqueued by an earlier relay of the same event, or the
+		// endpoint is not the tenant's.
+		out, err = scanDelivery(q.QueryRow(ctx,
+			`SELECT `+deliveryColumns+`
+             FROM webhook_deliveries
+             WHERE tenant_id = $1 AND endpoint_id = $2 AND event_id = $3 AND redelivery_of IS NULL`,
+			tenant, d.EndpointID, d.EventID,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return out, nil
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	var out []*domain.WebhookDelivery
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`UPDATE webhook_deliveries
+             SET next_attempt_at = $3
+             WHERE id IN (
+                 SELECT d.id
+                 FROM webhook_deliveries d
+                 JOIN webhook_endpoints e ON e.id = d.endpoint_id
+                 WHERE d.tenant_id = $1 AND d.status = 'pending' AND d.next_attempt_at <= $2 AND e.active
+                 ORDER BY d.next_attempt_at, d.id
+                 LIMIT $4
+                 FOR UPDATE OF d SKIP LOCKED)
+             RETURNING `+deliveryColumns,
+			tenant, now, now.Add(lease), limit,
+		)
+		if err != nil {
+			return err
+		}
+		out, err = collectDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	var lastError *string
+	if d.LastError != "" {
+		lastError = &d.LastError
+	}
+	var lastStatus *int
+	if d.LastStatusCode != 0 {
+		lastStatus = &d.LastStatusCode
+	}
+
+	return scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		tag, err := q.Exec(ctx,
+			`UPDATE webhook_deliveries
+             SET status = $3, attempts = $4, next_attempt_at = $5,
+                 last_status_code = $6, last_error = $7, delivered_at = $8
+             WHERE tenant_id = $1 AND id = $2`,
+			tenant, d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, lastStatus, lastError, d.DeliveredAt,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	var d *domain.WebhookDelivery
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) (err error) {
+		d, err = scanDelivery(q.QueryRow(ctx,
+			`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE tenant_id = $1 AND id = $2`, tenant, id))
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return d, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID int64, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	var out []*domain.WebhookDelivery
+	err := scoped(ctx, r.db, func(q DBTX, tenant string) error {
+		rows, err := q.Query(ctx,
+			`SELECT `+deliveryColumns+`
+             FROM webhook_deliveries
+             WHERE tenant_id = $1 AND endpoint_id = $2
+             ORDER BY id DESC
+             LIMIT $3 OFFSET $4`,
+			tenant, endpointID, limit, offset,
+		)
+		if err != nil {
+			return err
+		}
+		out, err = collectDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanEndpoint(row pgx.Row) (*domain.WebhookEndpoint, error) {
+	var e domain.WebhookEndpoint
+	var types []string
+	if err := row.Scan(&e.ID, &e.TenantID, &e.URL, &types, &e.Secret, &e.Active, &e.ConsecutiveFailures, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		e.EventTypes = append(e.EventTypes, domain.EventType(t))
+	}
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Second)
+	return &e, nil
+}
+
+func scanDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var eventType, status string
+	err := row.Scan(&d.ID, &d.TenantID, &d.EndpointID, &d.EventID, &eventType, &d.Payload, &status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.RedeliveryOf, &d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	d.EventType = domain.EventType(eventType)
+	d.Status = domain.DeliveryStatus(status)
+	return &d, nil
+}
+
+func collectDeliveries(rows pgx.Rows) ([]*domain.WebhookDelivery, error) {
+	defer rows.Close()
+
+	out := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func eventTypeStrings(types []domain.EventType) []string {
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = string(t)
+	}
+	return out
+}
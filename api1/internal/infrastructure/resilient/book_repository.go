@@ -0,0 +1,259 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// Config tunes a BookRepository. Zero values fall back to the defaults below.
+type Config struct {
+	// MaxAttempts is the number of tries per call, the first included.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Breaker     BreakerConfig
+	// InTx reports whether ctx belongs to a database transaction. A failed
+	// statement aborts the whole transaction, so such calls are never
+	// retried one by one; a Transactor reruns the transaction instead.
+	InTx func(ctx context.Context) bool
+}
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseBackoff = 50 * time.Millisecond
+	defaultMaxBackoff  = time.Second
+)
+
+func (cfg Config) withDefaults() Config {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.InTx == nil {
+		cfg.InTx = func(context.Context) bool { return false }
+	}
+	return cfg
+}
+
+// Stats describes the decorator since it was created.
+type Stats struct {
+	State    string `json:"state"`
+	Retries  uint64 `json:"retries"`
+	Trips    uint64 `json:"trips"`
+	Rejected uint64 `json:"rejected"`
+}
+
+// BookRepository wraps any domain.BookRepository with retries for
+// transient errors and a circuit breaker. Calls that fail transiently, or
+// that the open breaker refuses, return an error matching
+// domain.ErrUnavailable; permanent errors pass through, those of a
+// statement cut off by statement_timeout made to match
+// context.DeadlineExceeded.
+type BookRepository struct {
+	next    domain.BookRepository
+	cfg     Config
+	breaker *Breaker
+	sleep   func(ctx context.Context, d time.Duration) error
+	jitter  func(d time.Duration) time.Duration
+
+	retries atomic.Uint64
+}
+
+func NewBookRepository(next domain.BookRepository, cfg Config) *BookRepository {
+	cfg = cfg.withDefaults()
+	return &BookRepository{
+		next:    next,
+		cfg:     cfg,
+		breaker: NewBreaker("book repository", cfg.Breaker),
+		sleep:   sleep,
+		jitter:  equalJitter,
+	}
+}
+
+func (r *BookRepository) Stats() Stats {
+	return Stats{
+		State:    r.breaker.State().String(),
+		Retries:  r.retries.Load(),
+		Trips:    r.breaker.trips.Load(),
+		Rejected: r.breaker.rejected.Load(),
+	}
+}
+
+func (r *BookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, false, func() (err error) {
+		b, err = r.next.Create(ctx, in)
+		return err
+	})
+	return b, err
+}
+
+func (r *BookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, true, func() (err error) {
+		b, err = r.next.GetByID(ctx, id)
+		return err
+	})
+	return b, err
+}
+
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	var books map[int64]*domain.Book
+	err := r.do(ctx, true, func() (err error) {
+		books, err = r.next.GetByIDs(ctx, ids)
+		return err
+	})
+	return books, err
+}
+
+func (r *BookRepository) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	var books []*domain.Book
+	err := r.do(ctx, true, func() (err error) {
+		books, err = r.next.List(ctx, q)
+		return err
+	})
+	return books, err
+}
+
+func (r *BookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, false, func() (err error) {
+		b, err = r.next.Update(ctx, id, in)
+		return err
+	})
+	return b, err
+}
+
+func (r *BookRepository) Delete(ctx context.Context, id int64) error {
+	return r.do(ctx, false, func() error {
+		return r.next.Delete(ctx, id)
+	})
+}
+
+func (r *BookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, false, func() (err error) {
+		b, err = r.next.Restore(ctx, id)
+		return err
+	})
+	return b, err
+}
+
+func (r *BookRepository) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, false, func() (err error) {
+		b, err = r.next.SetCover(ctx, id, c)
+		return err
+	})
+	return b, err
+}
+
+// AdjustRating adds to running totals, so it is never idempotent.
+func (r *BookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	var b *domain.Book
+	err := r.do(ctx, false, func() (err error) {
+		b, err = r.next.AdjustRating(ctx, id, sum, count)
+		return err
+	})
+	return b, err
+}
+
+func (r *BookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	var c *domain.Cover
+	err := r.do(ctx, true, func() (err error) {
+		c, err = r.next.GetCover(ctx, id)
+		return err
+	})
+	return c, err
+}
+
+// do runs call through the breaker, retrying transient failures while
+// attempts remain, the call is safe to rerun and the context leaves enough
+// time for the backoff.
+func (r *BookRepository) do(ctx context.Context, idempotent bool, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		c, ok := r.breaker.Allow()
+		if !ok {
+			return fmt.Errorf("%w: circuit open", domain.ErrUnavailable)
+		}
+		err := call()
+		transient := Classify(err) == Transient
+		r.breaker.Done(c, outcome(err, transient))
+		if !transient {
+			return timedOut(err)
+		}
+
+		if attempt >= r.cfg.MaxAttempts || r.cfg.InTx(ctx) || !retrySafe(err, idempotent) {
+			return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+		}
+		delay := r.jitter(r.backoff(attempt))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+		}
+		if err := r.sleep(ctx, delay); err != nil {
+			return err
+		}
+		r.retries.Add(1)
+	}
+}
+
+// outcome tells the breaker how a call that returned err went.
+func outcome(err error, transient bool) Outcome {
+	switch {
+	case transient:
+		return Failed
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Abandoned
+	default:
+		return Succeeded
+	}
+}
+
+func (r *BookRepository) backoff(attempt int) time.Duration {
+	return backoff(r.cfg, attempt)
+}
+
+// backoff doubles from BaseBackoff for each failed attempt, capped at
+// MaxBackoff.
+func backoff(cfg Config, attempt int) time.Duration {
+	d := cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= cfg.MaxBackoff {
+			return cfg.MaxBackoff
+		}
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// equalJitter keeps half of d and randomizes the rest, so clients that
+// failed together do not retry in lockstep.
+func equalJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
@@ -0,0 +1,263 @@
+package resilient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// flakyRepo fails each call with the next scripted error, then succeeds.
+type flakyRepo struct {
+	domain.BookRepository
+	errs  []error
+	calls int
+}
+
+func (f *flakyRepo) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyRepo) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &domain.Book{ID: id}, nil
+}
+
+func (f *flakyRepo) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &domain.Book{ID: 1, Title: in.Title}, nil
+}
+
+var (
+	errSerialization = &pgconn.PgError{Code: "40001"}
+	errUnique        = &pgconn.PgError{Code: "23505"}
+)
+
+func newTestRepo(next domain.BookRepository, cfg Config) *BookRepository {
+	r := NewBookRepository(next, cfg)
+	r.sleep = func(context.Context, time.Duration) error { return nil }
+	r.jitter = func(d time.Duration) time.Duration { return d }
+	return r
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Class
+	}{
+		{nil, Permanent},
+		{domain.ErrNotFound, Permanent},
+		{errUnique, Permanent},
+		{errSerialization, Transient},
+		{&pgconn.PgError{Code: "40P01"}, Transient},
+		{&pgconn.PgError{Code: "08006"}, Transient},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), Transient},
+		{context.DeadlineExceeded, Permanent},
+		{context.Canceled, Permanent},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Classify(tt.err), "%v", tt.err)
+	}
+}
+
+func TestBookRepository_RetriesTransientRead(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{errSerialization, errSerialization}}
+	r := newTestRepo(flaky, Config{})
+
+	b, err := r.GetByID(context.Background(), 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), b.ID)
+	assert.Equal(t, 3, flaky.calls)
+	assert.Equal(t, uint64(2), r.Stats().Retries)
+}
+
+func TestBookRepository_GivesUpAsUnavailable(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{errSerialization, errSerialization, errSerialization}}
+	r := newTestRepo(flaky, Config{MaxAttempts: 3})
+
+	_, err := r.GetByID(context.Background(), 7)
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+func TestBookRepository_PermanentErrorPassesThrough(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{errUnique}}
+	r := newTestRepo(flaky, Config{})
+
+	_, err := r.Create(context.Background(), domain.CreateBookInput{Title: "Dune"})
+
+	assert.Equal(t, errUnique, err)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestBookRepository_StatementTimeoutIsADeadline(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{&pgconn.PgError{Code: "57014"}}}
+	r := newTestRepo(flaky, Config{})
+
+	_, err := r.GetByID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 1, flaky.calls, "a statement that ran out of time is not rerun")
+}
+
+func TestBookRepository_DoesNotRetryAmbiguousWrite(t *testing.T) {
+	// The connection dropped mid-statement: the insert may have committed.
+	flaky := &flakyRepo{errs: []error{io.ErrUnexpectedEOF}}
+	r := newTestRepo(flaky, Config{})
+
+	_, err := r.Create(context.Background(), domain.CreateBookInput{Title: "Dune"})
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestBookRepository_DoesNotRetryInsideTx(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{errSerialization}}
+	r := newTestRepo(flaky, Config{InTx: func(context.Context) bool { return true }})
+
+	_, err := r.GetByID(context.Background(), 7)
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestBookRepository_RespectsDeadline(t *testing.T) {
+	flaky := &flakyRepo{errs: []error{errSerialization}}
+	r := newTestRepo(flaky, Config{BaseBackoff: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := r.GetByID(ctx, 7)
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 1, flaky.calls, "a backoff past the deadline is not attempted")
+}
+
+func TestBookRepository_CircuitBreaker(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	flaky := &flakyRepo{errs: []error{errSerialization, errSerialization, errSerialization}}
+	r := newTestRepo(flaky, Config{
+		MaxAttempts: 1,
+		Breaker:     BreakerConfig{FailureThreshold: 2, OpenFor: time.Minute},
+	})
+	r.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Act & Assert: two failures open the breaker.
+	_, _ = r.GetByID(ctx, 1)
+	_, _ = r.GetByID(ctx, 1)
+	assert.Equal(t, Open, r.breaker.State())
+
+	_, err := r.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 2, flaky.calls, "an open breaker fails fast")
+
+	// After the cool-down one probe goes through; it fails and reopens.
+	now = now.Add(time.Minute)
+	_, _ = r.GetByID(ctx, 1)
+	assert.Equal(t, Open, r.breaker.State())
+
+	// The next probe succeeds and closes the breaker.
+	now = now.Add(time.Minute)
+	_, err = r.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, Closed, r.breaker.State())
+
+	stats := r.Stats()
+	assert.Equal(t, "closed", stats.State)
+	assert.Equal(t, uint64(2), stats.Trips)
+	assert.Equal(t, uint64(1), stats.Rejected)
+}
+
+func TestBreaker_HalfOpenAllowsOneProbe(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker("test", BreakerConfig{FailureThreshold: 1, OpenFor: time.Second})
+	b.now = func() time.Time { return now }
+
+	c, ok := b.Allow()
+	require.True(t, ok)
+	b.Done(c, Failed)
+	now = now.Add(time.Second)
+
+	_, ok = b.Allow()
+	assert.True(t, ok)
+	_, ok = b.Allow()
+	assert.False(t, ok, "only one probe while half-open")
+	assert.Equal(t, HalfOpen, b.State())
+}
+
+func TestBreaker_LateCallDoesNotEndProbe(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker("test", BreakerConfig{FailureThreshold: 1, OpenFor: time.Second})
+	b.now = func() time.Time { return now }
+	slow, _ := b.Allow()
+	failing, _ := b.Allow()
+	b.Done(failing, Failed)
+	now = now.Add(time.Second)
+	probe, ok := b.Allow()
+	require.True(t, ok)
+
+	// The slow call started while closed; it says nothing about now.
+	b.Done(slow, Succeeded)
+	assert.Equal(t, HalfOpen, b.State())
+	_, ok = b.Allow()
+	assert.False(t, ok, "the probe is still out")
+
+	b.Done(probe, Succeeded)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreaker_AbandonedProbeStaysHalfOpen(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker("test", BreakerConfig{FailureThreshold: 1, OpenFor: time.Second})
+	b.now = func() time.Time { return now }
+	c, _ := b.Allow()
+	b.Done(c, Failed)
+	now = now.Add(time.Second)
+
+	probe, ok := b.Allow()
+	require.True(t, ok)
+	b.Done(probe, Abandoned)
+
+	assert.Equal(t, HalfOpen, b.State())
+	_, ok = b.Allow()
+	assert.True(t, ok, "the next call probes instead")
+}
+
+func TestBookRepository_CanceledProbeDoesNotClose(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	flaky := &flakyRepo{errs: []error{errSerialization, context.Canceled}}
+	r := newTestRepo(flaky, Config{
+		MaxAttempts: 1,
+		Breaker:     BreakerConfig{FailureThreshold: 1, OpenFor: time.Minute},
+	})
+	r.breaker.now = func() time.Time { return now }
+	_, _ = r.GetByID(context.Background(), 1)
+	now = now.Add(time.Minute)
+
+	_, err := r.GetByID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, HalfOpen, r.breaker.State())
+}
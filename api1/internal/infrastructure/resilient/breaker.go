@@ -0,0 +1,173 @@
+package resilient
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the position of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every call through and counts consecutive failures.
+	Closed State = iota
+	// Open rejects every call until the cool-down has passed.
+	Open
+	// HalfOpen lets a single probe through; its outcome closes or reopens
+	// the breaker.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig tunes a Breaker. Zero values fall back to the defaults below.
+type BreakerConfig struct {
+	// FailureThreshold consecutive transient failures open the breaker.
+	FailureThreshold int
+	// OpenFor is how long the breaker stays open before probing.
+	OpenFor time.Duration
+}
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenFor          = 10 * time.Second
+)
+
+// Outcome is how a call let through by Allow ended.
+type Outcome int
+
+const (
+	// Succeeded calls got an answer from the backend, even an error one.
+	Succeeded Outcome = iota
+	// Failed calls failed transiently.
+	Failed
+	// Abandoned calls ended because the caller gave up first; they say
+	// nothing about the backend either way.
+	Abandoned
+)
+
+// Call is a call let through by Allow, to be handed back to Done.
+type Call struct {
+	epoch uint64
+}
+
+// Breaker is a closed/open/half-open circuit breaker. Only failures the
+// caller reports count; a permanent error proves the backend is answering
+// and is reported as a success.
+//
+// Every change of state starts a new epoch. Done ignores calls let through
+// in an earlier one, so a slow call that started while the breaker was
+// closed cannot end the probe of a later half-open state.
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	epoch    uint64
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	trips    atomic.Uint64
+	rejected atomic.Uint64
+}
+
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenFor <= 0 {
+		cfg.OpenFor = defaultOpenFor
+	}
+	return &Breaker{name: name, cfg: cfg, now: time.Now}
+}
+
+// Allow asks to make one call. If it returns true, the caller must report
+// how the call ended with Done.
+func (b *Breaker) Allow() (Call, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.cfg.OpenFor {
+		b.setState(HalfOpen)
+	}
+	switch {
+	case b.state == Closed:
+		return Call{epoch: b.epoch}, true
+	case b.state == HalfOpen && !b.probing:
+		b.probing = true
+		return Call{epoch: b.epoch}, true
+	}
+	b.rejected.Add(1)
+	return Call{}, false
+}
+
+// Done reports the outcome of c.
+func (b *Breaker) Done(c Call, o Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c.epoch != b.epoch {
+		return
+	}
+	if b.state == HalfOpen {
+		// Only the probe is let through while half-open. An abandoned one
+		// leaves the breaker half-open for the next call to probe.
+		b.probing = false
+		switch o {
+		case Failed:
+			b.open()
+		case Succeeded:
+			b.failures = 0
+			b.setState(Closed)
+		}
+		return
+	}
+
+	switch o {
+	case Succeeded:
+		b.failures = 0
+	case Failed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.failures = 0
+	b.trips.Add(1)
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(s State) {
+	if b.state == s {
+		return
+	}
+	log.Printf("%s breaker: %s -> %s", b.name, b.state, s)
+	b.state = s
+	b.epoch++
+}
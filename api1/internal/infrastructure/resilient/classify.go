@@ -0,0 +1,102 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Class says whether an error is worth trying again.
+type Class int
+
+const (
+	// Permanent errors will not go away on retry: bad input, constraint
+	// violations, not found, a caller that gave up, or a statement that ran
+	// past statement_timeout.
+	Permanent Class = iota
+	// Transient errors come from the database being briefly unable to serve
+	// the call: lost connections, lock conflicts, restarts.
+	Transient
+)
+
+// rejectedCodes are SQLSTATEs for statements the server rolled back or
+// refused without applying, so rerunning them is safe even for writes.
+var rejectedCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P03": true, // cannot_connect_now
+}
+
+// lostCodes are SQLSTATEs for a connection that went away; whatever was in
+// flight may or may not have been applied.
+var lostCodes = map[string]bool{
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+}
+
+// queryCanceled is the SQLSTATE of a statement the server cancelled, as it
+// does one that runs past statement_timeout.
+const queryCanceled = "57014"
+
+// timedOut makes err match context.DeadlineExceeded when the server
+// cancelled the statement for running out of time, so that it is reported
+// as the timeout it is rather than as a failure.
+func timedOut(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == queryCanceled && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+	}
+	return err
+}
+
+// Classify sorts pgx/pgconn errors into transient and permanent.
+func Classify(err error) Class {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Permanent
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if rejectedCodes[pgErr.Code] || lostCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08") {
+			return Transient
+		}
+		return Permanent
+	}
+
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &connErr),
+		pgconn.SafeToRetry(err),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE),
+		errors.As(err, &netErr):
+		return Transient
+	}
+	return Permanent
+}
+
+// retrySafe reports whether a call that failed with the transient err may
+// be rerun. Reads always may; a write only if the server is known not to
+// have applied it.
+func retrySafe(err error, idempotent bool) bool {
+	if idempotent || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return rejectedCodes[pgErr.Code]
+	}
+	var connErr *pgconn.ConnectError
+	return errors.As(err, &connErr)
+}
@@ -0,0 +1,79 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// Transactor wraps any domain.Transactor and reruns a whole transaction
+// that failed transiently. A failed statement aborts the transaction it ran
+// in, so writes made in one can only be retried from here, never by the
+// repositories themselves.
+//
+// Only the outermost transaction is rerun, and only when the server is
+// known to have rolled it back: a connection lost during COMMIT may have
+// committed it. fn may run several times, so it must not have effects
+// outside the transaction other than through AfterCommit. A transaction
+// that failed on statement_timeout fails matching context.DeadlineExceeded.
+type Transactor struct {
+	next   domain.Transactor
+	cfg    Config
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+
+	retries atomic.Uint64
+}
+
+// NewTransactor builds a Transactor. Breaker in cfg is not used: the
+// repositories called within the transaction have their own.
+func NewTransactor(next domain.Transactor, cfg Config) *Transactor {
+	return &Transactor{
+		next:   next,
+		cfg:    cfg.withDefaults(),
+		sleep:  sleep,
+		jitter: equalJitter,
+	}
+}
+
+// Retries is the number of transactions rerun so far.
+func (t *Transactor) Retries() uint64 {
+	return t.retries.Load()
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.cfg.InTx(ctx) {
+		return t.next.WithinTx(ctx, fn)
+	}
+	for attempt := 1; ; attempt++ {
+		err := t.next.WithinTx(ctx, fn)
+		if Classify(err) != Transient {
+			return timedOut(err)
+		}
+
+		if attempt >= t.cfg.MaxAttempts || !retrySafe(err, false) {
+			return unavailable(err)
+		}
+		delay := t.jitter(backoff(t.cfg, attempt))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return unavailable(err)
+		}
+		if err := t.sleep(ctx, delay); err != nil {
+			return err
+		}
+		t.retries.Add(1)
+	}
+}
+
+// unavailable marks a transient err as domain.ErrUnavailable, unless a
+// repository already did.
+func unavailable(err error) error {
+	if errors.Is(err, domain.ErrUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+}
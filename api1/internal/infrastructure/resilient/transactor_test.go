@@ -0,0 +1,104 @@
+package resilient
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// flakyTx runs fn, then fails the transaction with the next scripted error.
+type flakyTx struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.calls++
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func newTestTransactor(next domain.Transactor, cfg Config) *Transactor {
+	t := NewTransactor(next, cfg)
+	t.sleep = func(context.Context, time.Duration) error { return nil }
+	t.jitter = func(d time.Duration) time.Duration { return d }
+	return t
+}
+
+func TestTransactor_RerunsRejectedTransaction(t *testing.T) {
+	flaky := &flakyTx{errs: []error{errSerialization}}
+	tx := newTestTransactor(flaky, Config{})
+	runs := 0
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error {
+		runs++
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, uint64(1), tx.Retries())
+}
+
+func TestTransactor_GivesUpAsUnavailable(t *testing.T) {
+	flaky := &flakyTx{errs: []error{errSerialization, errSerialization}}
+	tx := newTestTransactor(flaky, Config{MaxAttempts: 2})
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error { return nil })
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 2, flaky.calls)
+}
+
+func TestTransactor_DoesNotRerunAmbiguousCommit(t *testing.T) {
+	// The connection dropped during COMMIT: the transaction may have landed.
+	flaky := &flakyTx{errs: []error{io.ErrUnexpectedEOF}}
+	tx := newTestTransactor(flaky, Config{})
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error { return nil })
+
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestTransactor_PermanentErrorPassesThrough(t *testing.T) {
+	tx := newTestTransactor(&flakyTx{}, Config{})
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error { return errUnique })
+
+	assert.Equal(t, errUnique, err)
+}
+
+func TestTransactor_StatementTimeoutIsADeadline(t *testing.T) {
+	flaky := &flakyTx{errs: []error{&pgconn.PgError{Code: "57014"}}}
+	tx := newTestTransactor(flaky, Config{})
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error { return nil })
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestTransactor_LeavesNestedCallsToTheOuterTransaction(t *testing.T) {
+	flaky := &flakyTx{errs: []error{errSerialization}}
+	tx := newTestTransactor(flaky, Config{InTx: func(context.Context) bool { return true }})
+
+	err := tx.WithinTx(context.Background(), func(context.Context) error { return nil })
+
+	assert.Equal(t, errSerialization, err)
+	assert.Equal(t, 1, flaky.calls)
+}
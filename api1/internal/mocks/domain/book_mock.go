@@ -41,6 +41,21 @@ func (m *MockBookRepository) EXPECT() *MockBookRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AdjustRating mocks base method.
+func (m *MockBookRepository) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustRating", ctx, id, sum, count)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustRating indicates an expected call of AdjustRating.
+func (mr *MockBookRepositoryMockRecorder) AdjustRating(ctx, id, sum, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustRating", reflect.TypeOf((*MockBookRepository)(nil).AdjustRating), ctx, id, sum, count)
+}
+
 // Create mocks base method.
 func (m *MockBookRepository) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
 	m.ctrl.T.Helper()
@@ -55,3 +70,122 @@ func (mr *MockBookRepositoryMockRecorder) Create(ctx, in any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBookRepository)(nil).Create), ctx, in)
 }
+
+// Delete mocks base method.
+func (m *MockBookRepository) Delete(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBookRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBookRepository)(nil).Delete), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockBookRepository) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockBookRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockBookRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDs mocks base method.
+func (m *MockBookRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].(map[int64]*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockBookRepositoryMockRecorder) GetByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockBookRepository)(nil).GetByIDs), ctx, ids)
+}
+
+// GetCover mocks base method.
+func (m *MockBookRepository) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCover", ctx, id)
+	ret0, _ := ret[0].(*domain.Cover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCover indicates an expected call of GetCover.
+func (mr *MockBookRepositoryMockRecorder) GetCover(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCover", reflect.TypeOf((*MockBookRepository)(nil).GetCover), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockBookRepository) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, q)
+	ret0, _ := ret[0].([]*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBookRepositoryMockRecorder) List(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBookRepository)(nil).List), ctx, q)
+}
+
+// Restore mocks base method.
+func (m *MockBookRepository) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockBookRepositoryMockRecorder) Restore(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockBookRepository)(nil).Restore), ctx, id)
+}
+
+// SetCover mocks base method.
+func (m *MockBookRepository) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCover", ctx, id, c)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCover indicates an expected call of SetCover.
+func (mr *MockBookRepositoryMockRecorder) SetCover(ctx, id, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCover", reflect.TypeOf((*MockBookRepository)(nil).SetCover), ctx, id, c)
+}
+
+// Update mocks base method.
+func (m *MockBookRepository) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, in)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBookRepositoryMockRecorder) Update(ctx, id, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBookRepository)(nil).Update), ctx, id, in)
+}
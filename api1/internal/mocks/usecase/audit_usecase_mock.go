@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/audit_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/audit_usecase.go -destination=api1/internal/mocks/usecase/audit_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditUsecase is a mock of AuditUsecase interface.
+type MockAuditUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditUsecaseMockRecorder is the mock recorder for MockAuditUsecase.
+type MockAuditUsecaseMockRecorder struct {
+	mock *MockAuditUsecase
+}
+
+// NewMockAuditUsecase creates a new mock instance.
+func NewMockAuditUsecase(ctrl *gomock.Controller) *MockAuditUsecase {
+	mock := &MockAuditUsecase{ctrl: ctrl}
+	mock.recorder = &MockAuditUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditUsecase) EXPECT() *MockAuditUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ListAudit mocks base method.
+func (m *MockAuditUsecase) ListAudit(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAudit", ctx, q)
+	ret0, _ := ret[0].([]*domain.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAudit indicates an expected call of ListAudit.
+func (mr *MockAuditUsecaseMockRecorder) ListAudit(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAudit", reflect.TypeOf((*MockAuditUsecase)(nil).ListAudit), ctx, q)
+}
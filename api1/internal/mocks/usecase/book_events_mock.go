@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/book_events.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/book_events.go -destination=api1/internal/mocks/usecase/book_events_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBookEventsUsecase is a mock of BookEventsUsecase interface.
+type MockBookEventsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockBookEventsUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockBookEventsUsecaseMockRecorder is the mock recorder for MockBookEventsUsecase.
+type MockBookEventsUsecaseMockRecorder struct {
+	mock *MockBookEventsUsecase
+}
+
+// NewMockBookEventsUsecase creates a new mock instance.
+func NewMockBookEventsUsecase(ctrl *gomock.Controller) *MockBookEventsUsecase {
+	mock := &MockBookEventsUsecase{ctrl: ctrl}
+	mock.recorder = &MockBookEventsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBookEventsUsecase) EXPECT() *MockBookEventsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// SubscribeBookChanges mocks base method.
+func (m *MockBookEventsUsecase) SubscribeBookChanges(ctx context.Context, q domain.BookChangeQuery, lastID string) (domain.BookChangeSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeBookChanges", ctx, q, lastID)
+	ret0, _ := ret[0].(domain.BookChangeSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeBookChanges indicates an expected call of SubscribeBookChanges.
+func (mr *MockBookEventsUsecaseMockRecorder) SubscribeBookChanges(ctx, q, lastID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeBookChanges", reflect.TypeOf((*MockBookEventsUsecase)(nil).SubscribeBookChanges), ctx, q, lastID)
+}
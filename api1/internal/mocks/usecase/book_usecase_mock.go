@@ -11,8 +11,13 @@ package usecase_mock
 
 import (
 	context "context"
+	io "io"
+	iter "iter"
 	reflect "reflect"
+	time "time"
 	domain "unit-test-demo/api1/internal/domain"
+	patch "unit-test-demo/api1/internal/patch"
+	usecase "unit-test-demo/api1/internal/usecase"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -55,3 +60,242 @@ func (mr *MockBookUsecaseMockRecorder) CreateBook(ctx, in any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBook", reflect.TypeOf((*MockBookUsecase)(nil).CreateBook), ctx, in)
 }
+
+// DeleteBook mocks base method.
+func (m *MockBookUsecase) DeleteBook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockBookUsecaseMockRecorder) DeleteBook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockBookUsecase)(nil).DeleteBook), ctx, id)
+}
+
+// ExportBooks mocks base method.
+func (m *MockBookUsecase) ExportBooks(ctx context.Context, q domain.ListBooksQuery) (iter.Seq2[*domain.Book, error], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBooks", ctx, q)
+	ret0, _ := ret[0].(iter.Seq2[*domain.Book, error])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportBooks indicates an expected call of ExportBooks.
+func (mr *MockBookUsecaseMockRecorder) ExportBooks(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBooks", reflect.TypeOf((*MockBookUsecase)(nil).ExportBooks), ctx, q)
+}
+
+// GetBook mocks base method.
+func (m *MockBookUsecase) GetBook(ctx context.Context, id int64) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBook", ctx, id)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBook indicates an expected call of GetBook.
+func (mr *MockBookUsecaseMockRecorder) GetBook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockBookUsecase)(nil).GetBook), ctx, id)
+}
+
+// GetBookAsOf mocks base method.
+func (m *MockBookUsecase) GetBookAsOf(ctx context.Context, id int64, at time.Time) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookAsOf", ctx, id, at)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookAsOf indicates an expected call of GetBookAsOf.
+func (mr *MockBookUsecaseMockRecorder) GetBookAsOf(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookAsOf", reflect.TypeOf((*MockBookUsecase)(nil).GetBookAsOf), ctx, id, at)
+}
+
+// GetBookView mocks base method.
+func (m *MockBookUsecase) GetBookView(ctx context.Context, id int64, sel domain.BookSelection) (*domain.BookView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookView", ctx, id, sel)
+	ret0, _ := ret[0].(*domain.BookView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookView indicates an expected call of GetBookView.
+func (mr *MockBookUsecaseMockRecorder) GetBookView(ctx, id, sel any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookView", reflect.TypeOf((*MockBookUsecase)(nil).GetBookView), ctx, id, sel)
+}
+
+// ListBookViews mocks base method.
+func (m *MockBookUsecase) ListBookViews(ctx context.Context, q domain.ListBooksQuery, sel domain.BookSelection) ([]*domain.BookView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBookViews", ctx, q, sel)
+	ret0, _ := ret[0].([]*domain.BookView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBookViews indicates an expected call of ListBookViews.
+func (mr *MockBookUsecaseMockRecorder) ListBookViews(ctx, q, sel any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBookViews", reflect.TypeOf((*MockBookUsecase)(nil).ListBookViews), ctx, q, sel)
+}
+
+// ListBooks mocks base method.
+func (m *MockBookUsecase) ListBooks(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBooks", ctx, q)
+	ret0, _ := ret[0].([]*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBooks indicates an expected call of ListBooks.
+func (mr *MockBookUsecaseMockRecorder) ListBooks(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBooks", reflect.TypeOf((*MockBookUsecase)(nil).ListBooks), ctx, q)
+}
+
+// ListDuplicates mocks base method.
+func (m *MockBookUsecase) ListDuplicates(ctx context.Context, limit, offset int) ([]*domain.DuplicateGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDuplicates", ctx, limit, offset)
+	ret0, _ := ret[0].([]*domain.DuplicateGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDuplicates indicates an expected call of ListDuplicates.
+func (mr *MockBookUsecaseMockRecorder) ListDuplicates(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDuplicates", reflect.TypeOf((*MockBookUsecase)(nil).ListDuplicates), ctx, limit, offset)
+}
+
+// ListRevisions mocks base method.
+func (m *MockBookUsecase) ListRevisions(ctx context.Context, id int64, limit, offset int) ([]*domain.BookRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, id, limit, offset)
+	ret0, _ := ret[0].([]*domain.BookRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockBookUsecaseMockRecorder) ListRevisions(ctx, id, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockBookUsecase)(nil).ListRevisions), ctx, id, limit, offset)
+}
+
+// OpenCover mocks base method.
+func (m *MockBookUsecase) OpenCover(ctx context.Context, id int64, thumb bool) (*usecase.CoverFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenCover", ctx, id, thumb)
+	ret0, _ := ret[0].(*usecase.CoverFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenCover indicates an expected call of OpenCover.
+func (mr *MockBookUsecaseMockRecorder) OpenCover(ctx, id, thumb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenCover", reflect.TypeOf((*MockBookUsecase)(nil).OpenCover), ctx, id, thumb)
+}
+
+// PatchBook mocks base method.
+func (m *MockBookUsecase) PatchBook(ctx context.Context, id int64, p patch.Patch) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchBook", ctx, id, p)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchBook indicates an expected call of PatchBook.
+func (mr *MockBookUsecaseMockRecorder) PatchBook(ctx, id, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchBook", reflect.TypeOf((*MockBookUsecase)(nil).PatchBook), ctx, id, p)
+}
+
+// RestoreBook mocks base method.
+func (m *MockBookUsecase) RestoreBook(ctx context.Context, id int64) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBook", ctx, id)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBook indicates an expected call of RestoreBook.
+func (mr *MockBookUsecaseMockRecorder) RestoreBook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBook", reflect.TypeOf((*MockBookUsecase)(nil).RestoreBook), ctx, id)
+}
+
+// RevertBook mocks base method.
+func (m *MockBookUsecase) RevertBook(ctx context.Context, id int64, rev int) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertBook", ctx, id, rev)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertBook indicates an expected call of RevertBook.
+func (mr *MockBookUsecaseMockRecorder) RevertBook(ctx, id, rev any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertBook", reflect.TypeOf((*MockBookUsecase)(nil).RevertBook), ctx, id, rev)
+}
+
+// SyncBooks mocks base method.
+func (m *MockBookUsecase) SyncBooks(ctx context.Context, cursor string, limit int) (*usecase.BookSyncPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncBooks", ctx, cursor, limit)
+	ret0, _ := ret[0].(*usecase.BookSyncPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncBooks indicates an expected call of SyncBooks.
+func (mr *MockBookUsecaseMockRecorder) SyncBooks(ctx, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncBooks", reflect.TypeOf((*MockBookUsecase)(nil).SyncBooks), ctx, cursor, limit)
+}
+
+// UpdateBook mocks base method.
+func (m *MockBookUsecase) UpdateBook(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, id, in)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockBookUsecaseMockRecorder) UpdateBook(ctx, id, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockBookUsecase)(nil).UpdateBook), ctx, id, in)
+}
+
+// UploadCover mocks base method.
+func (m *MockBookUsecase) UploadCover(ctx context.Context, id int64, r io.Reader) (*domain.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadCover", ctx, id, r)
+	ret0, _ := ret[0].(*domain.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadCover indicates an expected call of UploadCover.
+func (mr *MockBookUsecaseMockRecorder) UploadCover(ctx, id, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadCover", reflect.TypeOf((*MockBookUsecase)(nil).UploadCover), ctx, id, r)
+}
@@ -0,0 +1,161 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/category_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/category_usecase.go -destination=api1/internal/mocks/usecase/category_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCategoryUsecase is a mock of CategoryUsecase interface.
+type MockCategoryUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockCategoryUsecaseMockRecorder is the mock recorder for MockCategoryUsecase.
+type MockCategoryUsecaseMockRecorder struct {
+	mock *MockCategoryUsecase
+}
+
+// NewMockCategoryUsecase creates a new mock instance.
+func NewMockCategoryUsecase(ctrl *gomock.Controller) *MockCategoryUsecase {
+	mock := &MockCategoryUsecase{ctrl: ctrl}
+	mock.recorder = &MockCategoryUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategoryUsecase) EXPECT() *MockCategoryUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateCategory mocks base method.
+func (m *MockCategoryUsecase) CreateCategory(ctx context.Context, in domain.CategoryInput) (*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategory", ctx, in)
+	ret0, _ := ret[0].(*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCategory indicates an expected call of CreateCategory.
+func (mr *MockCategoryUsecaseMockRecorder) CreateCategory(ctx, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockCategoryUsecase)(nil).CreateCategory), ctx, in)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockCategoryUsecase) DeleteCategory(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockCategoryUsecaseMockRecorder) DeleteCategory(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCategoryUsecase)(nil).DeleteCategory), ctx, id)
+}
+
+// Facets mocks base method.
+func (m *MockCategoryUsecase) Facets(ctx context.Context, q domain.ListBooksQuery) (*domain.Facets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Facets", ctx, q)
+	ret0, _ := ret[0].(*domain.Facets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Facets indicates an expected call of Facets.
+func (mr *MockCategoryUsecaseMockRecorder) Facets(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Facets", reflect.TypeOf((*MockCategoryUsecase)(nil).Facets), ctx, q)
+}
+
+// GetBookTaxonomy mocks base method.
+func (m *MockCategoryUsecase) GetBookTaxonomy(ctx context.Context, bookID int64) (*domain.BookTaxonomy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookTaxonomy", ctx, bookID)
+	ret0, _ := ret[0].(*domain.BookTaxonomy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookTaxonomy indicates an expected call of GetBookTaxonomy.
+func (mr *MockCategoryUsecaseMockRecorder) GetBookTaxonomy(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookTaxonomy", reflect.TypeOf((*MockCategoryUsecase)(nil).GetBookTaxonomy), ctx, bookID)
+}
+
+// GetCategory mocks base method.
+func (m *MockCategoryUsecase) GetCategory(ctx context.Context, id int64) (*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategory", ctx, id)
+	ret0, _ := ret[0].(*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategory indicates an expected call of GetCategory.
+func (mr *MockCategoryUsecaseMockRecorder) GetCategory(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockCategoryUsecase)(nil).GetCategory), ctx, id)
+}
+
+// ListCategories mocks base method.
+func (m *MockCategoryUsecase) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategories", ctx)
+	ret0, _ := ret[0].([]*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategories indicates an expected call of ListCategories.
+func (mr *MockCategoryUsecaseMockRecorder) ListCategories(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategories", reflect.TypeOf((*MockCategoryUsecase)(nil).ListCategories), ctx)
+}
+
+// SetBookTaxonomy mocks base method.
+func (m *MockCategoryUsecase) SetBookTaxonomy(ctx context.Context, bookID int64, in domain.BookTaxonomyInput) (*domain.BookTaxonomy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBookTaxonomy", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.BookTaxonomy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBookTaxonomy indicates an expected call of SetBookTaxonomy.
+func (mr *MockCategoryUsecaseMockRecorder) SetBookTaxonomy(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBookTaxonomy", reflect.TypeOf((*MockCategoryUsecase)(nil).SetBookTaxonomy), ctx, bookID, in)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockCategoryUsecase) UpdateCategory(ctx context.Context, id int64, in domain.CategoryInput) (*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, id, in)
+	ret0, _ := ret[0].(*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockCategoryUsecaseMockRecorder) UpdateCategory(ctx, id, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockCategoryUsecase)(nil).UpdateCategory), ctx, id, in)
+}
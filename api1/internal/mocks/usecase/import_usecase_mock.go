@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/import_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/import_usecase.go -destination=api1/internal/mocks/usecase/import_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockImportUsecase is a mock of ImportUsecase interface.
+type MockImportUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockImportUsecaseMockRecorder is the mock recorder for MockImportUsecase.
+type MockImportUsecaseMockRecorder struct {
+	mock *MockImportUsecase
+}
+
+// NewMockImportUsecase creates a new mock instance.
+func NewMockImportUsecase(ctrl *gomock.Controller) *MockImportUsecase {
+	mock := &MockImportUsecase{ctrl: ctrl}
+	mock.recorder = &MockImportUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportUsecase) EXPECT() *MockImportUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CancelImport mocks base method.
+func (m *MockImportUsecase) CancelImport(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelImport", ctx, id)
+	ret0, _ := ret[0].(*domain.ImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelImport indicates an expected call of CancelImport.
+func (mr *MockImportUsecaseMockRecorder) CancelImport(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelImport", reflect.TypeOf((*MockImportUsecase)(nil).CancelImport), ctx, id)
+}
+
+// GetImport mocks base method.
+func (m *MockImportUsecase) GetImport(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImport", ctx, id)
+	ret0, _ := ret[0].(*domain.ImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImport indicates an expected call of GetImport.
+func (mr *MockImportUsecaseMockRecorder) GetImport(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImport", reflect.TypeOf((*MockImportUsecase)(nil).GetImport), ctx, id)
+}
+
+// ListImportErrors mocks base method.
+func (m *MockImportUsecase) ListImportErrors(ctx context.Context, id int64, limit, offset int) ([]*domain.ImportRowError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImportErrors", ctx, id, limit, offset)
+	ret0, _ := ret[0].([]*domain.ImportRowError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImportErrors indicates an expected call of ListImportErrors.
+func (mr *MockImportUsecaseMockRecorder) ListImportErrors(ctx, id, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImportErrors", reflect.TypeOf((*MockImportUsecase)(nil).ListImportErrors), ctx, id, limit, offset)
+}
+
+// RunNext mocks base method.
+func (m *MockImportUsecase) RunNext(ctx context.Context) (*domain.ImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunNext", ctx)
+	ret0, _ := ret[0].(*domain.ImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunNext indicates an expected call of RunNext.
+func (mr *MockImportUsecaseMockRecorder) RunNext(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunNext", reflect.TypeOf((*MockImportUsecase)(nil).RunNext), ctx)
+}
+
+// StartImport mocks base method.
+func (m *MockImportUsecase) StartImport(ctx context.Context, format domain.ImportFormat, r io.Reader) (*domain.ImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImport", ctx, format, r)
+	ret0, _ := ret[0].(*domain.ImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartImport indicates an expected call of StartImport.
+func (mr *MockImportUsecaseMockRecorder) StartImport(ctx, format, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImport", reflect.TypeOf((*MockImportUsecase)(nil).StartImport), ctx, format, r)
+}
@@ -0,0 +1,237 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/loan_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/loan_usecase.go -destination=api1/internal/mocks/usecase/loan_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoanUsecase is a mock of LoanUsecase interface.
+type MockLoanUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoanUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockLoanUsecaseMockRecorder is the mock recorder for MockLoanUsecase.
+type MockLoanUsecaseMockRecorder struct {
+	mock *MockLoanUsecase
+}
+
+// NewMockLoanUsecase creates a new mock instance.
+func NewMockLoanUsecase(ctrl *gomock.Controller) *MockLoanUsecase {
+	mock := &MockLoanUsecase{ctrl: ctrl}
+	mock.recorder = &MockLoanUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoanUsecase) EXPECT() *MockLoanUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AddCopy mocks base method.
+func (m *MockLoanUsecase) AddCopy(ctx context.Context, bookID int64, in domain.CreateCopyInput) (*domain.Copy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCopy", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.Copy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCopy indicates an expected call of AddCopy.
+func (mr *MockLoanUsecaseMockRecorder) AddCopy(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCopy", reflect.TypeOf((*MockLoanUsecase)(nil).AddCopy), ctx, bookID, in)
+}
+
+// CancelHold mocks base method.
+func (m *MockLoanUsecase) CancelHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelHold", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelHold indicates an expected call of CancelHold.
+func (mr *MockLoanUsecaseMockRecorder) CancelHold(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelHold", reflect.TypeOf((*MockLoanUsecase)(nil).CancelHold), ctx, bookID, id)
+}
+
+// Checkout mocks base method.
+func (m *MockLoanUsecase) Checkout(ctx context.Context, in domain.CheckoutInput) (*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkout", ctx, in)
+	ret0, _ := ret[0].(*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkout indicates an expected call of Checkout.
+func (mr *MockLoanUsecaseMockRecorder) Checkout(ctx, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockLoanUsecase)(nil).Checkout), ctx, in)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockLoanUsecase) ExpireHolds(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockLoanUsecaseMockRecorder) ExpireHolds(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockLoanUsecase)(nil).ExpireHolds), ctx)
+}
+
+// GetHold mocks base method.
+func (m *MockLoanUsecase) GetHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockLoanUsecaseMockRecorder) GetHold(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockLoanUsecase)(nil).GetHold), ctx, bookID, id)
+}
+
+// GetLoan mocks base method.
+func (m *MockLoanUsecase) GetLoan(ctx context.Context, id int64) (*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoan", ctx, id)
+	ret0, _ := ret[0].(*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoan indicates an expected call of GetLoan.
+func (mr *MockLoanUsecaseMockRecorder) GetLoan(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoan", reflect.TypeOf((*MockLoanUsecase)(nil).GetLoan), ctx, id)
+}
+
+// ListCopies mocks base method.
+func (m *MockLoanUsecase) ListCopies(ctx context.Context, bookID int64) ([]*domain.Copy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCopies", ctx, bookID)
+	ret0, _ := ret[0].([]*domain.Copy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCopies indicates an expected call of ListCopies.
+func (mr *MockLoanUsecaseMockRecorder) ListCopies(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCopies", reflect.TypeOf((*MockLoanUsecase)(nil).ListCopies), ctx, bookID)
+}
+
+// ListHolds mocks base method.
+func (m *MockLoanUsecase) ListHolds(ctx context.Context, q domain.ListHoldsQuery) ([]*domain.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHolds", ctx, q)
+	ret0, _ := ret[0].([]*domain.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHolds indicates an expected call of ListHolds.
+func (mr *MockLoanUsecaseMockRecorder) ListHolds(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHolds", reflect.TypeOf((*MockLoanUsecase)(nil).ListHolds), ctx, q)
+}
+
+// ListLoans mocks base method.
+func (m *MockLoanUsecase) ListLoans(ctx context.Context, q domain.ListLoansQuery, overdue bool) ([]*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoans", ctx, q, overdue)
+	ret0, _ := ret[0].([]*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoans indicates an expected call of ListLoans.
+func (mr *MockLoanUsecaseMockRecorder) ListLoans(ctx, q, overdue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoans", reflect.TypeOf((*MockLoanUsecase)(nil).ListLoans), ctx, q, overdue)
+}
+
+// PlaceHold mocks base method.
+func (m *MockLoanUsecase) PlaceHold(ctx context.Context, bookID int64) (*domain.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceHold", ctx, bookID)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceHold indicates an expected call of PlaceHold.
+func (mr *MockLoanUsecaseMockRecorder) PlaceHold(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceHold", reflect.TypeOf((*MockLoanUsecase)(nil).PlaceHold), ctx, bookID)
+}
+
+// Renew mocks base method.
+func (m *MockLoanUsecase) Renew(ctx context.Context, id int64) (*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx, id)
+	ret0, _ := ret[0].(*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockLoanUsecaseMockRecorder) Renew(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockLoanUsecase)(nil).Renew), ctx, id)
+}
+
+// ReportLost mocks base method.
+func (m *MockLoanUsecase) ReportLost(ctx context.Context, id int64) (*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportLost", ctx, id)
+	ret0, _ := ret[0].(*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportLost indicates an expected call of ReportLost.
+func (mr *MockLoanUsecaseMockRecorder) ReportLost(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportLost", reflect.TypeOf((*MockLoanUsecase)(nil).ReportLost), ctx, id)
+}
+
+// Return mocks base method.
+func (m *MockLoanUsecase) Return(ctx context.Context, id int64) (*domain.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Return", ctx, id)
+	ret0, _ := ret[0].(*domain.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Return indicates an expected call of Return.
+func (mr *MockLoanUsecaseMockRecorder) Return(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Return", reflect.TypeOf((*MockLoanUsecase)(nil).Return), ctx, id)
+}
@@ -0,0 +1,161 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/order_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/order_usecase.go -destination=api1/internal/mocks/usecase/order_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderUsecase is a mock of OrderUsecase interface.
+type MockOrderUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderUsecaseMockRecorder is the mock recorder for MockOrderUsecase.
+type MockOrderUsecaseMockRecorder struct {
+	mock *MockOrderUsecase
+}
+
+// NewMockOrderUsecase creates a new mock instance.
+func NewMockOrderUsecase(ctrl *gomock.Controller) *MockOrderUsecase {
+	mock := &MockOrderUsecase{ctrl: ctrl}
+	mock.recorder = &MockOrderUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderUsecase) EXPECT() *MockOrderUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ClearCart mocks base method.
+func (m *MockOrderUsecase) ClearCart(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearCart", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearCart indicates an expected call of ClearCart.
+func (mr *MockOrderUsecaseMockRecorder) ClearCart(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCart", reflect.TypeOf((*MockOrderUsecase)(nil).ClearCart), ctx)
+}
+
+// GetCart mocks base method.
+func (m *MockOrderUsecase) GetCart(ctx context.Context) (*domain.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", ctx)
+	ret0, _ := ret[0].(*domain.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockOrderUsecaseMockRecorder) GetCart(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockOrderUsecase)(nil).GetCart), ctx)
+}
+
+// GetInventory mocks base method.
+func (m *MockOrderUsecase) GetInventory(ctx context.Context, bookID int64) (*domain.Inventory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInventory", ctx, bookID)
+	ret0, _ := ret[0].(*domain.Inventory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInventory indicates an expected call of GetInventory.
+func (mr *MockOrderUsecaseMockRecorder) GetInventory(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventory", reflect.TypeOf((*MockOrderUsecase)(nil).GetInventory), ctx, bookID)
+}
+
+// GetOrder mocks base method.
+func (m *MockOrderUsecase) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, id)
+	ret0, _ := ret[0].(*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockOrderUsecaseMockRecorder) GetOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrderUsecase)(nil).GetOrder), ctx, id)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderUsecase) ListOrders(ctx context.Context, limit, offset int) ([]*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, limit, offset)
+	ret0, _ := ret[0].([]*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderUsecaseMockRecorder) ListOrders(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderUsecase)(nil).ListOrders), ctx, limit, offset)
+}
+
+// PlaceOrder mocks base method.
+func (m *MockOrderUsecase) PlaceOrder(ctx context.Context, in domain.PlaceOrderInput) (*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceOrder", ctx, in)
+	ret0, _ := ret[0].(*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceOrder indicates an expected call of PlaceOrder.
+func (mr *MockOrderUsecaseMockRecorder) PlaceOrder(ctx, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceOrder", reflect.TypeOf((*MockOrderUsecase)(nil).PlaceOrder), ctx, in)
+}
+
+// SetCartLine mocks base method.
+func (m *MockOrderUsecase) SetCartLine(ctx context.Context, bookID int64, in domain.SetCartLineInput) (*domain.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCartLine", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCartLine indicates an expected call of SetCartLine.
+func (mr *MockOrderUsecaseMockRecorder) SetCartLine(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCartLine", reflect.TypeOf((*MockOrderUsecase)(nil).SetCartLine), ctx, bookID, in)
+}
+
+// SetInventory mocks base method.
+func (m *MockOrderUsecase) SetInventory(ctx context.Context, bookID int64, in domain.InventoryInput) (*domain.Inventory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInventory", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.Inventory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetInventory indicates an expected call of SetInventory.
+func (mr *MockOrderUsecaseMockRecorder) SetInventory(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInventory", reflect.TypeOf((*MockOrderUsecase)(nil).SetInventory), ctx, bookID, in)
+}
@@ -0,0 +1,147 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/payment_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/payment_usecase.go -destination=api1/internal/mocks/usecase/payment_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentUsecase is a mock of PaymentUsecase interface.
+type MockPaymentUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentUsecaseMockRecorder is the mock recorder for MockPaymentUsecase.
+type MockPaymentUsecaseMockRecorder struct {
+	mock *MockPaymentUsecase
+}
+
+// NewMockPaymentUsecase creates a new mock instance.
+func NewMockPaymentUsecase(ctrl *gomock.Controller) *MockPaymentUsecase {
+	mock := &MockPaymentUsecase{ctrl: ctrl}
+	mock.recorder = &MockPaymentUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentUsecase) EXPECT() *MockPaymentUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ApplyGatewayPayment mocks base method.
+func (m *MockPaymentUsecase) ApplyGatewayPayment(ctx context.Context, gp domain.GatewayPayment) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyGatewayPayment", ctx, gp)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyGatewayPayment indicates an expected call of ApplyGatewayPayment.
+func (mr *MockPaymentUsecaseMockRecorder) ApplyGatewayPayment(ctx, gp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyGatewayPayment", reflect.TypeOf((*MockPaymentUsecase)(nil).ApplyGatewayPayment), ctx, gp)
+}
+
+// Authorize mocks base method.
+func (m *MockPaymentUsecase) Authorize(ctx context.Context, id int64, token string) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, id, token)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockPaymentUsecaseMockRecorder) Authorize(ctx, id, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockPaymentUsecase)(nil).Authorize), ctx, id, token)
+}
+
+// GetOrderPayment mocks base method.
+func (m *MockPaymentUsecase) GetOrderPayment(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderPayment", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderPayment indicates an expected call of GetOrderPayment.
+func (mr *MockPaymentUsecaseMockRecorder) GetOrderPayment(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderPayment", reflect.TypeOf((*MockPaymentUsecase)(nil).GetOrderPayment), ctx, orderID)
+}
+
+// Open mocks base method.
+func (m *MockPaymentUsecase) Open(ctx context.Context, order *domain.Order) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, order)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockPaymentUsecaseMockRecorder) Open(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockPaymentUsecase)(nil).Open), ctx, order)
+}
+
+// Reconcile mocks base method.
+func (m *MockPaymentUsecase) Reconcile(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockPaymentUsecaseMockRecorder) Reconcile(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockPaymentUsecase)(nil).Reconcile), ctx)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentUsecase) Refund(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentUsecaseMockRecorder) Refund(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentUsecase)(nil).Refund), ctx, orderID)
+}
+
+// Void mocks base method.
+func (m *MockPaymentUsecase) Void(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Void", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Void indicates an expected call of Void.
+func (mr *MockPaymentUsecaseMockRecorder) Void(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Void", reflect.TypeOf((*MockPaymentUsecase)(nil).Void), ctx, orderID)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/review_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/review_usecase.go -destination=api1/internal/mocks/usecase/review_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReviewUsecase is a mock of ReviewUsecase interface.
+type MockReviewUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockReviewUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockReviewUsecaseMockRecorder is the mock recorder for MockReviewUsecase.
+type MockReviewUsecaseMockRecorder struct {
+	mock *MockReviewUsecase
+}
+
+// NewMockReviewUsecase creates a new mock instance.
+func NewMockReviewUsecase(ctrl *gomock.Controller) *MockReviewUsecase {
+	mock := &MockReviewUsecase{ctrl: ctrl}
+	mock.recorder = &MockReviewUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReviewUsecase) EXPECT() *MockReviewUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateReview mocks base method.
+func (m *MockReviewUsecase) CreateReview(ctx context.Context, bookID int64, in domain.ReviewInput) (*domain.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReview", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReview indicates an expected call of CreateReview.
+func (mr *MockReviewUsecaseMockRecorder) CreateReview(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReview", reflect.TypeOf((*MockReviewUsecase)(nil).CreateReview), ctx, bookID, in)
+}
+
+// DeleteReview mocks base method.
+func (m *MockReviewUsecase) DeleteReview(ctx context.Context, bookID, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReview", ctx, bookID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReview indicates an expected call of DeleteReview.
+func (mr *MockReviewUsecaseMockRecorder) DeleteReview(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReview", reflect.TypeOf((*MockReviewUsecase)(nil).DeleteReview), ctx, bookID, id)
+}
+
+// GetReview mocks base method.
+func (m *MockReviewUsecase) GetReview(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReview", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReview indicates an expected call of GetReview.
+func (mr *MockReviewUsecaseMockRecorder) GetReview(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReview", reflect.TypeOf((*MockReviewUsecase)(nil).GetReview), ctx, bookID, id)
+}
+
+// ListReviews mocks base method.
+func (m *MockReviewUsecase) ListReviews(ctx context.Context, q domain.ListReviewsQuery) ([]*domain.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReviews", ctx, q)
+	ret0, _ := ret[0].([]*domain.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReviews indicates an expected call of ListReviews.
+func (mr *MockReviewUsecaseMockRecorder) ListReviews(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReviews", reflect.TypeOf((*MockReviewUsecase)(nil).ListReviews), ctx, q)
+}
+
+// MarkHelpful mocks base method.
+func (m *MockReviewUsecase) MarkHelpful(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkHelpful", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkHelpful indicates an expected call of MarkHelpful.
+func (mr *MockReviewUsecaseMockRecorder) MarkHelpful(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkHelpful", reflect.TypeOf((*MockReviewUsecase)(nil).MarkHelpful), ctx, bookID, id)
+}
+
+// UpdateReview mocks base method.
+func (m *MockReviewUsecase) UpdateReview(ctx context.Context, bookID, id int64, in domain.ReviewInput) (*domain.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReview", ctx, bookID, id, in)
+	ret0, _ := ret[0].(*domain.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateReview indicates an expected call of UpdateReview.
+func (mr *MockReviewUsecaseMockRecorder) UpdateReview(ctx, bookID, id, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReview", reflect.TypeOf((*MockReviewUsecase)(nil).UpdateReview), ctx, bookID, id, in)
+}
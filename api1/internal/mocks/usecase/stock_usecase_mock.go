@@ -0,0 +1,208 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/stock_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/stock_usecase.go -destination=api1/internal/mocks/usecase/stock_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockUsecase is a mock of StockUsecase interface.
+type MockStockUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockStockUsecaseMockRecorder is the mock recorder for MockStockUsecase.
+type MockStockUsecaseMockRecorder struct {
+	mock *MockStockUsecase
+}
+
+// NewMockStockUsecase creates a new mock instance.
+func NewMockStockUsecase(ctrl *gomock.Controller) *MockStockUsecase {
+	mock := &MockStockUsecase{ctrl: ctrl}
+	mock.recorder = &MockStockUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockUsecase) EXPECT() *MockStockUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AdjustStock mocks base method.
+func (m *MockStockUsecase) AdjustStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustStock", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.StockLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustStock indicates an expected call of AdjustStock.
+func (mr *MockStockUsecaseMockRecorder) AdjustStock(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustStock", reflect.TypeOf((*MockStockUsecase)(nil).AdjustStock), ctx, bookID, in)
+}
+
+// ExpireReservations mocks base method.
+func (m *MockStockUsecase) ExpireReservations(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireReservations", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireReservations indicates an expected call of ExpireReservations.
+func (mr *MockStockUsecaseMockRecorder) ExpireReservations(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireReservations", reflect.TypeOf((*MockStockUsecase)(nil).ExpireReservations), ctx)
+}
+
+// GetReservation mocks base method.
+func (m *MockStockUsecase) GetReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservation", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservation indicates an expected call of GetReservation.
+func (mr *MockStockUsecaseMockRecorder) GetReservation(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservation", reflect.TypeOf((*MockStockUsecase)(nil).GetReservation), ctx, bookID, id)
+}
+
+// GetStock mocks base method.
+func (m *MockStockUsecase) GetStock(ctx context.Context, bookID int64) (*domain.StockLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStock", ctx, bookID)
+	ret0, _ := ret[0].(*domain.StockLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStock indicates an expected call of GetStock.
+func (mr *MockStockUsecaseMockRecorder) GetStock(ctx, bookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStock", reflect.TypeOf((*MockStockUsecase)(nil).GetStock), ctx, bookID)
+}
+
+// ListMovements mocks base method.
+func (m *MockStockUsecase) ListMovements(ctx context.Context, bookID int64, limit, offset int) ([]*domain.StockMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMovements", ctx, bookID, limit, offset)
+	ret0, _ := ret[0].([]*domain.StockMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMovements indicates an expected call of ListMovements.
+func (mr *MockStockUsecaseMockRecorder) ListMovements(ctx, bookID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMovements", reflect.TypeOf((*MockStockUsecase)(nil).ListMovements), ctx, bookID, limit, offset)
+}
+
+// ReceiveStock mocks base method.
+func (m *MockStockUsecase) ReceiveStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveStock", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.StockLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReceiveStock indicates an expected call of ReceiveStock.
+func (mr *MockStockUsecaseMockRecorder) ReceiveStock(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveStock", reflect.TypeOf((*MockStockUsecase)(nil).ReceiveStock), ctx, bookID, in)
+}
+
+// Release mocks base method.
+func (m *MockStockUsecase) Release(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockStockUsecaseMockRecorder) Release(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockStockUsecase)(nil).Release), ctx, bookID, id)
+}
+
+// Reserve mocks base method.
+func (m *MockStockUsecase) Reserve(ctx context.Context, bookID int64, in domain.ReserveStockInput) (*domain.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockStockUsecaseMockRecorder) Reserve(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockStockUsecase)(nil).Reserve), ctx, bookID, in)
+}
+
+// ReserveLines mocks base method.
+func (m *MockStockUsecase) ReserveLines(ctx context.Context, lines []domain.StockLine, ref string, ttl time.Duration) ([]*domain.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveLines", ctx, lines, ref, ttl)
+	ret0, _ := ret[0].([]*domain.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveLines indicates an expected call of ReserveLines.
+func (mr *MockStockUsecaseMockRecorder) ReserveLines(ctx, lines, ref, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveLines", reflect.TypeOf((*MockStockUsecase)(nil).ReserveLines), ctx, lines, ref, ttl)
+}
+
+// SellReservation mocks base method.
+func (m *MockStockUsecase) SellReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SellReservation", ctx, bookID, id)
+	ret0, _ := ret[0].(*domain.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SellReservation indicates an expected call of SellReservation.
+func (mr *MockStockUsecaseMockRecorder) SellReservation(ctx, bookID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SellReservation", reflect.TypeOf((*MockStockUsecase)(nil).SellReservation), ctx, bookID, id)
+}
+
+// SetLowStockThreshold mocks base method.
+func (m *MockStockUsecase) SetLowStockThreshold(ctx context.Context, bookID int64, in domain.LowStockInput) (*domain.StockLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLowStockThreshold", ctx, bookID, in)
+	ret0, _ := ret[0].(*domain.StockLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLowStockThreshold indicates an expected call of SetLowStockThreshold.
+func (mr *MockStockUsecaseMockRecorder) SetLowStockThreshold(ctx, bookID, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLowStockThreshold", reflect.TypeOf((*MockStockUsecase)(nil).SetLowStockThreshold), ctx, bookID, in)
+}
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api1/internal/usecase/tenant_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api1/internal/usecase/tenant_usecase.go -destination=api1/internal/mocks/usecase/tenant_usecase_mock.go -package=usecase_mock
+//
+
+// Package usecase_mock is a generated GoMock package.
+package usecase_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "unit-test-demo/api1/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTenantUsecase is a mock of TenantUsecase interface.
+type MockTenantUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockTenantUsecaseMockRecorder is the mock recorder for MockTenantUsecase.
+type MockTenantUsecaseMockRecorder struct {
+	mock *MockTenantUsecase
+}
+
+// NewMockTenantUsecase creates a new mock instance.
+func NewMockTenantUsecase(ctrl *gomock.Controller) *MockTenantUsecase {
+	mock := &MockTenantUsecase{ctrl: ctrl}
+	mock.recorder = &MockTenantUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTenantUsecase) EXPECT() *MockTenantUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ActiveTenant mocks base method.
+func (m *MockTenantUsecase) ActiveTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActiveTenant", ctx, id)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActiveTenant indicates an expected call of ActiveTenant.
+func (mr *MockTenantUsecaseMockRecorder) ActiveTenant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveTenant", reflect.TypeOf((*MockTenantUsecase)(nil).ActiveTenant), ctx, id)
+}
+
+// CreateTenant mocks base method.
+func (m *MockTenantUsecase) CreateTenant(ctx context.Context, in domain.CreateTenantInput) (*domain.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTenant", ctx, in)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTenant indicates an expected call of CreateTenant.
+func (mr *MockTenantUsecaseMockRecorder) CreateTenant(ctx, in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTenant", reflect.TypeOf((*MockTenantUsecase)(nil).CreateTenant), ctx, in)
+}
+
+// ListTenants mocks base method.
+func (m *MockTenantUsecase) ListTenants(ctx context.Context, limit, offset int) ([]*domain.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTenants", ctx, limit, offset)
+	ret0, _ := ret[0].([]*domain.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTenants indicates an expected call of ListTenants.
+func (mr *MockTenantUsecaseMockRecorder) ListTenants(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTenants", reflect.TypeOf((*MockTenantUsecase)(nil).ListTenants), ctx, limit, offset)
+}
+
+// ResumeTenant mocks base method.
+func (m *MockTenantUsecase) ResumeTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeTenant", ctx, id)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeTenant indicates an expected call of ResumeTenant.
+func (mr *MockTenantUsecaseMockRecorder) ResumeTenant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeTenant", reflect.TypeOf((*MockTenantUsecase)(nil).ResumeTenant), ctx, id)
+}
+
+// SuspendTenant mocks base method.
+func (m *MockTenantUsecase) SuspendTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendTenant", ctx, id)
+	ret0, _ := ret[0].(*domain.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendTenant indicates an expected call of SuspendTenant.
+func (mr *MockTenantUsecaseMockRecorder) SuspendTenant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendTenant", reflect.TypeOf((*MockTenantUsecase)(nil).SuspendTenant), ctx, id)
+}
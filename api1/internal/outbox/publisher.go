@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// Publisher delivers one outbox message to the outside world. A nil error
+// means the message was accepted and will not be sent again.
+type Publisher interface {
+	Publish(ctx context.Context, msg domain.OutboxMessage) error
+}
+
+// StdoutPublisher writes each message as a JSON line. It is meant for local
+// runs and debugging.
+type StdoutPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutPublisher(w io.Writer) *StdoutPublisher {
+	return &StdoutPublisher{w: w}
+}
+
+func (p *StdoutPublisher) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return json.NewEncoder(p.w).Encode(msg)
+}
+
+// HTTPPublisher POSTs each message as JSON to a fixed URL. Any non-2xx
+// status counts as a failure and the message is retried.
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher posts with client, or without one with a client that
+// gives up on a sink after defaultPublishTimeout.
+func NewHTTPPublisher(url string, client *http.Client) *HTTPPublisher {
+	if client == nil {
+		client = &http.Client{Timeout: defaultPublishTimeout}
+	}
+	return &HTTPPublisher{url: url, client: client}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("outbox-%d", msg.ID))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("publish outbox message %d: unexpected status %d", msg.ID, res.StatusCode)
+	}
+	return nil
+}
+
+// MemoryPublisher keeps every published message in order. Tests can make
+// it fail through FailWith.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []domain.OutboxMessage
+	failWith error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failWith != nil {
+		return p.failWith
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// FailWith makes every following Publish return err; nil restores success.
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failWith = err
+}
+
+func (p *MemoryPublisher) Messages() []domain.OutboxMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.OutboxMessage(nil), p.messages...)
+}
+
+// MultiPublisher hands each message to every publisher in turn. If one
+// fails the message is retried for all of them, so each must tolerate
+// duplicates.
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
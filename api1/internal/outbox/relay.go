@@ -0,0 +1,153 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// RelayConfig tunes polling and retry behaviour. PublishTimeout bounds
+// the publishing of one message. Zero values fall back to the defaults
+// below.
+type RelayConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+	PublishTimeout time.Duration
+}
+
+const (
+	defaultPollInterval   = time.Second
+	defaultBatchSize      = 100
+	defaultBaseBackoff    = time.Second
+	defaultMaxBackoff     = 5 * time.Minute
+	defaultPublishTimeout = 10 * time.Second
+)
+
+// Relay moves messages from the outbox to a Publisher. Each batch is
+// claimed with a lease, published with no transaction open, and marked in
+// a second transaction; a crash before the marks leaves the messages to
+// be claimed again once the lease is up, so delivery is at-least-once.
+// Consumers should de-duplicate on the message ID.
+type Relay struct {
+	tx    domain.Transactor
+	store domain.OutboxRepository
+	pub   Publisher
+	cfg   RelayConfig
+	now   func() time.Time
+}
+
+func NewRelay(tx domain.Transactor, store domain.OutboxRepository, pub Publisher, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = defaultPublishTimeout
+	}
+	return &Relay{tx: tx, store: store, pub: pub, cfg: cfg, now: time.Now}
+}
+
+// Run polls until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("outbox relay: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce relays one batch and reports how many messages were dispatched.
+// A failed publish is not an error here: the message is rescheduled with
+// backoff and the rest of the batch carries on. The lease covers every
+// message of a full batch taking its PublishTimeout.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	now := r.now()
+	lease := time.Duration(r.cfg.BatchSize) * r.cfg.PublishTimeout
+	var msgs []domain.OutboxMessage
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		msgs, err = r.store.ClaimPending(ctx, now, now.Add(lease), r.cfg.BatchSize)
+		return err
+	})
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+
+	// A message missing from outcome was not tried before ctx ended; the
+	// lease hands it out again.
+	outcome := make(map[int64]error, len(msgs))
+	for _, m := range msgs {
+		err := r.publish(ctx, m)
+		if ctx.Err() != nil {
+			break
+		}
+		outcome[m.ID] = err
+	}
+
+	dispatched := 0
+	err = r.tx.WithinTx(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		dispatched = 0
+		at := r.now()
+		for _, m := range msgs {
+			err, tried := outcome[m.ID]
+			if !tried {
+				continue
+			}
+			if err != nil {
+				next := at.Add(r.backoff(m.Attempts + 1))
+				if err := r.store.MarkFailed(ctx, m.ID, next, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.store.MarkDispatched(ctx, m.ID, at); err != nil {
+				return err
+			}
+			dispatched++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dispatched, nil
+}
+
+// publish hands m to the publisher, giving it PublishTimeout.
+func (r *Relay) publish(ctx context.Context, m domain.OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+	defer cancel()
+	return r.pub.Publish(ctx, m)
+}
+
+// backoff doubles from BaseBackoff for each failed attempt, capped at
+// MaxBackoff.
+func (r *Relay) backoff(attempt int) time.Duration {
+	d := r.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return d
+}
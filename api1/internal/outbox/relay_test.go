@@ -0,0 +1,167 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/outbox"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+func book(id int64, title string) domain.Book {
+	return domain.Book{ID: id, Title: title, Author: "Frank Herbert"}
+}
+
+func TestRelay_DispatchesAndMarks(t *testing.T) {
+	// Arrange
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	store := memory.NewOutboxRepository()
+	pub := outbox.NewMemoryPublisher()
+	require.NoError(t, store.Append(ctx,
+		domain.BookCreated{Book: book(1, "Dune")},
+		domain.BookCreated{Book: book(2, "Emma")},
+	))
+	relay := outbox.NewRelay(memory.NewTransactor(), store, pub, outbox.RelayConfig{})
+
+	// Act
+	n, err := relay.RunOnce(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, pub.Messages(), 2)
+	assert.Equal(t, 0, store.Pending())
+
+	// Nothing left to send on the next poll.
+	n, _ = relay.RunOnce(ctx)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelay_OrderedPerAggregate(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	store := memory.NewOutboxRepository()
+	pub := outbox.NewMemoryPublisher()
+	require.NoError(t, store.Append(ctx,
+		domain.BookCreated{Book: book(1, "Dune")},
+		domain.BookUpdated{Book: book(1, "Dune Messiah")},
+		domain.BookUpdated{Book: book(1, "Children of Dune")},
+	))
+	relay := outbox.NewRelay(memory.NewTransactor(), store, pub, outbox.RelayConfig{})
+
+	// Only the head of an aggregate is eligible per poll.
+	for i := 0; i < 3; i++ {
+		n, err := relay.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+
+	msgs := pub.Messages()
+	require.Len(t, msgs, 3)
+	assert.Equal(t, domain.EventBookCreated, msgs[0].EventType)
+	assert.Less(t, msgs[0].ID, msgs[1].ID)
+	assert.Less(t, msgs[1].ID, msgs[2].ID)
+}
+
+func TestRelay_RetriesWithBackoff(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	store := memory.NewOutboxRepository()
+	pub := outbox.NewMemoryPublisher()
+	require.NoError(t, store.Append(ctx,
+		domain.BookCreated{Book: book(1, "Dune")},
+		domain.BookUpdated{Book: book(1, "Dune Messiah")},
+	))
+	relay := outbox.NewRelay(memory.NewTransactor(), store, pub, outbox.RelayConfig{BaseBackoff: time.Hour})
+
+	pub.FailWith(errors.New("broker down"))
+	n, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// The failed head is backing off and holds back the later update.
+	pub.FailWith(nil)
+	n, _ = relay.RunOnce(ctx)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 2, store.Pending())
+
+	msgs, _ := store.ClaimPending(ctx, time.Now().Add(2*time.Hour), time.Now().Add(3*time.Hour), 10)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 1, msgs[0].Attempts)
+	assert.Equal(t, domain.EventBookCreated, msgs[0].EventType)
+}
+
+type publishFunc func(ctx context.Context, msg domain.OutboxMessage) error
+
+func (f publishFunc) Publish(ctx context.Context, msg domain.OutboxMessage) error { return f(ctx, msg) }
+
+func TestRelay_PublishesOutsideTheTransaction(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	store := memory.NewOutboxRepository()
+	require.NoError(t, store.Append(ctx, domain.BookCreated{Book: book(1, "Dune")}))
+	tx := memory.NewTransactor()
+	other := outbox.NewRelay(tx, store, outbox.NewMemoryPublisher(), outbox.RelayConfig{})
+	var claimedMeanwhile int
+	relay := outbox.NewRelay(tx, store, publishFunc(func(ctx context.Context, msg domain.OutboxMessage) error {
+		// The memory transactor runs one transaction at a time, so this
+		// would hang if the relay still held one.
+		n, err := other.RunOnce(ctx)
+		claimedMeanwhile = n
+		return err
+	}), outbox.RelayConfig{})
+
+	n, err := relay.RunOnce(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Zero(t, claimedMeanwhile, "the message is leased to the first relay")
+	assert.Zero(t, store.Pending())
+}
+
+func TestRelay_GivesUpOnAHungPublisher(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	store := memory.NewOutboxRepository()
+	require.NoError(t, store.Append(ctx, domain.BookCreated{Book: book(1, "Dune")}))
+	hung := publishFunc(func(ctx context.Context, msg domain.OutboxMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	relay := outbox.NewRelay(memory.NewTransactor(), store, hung, outbox.RelayConfig{PublishTimeout: 20 * time.Millisecond})
+
+	n, err := relay.RunOnce(ctx)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	msgs, _ := store.ClaimPending(ctx, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour), 10)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 1, msgs[0].Attempts, "the timeout counts as a failed attempt")
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "outbox-7", r.Header.Get("Idempotency-Key"))
+		if calls.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	pub := outbox.NewHTTPPublisher(srv.URL, srv.Client())
+	msg := domain.OutboxMessage{ID: 7, EventType: domain.EventBookCreated, Payload: []byte(`{}`)}
+
+	assert.Error(t, pub.Publish(context.Background(), msg))
+	assert.NoError(t, pub.Publish(context.Background(), msg))
+}
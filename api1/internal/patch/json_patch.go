@@ -0,0 +1,346 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of a JSON Patch. Value is kept raw so that a
+// missing value can be told apart from null.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is an RFC 6902 JSON Patch. Its operations apply in order, and
+// the patch applies as a whole or not at all.
+type JSONPatch struct {
+	ops []op
+}
+
+// op is an Operation with its pointers and value parsed.
+type op struct {
+	Operation
+	path, from []string
+	value      any
+}
+
+func ParseJSONPatch(body []byte) (*JSONPatch, error) {
+	var ops []Operation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: want an array of operations: %v", ErrInvalid, err)
+	}
+
+	p := &JSONPatch{ops: make([]op, 0, len(ops))}
+	for i, o := range ops {
+		parsed, err := parseOp(o)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalid, i, err)
+		}
+		p.ops = append(p.ops, parsed)
+	}
+	return p, nil
+}
+
+func parseOp(o Operation) (op, error) {
+	parsed := op{Operation: o}
+	var err error
+	if parsed.path, err = parsePointer(o.Path); err != nil {
+		return op{}, fmt.Errorf("path: %v", err)
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return op{}, fmt.Errorf("%s needs a value", o.Op)
+		}
+		if parsed.value, err = decode(o.Value); err != nil {
+			return op{}, fmt.Errorf("value: %v", err)
+		}
+	case "remove":
+	case "move", "copy":
+		if parsed.from, err = parsePointer(o.From); err != nil {
+			return op{}, fmt.Errorf("from: %v", err)
+		}
+		if o.Op == "move" && isProperPrefix(parsed.from, parsed.path) {
+			return op{}, fmt.Errorf("cannot move %q into one of its children", o.From)
+		}
+	default:
+		return op{}, fmt.Errorf("unknown op %q", o.Op)
+	}
+	return parsed, nil
+}
+
+func (p *JSONPatch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range p.ops {
+		if root, err = o.apply(root); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, o.Op, o.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// apply runs o against root and returns the new root. Values from the
+// patch are copied in, so the patch can be applied again.
+func (o op) apply(root any) (any, error) {
+	switch o.Op {
+	case "add":
+		return add(root, o.path, clone(o.value))
+	case "remove":
+		root, _, err := remove(root, o.path)
+		return root, err
+	case "replace":
+		if len(o.path) == 0 {
+			return clone(o.value), nil
+		}
+		root, _, err := remove(root, o.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, o.path, clone(o.value))
+	case "move":
+		root, v, err := remove(root, o.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, o.path, v)
+	case "copy":
+		v, err := get(root, o.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, o.path, clone(v))
+	case "test":
+		v, err := get(root, o.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(v, o.value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalid, o.Op)
+}
+
+func get(node any, path []string) (any, error) {
+	for _, tok := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("%w: no member %q", ErrPath, tok)
+			}
+			node = v
+		case []any:
+			i, err := index(tok, len(n))
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is not in a container", ErrPath, tok)
+		}
+	}
+	return node, nil
+}
+
+// add sets the value at path, inserting into arrays, and returns the new
+// root.
+func add(root any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(root, path, func(parent any, tok string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			n[tok] = v
+			return n, nil
+		case []any:
+			if tok == "-" {
+				return append(n, v), nil
+			}
+			i, err := index(tok, len(n)+1)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:i], append([]any{v}, n[i:]...)...), nil
+		}
+		return nil, fmt.Errorf("%w: %q is not in a container", ErrPath, tok)
+	})
+}
+
+// remove deletes the value at path and returns the new root along with
+// the value removed. The whole document cannot be removed.
+func remove(root any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrPath)
+	}
+	var removed any
+	root, err := update(root, path, func(parent any, tok string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("%w: no member %q", ErrPath, tok)
+			}
+			removed = v
+			delete(n, tok)
+			return n, nil
+		case []any:
+			i, err := index(tok, len(n))
+			if err != nil {
+				return nil, err
+			}
+			removed = n[i]
+			return append(n[:i:i], n[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %q is not in a container", ErrPath, tok)
+	})
+	return root, removed, err
+}
+
+// update walks to the container of the last token of path, which must
+// not be empty, and replaces it with what fn returns. Arrays can change
+// length, so every container on the way is written back into its parent.
+func update(node any, path []string, fn func(parent any, tok string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	tok := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[tok]
+		if !ok {
+			return nil, fmt.Errorf("%w: no member %q", ErrPath, tok)
+		}
+		c, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[tok] = c
+		return n, nil
+	case []any:
+		i, err := index(tok, len(n))
+		if err != nil {
+			return nil, err
+		}
+		c, err := update(n[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = c
+		return n, nil
+	}
+	return nil, fmt.Errorf("%w: %q is not in a container", ErrPath, tok)
+}
+
+// index parses an array index token, which must be below limit. Leading
+// zeros are not allowed.
+func index(tok string, limit int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || strings.TrimLeft(tok, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrPath, tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i >= limit {
+		return 0, fmt.Errorf("%w: index %s out of range", ErrPath, tok)
+	}
+	return i, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+// The empty pointer is the whole document.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("pointer %q must start with /", s)
+	}
+	toks := strings.Split(s[1:], "/")
+	for i, t := range toks {
+		toks[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return toks, nil
+}
+
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares decoded JSON values the way the test operation does:
+// numbers by value, objects regardless of member order.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		p, pok := new(big.Rat).SetString(string(x))
+		q, qok := new(big.Rat).SetString(string(y))
+		return pok && qok && p.Cmp(q) == 0
+	default:
+		return a == b
+	}
+}
+
+// clone deep-copies a decoded JSON value.
+func clone(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[k] = clone(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, e := range x {
+			s[i] = clone(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
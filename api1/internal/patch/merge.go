@@ -0,0 +1,49 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch is an RFC 7396 JSON Merge Patch: objects merge member by
+// member, null removes a member, and anything else replaces the target.
+type MergePatch struct {
+	patch any
+}
+
+func ParseMergePatch(body []byte) (*MergePatch, error) {
+	v, err := decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return &MergePatch{patch: v}, nil
+}
+
+func (p *MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p.patch))
+}
+
+// merge is MergePatch(Target, Patch) from section 2 of the RFC. It may
+// modify target, but never patch, so one MergePatch can be applied again.
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
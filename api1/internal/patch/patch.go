@@ -0,0 +1,82 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values, and through them to Go values that
+// round-trip through encoding/json.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media types of the two patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedType means the media type names neither format.
+	ErrUnsupportedType = errors.New("unsupported patch media type")
+	// ErrInvalid means the patch document itself is malformed.
+	ErrInvalid = errors.New("invalid patch")
+	// ErrPath means an operation refers to a location the target does not
+	// have, or the patched document no longer fits the target type.
+	ErrPath = errors.New("patch does not apply")
+	// ErrTestFailed means a JSON Patch test operation did not match.
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Patch changes a JSON document.
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Parse reads body as a patch of the given media type, without parameters.
+func Parse(mediaType string, body []byte) (Patch, error) {
+	switch mediaType {
+	case MergePatchType:
+		return ParseMergePatch(body)
+	case JSONPatchType:
+		return ParseJSONPatch(body)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedType, mediaType)
+	}
+}
+
+// ApplyTo applies p to the JSON encoding of v and decodes the result into
+// a new T. Fields the patch adds that T does not have fail with ErrPath.
+func ApplyTo[T any](p Patch, v T) (T, error) {
+	var out T
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	doc, err = p.Apply(doc)
+	if err != nil {
+		return out, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return out, fmt.Errorf("%w: %v", ErrPath, err)
+	}
+	return out, nil
+}
+
+// decode parses a JSON value, keeping numbers exact so that large IDs
+// survive the round trip.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
@@ -0,0 +1,166 @@
+package patch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/patch"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Cases from Appendix A of RFC 7396.
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		p, err := patch.ParseMergePatch([]byte(tt.patch))
+		require.NoError(t, err)
+
+		got, err := p.Apply([]byte(tt.target))
+
+		require.NoError(t, err, "%s + %s", tt.target, tt.patch)
+		assert.JSONEq(t, tt.want, string(got), "%s + %s", tt.target, tt.patch)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// Mostly the examples of Appendix A of RFC 6902.
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append", `{"foo":[1]}`, `[{"op":"add","path":"/foo/-","value":2}]`, `{"foo":[1,2]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"replace whole document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`},
+		{"test passes", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+		{"add null", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":null}]`, `{"foo":"bar","child":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := patch.ParseJSONPatch([]byte(tt.patch))
+			require.NoError(t, err)
+
+			got, err := p.Apply([]byte(tt.doc))
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestJSONPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		wantErr          error
+	}{
+		{"test fails", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, patch.ErrTestFailed},
+		{"missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, patch.ErrPath},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, patch.ErrPath},
+		{"index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/3","value":2}]`, patch.ErrPath},
+		{"leading zero", `{"foo":[1,2]}`, `[{"op":"replace","path":"/foo/01","value":2}]`, patch.ErrPath},
+		{"replace missing", `{}`, `[{"op":"replace","path":"/a","value":1}]`, patch.ErrPath},
+		{"fails after an earlier op", `{"a":1}`, `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/a","value":2}]`, patch.ErrTestFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := patch.ParseJSONPatch([]byte(tt.patch))
+			require.NoError(t, err)
+
+			_, err = p.Apply([]byte(tt.doc))
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseJSONPatch_Invalid(t *testing.T) {
+	for _, body := range []string{
+		`{"op":"add"}`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"move","from":"/a","path":"/a/b"}]`,
+	} {
+		_, err := patch.ParseJSONPatch([]byte(body))
+		assert.ErrorIs(t, err, patch.ErrInvalid, body)
+	}
+}
+
+func TestParse(t *testing.T) {
+	_, err := patch.Parse(patch.MergePatchType, []byte(`{"a":1}`))
+	assert.NoError(t, err)
+	_, err = patch.Parse(patch.JSONPatchType, []byte(`[]`))
+	assert.NoError(t, err)
+	_, err = patch.Parse("application/json", []byte(`{}`))
+	assert.ErrorIs(t, err, patch.ErrUnsupportedType)
+	_, err = patch.Parse(patch.MergePatchType, []byte(`{`))
+	assert.ErrorIs(t, err, patch.ErrInvalid)
+}
+
+type item struct {
+	ID    int64     `json:"id"`
+	Name  string    `json:"name"`
+	Added time.Time `json:"added"`
+}
+
+func TestApplyTo(t *testing.T) {
+	in := item{ID: 1 << 60, Name: "a", Added: time.Date(2025, 10, 20, 12, 0, 0, 123, time.UTC)}
+	p, err := patch.ParseMergePatch([]byte(`{"name":"b"}`))
+	require.NoError(t, err)
+
+	got, err := patch.ApplyTo(p, in)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<60), got.ID, "large numbers survive")
+	assert.Equal(t, "b", got.Name)
+	assert.True(t, in.Added.Equal(got.Added))
+	assert.Equal(t, "a", in.Name, "input is left alone")
+
+	p, err = patch.ParseMergePatch([]byte(`{"color":"red"}`))
+	require.NoError(t, err)
+	_, err = patch.ApplyTo(p, in)
+	assert.ErrorIs(t, err, patch.ErrPath)
+}
+
+func TestApply_Repeatable(t *testing.T) {
+	p, err := patch.ParseJSONPatch([]byte(`[{"op":"add","path":"/a","value":{"b":[1]}},{"op":"add","path":"/a/b/-","value":2}]`))
+	require.NoError(t, err)
+
+	for range 2 {
+		got, err := p.Apply([]byte(`{}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a":{"b":[1,2]}}`, string(got))
+	}
+}
@@ -0,0 +1,47 @@
+// Package requestmeta carries who made a request, and from where, through
+// the context so that lower layers can record it without depending on HTTP.
+package requestmeta
+
+import "context"
+
+const AnonymousActor = "anonymous"
+
+type Meta struct {
+	Actor string
+	// MemberType is the library member type the actor's token was issued
+	// for, if any.
+	MemberType string
+	RequestID  string
+	ClientIP   string
+}
+
+type ctxKey struct{}
+
+func WithMeta(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, ctxKey{}, m)
+}
+
+// WithActor sets the actor of the metadata in ctx, keeping the rest.
+func WithActor(ctx context.Context, actor string) context.Context {
+	m, _ := ctx.Value(ctxKey{}).(Meta)
+	m.Actor = actor
+	return WithMeta(ctx, m)
+}
+
+// WithMemberType sets the member type of the metadata in ctx, keeping the
+// rest.
+func WithMemberType(ctx context.Context, memberType string) context.Context {
+	m, _ := ctx.Value(ctxKey{}).(Meta)
+	m.MemberType = memberType
+	return WithMeta(ctx, m)
+}
+
+// FromContext returns the metadata stored in ctx. Without any, the actor
+// is AnonymousActor and the other fields are empty.
+func FromContext(ctx context.Context) Meta {
+	m, _ := ctx.Value(ctxKey{}).(Meta)
+	if m.Actor == "" {
+		m.Actor = AnonymousActor
+	}
+	return m
+}
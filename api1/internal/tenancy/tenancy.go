@@ -0,0 +1,32 @@
+// Package tenancy carries the tenant a request acts for. Repositories read
+// it from the context to scope every query, so a missing tenant is an
+// error rather than a license to see everything.
+package tenancy
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoTenant = errors.New("no tenant in context")
+
+type ctxKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant stored by WithTenant, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Require is FromContext for code that must not run without a tenant.
+func Require(ctx context.Context) (string, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrNoTenant
+	}
+	return id, nil
+}
@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+type AuditUsecase interface {
+	ListAudit(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error)
+}
+
+type auditUsecase struct {
+	repo domain.AuditRepository
+}
+
+func NewAuditUsecase(repo domain.AuditRepository) AuditUsecase {
+	return &auditUsecase{repo: repo}
+}
+
+func (u *auditUsecase) ListAudit(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditRecord, error) {
+	if q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 || q.EntityID < 0 {
+		return nil, ErrValidation
+	}
+	if q.EntityID != 0 && q.EntityType == "" {
+		// An ID alone is ambiguous across entity types.
+		return nil, ErrValidation
+	}
+	return u.repo.List(ctx, q)
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// stage publishes the change behind e once the transaction in ctx has
+// committed, so subscribers never see a change that was rolled back: not
+// even when the book usecase runs inside a caller's transaction, as an
+// import chunk does. book is the book after the change, or before it for a
+// delete, if known.
+func (u *bookUsecase) stage(ctx context.Context, e domain.Event, book *domain.Book) error {
+	if u.changes == nil {
+		return nil
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := changeID()
+	if err != nil {
+		return err
+	}
+
+	c := domain.BookChange{
+		ID:       id,
+		TenantID: tenant,
+		Type:     e.EventType(),
+		BookID:   e.AggregateID(),
+		Book:     book,
+		At:       time.Now().UTC(),
+	}
+	if u.taxonomy != nil && c.Type != domain.EventBookDeleted {
+		t, err := u.taxonomy.GetBookTaxonomy(ctx, c.BookID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if t != nil {
+			for _, cat := range t.Categories {
+				c.Categories = append(c.Categories, cat.Path)
+			}
+		}
+	}
+	domain.AfterCommit(ctx, func() {
+		// The change is committed whatever happens here; a subscriber that
+		// misses it finds out from the gap when it resumes.
+		_ = u.changes.PublishBookChange(context.WithoutCancel(ctx), c)
+	})
+	return nil
+}
+
+// changeID is a fresh BookChange ID. It starts with the time so IDs sort
+// roughly in publishing order when read by people.
+func changeID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x-%s", time.Now().UnixMilli(), hex.EncodeToString(b)), nil
+}
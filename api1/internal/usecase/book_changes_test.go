@@ -0,0 +1,108 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/broadcast"
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// failingCommit runs fn and then fails as if the commit did.
+type failingCommit struct{}
+
+func (failingCommit) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, end := domain.BeginTxHooks(ctx)
+	defer end(false)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return errors.New("commit failed")
+}
+
+func receive(t *testing.T, sub domain.BookChangeSubscription) domain.BookChange {
+	t.Helper()
+	select {
+	case c := <-sub.Changes():
+		return c
+	default:
+		t.Fatal("no change published")
+		return domain.BookChange{}
+	}
+}
+
+func TestBookUsecase_PublishesCommittedChanges(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	repo := memory.NewBookRepository()
+	feed := broadcast.New(broadcast.Config{})
+	uc := usecase.NewBookUsecase(repo, usecase.WithTransactor(memory.NewTransactor()), usecase.WithChangeStream(feed, repo))
+	events := usecase.NewBookEventsUsecase(feed, repo)
+	fiction, err := repo.CreateCategory(ctx, domain.CategoryInput{Slug: "fiction", Name: "Fiction"})
+	require.NoError(t, err)
+	scifi, err := repo.CreateCategory(ctx, domain.CategoryInput{ParentID: &fiction.ID, Slug: "sci-fi", Name: "Science fiction"})
+	require.NoError(t, err)
+	sub, err := events.SubscribeBookChanges(ctx, domain.BookChangeQuery{Category: "fiction"}, "")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	// Act
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = repo.SetBookTaxonomy(ctx, book.ID, domain.BookTaxonomyInput{CategoryIDs: []int64{scifi.ID}})
+	require.NoError(t, err)
+	_, err = uc.UpdateBook(ctx, book.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteBook(ctx, book.ID))
+
+	// Assert: the create came before the book was filed under fiction.
+	updated := receive(t, sub)
+	assert.Equal(t, domain.EventBookUpdated, updated.Type)
+	assert.Equal(t, "acme", updated.TenantID)
+	assert.Equal(t, "Dune Messiah", updated.Book.Title)
+	assert.Equal(t, []string{scifi.Path}, updated.Categories)
+
+	deleted := receive(t, sub)
+	assert.Equal(t, domain.EventBookDeleted, deleted.Type)
+	assert.Equal(t, book.ID, deleted.BookID)
+	require.NotNil(t, deleted.Book, "a delete carries the book as it was")
+	assert.Equal(t, "Dune Messiah", deleted.Book.Title)
+	assert.NotEqual(t, updated.ID, deleted.ID)
+}
+
+func TestBookUsecase_FailedCommitPublishesNothing(t *testing.T) {
+	ctx := tenantCtx()
+	feed := broadcast.New(broadcast.Config{})
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithTransactor(failingCommit{}), usecase.WithChangeStream(feed, nil))
+	sub, err := feed.Subscribe(ctx, domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+
+	assert.Error(t, err)
+	assert.Empty(t, sub.Changes())
+}
+
+func TestBookEventsUsecase_UnknownCategory(t *testing.T) {
+	repo := memory.NewBookRepository()
+	events := usecase.NewBookEventsUsecase(broadcast.New(broadcast.Config{}), repo)
+
+	_, err := events.SubscribeBookChanges(tenantCtx(), domain.BookChangeQuery{Category: "poetry"}, "")
+
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
+
+func TestBookEventsUsecase_NoCategories(t *testing.T) {
+	events := usecase.NewBookEventsUsecase(broadcast.New(broadcast.Config{}), nil)
+
+	_, err := events.SubscribeBookChanges(tenantCtx(), domain.BookChangeQuery{Category: "fiction"}, "")
+
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/imaging"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// MaxCoverBytes bounds an uploaded cover image.
+const MaxCoverBytes = 5 << 20
+
+const (
+	minCoverSide   = 64
+	maxCoverSide   = 6000
+	coverThumbSide = 320
+)
+
+// CoverFile is an opened cover image. The caller must close Body.
+type CoverFile struct {
+	Body        io.ReadCloser
+	ContentType string
+	ETag        string
+	Size        int64
+}
+
+// UploadCover validates the image in r, stores it with a thumbnail and
+// makes it the cover of book id. The previous cover's blobs are removed
+// once the new one is recorded.
+func (u *bookUsecase) UploadCover(ctx context.Context, id int64, r io.Reader) (*domain.Book, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	if u.blobs == nil {
+		return nil, domain.ErrNotFound
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxCoverBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxCoverBytes {
+		return nil, ErrTooLarge
+	}
+	info, err := imaging.Inspect(data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		return nil, ErrUnsupportedMedia
+	case err != nil:
+		return nil, ErrValidation
+	case info.Width < minCoverSide || info.Height < minCoverSide ||
+		info.Width > maxCoverSide || info.Height > maxCoverSide:
+		return nil, ErrValidation
+	}
+
+	// Fail before writing any blob if the book is not there.
+	before, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	old, err := u.repo.GetCover(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	thumb, thumbType, err := imaging.Thumbnail(data, coverThumbSide)
+	if err != nil {
+		return nil, ErrValidation
+	}
+	cover := domain.Cover{
+		ContentType: info.ContentType,
+		Width:       info.Width,
+		Height:      info.Height,
+		Size:        int64(len(data)),
+		ETag:        contentHash(data),
+		ThumbType:   thumbType,
+		ThumbSize:   int64(len(thumb)),
+		ThumbETag:   contentHash(thumb),
+	}
+	cover.Key = coverKey(tenant, id, cover.ETag, cover.ContentType)
+	cover.ThumbKey = coverKey(tenant, id, cover.ThumbETag, thumbType)
+
+	if err := u.blobs.Put(ctx, cover.Key, bytes.NewReader(data), cover.Size, cover.ContentType); err != nil {
+		return nil, err
+	}
+	if err := u.blobs.Put(ctx, cover.ThumbKey, bytes.NewReader(thumb), cover.ThumbSize, thumbType); err != nil {
+		u.dropBlobs(ctx, old, cover.Key)
+		return nil, err
+	}
+
+	var book *domain.Book
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		b, err := u.repo.SetCover(ctx, id, cover)
+		if err != nil {
+			return err
+		}
+		book = b
+		return u.record(ctx, domain.AuditUpdate, id, before, b, domain.BookUpdated{Book: *b})
+	})
+	if err != nil {
+		u.dropBlobs(ctx, old, cover.Key, cover.ThumbKey)
+		return nil, err
+	}
+
+	if old != nil {
+		u.dropBlobs(ctx, &cover, old.Key, old.ThumbKey)
+	}
+	return book, nil
+}
+
+// OpenCover opens the cover of book id, or its thumbnail.
+func (u *bookUsecase) OpenCover(ctx context.Context, id int64, thumb bool) (*CoverFile, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	if u.blobs == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	c, err := u.repo.GetCover(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	f := &CoverFile{ContentType: c.ContentType, ETag: c.ETag, Size: c.Size}
+	key := c.Key
+	if thumb {
+		f.ContentType, f.ETag, f.Size, key = c.ThumbType, c.ThumbETag, c.ThumbSize, c.ThumbKey
+	}
+	if f.Body, err = u.blobs.Get(ctx, key); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// dropBlobs deletes keys on a best-effort basis, skipping any that keep
+// serves. Re-uploading identical bytes yields identical keys, which must
+// survive the cleanup.
+func (u *bookUsecase) dropBlobs(ctx context.Context, keep *domain.Cover, keys ...string) {
+	for _, k := range keys {
+		if keep != nil && (k == keep.Key || k == keep.ThumbKey) {
+			continue
+		}
+		_ = u.blobs.Delete(context.WithoutCancel(ctx), k)
+	}
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// coverKey lays blobs out per tenant and book, named by content hash.
+func coverKey(tenant string, bookID int64, etag, contentType string) string {
+	return fmt.Sprintf("covers/%s/%d/%s.%s", tenant, bookID, etag, strings.TrimPrefix(contentType, "image/"))
+}
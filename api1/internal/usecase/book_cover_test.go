@@ -0,0 +1,131 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// pngImage encodes a w×h image filled with c.
+func pngImage(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newCoverUsecase(t *testing.T) (usecase.BookUsecase, *memory.BlobStore, *domain.Book) {
+	t.Helper()
+	blobs := memory.NewBlobStore()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithCoverStore(blobs))
+	book, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	return uc, blobs, book
+}
+
+func TestBookUsecase_UploadCover(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	uc, blobs, book := newCoverUsecase(t)
+	data := pngImage(t, 800, 400, color.RGBA{R: 200, A: 255})
+
+	// Act
+	got, err := uc.UploadCover(ctx, book.ID, bytes.NewReader(data))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Contains(t, got.CoverURL, "/v1/books/1/cover?v=")
+	assert.Equal(t, 2, blobs.Len(), "original and thumbnail")
+
+	original, err := uc.OpenCover(ctx, book.ID, false)
+	require.NoError(t, err)
+	defer original.Body.Close()
+	assert.Equal(t, "image/png", original.ContentType)
+	stored, _ := io.ReadAll(original.Body)
+	assert.Equal(t, data, stored)
+
+	thumb, err := uc.OpenCover(ctx, book.ID, true)
+	require.NoError(t, err)
+	defer thumb.Body.Close()
+	cfg, err := png.DecodeConfig(thumb.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 320, cfg.Width)
+	assert.Equal(t, 160, cfg.Height)
+	assert.NotEqual(t, original.ETag, thumb.ETag)
+
+	again, err := uc.GetBook(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, got.CoverURL, again.CoverURL)
+}
+
+func TestBookUsecase_UploadCover_ReplacesOldBlobs(t *testing.T) {
+	ctx := tenantCtx()
+	uc, blobs, book := newCoverUsecase(t)
+	_, err := uc.UploadCover(ctx, book.ID, bytes.NewReader(pngImage(t, 400, 400, color.White)))
+	require.NoError(t, err)
+
+	_, err = uc.UploadCover(ctx, book.ID, bytes.NewReader(pngImage(t, 400, 400, color.Black)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, blobs.Len())
+
+	// Uploading the same bytes again must not delete what it just stored.
+	_, err = uc.UploadCover(ctx, book.ID, bytes.NewReader(pngImage(t, 400, 400, color.Black)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, blobs.Len())
+}
+
+func TestBookUsecase_UploadCover_Rejected(t *testing.T) {
+	ctx := tenantCtx()
+	uc, blobs, book := newCoverUsecase(t)
+
+	tests := []struct {
+		name string
+		body io.Reader
+		want error
+	}{
+		{"not an image", strings.NewReader("hello, world"), usecase.ErrUnsupportedMedia},
+		{"too large", io.MultiReader(bytes.NewReader(pngImage(t, 400, 400, color.White)), bytes.NewReader(make([]byte, 6<<20))), usecase.ErrTooLarge},
+		{"too small", bytes.NewReader(pngImage(t, 10, 10, color.White)), usecase.ErrValidation},
+		{"truncated", bytes.NewReader(pngImage(t, 400, 400, color.White)[:60]), usecase.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.UploadCover(ctx, book.ID, tt.body)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+	assert.Zero(t, blobs.Len())
+}
+
+func TestBookUsecase_Cover_TenantScoped(t *testing.T) {
+	uc, blobs, book := newCoverUsecase(t)
+	_, err := uc.UploadCover(tenantCtx(), book.ID, bytes.NewReader(pngImage(t, 400, 400, color.White)))
+	require.NoError(t, err)
+	other := tenancy.WithTenant(context.Background(), "globex")
+
+	_, err = uc.OpenCover(other, book.ID, false)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = uc.UploadCover(other, book.ID, bytes.NewReader(pngImage(t, 400, 400, color.Black)))
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Equal(t, 2, blobs.Len())
+}
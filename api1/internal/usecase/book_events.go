@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// BookEventsUsecase lets clients follow book changes live.
+type BookEventsUsecase interface {
+	SubscribeBookChanges(ctx context.Context, q domain.BookChangeQuery, lastID string) (domain.BookChangeSubscription, error)
+}
+
+type bookEventsUsecase struct {
+	feed       domain.BookChangeFeed
+	categories domain.CategoryRepository
+}
+
+// NewBookEventsUsecase serves subscriptions from feed, which the book
+// usecase has to publish to through WithChangeStream. categories may be
+// nil for books kept without categories; every category is then unknown.
+func NewBookEventsUsecase(feed domain.BookChangeFeed, categories domain.CategoryRepository) BookEventsUsecase {
+	return &bookEventsUsecase{feed: feed, categories: categories}
+}
+
+// SubscribeBookChanges fails with ErrValidation for a category slug the
+// tenant does not have. The caller must Close the subscription.
+func (u *bookEventsUsecase) SubscribeBookChanges(ctx context.Context, q domain.BookChangeQuery, lastID string) (domain.BookChangeSubscription, error) {
+	f := domain.BookChangeFilter{Author: q.Author}
+	if q.Category != "" {
+		var cats []*domain.Category
+		if u.categories != nil {
+			var err error
+			if cats, err = u.categories.ListCategories(ctx); err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range cats {
+			if c.Slug == q.Category {
+				f.CategoryPath = c.Path
+			}
+		}
+		if f.CategoryPath == "" {
+			return nil, fmt.Errorf("%w: unknown category %q", ErrValidation, q.Category)
+		}
+	}
+	return u.feed.Subscribe(ctx, f, lastID)
+}
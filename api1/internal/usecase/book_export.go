@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// errStopExport ends an export early when the consumer stops ranging.
+var errStopExport = errors.New("export stopped")
+
+// ExportBooks returns every book matching the filters of q, in ID order,
+// for the caller to range over once while ctx lasts. Limit and Offset are
+// ignored. Problems found up front are returned at once; a failure while
+// reading ends the sequence with it.
+func (u *bookUsecase) ExportBooks(ctx context.Context, q domain.ListBooksQuery) (iter.Seq2[*domain.Book, error], error) {
+	if u.export == nil {
+		return nil, domain.ErrNotFound
+	}
+	if _, err := tenancy.Require(ctx); err != nil {
+		return nil, err
+	}
+	q.Tags = NormalizeTags(q.Tags)
+	q.Limit, q.Offset = 0, 0
+
+	return func(yield func(*domain.Book, error) bool) {
+		err := u.export.ExportBooks(ctx, q, func(b *domain.Book) error {
+			if !yield(b, nil) {
+				return errStopExport
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopExport) {
+			yield(nil, err)
+		}
+	}, nil
+}
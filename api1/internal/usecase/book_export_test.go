@@ -0,0 +1,64 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_ExportBooks(t *testing.T) {
+	// Arrange
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo, usecase.WithExporter(repo))
+	for i := 0; i < 3; i++ {
+		_, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: string(rune('A' + i)), Author: "Jane Austen"})
+		require.NoError(t, err)
+	}
+	_, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	// Act
+	books, err := uc.ExportBooks(tenantCtx(), domain.ListBooksQuery{Author: "Jane Austen", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	var titles []string
+	for b, err := range books {
+		require.NoError(t, err)
+		titles = append(titles, b.Title)
+	}
+
+	// Assert
+	assert.Equal(t, []string{"A", "B", "C"}, titles, "paging does not apply to exports")
+}
+
+func TestBookUsecase_ExportBooks_StopsWhenTheConsumerDoes(t *testing.T) {
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo, usecase.WithExporter(repo))
+	for _, title := range []string{"A", "B", "C"} {
+		_, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: title, Author: "Jane Austen"})
+		require.NoError(t, err)
+	}
+
+	books, err := uc.ExportBooks(tenantCtx(), domain.ListBooksQuery{})
+	require.NoError(t, err)
+	n := 0
+	for _, err := range books {
+		require.NoError(t, err)
+		if n++; n == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, n)
+}
+
+func TestBookUsecase_ExportBooks_Unconfigured(t *testing.T) {
+	uc := usecase.NewBookUsecase(memory.NewBookRepository())
+
+	_, err := uc.ExportBooks(tenantCtx(), domain.ListBooksQuery{})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// ErrCursorExpired means the changes after a sync cursor can no longer be
+// told apart, so the client has to drop its copy and sync from scratch.
+var ErrCursorExpired = errors.New("sync cursor expired")
+
+// DefaultSyncRetention is how long a sync cursor stays good when
+// SyncConfig leaves Retention zero.
+const DefaultSyncRetention = 30 * 24 * time.Hour
+
+// SyncConfig tunes SyncBooks. Retention is how long tombstones of deleted
+// books are kept for clients; a cursor issued longer ago than that
+// expires, as deletes it has not seen may be gone. Now defaults to
+// time.Now.
+type SyncConfig struct {
+	Retention time.Duration
+	Now       func() time.Time
+}
+
+// BookSyncPage is one page of changes for an offline client. Cursor asks
+// for the changes after them; HasMore says more are already waiting.
+type BookSyncPage struct {
+	Changes []*domain.BookSyncChange
+	Cursor  string
+	HasMore bool
+}
+
+// syncCursor is what a cursor encodes: the position reached and the time
+// from which the client is known to have seen every delete.
+type syncCursor struct {
+	pos    domain.BookSyncPosition
+	issued time.Time
+}
+
+// SyncBooks returns up to limit changes after cursor, or from the start
+// of the catalogue for an empty cursor, oldest first. A limit of 0 means
+// the largest page.
+func (u *bookUsecase) SyncBooks(ctx context.Context, cursor string, limit int) (*BookSyncPage, error) {
+	if limit < 0 || limit > maxListLimit {
+		return nil, ErrValidation
+	}
+	if limit == 0 {
+		limit = maxListLimit
+	}
+	if u.sync == nil {
+		return nil, domain.ErrNotFound
+	}
+	if _, err := tenancy.Require(ctx); err != nil {
+		return nil, err
+	}
+
+	now := u.syncCfg.Now()
+	cur := syncCursor{issued: now}
+	if cursor != "" {
+		var err error
+		if cur, err = decodeSyncCursor(cursor); err != nil {
+			return nil, err
+		}
+		if now.Sub(cur.issued) > u.syncCfg.Retention {
+			return nil, ErrCursorExpired
+		}
+	}
+
+	changes, head, err := u.sync.BookChangesSince(ctx, cur.pos, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if cur.pos.Seq > head {
+		// The cursor is from a history this one does not continue, such
+		// as a database restored from an older backup.
+		return nil, ErrCursorExpired
+	}
+
+	page := &BookSyncPage{Changes: changes}
+	if len(changes) > limit {
+		page.Changes, page.HasMore = changes[:limit], true
+	}
+	if n := len(page.Changes); n > 0 {
+		cur.pos = page.Changes[n-1].Position
+	}
+	if !page.HasMore {
+		// Caught up: every delete so far has been seen.
+		cur.issued = now
+	}
+	page.Cursor = cur.encode()
+	return page, nil
+}
+
+func (c syncCursor) encode() string {
+	raw := fmt.Sprintf("%d.%d.%d", c.pos.Seq, c.pos.BookID, c.issued.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSyncCursor(s string) (syncCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return syncCursor{}, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+	var c syncCursor
+	var issued int64
+	if _, err := fmt.Sscanf(string(raw), "%d.%d.%d", &c.pos.Seq, &c.pos.BookID, &issued); err != nil ||
+		c.pos.Seq < 0 || c.pos.BookID < 0 {
+		return syncCursor{}, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+	c.issued = time.Unix(issued, 0)
+	return c, nil
+}
@@ -0,0 +1,123 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+type syncFixture struct {
+	uc  usecase.BookUsecase
+	now time.Time
+}
+
+func newSyncFixture(t *testing.T, repo *memory.BookRepository) *syncFixture {
+	t.Helper()
+	f := &syncFixture{now: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)}
+	f.uc = usecase.NewBookUsecase(repo, usecase.WithSync(repo, usecase.SyncConfig{
+		Retention: 24 * time.Hour,
+		Now:       func() time.Time { return f.now },
+	}))
+	return f
+}
+
+// ops summarizes a page as "upsert:Title" and "delete:ID" entries.
+func ops(page *usecase.BookSyncPage) []string {
+	var out []string
+	for _, c := range page.Changes {
+		if c.Deleted {
+			out = append(out, "delete:"+c.Book.Title)
+		} else {
+			out = append(out, "upsert:"+c.Book.Title)
+		}
+	}
+	return out
+}
+
+func TestBookUsecase_SyncBooks(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	repo := memory.NewBookRepository()
+	f := newSyncFixture(t, repo)
+	dune, err := f.uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	emma, err := f.uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	_, err = f.uc.CreateBook(tenancy.WithTenant(context.Background(), "globex"), domain.CreateBookInput{Title: "Beloved", Author: "Toni Morrison"})
+	require.NoError(t, err)
+
+	// Act: a full sync in pages of one.
+	first, err := f.uc.SyncBooks(ctx, "", 1)
+	require.NoError(t, err)
+	second, err := f.uc.SyncBooks(ctx, first.Cursor, 1)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []string{"upsert:Dune"}, ops(first))
+	assert.True(t, first.HasMore)
+	assert.Equal(t, []string{"upsert:Emma"}, ops(second))
+	assert.False(t, second.HasMore)
+
+	// Later changes come in the order they were made, deletes as tombstones.
+	_, err = f.uc.UpdateBook(ctx, dune.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, f.uc.DeleteBook(ctx, emma.ID))
+
+	delta, err := f.uc.SyncBooks(ctx, second.Cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"upsert:Dune Messiah", "delete:Emma"}, ops(delta))
+	assert.Equal(t, emma.ID, delta.Changes[1].Book.ID)
+
+	idle, err := f.uc.SyncBooks(ctx, delta.Cursor, 0)
+	require.NoError(t, err)
+	assert.Empty(t, idle.Changes)
+	assert.False(t, idle.HasMore)
+}
+
+func TestBookUsecase_SyncBooks_ExpiredCursor(t *testing.T) {
+	ctx := tenantCtx()
+	repo := memory.NewBookRepository()
+	f := newSyncFixture(t, repo)
+	_, err := f.uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	page, err := f.uc.SyncBooks(ctx, "", 0)
+	require.NoError(t, err)
+
+	// Polling renews the cursor while it is fresh.
+	f.now = f.now.Add(20 * time.Hour)
+	page, err = f.uc.SyncBooks(ctx, page.Cursor, 0)
+	require.NoError(t, err)
+	f.now = f.now.Add(20 * time.Hour)
+	page, err = f.uc.SyncBooks(ctx, page.Cursor, 0)
+	require.NoError(t, err)
+
+	f.now = f.now.Add(25 * time.Hour)
+	_, err = f.uc.SyncBooks(ctx, page.Cursor, 0)
+	assert.ErrorIs(t, err, usecase.ErrCursorExpired)
+
+	// A cursor from a longer history than this one is no good either.
+	fresh := newSyncFixture(t, memory.NewBookRepository())
+	fresh.now = f.now
+	_, err = fresh.uc.SyncBooks(ctx, page.Cursor, 0)
+	assert.ErrorIs(t, err, usecase.ErrCursorExpired)
+}
+
+func TestBookUsecase_SyncBooks_Validation(t *testing.T) {
+	f := newSyncFixture(t, memory.NewBookRepository())
+
+	_, err := f.uc.SyncBooks(tenantCtx(), "not a cursor", 0)
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = f.uc.SyncBooks(tenantCtx(), "", 1000)
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+
+	_, err = usecase.NewBookUsecase(memory.NewBookRepository()).SyncBooks(tenantCtx(), "", 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -2,42 +2,450 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/patch"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
 )
 
 var (
-	ErrValidation = errors.New("validation error")
+	ErrValidation       = errors.New("validation error")
+	ErrTooLarge         = errors.New("payload too large")
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+	ErrForbidden        = errors.New("forbidden")
 )
 
+const maxListLimit = 100
+
 type BookUsecase interface {
 	CreateBook(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error)
+	GetBook(ctx context.Context, id int64) (*domain.Book, error)
+	ListBooks(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error)
+	GetBookView(ctx context.Context, id int64, sel domain.BookSelection) (*domain.BookView, error)
+	ListBookViews(ctx context.Context, q domain.ListBooksQuery, sel domain.BookSelection) ([]*domain.BookView, error)
+	UpdateBook(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error)
+	PatchBook(ctx context.Context, id int64, p patch.Patch) (*domain.Book, error)
+	DeleteBook(ctx context.Context, id int64) error
+	RestoreBook(ctx context.Context, id int64) (*domain.Book, error)
+	ListRevisions(ctx context.Context, id int64, limit, offset int) ([]*domain.BookRevision, error)
+	GetBookAsOf(ctx context.Context, id int64, at time.Time) (*domain.Book, error)
+	RevertBook(ctx context.Context, id int64, rev int) (*domain.Book, error)
+	UploadCover(ctx context.Context, id int64, r io.Reader) (*domain.Book, error)
+	OpenCover(ctx context.Context, id int64, thumb bool) (*CoverFile, error)
+	ListDuplicates(ctx context.Context, limit, offset int) ([]*domain.DuplicateGroup, error)
+	ExportBooks(ctx context.Context, q domain.ListBooksQuery) (iter.Seq2[*domain.Book, error], error)
+	SyncBooks(ctx context.Context, cursor string, limit int) (*BookSyncPage, error)
 }
 
 type bookUsecase struct {
-	repo domain.BookRepository
+	repo   domain.BookRepository
+	tx     domain.Transactor
+	outbox domain.OutboxRepository
+	audit  domain.AuditRepository
+	revs   domain.BookRevisionRepository
+	quota  domain.TenantRepository
+	blobs  domain.BlobStore
+	dups   domain.DuplicateRepository
+	export domain.BookExporter
+
+	sync    domain.BookSyncRepository
+	syncCfg SyncConfig
+
+	changes  domain.BookChangePublisher
+	taxonomy domain.CategoryRepository
+
+	fields  domain.BookFieldRepository
+	reviews domain.ReviewRepository
+	copies  domain.LoanRepository
 }
 
-func NewBookUsecase(repo domain.BookRepository) BookUsecase {
-	return &bookUsecase{repo: repo}
+func NewBookUsecase(repo domain.BookRepository, opts ...Option) BookUsecase {
+	u := &bookUsecase{repo: repo, tx: noTx{}}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 func (u *bookUsecase) CreateBook(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
-	if strings.TrimSpace(in.Title) == "" || strings.TrimSpace(in.Author) == "" {
+	if err := checkBook(in.Title, in.Author); err != nil {
+		return nil, err
+	}
+
+	var book *domain.Book
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.reserve(ctx); err != nil {
+			return err
+		}
+
+		b, err := u.repo.Create(ctx, in)
+		if err != nil {
+			return err
+		}
+
+		if b.CreatedAt.IsZero() {
+			b.CreatedAt = time.Now()
+		}
+
+		book = b
+		return u.record(ctx, domain.AuditCreate, b.ID, nil, b, domain.BookCreated{Book: *b})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+func (u *bookUsecase) UpdateBook(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	if id <= 0 {
 		return nil, ErrValidation
 	}
+	if err := checkBook(in.Title, in.Author); err != nil {
+		return nil, err
+	}
 
-	book, err := u.repo.Create(ctx, in)
+	var book *domain.Book
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		b, err := u.update(ctx, domain.AuditUpdate, id, in)
+		book = b
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if book.CreatedAt.IsZero() {
-		book.CreatedAt = time.Now()
+	return book, nil
+}
+
+// PatchBook applies p to the book as the API shows it and stores the
+// result like UpdateBook. Only the title and author may change; a patch
+// that changes any other field fails with ErrValidation, while one that
+// only tests or rewrites them unchanged is fine.
+func (u *bookUsecase) PatchBook(ctx context.Context, id int64, p patch.Patch) (*domain.Book, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+
+	var book *domain.Book
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cur, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		patched, err := patch.ApplyTo(p, *cur)
+		if err != nil {
+			return err
+		}
+		if err := readOnlyUnchanged(cur, &patched); err != nil {
+			return err
+		}
+		if err := checkBook(patched.Title, patched.Author); err != nil {
+			return err
+		}
+
+		in := domain.UpdateBookInput{Title: patched.Title, Author: patched.Author}
+		b, err := u.update(ctx, domain.AuditUpdate, id, in)
+		book = b
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return book, nil
 }
+
+// checkBook is the validation shared by every way of writing a book's
+// title and author.
+func checkBook(title, author string) error {
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(author) == "" {
+		return ErrValidation
+	}
+	return nil
+}
+
+func (u *bookUsecase) DeleteBook(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrValidation
+	}
+
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := u.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := u.release(ctx); err != nil {
+			return err
+		}
+
+		return u.record(ctx, domain.AuditDelete, id, before, nil, domain.BookDeleted{BookID: id})
+	})
+}
+
+func (u *bookUsecase) RestoreBook(ctx context.Context, id int64) (*domain.Book, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+
+	var book *domain.Book
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.reserve(ctx); err != nil {
+			return err
+		}
+
+		b, err := u.repo.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		book = b
+		return u.record(ctx, domain.AuditRestore, id, nil, b, domain.BookRestored{Book: *b})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// RevertBook writes the fields of revision rev back as a new revision, so
+// history only ever grows. A deleted book has to be restored first, and a
+// revision that records a delete cannot be reverted to.
+func (u *bookUsecase) RevertBook(ctx context.Context, id int64, rev int) (*domain.Book, error) {
+	if id <= 0 || rev <= 0 {
+		return nil, ErrValidation
+	}
+	if u.revs == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	var book *domain.Book
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		old, err := u.revs.GetRevision(ctx, id, rev)
+		if err != nil {
+			return err
+		}
+		if old.Deleted {
+			return ErrValidation
+		}
+
+		in := domain.UpdateBookInput{Title: old.Book.Title, Author: old.Book.Author}
+		b, err := u.update(ctx, domain.AuditRevert, id, in)
+		book = b
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+func (u *bookUsecase) ListRevisions(ctx context.Context, id int64, limit, offset int) ([]*domain.BookRevision, error) {
+	if id <= 0 || limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	if u.revs == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	revs, err := u.revs.ListRevisions(ctx, id, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	// Every book has at least its creation revision.
+	if len(revs) == 0 && offset == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return revs, nil
+}
+
+// ListDuplicates reports the groups of live books that share a
+// domain.BookKey, which only admin-forced creates and books stored before
+// keys existed can produce.
+func (u *bookUsecase) ListDuplicates(ctx context.Context, limit, offset int) ([]*domain.DuplicateGroup, error) {
+	if limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	if u.dups == nil {
+		return nil, domain.ErrNotFound
+	}
+	return u.dups.ListDuplicates(ctx, limit, offset)
+}
+
+func (u *bookUsecase) GetBookAsOf(ctx context.Context, id int64, at time.Time) (*domain.Book, error) {
+	if id <= 0 || at.IsZero() {
+		return nil, ErrValidation
+	}
+	if u.revs == nil {
+		return nil, domain.ErrNotFound
+	}
+	return u.revs.GetAsOf(ctx, id, at)
+}
+
+func (u *bookUsecase) GetBook(ctx context.Context, id int64) (*domain.Book, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	return u.repo.GetByID(ctx, id)
+}
+
+func (u *bookUsecase) ListBooks(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	if q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 {
+		return nil, ErrValidation
+	}
+	q.Tags = NormalizeTags(q.Tags)
+	return u.repo.List(ctx, q)
+}
+
+// update applies in to book id and records it under action. It must be
+// called inside u.tx.
+func (u *bookUsecase) update(ctx context.Context, action domain.AuditAction, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	before, err := u.snapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := u.repo.Update(ctx, id, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, u.record(ctx, action, id, before, b, domain.BookUpdated{Book: *b})
+}
+
+// readOnlyUnchanged fails with ErrValidation naming the fields other than
+// title and author that differ between before and after, compared as the
+// API renders them.
+func readOnlyUnchanged(before, after *domain.Book) error {
+	want := *before
+	want.Title, want.Author = after.Title, after.Author
+
+	a, err := jsonFields(&want)
+	if err != nil {
+		return err
+	}
+	b, err := jsonFields(after)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	for k, v := range b {
+		if w, ok := a[k]; !ok || !reflect.DeepEqual(v, w) {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("%w: read-only fields cannot be patched: %s", ErrValidation, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// jsonFields decodes the JSON rendering of b into its members.
+func jsonFields(b *domain.Book) (map[string]any, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// reserve takes one unit of the tenant's book quota. It must be called
+// inside u.tx, before the write it pays for, so a failed write gives it
+// back on rollback.
+func (u *bookUsecase) reserve(ctx context.Context) error {
+	if u.quota == nil {
+		return nil
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return u.quota.ReserveBook(ctx, tenant)
+}
+
+// release returns the quota unit of a deleted book. It must be called
+// inside u.tx.
+func (u *bookUsecase) release(ctx context.Context) error {
+	if u.quota == nil {
+		return nil
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return u.quota.ReleaseBook(ctx, tenant)
+}
+
+// snapshot loads the book as it is before a mutation, for the audit diff
+// and for the change stream to say which book a delete was. Without either
+// there is no use for it, so it skips the read.
+func (u *bookUsecase) snapshot(ctx context.Context, id int64) (*domain.Book, error) {
+	if u.audit == nil && u.changes == nil {
+		return nil, nil
+	}
+	return u.repo.GetByID(ctx, id)
+}
+
+// record writes what a mutation did: its events to the outbox and an audit
+// record of the change. It must be called inside u.tx so both commit with
+// the change that caused them; the events are also staged for the change
+// stream, which gets them after the commit.
+func (u *bookUsecase) record(ctx context.Context, action domain.AuditAction, id int64, before, after *domain.Book, events ...domain.Event) error {
+	if u.outbox != nil {
+		if err := u.outbox.Append(ctx, events...); err != nil {
+			return err
+		}
+	}
+	book := after
+	if book == nil {
+		book = before
+	}
+	for _, e := range events {
+		if err := u.stage(ctx, e, book); err != nil {
+			return err
+		}
+	}
+
+	if u.audit == nil {
+		return nil
+	}
+	changes, err := domain.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	meta := requestmeta.FromContext(ctx)
+	return u.audit.Append(ctx, domain.AuditRecord{
+		EntityType: domain.AggregateBook,
+		EntityID:   id,
+		Action:     action,
+		Actor:      meta.Actor,
+		RequestID:  meta.RequestID,
+		ClientIP:   meta.ClientIP,
+		Changes:    changes,
+	})
+}
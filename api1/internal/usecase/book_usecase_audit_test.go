@@ -0,0 +1,101 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_AuditTrail(t *testing.T) {
+	// Arrange
+	ctx := requestmeta.WithMeta(tenantCtx(), requestmeta.Meta{
+		Actor:     "librarian@example.com",
+		RequestID: "req-1",
+		ClientIP:  "10.0.0.7",
+	})
+	audit := memory.NewAuditRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(),
+		usecase.WithTransactor(memory.NewTransactor()),
+		usecase.WithAuditLog(audit),
+	)
+
+	// Act
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = uc.UpdateBook(ctx, book.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteBook(ctx, book.ID))
+	_, err = uc.RestoreBook(ctx, book.ID)
+	require.NoError(t, err)
+
+	// Assert
+	records, err := audit.List(ctx, domain.AuditQuery{EntityType: domain.AggregateBook, EntityID: book.ID})
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	actions := []domain.AuditAction{domain.AuditCreate, domain.AuditUpdate, domain.AuditDelete, domain.AuditRestore}
+	for i, rec := range records {
+		assert.Equal(t, actions[i], rec.Action)
+		assert.Equal(t, "librarian@example.com", rec.Actor)
+		assert.Equal(t, "req-1", rec.RequestID)
+		assert.Equal(t, "10.0.0.7", rec.ClientIP)
+		assert.Equal(t, "acme", rec.TenantID)
+	}
+
+	update := records[1].Changes
+	assert.Equal(t, domain.FieldChange{Before: "Dune", After: "Dune Messiah"}, update["title"])
+	assert.NotContains(t, update, "author", "unchanged fields are not part of the diff")
+
+	assert.Equal(t, "Dune Messiah", records[2].Changes["title"].Before)
+	assert.Nil(t, records[2].Changes["title"].After)
+}
+
+func TestBookUsecase_DeleteBook_NotFound_NoAudit(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithAuditLog(audit))
+
+	err := uc.DeleteBook(tenantCtx(), 99)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	records, _ := audit.List(tenantCtx(), domain.AuditQuery{})
+	assert.Empty(t, records)
+}
+
+func TestBookUsecase_AuditIsPerTenant(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithAuditLog(audit))
+	_, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	records, err := audit.List(tenancy.WithTenant(context.Background(), "globex"), domain.AuditQuery{})
+
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestBookUsecase_AnonymousActor(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithAuditLog(audit))
+
+	_, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	records, _ := audit.List(tenantCtx(), domain.AuditQuery{Actor: requestmeta.AnonymousActor})
+	assert.Len(t, records, 1)
+}
+
+func TestAuditUsecase_ListAudit_IDRequiresEntity(t *testing.T) {
+	uc := usecase.NewAuditUsecase(memory.NewAuditRepository())
+
+	_, err := uc.ListAudit(tenantCtx(), domain.AuditQuery{EntityID: 1})
+
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
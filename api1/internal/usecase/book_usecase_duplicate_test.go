@@ -0,0 +1,113 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Dune", "dune"},
+		{"  Les   Misérables ", "les miserables"},
+		{"LES MISÉRABLES", "les miserables"},
+		{"Harry Potter: The Philosopher's Stone!", "harry potter the philosophers stone"},
+		{"Straße", "strasse"},
+		{"C++ & you", "c you"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, domain.NormalizeKey(tt.in), "NormalizeKey(%q)", tt.in)
+	}
+}
+
+func TestBookUsecase_CreateDuplicate(t *testing.T) {
+	// Arrange
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo, usecase.WithDuplicates(repo))
+	first, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Les Misérables", Author: "Victor Hugo"})
+	require.NoError(t, err)
+
+	// Act
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "les  miserables.", Author: "VICTOR HUGO"})
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrDuplicate)
+	var dup *domain.DuplicateError
+	require.True(t, errors.As(err, &dup))
+	assert.Equal(t, first.ID, dup.ExistingID)
+
+	// Another tenant may hold the same book.
+	_, err = uc.CreateBook(tenancy.WithTenant(context.Background(), "globex"),
+		domain.CreateBookInput{Title: "Les Misérables", Author: "Victor Hugo"})
+	assert.NoError(t, err)
+}
+
+func TestBookUsecase_UpdateAndRestoreDuplicate(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo)
+	dune, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	other, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	_, err = uc.UpdateBook(ctx, other.ID, domain.UpdateBookInput{Title: "DUNE", Author: "Frank Herbert"})
+	assert.ErrorIs(t, err, domain.ErrDuplicate)
+
+	// Renaming a book to its own key is not a duplicate.
+	_, err = uc.UpdateBook(ctx, dune.ID, domain.UpdateBookInput{Title: "dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	// A deleted book frees its key, and cannot come back while it is taken.
+	require.NoError(t, uc.DeleteBook(ctx, dune.ID))
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = uc.RestoreBook(ctx, dune.ID)
+	assert.ErrorIs(t, err, domain.ErrDuplicate)
+}
+
+func TestBookUsecase_ForceCreateAndReport(t *testing.T) {
+	// Arrange
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo, usecase.WithDuplicates(repo))
+	first, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	// Act
+	forced, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "DUNE", Author: "frank herbert", Force: true})
+	require.NoError(t, err)
+	groups, err := uc.ListDuplicates(ctx, 0, 0)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, domain.BookKey("Dune", "Frank Herbert"), groups[0].Key)
+	require.Len(t, groups[0].Books, 2)
+	assert.Equal(t, first.ID, groups[0].Books[0].ID)
+	assert.Equal(t, forced.ID, groups[0].Books[1].ID)
+}
+
+func TestBookUsecase_ListDuplicatesDisabled(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	uc := usecase.NewBookUsecase(memory.NewBookRepository())
+
+	_, err := uc.ListDuplicates(ctx, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = uc.ListDuplicates(ctx, 101, 0)
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
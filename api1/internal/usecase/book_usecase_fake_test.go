@@ -15,6 +15,7 @@ import (
 type fakeRepo struct {
 	// seed & state
 	created []*domain.Book
+	deleted []*domain.Book
 
 	// knobs
 	nextErr error
@@ -53,6 +54,78 @@ func (f *fakeRepo) Create(ctx context.Context, in domain.CreateBookInput) (*doma
 	return b, nil
 }
 
+func (f *fakeRepo) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	for _, b := range f.created {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeRepo) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	books := make(map[int64]*domain.Book)
+	for _, id := range ids {
+		if b, err := f.GetByID(ctx, id); err == nil {
+			books[id] = b
+		}
+	}
+	return books, nil
+}
+
+func (f *fakeRepo) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	var out []*domain.Book
+	for _, b := range f.created {
+		if q.Author == "" || b.Author == q.Author {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	b, err := f.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b.Title, b.Author = in.Title, in.Author
+	return b, nil
+}
+
+func (f *fakeRepo) Delete(ctx context.Context, id int64) error {
+	for i, b := range f.created {
+		if b.ID == id {
+			f.created = append(f.created[:i], f.created[i+1:]...)
+			f.deleted = append(f.deleted, b)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (f *fakeRepo) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	for i, b := range f.deleted {
+		if b.ID == id {
+			f.deleted = append(f.deleted[:i], f.deleted[i+1:]...)
+			f.created = append(f.created, b)
+			return b, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeRepo) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeRepo) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeRepo) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	return nil, domain.ErrNotFound
+}
+
 // ---- Tests ----
 
 func TestBookUsecase_CreateBook_HappyPath(t *testing.T) {
@@ -155,3 +228,53 @@ func TestBookUsecase_CreateBook_FillCreatedAt_WhenRepoReturnsZero(t *testing.T)
 		t.Fatalf("CreatedAt not within expected window, got=%v start=%v end=%v", got.CreatedAt, start, end)
 	}
 }
+
+func TestBookUsecase_GetBook_AfterCreate(t *testing.T) {
+	// Arrange
+	fake := newFakeRepo()
+	uc := usecase.NewBookUsecase(fake)
+	created, _ := uc.CreateBook(context.Background(), domain.CreateBookInput{
+		Title:  "Refactoring",
+		Author: "Martin Fowler",
+	})
+
+	// Act
+	got, err := uc.GetBook(context.Background(), created.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Title != "Refactoring" {
+		t.Fatalf("got title %q, want %q", got.Title, "Refactoring")
+	}
+}
+
+func TestBookUsecase_GetBook_NotFound(t *testing.T) {
+	uc := usecase.NewBookUsecase(newFakeRepo())
+
+	_, err := uc.GetBook(context.Background(), 404)
+
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+}
+
+func TestBookUsecase_ListBooks_FilterByAuthor(t *testing.T) {
+	// Arrange
+	fake := newFakeRepo()
+	uc := usecase.NewBookUsecase(fake)
+	_, _ = uc.CreateBook(context.Background(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	_, _ = uc.CreateBook(context.Background(), domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+
+	// Act
+	got, err := uc.ListBooks(context.Background(), domain.ListBooksQuery{Author: "Jane Austen"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Emma" {
+		t.Fatalf("unexpected listing: %+v", got)
+	}
+}
@@ -0,0 +1,68 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_CreateBook_RecordsBookCreated(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	outbox := memory.NewOutboxRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithTransactor(memory.NewTransactor()), usecase.WithOutbox(outbox))
+
+	// Act
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	// Assert
+	msgs, err := outbox.ClaimPending(ctx, time.Now().Add(time.Second), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, domain.EventBookCreated, msgs[0].EventType)
+	assert.Equal(t, book.ID, msgs[0].AggregateID)
+
+	var payload domain.BookCreated
+	require.NoError(t, json.Unmarshal(msgs[0].Payload, &payload))
+	assert.Equal(t, "Dune", payload.Book.Title)
+}
+
+func TestBookUsecase_UpdateBook_RecordsBookUpdated(t *testing.T) {
+	ctx := tenantCtx()
+	outbox := memory.NewOutboxRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithTransactor(memory.NewTransactor()), usecase.WithOutbox(outbox))
+	book, _ := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+
+	_, err := uc.UpdateBook(ctx, book.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, outbox.Pending())
+}
+
+func TestBookUsecase_UpdateBook_NotFound_RecordsNothing(t *testing.T) {
+	ctx := tenantCtx()
+	outbox := memory.NewOutboxRepository()
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(), usecase.WithTransactor(memory.NewTransactor()), usecase.WithOutbox(outbox))
+
+	_, err := uc.UpdateBook(ctx, 42, domain.UpdateBookInput{Title: "X", Author: "Y"})
+
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+	assert.Equal(t, 0, outbox.Pending())
+}
+
+// tenantCtx is the context of a request acting for the test tenant; the
+// memory repository refuses to work without one.
+func tenantCtx() context.Context {
+	return tenancy.WithTenant(context.Background(), "acme")
+}
@@ -0,0 +1,126 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/patch"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_PatchBook(t *testing.T) {
+	tests := []struct {
+		name       string
+		mediaType  string
+		body       string
+		wantTitle  string
+		wantAuthor string
+		wantErr    error
+	}{
+		{
+			name:      "merge patch one field",
+			mediaType: patch.MergePatchType,
+			body:      `{"title":"Dune Messiah"}`,
+			wantTitle: "Dune Messiah", wantAuthor: "Frank Herbert",
+		},
+		{
+			name:      "json patch with test",
+			mediaType: patch.JSONPatchType,
+			body:      `[{"op":"test","path":"/title","value":"Dune"},{"op":"replace","path":"/author","value":"F. Herbert"}]`,
+			wantTitle: "Dune", wantAuthor: "F. Herbert",
+		},
+		{
+			name:      "copy between writable fields",
+			mediaType: patch.JSONPatchType,
+			body:      `[{"op":"copy","from":"/author","path":"/title"}]`,
+			wantTitle: "Frank Herbert", wantAuthor: "Frank Herbert",
+		},
+		{
+			name:      "read-only field rewritten unchanged",
+			mediaType: patch.JSONPatchType,
+			body:      `[{"op":"replace","path":"/id","value":1},{"op":"replace","path":"/title","value":"Dune!"}]`,
+			wantTitle: "Dune!", wantAuthor: "Frank Herbert",
+		},
+		{
+			name:      "id changed",
+			mediaType: patch.MergePatchType,
+			body:      `{"id":99}`,
+			wantErr:   usecase.ErrValidation,
+		},
+		{
+			name:      "created_at removed",
+			mediaType: patch.JSONPatchType,
+			body:      `[{"op":"remove","path":"/created_at"}]`,
+			wantErr:   usecase.ErrValidation,
+		},
+		{
+			name:      "title blanked",
+			mediaType: patch.MergePatchType,
+			body:      `{"title":"  "}`,
+			wantErr:   usecase.ErrValidation,
+		},
+		{
+			name:      "title removed",
+			mediaType: patch.MergePatchType,
+			body:      `{"title":null}`,
+			wantErr:   usecase.ErrValidation,
+		},
+		{
+			name:      "unknown field",
+			mediaType: patch.MergePatchType,
+			body:      `{"isbn":"123"}`,
+			wantErr:   patch.ErrPath,
+		},
+		{
+			name:      "failed test",
+			mediaType: patch.JSONPatchType,
+			body:      `[{"op":"test","path":"/title","value":"Emma"},{"op":"replace","path":"/title","value":"X"}]`,
+			wantErr:   patch.ErrTestFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := tenancy.WithTenant(context.Background(), "acme")
+			repo := memory.NewBookRepository()
+			uc := usecase.NewBookUsecase(repo)
+			book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+			require.NoError(t, err)
+			p, err := patch.Parse(tt.mediaType, []byte(tt.body))
+			require.NoError(t, err)
+
+			// Act
+			got, err := uc.PatchBook(ctx, book.ID, p)
+
+			// Assert
+			stored, gerr := uc.GetBook(ctx, book.ID)
+			require.NoError(t, gerr)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, "Dune", stored.Title, "failed patch leaves the book alone")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTitle, got.Title)
+			assert.Equal(t, tt.wantAuthor, got.Author)
+			assert.Equal(t, tt.wantTitle, stored.Title)
+		})
+	}
+}
+
+func TestBookUsecase_PatchBookNotFound(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	uc := usecase.NewBookUsecase(memory.NewBookRepository())
+	p, err := patch.ParseMergePatch([]byte(`{"title":"x"}`))
+	require.NoError(t, err)
+
+	_, err = uc.PatchBook(ctx, 42, p)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
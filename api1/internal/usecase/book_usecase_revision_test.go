@@ -0,0 +1,119 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// steppingClock advances by one minute on every call so each write gets a
+// distinct, predictable revision time.
+func steppingClock(start time.Time) func() time.Time {
+	t := start
+	return func() time.Time {
+		t = t.Add(time.Minute)
+		return t
+	}
+}
+
+func newRevisionUsecase(t *testing.T) (usecase.BookUsecase, *memory.AuditRepository) {
+	t.Helper()
+	repo := memory.NewBookRepository()
+	repo.SetClock(steppingClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)))
+	audit := memory.NewAuditRepository()
+	uc := usecase.NewBookUsecase(repo,
+		usecase.WithTransactor(memory.NewTransactor()),
+		usecase.WithAuditLog(audit),
+		usecase.WithRevisions(repo),
+	)
+	return uc, audit
+}
+
+func TestBookUsecase_RevertBook_AppendsRevision(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	uc, audit := newRevisionUsecase(t)
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = uc.UpdateBook(ctx, book.ID, domain.UpdateBookInput{Title: "Dune (typo)", Author: "F. Herbert"})
+	require.NoError(t, err)
+
+	// Act
+	reverted, err := uc.RevertBook(ctx, book.ID, 1)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", reverted.Title)
+	assert.Equal(t, "Frank Herbert", reverted.Author)
+
+	revs, err := uc.ListRevisions(ctx, book.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, revs, 3, "a revert adds a revision instead of rewriting history")
+	assert.Equal(t, 3, revs[2].Revision)
+	assert.Equal(t, "Dune", revs[2].Book.Title)
+	assert.Equal(t, "Dune (typo)", revs[1].Book.Title)
+
+	records, _ := audit.List(ctx, domain.AuditQuery{Action: domain.AuditRevert})
+	assert.Len(t, records, 1)
+}
+
+func TestBookUsecase_RevertBook_Rejected(t *testing.T) {
+	ctx := tenantCtx()
+	uc, _ := newRevisionUsecase(t)
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	_, err = uc.RevertBook(ctx, book.ID, 7)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "unknown revision")
+
+	require.NoError(t, uc.DeleteBook(ctx, book.ID))
+	_, err = uc.RevertBook(ctx, book.ID, 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "a deleted book must be restored first")
+
+	_, err = uc.RestoreBook(ctx, book.ID)
+	require.NoError(t, err)
+	_, err = uc.RevertBook(ctx, book.ID, 2)
+	assert.ErrorIs(t, err, usecase.ErrValidation, "revision 2 records the delete")
+}
+
+func TestBookUsecase_GetBookAsOf(t *testing.T) {
+	// Arrange: create at 12:01, update at 12:02, delete at 12:03.
+	ctx := tenantCtx()
+	uc, _ := newRevisionUsecase(t)
+	book, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = uc.UpdateBook(ctx, book.ID, domain.UpdateBookInput{Title: "Dune Messiah", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteBook(ctx, book.ID))
+
+	at := func(min int) time.Time { return time.Date(2025, 1, 1, 12, min, 30, 0, time.UTC) }
+
+	// Act & Assert
+	_, err = uc.GetBookAsOf(ctx, book.ID, at(0))
+	assert.ErrorIs(t, err, domain.ErrNotFound, "before it was created")
+
+	b, err := uc.GetBookAsOf(ctx, book.ID, at(1))
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", b.Title)
+
+	b, err = uc.GetBookAsOf(ctx, book.ID, at(2))
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", b.Title)
+
+	_, err = uc.GetBookAsOf(ctx, book.ID, at(3))
+	assert.ErrorIs(t, err, domain.ErrNotFound, "after it was deleted")
+}
+
+func TestBookUsecase_ListRevisions_UnknownBook(t *testing.T) {
+	uc, _ := newRevisionUsecase(t)
+
+	_, err := uc.ListRevisions(tenantCtx(), 404, 0, 0)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -28,6 +28,57 @@ func (s *stubRepo) Create(ctx context.Context, in domain.CreateBookInput) (*doma
 	return s.returnBook, s.returnErr
 }
 
+func (s *stubRepo) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	s.called = true
+	return s.returnBook, s.returnErr
+}
+
+func (s *stubRepo) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	s.called = true
+	if s.returnBook == nil {
+		return nil, s.returnErr
+	}
+	return map[int64]*domain.Book{s.returnBook.ID: s.returnBook}, s.returnErr
+}
+
+func (s *stubRepo) List(ctx context.Context, q domain.ListBooksQuery) ([]*domain.Book, error) {
+	s.called = true
+	if s.returnBook == nil {
+		return nil, s.returnErr
+	}
+	return []*domain.Book{s.returnBook}, s.returnErr
+}
+
+func (s *stubRepo) Update(ctx context.Context, id int64, in domain.UpdateBookInput) (*domain.Book, error) {
+	s.called = true
+	return s.returnBook, s.returnErr
+}
+
+func (s *stubRepo) Delete(ctx context.Context, id int64) error {
+	s.called = true
+	return s.returnErr
+}
+
+func (s *stubRepo) Restore(ctx context.Context, id int64) (*domain.Book, error) {
+	s.called = true
+	return s.returnBook, s.returnErr
+}
+
+func (s *stubRepo) SetCover(ctx context.Context, id int64, c domain.Cover) (*domain.Book, error) {
+	s.called = true
+	return s.returnBook, s.returnErr
+}
+
+func (s *stubRepo) GetCover(ctx context.Context, id int64) (*domain.Cover, error) {
+	s.called = true
+	return nil, s.returnErr
+}
+
+func (s *stubRepo) AdjustRating(ctx context.Context, id int64, sum, count int) (*domain.Book, error) {
+	s.called = true
+	return s.returnBook, s.returnErr
+}
+
 // ---- Tests ----
 
 func TestBookUsecase_CreateBook_HappyPath_WithStub(t *testing.T) {
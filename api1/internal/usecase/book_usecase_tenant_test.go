@@ -0,0 +1,121 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_TenantIsolation(t *testing.T) {
+	// Arrange
+	acme := tenancy.WithTenant(context.Background(), "acme")
+	globex := tenancy.WithTenant(context.Background(), "globex")
+	repo := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(repo, usecase.WithRevisions(repo))
+	book, err := uc.CreateBook(acme, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", book.TenantID)
+
+	// Act & Assert: the other tenant cannot see or touch the book.
+	_, err = uc.GetBook(globex, book.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = uc.UpdateBook(globex, book.ID, domain.UpdateBookInput{Title: "Mine", Author: "Me"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, uc.DeleteBook(globex, book.ID), domain.ErrNotFound)
+	_, err = uc.ListRevisions(globex, book.ID, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	books, err := uc.ListBooks(globex, domain.ListBooksQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, books)
+
+	got, err := uc.GetBook(acme, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", got.Title)
+}
+
+func TestBookUsecase_RequiresTenant(t *testing.T) {
+	uc := usecase.NewBookUsecase(memory.NewBookRepository())
+
+	_, err := uc.CreateBook(context.Background(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+
+	assert.ErrorIs(t, err, tenancy.ErrNoTenant)
+}
+
+func TestBookUsecase_Quota(t *testing.T) {
+	// Arrange
+	ctx := tenantCtx()
+	tenants := memory.NewTenantRepository()
+	_, err := tenants.Create(ctx, domain.CreateTenantInput{ID: "acme", Name: "Acme", BookQuota: 1})
+	require.NoError(t, err)
+	uc := usecase.NewBookUsecase(memory.NewBookRepository(),
+		usecase.WithTransactor(memory.NewTransactor()),
+		usecase.WithQuota(tenants),
+	)
+
+	// Act & Assert
+	first, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+
+	require.NoError(t, uc.DeleteBook(ctx, first.ID))
+	second, err := uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err, "deleting frees quota")
+
+	_, err = uc.RestoreBook(ctx, first.ID)
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded, "restoring counts against quota")
+
+	require.NoError(t, uc.DeleteBook(ctx, second.ID))
+	_, err = tenants.SetSuspended(ctx, "acme", true)
+	require.NoError(t, err)
+	_, err = uc.CreateBook(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	assert.ErrorIs(t, err, domain.ErrTenantSuspended)
+}
+
+func TestTenantUsecase_CreateTenant_Validation(t *testing.T) {
+	uc := usecase.NewTenantUsecase(memory.NewTenantRepository())
+	ctx := context.Background()
+
+	for _, in := range []domain.CreateTenantInput{
+		{ID: "Acme Library", Name: "Acme"},
+		{ID: "acme", Name: " "},
+		{ID: "acme", Name: "Acme", BookQuota: -1},
+	} {
+		_, err := uc.CreateTenant(ctx, in)
+		assert.ErrorIs(t, err, usecase.ErrValidation, "%+v", in)
+	}
+
+	_, err := uc.CreateTenant(ctx, domain.CreateTenantInput{ID: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	_, err = uc.CreateTenant(ctx, domain.CreateTenantInput{ID: "acme", Name: "Acme again"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
+
+func TestTenantUsecase_ActiveTenant(t *testing.T) {
+	uc := usecase.NewTenantUsecase(memory.NewTenantRepository())
+	ctx := context.Background()
+	_, err := uc.CreateTenant(ctx, domain.CreateTenantInput{ID: "acme", Name: "Acme"})
+	require.NoError(t, err)
+
+	_, err = uc.ActiveTenant(ctx, "acme")
+	require.NoError(t, err)
+
+	_, err = uc.SuspendTenant(ctx, "acme")
+	require.NoError(t, err)
+	_, err = uc.ActiveTenant(ctx, "acme")
+	assert.ErrorIs(t, err, domain.ErrTenantSuspended)
+
+	_, err = uc.ResumeTenant(ctx, "acme")
+	require.NoError(t, err)
+	_, err = uc.ActiveTenant(ctx, "acme")
+	assert.NoError(t, err)
+}
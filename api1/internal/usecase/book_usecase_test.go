@@ -58,3 +58,42 @@ func TestCreateBook_RepoError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, got)
 }
+
+func TestGetBook_InvalidID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mr := domain_mock.NewMockBookRepository(ctrl)
+	uc := usecase.NewBookUsecase(mr)
+
+	got, err := uc.GetBook(context.Background(), 0)
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	assert.Nil(t, got)
+}
+
+func TestListBooks_LimitTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mr := domain_mock.NewMockBookRepository(ctrl)
+	uc := usecase.NewBookUsecase(mr)
+
+	got, err := uc.ListBooks(context.Background(), domain.ListBooksQuery{Limit: 1000})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	assert.Nil(t, got)
+}
+
+func TestListBooks_PassesQueryToRepo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mr := domain_mock.NewMockBookRepository(ctrl)
+	q := domain.ListBooksQuery{Author: "Y", Limit: 10, Offset: 20}
+	mr.EXPECT().List(gomock.Any(), q).Return([]*domain.Book{{ID: 1, Title: "X", Author: "Y"}}, nil)
+
+	uc := usecase.NewBookUsecase(mr)
+	got, err := uc.ListBooks(context.Background(), q)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+}
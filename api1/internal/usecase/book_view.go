@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// IncludedReviews is how many of its most recent reviews a book view
+// embeds; the rest are a ListReviews away.
+const IncludedReviews = 10
+
+func (u *bookUsecase) GetBookView(ctx context.Context, id int64, sel domain.BookSelection) (*domain.BookView, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	if err := checkSelection(sel); err != nil {
+		return nil, err
+	}
+
+	var b *domain.Book
+	var err error
+	if u.fields != nil {
+		b, err = u.fields.GetFields(ctx, id, sel.Fields)
+	} else {
+		b, err = u.repo.GetByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	views := []*domain.BookView{{Book: b, Fields: sel.Fields}}
+	if err := u.include(ctx, views, sel); err != nil {
+		return nil, err
+	}
+	return views[0], nil
+}
+
+func (u *bookUsecase) ListBookViews(ctx context.Context, q domain.ListBooksQuery, sel domain.BookSelection) ([]*domain.BookView, error) {
+	if q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 {
+		return nil, ErrValidation
+	}
+	if err := checkSelection(sel); err != nil {
+		return nil, err
+	}
+	q.Tags = NormalizeTags(q.Tags)
+
+	var books []*domain.Book
+	var err error
+	if u.fields != nil {
+		books, err = u.fields.ListFields(ctx, q, sel.Fields)
+	} else {
+		books, err = u.repo.List(ctx, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*domain.BookView, len(books))
+	for i, b := range books {
+		views[i] = &domain.BookView{Book: b, Fields: sel.Fields}
+	}
+	if err := u.include(ctx, views, sel); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// include loads each selected relation for all views with one call.
+func (u *bookUsecase) include(ctx context.Context, views []*domain.BookView, sel domain.BookSelection) error {
+	if len(sel.Include) == 0 {
+		return nil
+	}
+	if u.reviews == nil || u.copies == nil {
+		return domain.ErrNotFound
+	}
+
+	ids := make([]int64, len(views))
+	for i, v := range views {
+		ids[i] = v.Book.ID
+	}
+
+	if sel.Includes("reviews") {
+		byBook, err := u.reviews.ListForBooks(ctx, ids, IncludedReviews)
+		if err != nil {
+			return err
+		}
+		for _, v := range views {
+			v.Reviews = byBook[v.Book.ID]
+			if v.Reviews == nil {
+				v.Reviews = []*domain.Review{}
+			}
+		}
+	}
+	if sel.Includes("copies") {
+		byBook, err := u.copies.ListCopiesForBooks(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, v := range views {
+			v.Copies = byBook[v.Book.ID]
+			if v.Copies == nil {
+				v.Copies = []*domain.Copy{}
+			}
+		}
+	}
+	return nil
+}
+
+func checkSelection(sel domain.BookSelection) error {
+	for _, f := range sel.Fields {
+		if !slices.Contains(domain.BookFields, f) {
+			return fmt.Errorf("%w: unknown field %q", ErrValidation, f)
+		}
+	}
+	for _, rel := range sel.Include {
+		if !slices.Contains(domain.BookIncludes, rel) {
+			return fmt.Errorf("%w: unknown include %q", ErrValidation, rel)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package usecase_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestBookUsecase_ListBookViewsIncludes(t *testing.T) {
+	// Arrange
+	books := memory.NewBookRepository()
+	reviews := memory.NewReviewRepository()
+	loans := memory.NewLoanRepository()
+	uc := usecase.NewBookUsecase(books, usecase.WithFieldRepository(books), usecase.WithIncludes(reviews, loans))
+	dune, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	emma, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+
+	start := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	for i := range usecase.IncludedReviews + 2 {
+		reviews.SetClock(func() time.Time { return start.Add(time.Duration(i) * time.Minute) })
+		_, err := reviews.Create(tenantCtx(), dune.ID, fmt.Sprintf("reader%d", i), domain.ReviewInput{Rating: 4})
+		require.NoError(t, err)
+	}
+	_, err = loans.CreateCopy(tenantCtx(), emma.ID, domain.CreateCopyInput{Barcode: "E-1"})
+	require.NoError(t, err)
+
+	// Act
+	views, err := uc.ListBookViews(tenantCtx(), domain.ListBooksQuery{},
+		domain.BookSelection{Fields: []string{"title"}, Include: []string{"reviews", "copies"}})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Equal(t, []string{"title"}, views[0].Fields)
+	assert.Len(t, views[0].Reviews, usecase.IncludedReviews)
+	assert.Equal(t, fmt.Sprintf("reader%d", usecase.IncludedReviews+1), views[0].Reviews[0].Reviewer, "newest first")
+	assert.Empty(t, views[0].Copies)
+	assert.NotNil(t, views[0].Copies, "included relations are never nil")
+	assert.Empty(t, views[1].Reviews)
+	require.Len(t, views[1].Copies, 1)
+	assert.Equal(t, "E-1", views[1].Copies[0].Barcode)
+}
+
+func TestBookUsecase_GetBookView(t *testing.T) {
+	books := memory.NewBookRepository()
+	uc := usecase.NewBookUsecase(books, usecase.WithFieldRepository(books))
+	dune, err := uc.CreateBook(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	view, err := uc.GetBookView(tenantCtx(), dune.ID, domain.BookSelection{Fields: []string{"id", "title"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", view.Book.Title)
+	assert.Nil(t, view.Reviews)
+
+	// Includes need WithIncludes.
+	_, err = uc.GetBookView(tenantCtx(), dune.ID, domain.BookSelection{Include: []string{"reviews"}})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = uc.GetBookView(tenantCtx(), dune.ID, domain.BookSelection{Fields: []string{"isbn"}})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = uc.GetBookView(tenantCtx(), dune.ID, domain.BookSelection{Include: []string{"authors"}})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+const (
+	maxCategoryNameLen = 100
+	maxSlugLen         = 64
+	maxTagLen          = 40
+	maxTagsPerBook     = 20
+	maxBookCategories  = 20
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type CategoryUsecase interface {
+	CreateCategory(ctx context.Context, in domain.CategoryInput) (*domain.Category, error)
+	GetCategory(ctx context.Context, id int64) (*domain.Category, error)
+	ListCategories(ctx context.Context) ([]*domain.Category, error)
+	UpdateCategory(ctx context.Context, id int64, in domain.CategoryInput) (*domain.Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+
+	GetBookTaxonomy(ctx context.Context, bookID int64) (*domain.BookTaxonomy, error)
+	SetBookTaxonomy(ctx context.Context, bookID int64, in domain.BookTaxonomyInput) (*domain.BookTaxonomy, error)
+	Facets(ctx context.Context, q domain.ListBooksQuery) (*domain.Facets, error)
+}
+
+type categoryUsecase struct {
+	categories domain.CategoryRepository
+	books      domain.BookRepository
+}
+
+func NewCategoryUsecase(categories domain.CategoryRepository, books domain.BookRepository) CategoryUsecase {
+	return &categoryUsecase{categories: categories, books: books}
+}
+
+// CreateCategory adds a category under in.ParentID, or as a root. Without
+// a slug one is derived from the name.
+func (u *categoryUsecase) CreateCategory(ctx context.Context, in domain.CategoryInput) (*domain.Category, error) {
+	in, err := normalizeCategory(in)
+	if err != nil {
+		return nil, err
+	}
+	c, err := u.categories.CreateCategory(ctx, in)
+	return c, categoryErr(err)
+}
+
+func (u *categoryUsecase) GetCategory(ctx context.Context, id int64) (*domain.Category, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	return u.categories.GetCategory(ctx, id)
+}
+
+// ListCategories returns the whole tree, each category right after its
+// parent.
+func (u *categoryUsecase) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	return u.categories.ListCategories(ctx)
+}
+
+// UpdateCategory renames a category or moves it, with its subtree, under
+// another parent. Moving it under itself or a descendant is refused.
+func (u *categoryUsecase) UpdateCategory(ctx context.Context, id int64, in domain.CategoryInput) (*domain.Category, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	in, err := normalizeCategory(in)
+	if err != nil {
+		return nil, err
+	}
+	if in.ParentID != nil && *in.ParentID == id {
+		return nil, domain.ErrCategoryCycle
+	}
+	if _, err := u.categories.GetCategory(ctx, id); err != nil {
+		return nil, err
+	}
+	c, err := u.categories.UpdateCategory(ctx, id, in)
+	return c, categoryErr(err)
+}
+
+// DeleteCategory removes a leaf category; books filed under it are
+// unfiled from it.
+func (u *categoryUsecase) DeleteCategory(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrValidation
+	}
+	err := u.categories.DeleteCategory(ctx, id)
+	if errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("%w: category has subcategories", domain.ErrConflict)
+	}
+	return err
+}
+
+func (u *categoryUsecase) GetBookTaxonomy(ctx context.Context, bookID int64) (*domain.BookTaxonomy, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	return u.categories.GetBookTaxonomy(ctx, bookID)
+}
+
+// SetBookTaxonomy replaces the categories and tags of a book. Tags are
+// case-insensitive and stored in lower case.
+func (u *categoryUsecase) SetBookTaxonomy(ctx context.Context, bookID int64, in domain.BookTaxonomyInput) (*domain.BookTaxonomy, error) {
+	if bookID <= 0 || len(in.CategoryIDs) > maxBookCategories {
+		return nil, ErrValidation
+	}
+	for _, id := range in.CategoryIDs {
+		if id <= 0 {
+			return nil, ErrValidation
+		}
+	}
+	in.CategoryIDs = slices.Compact(slices.Sorted(slices.Values(in.CategoryIDs)))
+	in.Tags = NormalizeTags(in.Tags)
+	if len(in.Tags) > maxTagsPerBook {
+		return nil, fmt.Errorf("%w: at most %d tags per book", ErrValidation, maxTagsPerBook)
+	}
+	for _, t := range in.Tags {
+		if utf8.RuneCountInString(t) > maxTagLen || strings.Contains(t, ",") {
+			return nil, fmt.Errorf("%w: invalid tag %q", ErrValidation, t)
+		}
+	}
+
+	// The book is checked first, so a not-found from the repository
+	// below can only be about a category.
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	t, err := u.categories.SetBookTaxonomy(ctx, bookID, in)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown category", ErrValidation)
+	}
+	return t, err
+}
+
+// Facets counts the books matching q per category and tag. Paging in q is
+// ignored.
+func (u *categoryUsecase) Facets(ctx context.Context, q domain.ListBooksQuery) (*domain.Facets, error) {
+	q.Tags = NormalizeTags(q.Tags)
+	q.Limit, q.Offset = 0, 0
+	return u.categories.Facets(ctx, q)
+}
+
+// NormalizeTags trims and lower-cases tags, and drops empty and repeated
+// ones, keeping the rest sorted.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+func normalizeCategory(in domain.CategoryInput) (domain.CategoryInput, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || utf8.RuneCountInString(in.Name) > maxCategoryNameLen {
+		return in, fmt.Errorf("%w: name must be 1 to %d characters", ErrValidation, maxCategoryNameLen)
+	}
+	if in.ParentID != nil && *in.ParentID <= 0 {
+		return in, ErrValidation
+	}
+	if in.Slug == "" {
+		in.Slug = slugify(in.Name)
+	}
+	if len(in.Slug) > maxSlugLen || !slugPattern.MatchString(in.Slug) {
+		return in, fmt.Errorf("%w: slug must be lower-case letters and digits separated by hyphens", ErrValidation)
+	}
+	return in, nil
+}
+
+// slugify turns "Science Fiction & Fantasy" into "science-fiction-fantasy".
+// Letters outside ASCII are dropped, so a name made only of them needs an
+// explicit slug.
+func slugify(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// categoryErr words the repository's errors for a category write whose
+// category is known to exist, so a not-found is about the parent.
+func categoryErr(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrConflict):
+		return fmt.Errorf("%w: slug is already taken", domain.ErrConflict)
+	case errors.Is(err, domain.ErrNotFound):
+		return fmt.Errorf("%w: unknown parent category", ErrValidation)
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// catalog is a small tree with a few filed and tagged books:
+//
+//	fiction
+//	├── sf        dune [classic, space], hyperion [space]
+//	└── fantasy   hobbit [classic]
+//	history       spqr
+type catalog struct {
+	uc     usecase.CategoryUsecase
+	books  usecase.BookUsecase
+	cats   map[string]*domain.Category
+	bookID map[string]int64
+}
+
+func newCatalog(t *testing.T) *catalog {
+	t.Helper()
+	repo := memory.NewBookRepository()
+	c := &catalog{
+		uc:     usecase.NewCategoryUsecase(repo, repo),
+		books:  usecase.NewBookUsecase(repo),
+		cats:   map[string]*domain.Category{},
+		bookID: map[string]int64{},
+	}
+	add := func(name string, parent string) {
+		in := domain.CategoryInput{Name: name}
+		if parent != "" {
+			in.ParentID = &c.cats[parent].ID
+		}
+		cat, err := c.uc.CreateCategory(tenantCtx(), in)
+		require.NoError(t, err)
+		c.cats[cat.Slug] = cat
+	}
+	add("Fiction", "")
+	add("Science Fiction", "fiction")
+	add("Fantasy", "fiction")
+	add("History", "")
+
+	file := func(title, category string, tags ...string) {
+		b, err := repo.Create(tenantCtx(), domain.CreateBookInput{Title: title, Author: "A"})
+		require.NoError(t, err)
+		_, err = c.uc.SetBookTaxonomy(tenantCtx(), b.ID, domain.BookTaxonomyInput{
+			CategoryIDs: []int64{c.cats[category].ID}, Tags: tags,
+		})
+		require.NoError(t, err)
+		c.bookID[title] = b.ID
+	}
+	file("dune", "science-fiction", "Classic", " space ")
+	file("hyperion", "science-fiction", "space")
+	file("hobbit", "fantasy", "classic")
+	file("spqr", "history")
+	return c
+}
+
+func (c *catalog) titles(t *testing.T, q domain.ListBooksQuery) []string {
+	t.Helper()
+	books, err := c.books.ListBooks(tenantCtx(), q)
+	require.NoError(t, err)
+	var titles []string
+	for _, b := range books {
+		titles = append(titles, b.Title)
+	}
+	return titles
+}
+
+func TestCategoryUsecase_FilterByCategoryIncludesDescendants(t *testing.T) {
+	c := newCatalog(t)
+
+	assert.Equal(t, []string{"dune", "hyperion", "hobbit"}, c.titles(t, domain.ListBooksQuery{Category: "fiction"}))
+	assert.Equal(t, []string{"hobbit"}, c.titles(t, domain.ListBooksQuery{Category: "fantasy"}))
+	assert.Empty(t, c.titles(t, domain.ListBooksQuery{Category: "poetry"}))
+}
+
+func TestCategoryUsecase_FilterByTags(t *testing.T) {
+	c := newCatalog(t)
+
+	assert.Equal(t, []string{"dune", "hyperion", "hobbit"}, c.titles(t, domain.ListBooksQuery{Tags: []string{"space", "CLASSIC"}}))
+	assert.Equal(t, []string{"dune"}, c.titles(t, domain.ListBooksQuery{Tags: []string{"space", "classic"}, AllTags: true}))
+	assert.Equal(t, []string{"hobbit"}, c.titles(t, domain.ListBooksQuery{Category: "fantasy", Tags: []string{"classic"}}))
+}
+
+func TestCategoryUsecase_Facets(t *testing.T) {
+	// Arrange
+	c := newCatalog(t)
+
+	// Act
+	f, err := c.uc.Facets(tenantCtx(), domain.ListBooksQuery{Tags: []string{"classic", "space"}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.Total)
+	counts := map[string]int{}
+	for _, cf := range f.Categories {
+		counts[cf.Slug] = cf.Count
+	}
+	assert.Equal(t, map[string]int{"fiction": 3, "science-fiction": 2, "fantasy": 1}, counts)
+	require.Len(t, f.Tags, 2)
+	assert.Equal(t, domain.TagFacet{Tag: "classic", Count: 2}, *f.Tags[0], "ties go alphabetically")
+	assert.Equal(t, domain.TagFacet{Tag: "space", Count: 2}, *f.Tags[1])
+}
+
+func TestCategoryUsecase_MoveCarriesTheSubtree(t *testing.T) {
+	c := newCatalog(t)
+	history := c.cats["history"].ID
+
+	_, err := c.uc.UpdateCategory(tenantCtx(), c.cats["fiction"].ID, domain.CategoryInput{Name: "Fiction", ParentID: &history})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dune", "hyperion", "hobbit", "spqr"}, c.titles(t, domain.ListBooksQuery{Category: "history"}))
+	cats, err := c.uc.ListCategories(tenantCtx())
+	require.NoError(t, err)
+	var slugs []string
+	for _, cat := range cats {
+		slugs = append(slugs, cat.Slug)
+	}
+	assert.Equal(t, []string{"history", "fiction", "science-fiction", "fantasy"}, slugs)
+}
+
+func TestCategoryUsecase_RejectsCycles(t *testing.T) {
+	c := newCatalog(t)
+	fiction, sf := c.cats["fiction"].ID, c.cats["science-fiction"].ID
+
+	_, err := c.uc.UpdateCategory(tenantCtx(), fiction, domain.CategoryInput{Name: "Fiction", ParentID: &sf})
+	assert.ErrorIs(t, err, domain.ErrCategoryCycle)
+	_, err = c.uc.UpdateCategory(tenantCtx(), fiction, domain.CategoryInput{Name: "Fiction", ParentID: &fiction})
+	assert.ErrorIs(t, err, domain.ErrCategoryCycle)
+}
+
+func TestCategoryUsecase_Rejected(t *testing.T) {
+	c := newCatalog(t)
+	missing := int64(99)
+
+	_, err := c.uc.CreateCategory(tenantCtx(), domain.CategoryInput{Name: "Sci-fi", Slug: "science-fiction"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	_, err = c.uc.CreateCategory(tenantCtx(), domain.CategoryInput{Name: "Poetry", Slug: "Poetry!"})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = c.uc.CreateCategory(tenantCtx(), domain.CategoryInput{Name: "Poetry", ParentID: &missing})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+
+	assert.ErrorIs(t, c.uc.DeleteCategory(tenantCtx(), c.cats["fiction"].ID), domain.ErrConflict)
+	_, err = c.uc.SetBookTaxonomy(tenantCtx(), c.bookID["spqr"], domain.BookTaxonomyInput{CategoryIDs: []int64{missing}})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
+
+func TestCategoryUsecase_DeleteUnfilesBooks(t *testing.T) {
+	c := newCatalog(t)
+
+	require.NoError(t, c.uc.DeleteCategory(tenantCtx(), c.cats["fantasy"].ID))
+
+	tax, err := c.uc.GetBookTaxonomy(tenantCtx(), c.bookID["hobbit"])
+	require.NoError(t, err)
+	assert.Empty(t, tax.Categories)
+	assert.Equal(t, []string{"classic"}, tax.Tags)
+	assert.Equal(t, []string{"dune", "hyperion"}, c.titles(t, domain.ListBooksQuery{Category: "fiction"}))
+}
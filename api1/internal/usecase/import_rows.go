@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// maxJSONLine bounds one line of a JSON Lines import.
+const maxJSONLine = 1 << 20
+
+// importRow is one data row of an import file. err is set when the row
+// could not be read as a book; the rest of the file is still usable.
+type importRow struct {
+	n   int
+	in  domain.CreateBookInput
+	err error
+}
+
+// rowReader yields the data rows of an import file in order. Next returns
+// io.EOF after the last one; any other error means the file cannot be
+// read any further.
+type rowReader interface {
+	Next() (importRow, error)
+}
+
+func newRowReader(format domain.ImportFormat, r io.Reader) (rowReader, error) {
+	switch format {
+	case domain.ImportCSV:
+		return newCSVRows(r)
+	case domain.ImportJSONL:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64<<10), maxJSONLine)
+		return &jsonlRows{s: s}, nil
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+type csvRows struct {
+	r             *csv.Reader
+	title, author int
+	n             int
+}
+
+// newCSVRows reads the header row and finds the title and author columns
+// in it, in any order and case. Other columns are ignored.
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CSV header: %w", err)
+	}
+	rows := &csvRows{r: cr, title: -1, author: -1}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "title":
+			rows.title = i
+		case "author":
+			rows.author = i
+		}
+	}
+	if rows.title < 0 || rows.author < 0 {
+		return nil, errors.New("CSV header must name a title and an author column")
+	}
+	return rows, nil
+}
+
+func (c *csvRows) Next() (importRow, error) {
+	rec, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return importRow{}, io.EOF
+	}
+	c.n++
+	row := importRow{n: c.n}
+	var perr *csv.ParseError
+	switch {
+	case errors.As(err, &perr):
+		row.err = perr.Err
+		return row, nil
+	case err != nil:
+		return importRow{}, err
+	case c.title >= len(rec) || c.author >= len(rec):
+		row.err = errors.New("missing title or author column")
+		return row, nil
+	}
+	row.in = domain.CreateBookInput{Title: rec[c.title], Author: rec[c.author]}
+	return row, nil
+}
+
+type jsonlRows struct {
+	s *bufio.Scanner
+	n int
+}
+
+// Next skips blank lines, which are not rows.
+func (j *jsonlRows) Next() (importRow, error) {
+	for j.s.Scan() {
+		line := strings.TrimSpace(j.s.Text())
+		if line == "" {
+			continue
+		}
+		j.n++
+		row := importRow{n: j.n}
+		if err := json.Unmarshal([]byte(line), &row.in); err != nil {
+			row.err = errors.New("not a JSON object with title and author")
+		}
+		return row, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+const (
+	// MaxImportBytes bounds an uploaded import file.
+	MaxImportBytes = 256 << 20
+	// MaxImportErrors bounds the row errors kept per job; rows past it
+	// still count as failed.
+	MaxImportErrors = 10000
+
+	DefaultImportChunk = 500
+	DefaultImportLease = 5 * time.Minute
+)
+
+// ImportConfig sets how many rows commit together and how long a running
+// job may go without progress before another worker takes it over. Now is
+// the clock for job timestamps; it defaults to time.Now.
+type ImportConfig struct {
+	ChunkSize int
+	Lease     time.Duration
+	Now       func() time.Time
+}
+
+type ImportUsecase interface {
+	StartImport(ctx context.Context, format domain.ImportFormat, r io.Reader) (*domain.ImportJob, error)
+	GetImport(ctx context.Context, id int64) (*domain.ImportJob, error)
+	ListImportErrors(ctx context.Context, id int64, limit, offset int) ([]*domain.ImportRowError, error)
+	CancelImport(ctx context.Context, id int64) (*domain.ImportJob, error)
+	RunNext(ctx context.Context) (*domain.ImportJob, error)
+}
+
+type importUsecase struct {
+	jobs  domain.ImportRepository
+	books BookUsecase
+	blobs domain.BlobStore
+	tx    domain.Transactor
+	cfg   ImportConfig
+}
+
+// NewImportUsecase creates books through books, so imported rows get the
+// same validation, duplicate checks, quota and audit trail as single
+// creates. Uploads wait in blobs until their job finishes; tx should be
+// given so that a chunk and the job's counts commit together.
+func NewImportUsecase(jobs domain.ImportRepository, books BookUsecase, blobs domain.BlobStore, tx domain.Transactor, cfg ImportConfig) ImportUsecase {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultImportChunk
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = DefaultImportLease
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &importUsecase{jobs: jobs, books: books, blobs: blobs, tx: tx, cfg: cfg}
+}
+
+// StartImport stores the file in r and queues a job for it. A CSV header
+// is checked up front; everything else is reported per row by the job.
+func (u *importUsecase) StartImport(ctx context.Context, format domain.ImportFormat, r io.Reader) (*domain.ImportJob, error) {
+	if format != domain.ImportCSV && format != domain.ImportJSONL {
+		return nil, ErrUnsupportedMedia
+	}
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(r, 64<<10)
+	if format == domain.ImportCSV {
+		if err := checkCSVHeader(br); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := importKey(tenant)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "text/csv"
+	if format == domain.ImportJSONL {
+		contentType = "application/x-ndjson"
+	}
+	if err := u.blobs.Put(ctx, key, &cappedReader{r: br, n: MaxImportBytes}, -1, contentType); err != nil {
+		_ = u.blobs.Delete(ctx, key)
+		return nil, err
+	}
+
+	job, err := u.jobs.Create(ctx, domain.ImportJob{
+		Format:    format,
+		CreatedBy: requestmeta.FromContext(ctx).Actor,
+		BlobKey:   key,
+	})
+	if err != nil {
+		_ = u.blobs.Delete(ctx, key)
+		return nil, err
+	}
+	return job, nil
+}
+
+func (u *importUsecase) GetImport(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	return u.jobs.Get(ctx, id)
+}
+
+func (u *importUsecase) ListImportErrors(ctx context.Context, id int64, limit, offset int) ([]*domain.ImportRowError, error) {
+	if _, err := u.GetImport(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return u.jobs.ListErrors(ctx, id, limit, offset)
+}
+
+// CancelImport cancels a queued job at once. A running job is only
+// flagged; its worker stops after the chunk in hand, keeping the books
+// already imported.
+func (u *importUsecase) CancelImport(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	job, err := u.jobs.RequestCancel(ctx, id, u.cfg.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if job.Done() {
+		_ = u.blobs.Delete(ctx, job.BlobKey)
+	}
+	return job, nil
+}
+
+// RunNext claims the next job of the tenant in ctx and runs it to the
+// end. It returns nil when there is nothing to do. On an error the job is
+// failed, unless ctx was cancelled: then it stays running and is resumed
+// after the lease from its last committed chunk.
+func (u *importUsecase) RunNext(ctx context.Context) (*domain.ImportJob, error) {
+	now := u.cfg.Now().UTC()
+	job, err := u.jobs.Claim(ctx, now, now.Add(-u.cfg.Lease))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx = requestmeta.WithMeta(ctx, requestmeta.Meta{
+		Actor:     job.CreatedBy,
+		RequestID: fmt.Sprintf("import-%d", job.ID),
+	})
+
+	done, err := u.run(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return done, err
+	}
+	failed, ferr := u.finish(ctx, done, domain.ImportFailed,
+		fmt.Sprintf("stopped after row %d: internal error", done.Processed))
+	if ferr != nil {
+		return done, errors.Join(err, ferr)
+	}
+	return failed, err
+}
+
+// run imports the rows of job past job.Processed a chunk at a time. It
+// returns the job as last committed along with any error that stopped it.
+func (u *importUsecase) run(ctx context.Context, job *domain.ImportJob) (*domain.ImportJob, error) {
+	body, err := u.blobs.Get(ctx, job.BlobKey)
+	if errors.Is(err, domain.ErrNotFound) {
+		return u.finish(ctx, job, domain.ImportFailed, "uploaded file is gone")
+	}
+	if err != nil {
+		return job, err
+	}
+	defer body.Close()
+
+	rows, err := newRowReader(job.Format, body)
+	if err != nil {
+		return u.finish(ctx, job, domain.ImportFailed, err.Error())
+	}
+
+	for {
+		if job.CancelRequested {
+			return u.finish(ctx, job, domain.ImportCancelled, "")
+		}
+
+		chunk := make([]importRow, 0, u.cfg.ChunkSize)
+		var readErr error
+		for len(chunk) < u.cfg.ChunkSize {
+			row, err := rows.Next()
+			if err != nil {
+				readErr = err
+				break
+			}
+			if row.n > job.Processed {
+				chunk = append(chunk, row)
+			}
+		}
+		if len(chunk) > 0 {
+			next, err := u.commit(ctx, job, chunk)
+			if errors.Is(err, domain.ErrImportFinished) {
+				// Finished elsewhere, e.g. by a worker that took over.
+				return u.jobs.Get(ctx, job.ID)
+			}
+			if err != nil {
+				return job, err
+			}
+			job = next
+		}
+
+		switch {
+		case errors.Is(readErr, io.EOF):
+			return u.finish(ctx, job, domain.ImportSucceeded, "")
+		case readErr != nil:
+			return u.finish(ctx, job, domain.ImportFailed,
+				fmt.Sprintf("unreadable after row %d: %v", job.Processed, readErr))
+		}
+	}
+}
+
+// commit creates the books of chunk and records the outcome in one
+// transaction. Rows the book usecase refuses become row errors; anything
+// else aborts the chunk.
+func (u *importUsecase) commit(ctx context.Context, job *domain.ImportJob, chunk []importRow) (*domain.ImportJob, error) {
+	var next *domain.ImportJob
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var p domain.ImportProgress
+		var errs []domain.ImportRowError
+		room := MaxImportErrors - job.Failed
+		for _, row := range chunk {
+			p.Processed++
+			msg := ""
+			if row.err != nil {
+				msg = row.err.Error()
+			} else if _, err := u.books.CreateBook(ctx, row.in); err != nil {
+				if msg = rowMessage(err); msg == "" {
+					return err
+				}
+			}
+			if msg == "" {
+				p.Created++
+				continue
+			}
+			p.Failed++
+			if len(errs) < room {
+				errs = append(errs, domain.ImportRowError{
+					Row:     row.n,
+					Title:   row.in.Title,
+					Author:  row.in.Author,
+					Message: msg,
+				})
+			}
+		}
+		j, err := u.jobs.Progress(ctx, job.ID, p, errs, u.cfg.Now().UTC())
+		next = j
+		return err
+	})
+	return next, err
+}
+
+// rowMessage says why a row was refused, or returns "" if err is not
+// about the row.
+func rowMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return "title and author are required"
+	case errors.Is(err, domain.ErrDuplicate), errors.Is(err, domain.ErrQuotaExceeded):
+		return err.Error()
+	default:
+		return ""
+	}
+}
+
+// finish moves job to status and drops its upload.
+func (u *importUsecase) finish(ctx context.Context, job *domain.ImportJob, status domain.ImportStatus, reason string) (*domain.ImportJob, error) {
+	done, err := u.jobs.Finish(ctx, job.ID, status, reason, u.cfg.Now().UTC())
+	if errors.Is(err, domain.ErrImportFinished) {
+		return u.jobs.Get(ctx, job.ID)
+	}
+	if err != nil {
+		return job, err
+	}
+	_ = u.blobs.Delete(ctx, job.BlobKey)
+	return done, nil
+}
+
+// checkCSVHeader fails with ErrValidation unless the first line of br is a
+// usable CSV header. It only peeks, leaving br where it was.
+func checkCSVHeader(br *bufio.Reader) error {
+	head, err := br.Peek(br.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return err
+	}
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	} else if len(head) == br.Size() {
+		return fmt.Errorf("%w: CSV header is too long", ErrValidation)
+	}
+	if _, err := newCSVRows(bytes.NewReader(head)); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return nil
+}
+
+// importKey is a fresh blob key for an upload of tenant.
+func importKey(tenant string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("imports/%s/%s", tenant, hex.EncodeToString(b)), nil
+}
+
+// cappedReader fails with ErrTooLarge once more than n bytes are read.
+type cappedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	if c.n < 0 {
+		return 0, ErrTooLarge
+	}
+	return n, err
+}
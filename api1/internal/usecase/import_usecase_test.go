@@ -0,0 +1,265 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/broadcast"
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+type importFixture struct {
+	uc    usecase.ImportUsecase
+	jobs  *memory.ImportRepository
+	books *memory.BookRepository
+	blobs *memory.BlobStore
+	now   time.Time
+}
+
+func newImportFixture(t *testing.T, chunk int) *importFixture {
+	t.Helper()
+	f := &importFixture{
+		jobs:  memory.NewImportRepository(),
+		books: memory.NewBookRepository(),
+		blobs: memory.NewBlobStore(),
+		now:   time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC),
+	}
+	tx := memory.NewTransactor()
+	books := usecase.NewBookUsecase(f.books, usecase.WithTransactor(tx))
+	f.uc = usecase.NewImportUsecase(f.jobs, books, f.blobs, tx, usecase.ImportConfig{
+		ChunkSize: chunk,
+		Lease:     time.Minute,
+		Now:       func() time.Time { return f.now },
+	})
+	return f
+}
+
+func (f *importFixture) titles(t *testing.T) []string {
+	t.Helper()
+	books, err := f.books.List(tenantCtx(), domain.ListBooksQuery{})
+	require.NoError(t, err)
+	var titles []string
+	for _, b := range books {
+		titles = append(titles, b.Title)
+	}
+	return titles
+}
+
+func TestImportUsecase_CSV(t *testing.T) {
+	// Arrange
+	f := newImportFixture(t, 2)
+	file := "\ufeffAuthor,Year,TITLE\n" +
+		"Frank Herbert,1965,Dune\n" +
+		"Jane Austen,1815,\n" +
+		"Ursula K. Le Guin,1969,The Left Hand of Darkness\n" +
+		"frank  herbert,1965,DUNE\n" +
+		"Mary Shelley,1818,\"Frankenstein\n"
+
+	// Act
+	job, err := f.uc.StartImport(actorCtx("alice"), domain.ImportCSV, strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportQueued, job.Status)
+	assert.Equal(t, "alice", job.CreatedBy)
+
+	done, err := f.uc.RunNext(tenantCtx())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSucceeded, done.Status)
+	assert.Equal(t, 5, done.Processed)
+	assert.Equal(t, 2, done.Created)
+	assert.Equal(t, 3, done.Failed)
+	assert.Equal(t, []string{"Dune", "The Left Hand of Darkness"}, f.titles(t))
+
+	errs, err := f.uc.ListImportErrors(tenantCtx(), job.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+	assert.Equal(t, domain.ImportRowError{Row: 2, Author: "Jane Austen", Message: "title and author are required"}, *errs[0])
+	assert.Equal(t, 4, errs[1].Row)
+	assert.Contains(t, errs[1].Message, "duplicate")
+	assert.Equal(t, 5, errs[2].Row)
+
+	_, err = f.blobs.Get(tenantCtx(), done.BlobKey)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "the upload is dropped when the job finishes")
+
+	next, err := f.uc.RunNext(tenantCtx())
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestImportUsecase_JSONLines(t *testing.T) {
+	f := newImportFixture(t, 500)
+	file := `{"title":"Dune","author":"Frank Herbert","isbn":"ignored"}
+
+{"title":"Emma"}
+not json
+{"title":"Emma","author":"Jane Austen"}
+`
+
+	job, err := f.uc.StartImport(tenantCtx(), domain.ImportJSONL, strings.NewReader(file))
+	require.NoError(t, err)
+	done, err := f.uc.RunNext(tenantCtx())
+
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, done.ID)
+	assert.Equal(t, domain.ImportSucceeded, done.Status)
+	assert.Equal(t, 4, done.Processed, "blank lines are not rows")
+	assert.Equal(t, 2, done.Created)
+	errs, err := f.uc.ListImportErrors(tenantCtx(), job.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 2, errs[0].Row)
+	assert.Equal(t, 3, errs[1].Row)
+}
+
+func TestImportUsecase_RejectsUnusableCSVHeader(t *testing.T) {
+	f := newImportFixture(t, 0)
+
+	_, err := f.uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader("name,writer\nDune,Frank Herbert\n"))
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+
+	_, err = f.uc.StartImport(tenantCtx(), "xlsx", strings.NewReader(""))
+	assert.ErrorIs(t, err, usecase.ErrUnsupportedMedia)
+}
+
+func TestImportUsecase_CancelQueued(t *testing.T) {
+	f := newImportFixture(t, 0)
+	job, err := f.uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader("title,author\nDune,Frank Herbert\n"))
+	require.NoError(t, err)
+
+	cancelled, err := f.uc.CancelImport(tenantCtx(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportCancelled, cancelled.Status)
+
+	next, err := f.uc.RunNext(tenantCtx())
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Empty(t, f.titles(t))
+
+	_, err = f.uc.CancelImport(tenantCtx(), job.ID)
+	assert.ErrorIs(t, err, domain.ErrImportFinished)
+}
+
+func TestImportUsecase_CancelRunningStopsAtNextChunk(t *testing.T) {
+	f := newImportFixture(t, 1)
+	job, err := f.uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader("title,author\nDune,Frank Herbert\nEmma,Jane Austen\n"))
+	require.NoError(t, err)
+	// A worker has the job and went away after committing the first row.
+	_, err = f.books.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	_, err = f.jobs.Claim(tenantCtx(), f.now, f.now)
+	require.NoError(t, err)
+	_, err = f.jobs.Progress(tenantCtx(), job.ID, domain.ImportProgress{Processed: 1, Created: 1}, nil, f.now)
+	require.NoError(t, err)
+
+	flagged, err := f.uc.CancelImport(tenantCtx(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportRunning, flagged.Status)
+	assert.True(t, flagged.CancelRequested)
+
+	f.now = f.now.Add(2 * time.Minute)
+	done, err := f.uc.RunNext(tenantCtx())
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportCancelled, done.Status)
+	assert.Equal(t, 1, done.Processed)
+	assert.Equal(t, []string{"Dune"}, f.titles(t))
+}
+
+func TestImportUsecase_ResumesStaleJobAfterLastChunk(t *testing.T) {
+	// Arrange
+	f := newImportFixture(t, 2)
+	file := "title,author\nDune,Frank Herbert\nEmma,Jane Austen\nBeloved,Toni Morrison\n"
+	job, err := f.uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader(file))
+	require.NoError(t, err)
+	// The first chunk committed before the worker died.
+	for _, in := range []domain.CreateBookInput{{Title: "Dune", Author: "Frank Herbert"}, {Title: "Emma", Author: "Jane Austen"}} {
+		_, err = f.books.Create(tenantCtx(), in)
+		require.NoError(t, err)
+	}
+	_, err = f.jobs.Claim(tenantCtx(), f.now, f.now)
+	require.NoError(t, err)
+	_, err = f.jobs.Progress(tenantCtx(), job.ID, domain.ImportProgress{Processed: 2, Created: 2}, nil, f.now)
+	require.NoError(t, err)
+
+	// Act
+	early, err := f.uc.RunNext(tenantCtx())
+	require.NoError(t, err)
+	assert.Nil(t, early, "the job is still leased to its worker")
+
+	f.now = f.now.Add(2 * time.Minute)
+	done, err := f.uc.RunNext(tenantCtx())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSucceeded, done.Status)
+	assert.Equal(t, 3, done.Processed)
+	assert.Equal(t, 3, done.Created)
+	assert.Zero(t, done.Failed, "rows of the committed chunk are not imported again")
+	assert.Equal(t, []string{"Dune", "Emma", "Beloved"}, f.titles(t))
+}
+
+func TestImportUsecase_GetImportOfOtherTenant(t *testing.T) {
+	f := newImportFixture(t, 0)
+	job, err := f.uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader("title,author\n"))
+	require.NoError(t, err)
+
+	other := tenancy.WithTenant(context.Background(), "globex")
+	_, err = f.uc.GetImport(other, job.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = f.uc.ListImportErrors(other, job.ID, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// failingBooks fails to create the book titled fail, as a lost connection
+// would.
+type failingBooks struct {
+	*memory.BookRepository
+	fail string
+}
+
+func (r failingBooks) Create(ctx context.Context, in domain.CreateBookInput) (*domain.Book, error) {
+	if in.Title == r.fail {
+		return nil, errors.New("connection reset")
+	}
+	return r.BookRepository.Create(ctx, in)
+}
+
+func TestImportUsecase_FailedChunkPublishesNothing(t *testing.T) {
+	// Arrange
+	f := newImportFixture(t, 2)
+	feed := broadcast.New(broadcast.Config{})
+	tx := memory.NewTransactor()
+	books := usecase.NewBookUsecase(failingBooks{f.books, "Frankenstein"},
+		usecase.WithTransactor(tx), usecase.WithChangeStream(feed, nil))
+	uc := usecase.NewImportUsecase(f.jobs, books, f.blobs, tx, usecase.ImportConfig{ChunkSize: 2, Now: func() time.Time { return f.now }})
+	sub, err := feed.Subscribe(tenantCtx(), domain.BookChangeFilter{}, "")
+	require.NoError(t, err)
+	defer sub.Close()
+	file := "title,author\nDune,Frank Herbert\nEmma,Jane Austen\nBeloved,Toni Morrison\nFrankenstein,Mary Shelley\n"
+	_, err = uc.StartImport(tenantCtx(), domain.ImportCSV, strings.NewReader(file))
+	require.NoError(t, err)
+
+	// Act
+	done, err := uc.RunNext(tenantCtx())
+
+	// Assert: the first chunk committed; Beloved went down with the second.
+	require.Error(t, err)
+	assert.Equal(t, domain.ImportFailed, done.Status)
+	assert.Equal(t, 2, done.Processed)
+	var published []string
+	for len(sub.Changes()) > 0 {
+		c := <-sub.Changes()
+		published = append(published, c.Book.Title)
+	}
+	assert.Equal(t, []string{"Dune", "Emma"}, published)
+}
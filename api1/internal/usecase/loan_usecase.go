@@ -0,0 +1,433 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/unittest2/lib"
+)
+
+// DefaultLoanPolicies apply when LoanConfig.Policies is empty.
+var DefaultLoanPolicies = map[domain.MemberType]domain.LoanPolicy{
+	domain.MemberStandard: {LoanDays: 21, MaxRenewals: 2},
+	domain.MemberStudent:  {LoanDays: 28, MaxRenewals: 3},
+	domain.MemberStaff:    {LoanDays: 56, MaxRenewals: 5},
+}
+
+// DefaultPickupWindow applies when LoanConfig.PickupWindow is zero.
+const DefaultPickupWindow = 3 * 24 * time.Hour
+
+// LoanConfig sets the loan policies per member type and how long a ready
+// hold waits to be picked up. Now is the clock used for checkouts, due
+// dates, overdue checks and hold expiry; it defaults to time.Now.
+type LoanConfig struct {
+	Policies     map[domain.MemberType]domain.LoanPolicy
+	PickupWindow time.Duration
+	Now          func() time.Time
+}
+
+type LoanUsecase interface {
+	AddCopy(ctx context.Context, bookID int64, in domain.CreateCopyInput) (*domain.Copy, error)
+	ListCopies(ctx context.Context, bookID int64) ([]*domain.Copy, error)
+
+	Checkout(ctx context.Context, in domain.CheckoutInput) (*domain.Loan, error)
+	GetLoan(ctx context.Context, id int64) (*domain.Loan, error)
+	ListLoans(ctx context.Context, q domain.ListLoansQuery, overdue bool) ([]*domain.Loan, error)
+	Return(ctx context.Context, id int64) (*domain.Loan, error)
+	Renew(ctx context.Context, id int64) (*domain.Loan, error)
+	ReportLost(ctx context.Context, id int64) (*domain.Loan, error)
+
+	PlaceHold(ctx context.Context, bookID int64) (*domain.Hold, error)
+	GetHold(ctx context.Context, bookID, id int64) (*domain.Hold, error)
+	ListHolds(ctx context.Context, q domain.ListHoldsQuery) ([]*domain.Hold, error)
+	CancelHold(ctx context.Context, bookID, id int64) (*domain.Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+type loanUsecase struct {
+	loans domain.LoanRepository
+	holds domain.HoldRepository
+	books domain.BookRepository
+	tx    domain.Transactor
+	cfg   LoanConfig
+}
+
+// NewLoanUsecase offers every copy that comes back to the book's hold
+// queue in the same transaction as the return; tx should be given so that
+// concurrent returns cannot hand one copy to two holds.
+func NewLoanUsecase(loans domain.LoanRepository, holds domain.HoldRepository, books domain.BookRepository, tx domain.Transactor, cfg LoanConfig) LoanUsecase {
+	if len(cfg.Policies) == 0 {
+		cfg.Policies = DefaultLoanPolicies
+	}
+	if cfg.PickupWindow <= 0 {
+		cfg.PickupWindow = DefaultPickupWindow
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &loanUsecase{loans: loans, holds: holds, books: books, tx: tx, cfg: cfg}
+}
+
+func (u *loanUsecase) AddCopy(ctx context.Context, bookID int64, in domain.CreateCopyInput) (*domain.Copy, error) {
+	if bookID <= 0 || strings.TrimSpace(in.Barcode) == "" {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	// A new copy goes to the front of the hold queue if there is one.
+	var c *domain.Copy
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		if c, err = u.loans.CreateCopy(ctx, bookID, in); err != nil {
+			return err
+		}
+		if err := u.allocate(ctx, bookID); err != nil {
+			return err
+		}
+		c, err = u.loans.GetCopy(ctx, c.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (u *loanUsecase) ListCopies(ctx context.Context, bookID int64) ([]*domain.Copy, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return u.loans.ListCopies(ctx, bookID)
+}
+
+// Checkout lends a copy to the actor until the due date of their member
+// type's policy. A copy that is out or lost cannot be checked out, and one
+// set aside for a hold only by the member who placed it.
+func (u *loanUsecase) Checkout(ctx context.Context, in domain.CheckoutInput) (*domain.Loan, error) {
+	if in.CopyID <= 0 {
+		return nil, ErrValidation
+	}
+	memberID, memberType, err := member(ctx, "borrow books")
+	if err != nil {
+		return nil, err
+	}
+	policy, ok := u.cfg.Policies[memberType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown member type %q", ErrValidation, memberType)
+	}
+
+	now := u.now()
+	l, err := u.loans.Checkout(ctx, domain.Loan{
+		CopyID:       in.CopyID,
+		MemberID:     memberID,
+		MemberType:   memberType,
+		CheckedOutAt: now,
+		DueAt:        dueAfter(now, policy),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.present(l), nil
+}
+
+func (u *loanUsecase) GetLoan(ctx context.Context, id int64) (*domain.Loan, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	l, err := u.loans.GetLoan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.present(l), nil
+}
+
+// ListLoans lists loans matching q; overdue narrows it to open loans past
+// their due date.
+func (u *loanUsecase) ListLoans(ctx context.Context, q domain.ListLoansQuery, overdue bool) ([]*domain.Loan, error) {
+	if q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 {
+		return nil, ErrValidation
+	}
+	if overdue {
+		q.OpenOnly = true
+		q.DueBefore = u.now()
+	}
+
+	loans, err := u.loans.ListLoans(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range loans {
+		u.present(l)
+	}
+	return loans, nil
+}
+
+func (u *loanUsecase) Return(ctx context.Context, id int64) (*domain.Loan, error) {
+	return u.close(ctx, id, domain.LoanReturned)
+}
+
+// ReportLost ends the loan and takes the copy out of circulation.
+func (u *loanUsecase) ReportLost(ctx context.Context, id int64) (*domain.Loan, error) {
+	return u.close(ctx, id, domain.LoanLost)
+}
+
+// Renew pushes the due date to a full loan period from now. Overdue loans
+// have to be returned instead, and each policy caps the renewals.
+func (u *loanUsecase) Renew(ctx context.Context, id int64) (*domain.Loan, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+
+	l, err := u.loans.GetLoan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := u.now()
+	switch u.present(l).Status {
+	case domain.LoanOverdue:
+		return nil, fmt.Errorf("%w: overdue loans cannot be renewed", ErrValidation)
+	case domain.LoanReturned, domain.LoanLost:
+		return nil, domain.ErrLoanClosed
+	}
+	policy, ok := u.cfg.Policies[l.MemberType]
+	if !ok || l.Renewals >= policy.MaxRenewals {
+		return nil, fmt.Errorf("%w: renewal limit reached", ErrValidation)
+	}
+
+	// The repository only applies the renewal if nobody renewed in between,
+	// so two concurrent renewals cannot both slip under the limit.
+	renewed, err := u.loans.Renew(ctx, id, l.Renewals, dueAfter(now, policy))
+	if errors.Is(err, domain.ErrConflict) {
+		return nil, fmt.Errorf("%w: loan changed while renewing, try again", domain.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.present(renewed), nil
+}
+
+// close ends the loan and, when the copy comes back, offers it to the
+// book's hold queue in the same transaction.
+func (u *loanUsecase) close(ctx context.Context, id int64, status domain.LoanStatus) (*domain.Loan, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	var l *domain.Loan
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		if l, err = u.loans.Close(ctx, id, status, u.now()); err != nil {
+			return err
+		}
+		if status == domain.LoanReturned {
+			return u.allocate(ctx, l.BookID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.present(l), nil
+}
+
+// PlaceHold queues the actor for the book. If a copy is free already the
+// hold is ready for pickup straight away.
+func (u *loanUsecase) PlaceHold(ctx context.Context, bookID int64) (*domain.Hold, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	memberID, err := actor(ctx, "place holds")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	var h *domain.Hold
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		if h, err = u.holds.PlaceHold(ctx, bookID, memberID, u.now()); err != nil {
+			return err
+		}
+		if err := u.allocate(ctx, bookID); err != nil {
+			return err
+		}
+		h, err = u.holds.GetHold(ctx, bookID, h.ID)
+		return err
+	})
+	if errors.Is(err, domain.ErrConflict) {
+		return nil, fmt.Errorf("%w: member already has an active hold on this book", domain.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return presentHold(h), nil
+}
+
+// GetHold returns one of the actor's holds. Other members' holds are not
+// found.
+func (u *loanUsecase) GetHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	h, err := u.ownHold(ctx, bookID, id)
+	if err != nil {
+		return nil, err
+	}
+	return presentHold(h), nil
+}
+
+// ListHolds lists a book's holds in queue order.
+func (u *loanUsecase) ListHolds(ctx context.Context, q domain.ListHoldsQuery) ([]*domain.Hold, error) {
+	if q.BookID <= 0 || q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, q.BookID); err != nil {
+		return nil, err
+	}
+	holds, err := u.holds.ListHolds(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holds {
+		presentHold(h)
+	}
+	return holds, nil
+}
+
+// CancelHold takes one of the actor's holds out of the queue. A ready hold
+// gives its copy to the next member in line.
+func (u *loanUsecase) CancelHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	var h *domain.Hold
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		if _, err := u.ownHold(ctx, bookID, id); err != nil {
+			return err
+		}
+		if h, err = u.holds.CancelHold(ctx, bookID, id, u.now()); err != nil {
+			return err
+		}
+		return u.allocate(ctx, bookID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return presentHold(h), nil
+}
+
+// ExpireHolds ends the ready holds of the tenant in ctx whose pickup window
+// has passed and passes their copies down the queue. It reports how many
+// holds expired and is meant to be run periodically.
+func (u *loanUsecase) ExpireHolds(ctx context.Context) (int, error) {
+	var n int
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		expired, err := u.holds.ExpireHolds(ctx, u.now())
+		if err != nil {
+			return err
+		}
+		n = len(expired)
+
+		seen := make(map[int64]bool)
+		for _, h := range expired {
+			if seen[h.BookID] {
+				continue
+			}
+			seen[h.BookID] = true
+			if err := u.allocate(ctx, h.BookID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// ownHold returns the hold if it is the actor's, and domain.ErrNotFound
+// if it is someone else's.
+func (u *loanUsecase) ownHold(ctx context.Context, bookID, id int64) (*domain.Hold, error) {
+	memberID, err := actor(ctx, "see holds")
+	if err != nil {
+		return nil, err
+	}
+	h, err := u.holds.GetHold(ctx, bookID, id)
+	if err != nil {
+		return nil, err
+	}
+	if h.MemberID != memberID {
+		return nil, domain.ErrNotFound
+	}
+	return h, nil
+}
+
+// allocate sets free copies of the book aside for the oldest queued holds.
+func (u *loanUsecase) allocate(ctx context.Context, bookID int64) error {
+	now := u.now()
+	_, err := u.holds.AllocateCopies(ctx, bookID, now, now.Add(u.cfg.PickupWindow))
+	return err
+}
+
+// present fills in what is derived rather than stored: the overdue status
+// and the due date as shown to members.
+func (u *loanUsecase) present(l *domain.Loan) *domain.Loan {
+	if l.Status == domain.LoanOnLoan && u.now().After(l.DueAt) {
+		l.Status = domain.LoanOverdue
+	}
+	l.DueDate = longDate(l.DueAt)
+	return l
+}
+
+// presentHold fills in the pickup date as shown to members.
+func presentHold(h *domain.Hold) *domain.Hold {
+	if h.PickupBy != nil {
+		h.PickupDate = longDate(*h.PickupBy)
+	}
+	return h
+}
+
+// longDate renders t as members read dates, "20 October 2025". The date
+// handed to lib.FormatDateLong is made here, so it always parses.
+func longDate(t time.Time) string {
+	s, _ := lib.FormatDateLong(t.Format(time.DateOnly))
+	return s
+}
+
+// member returns the actor as a library member, with the member type
+// their credentials carry. Members whose credentials carry none are
+// standard members.
+func member(ctx context.Context, what string) (string, domain.MemberType, error) {
+	id, err := actor(ctx, what)
+	if err != nil {
+		return "", "", err
+	}
+	memberType := domain.MemberType(requestmeta.FromContext(ctx).MemberType)
+	if memberType == "" {
+		memberType = domain.MemberStandard
+	}
+	return id, memberType, nil
+}
+
+func (u *loanUsecase) now() time.Time {
+	return u.cfg.Now().UTC().Truncate(time.Second)
+}
+
+// dueAfter is the end of the last day of the loan period starting at t, so
+// a book is due back by closing time rather than by the minute it left.
+func dueAfter(t time.Time, p domain.LoanPolicy) time.Time {
+	y, m, d := t.AddDate(0, 0, p.LoanDays).Date()
+	return time.Date(y, m, d, 23, 59, 59, 0, time.UTC)
+}
@@ -0,0 +1,360 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/usecase"
+	"unit-test-demo/unittest2/lib"
+)
+
+// fixedClock returns a clock reading *now, so tests can move time.
+func fixedClock(now *time.Time) func() time.Time {
+	return func() time.Time { return *now }
+}
+
+// memberCtx is actorCtx for a member whose credentials carry memberType.
+func memberCtx(who string, memberType domain.MemberType) context.Context {
+	return requestmeta.WithMemberType(actorCtx(who), string(memberType))
+}
+
+func newLoanUsecase(t *testing.T, now *time.Time) (usecase.LoanUsecase, *domain.Copy) {
+	t.Helper()
+	books := memory.NewBookRepository()
+	book, err := books.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	loans := memory.NewLoanRepository()
+	uc := usecase.NewLoanUsecase(loans, loans, books, memory.NewTransactor(), usecase.LoanConfig{Now: fixedClock(now)})
+	c, err := uc.AddCopy(tenantCtx(), book.ID, domain.CreateCopyInput{Barcode: "DUNE-001"})
+	require.NoError(t, err)
+	return uc, c
+}
+
+func TestLoanUsecase_Checkout_DueDateFollowsPolicy(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 10, 1, 14, 30, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+
+	// Act
+	l, err := uc.Checkout(memberCtx("m-1", domain.MemberStudent), domain.CheckoutInput{CopyID: c.ID})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanOnLoan, l.Status)
+	assert.Equal(t, time.Date(2025, 10, 29, 23, 59, 59, 0, time.UTC), l.DueAt)
+	assert.Equal(t, "29 October 2025", l.DueDate)
+
+	copies, err := uc.ListCopies(tenantCtx(), c.BookID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CopyOnLoan, copies[0].Status)
+}
+
+func TestLoanUsecase_Checkout_Rejected(t *testing.T) {
+	now := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+
+	_, err := uc.Checkout(memberCtx("m-1", "vip"), domain.CheckoutInput{CopyID: c.ID})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+
+	_, err = uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: 99})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: c.ID})
+	require.NoError(t, err)
+	_, err = uc.Checkout(actorCtx("m-2"), domain.CheckoutInput{CopyID: c.ID})
+	assert.ErrorIs(t, err, domain.ErrCopyUnavailable)
+}
+
+func TestLoanUsecase_ConcurrentCheckoutsOfOneCopy(t *testing.T) {
+	now := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var won, lost int
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.Checkout(actorCtx("m"), domain.CheckoutInput{CopyID: c.ID})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				won++
+			} else if assert.ErrorIs(t, err, domain.ErrCopyUnavailable) {
+				lost++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, won)
+	assert.Equal(t, 19, lost)
+}
+
+func TestLoanUsecase_ReturnFreesTheCopy(t *testing.T) {
+	now := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	l, err := uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: c.ID})
+	require.NoError(t, err)
+
+	returned, err := uc.Return(tenantCtx(), l.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanReturned, returned.Status)
+	require.NotNil(t, returned.ClosedAt)
+
+	_, err = uc.Return(tenantCtx(), l.ID)
+	assert.ErrorIs(t, err, domain.ErrLoanClosed)
+	_, err = uc.Renew(tenantCtx(), l.ID)
+	assert.ErrorIs(t, err, domain.ErrLoanClosed)
+
+	_, err = uc.Checkout(actorCtx("m-2"), domain.CheckoutInput{CopyID: c.ID})
+	assert.NoError(t, err)
+}
+
+func TestLoanUsecase_RenewalLimit(t *testing.T) {
+	now := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	l, err := uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: c.ID})
+	require.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		now = now.AddDate(0, 0, 10)
+		renewed, err := uc.Renew(tenantCtx(), l.ID)
+		require.NoError(t, err)
+		assert.Equal(t, i, renewed.Renewals)
+		want, err := lib.FormatDateLong(now.AddDate(0, 0, 21).Format(time.DateOnly))
+		require.NoError(t, err)
+		assert.Equal(t, want, renewed.DueDate)
+	}
+
+	_, err = uc.Renew(tenantCtx(), l.ID)
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
+
+func TestLoanUsecase_Overdue(t *testing.T) {
+	now := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	l, err := uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: c.ID})
+	require.NoError(t, err)
+
+	overdue, err := uc.ListLoans(tenantCtx(), domain.ListLoansQuery{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, overdue)
+
+	now = now.AddDate(0, 0, 30)
+	got, err := uc.GetLoan(tenantCtx(), l.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanOverdue, got.Status)
+
+	overdue, err = uc.ListLoans(tenantCtx(), domain.ListLoansQuery{}, true)
+	require.NoError(t, err)
+	require.Len(t, overdue, 1)
+	assert.Equal(t, domain.LoanOverdue, overdue[0].Status)
+
+	_, err = uc.Renew(tenantCtx(), l.ID)
+	assert.ErrorIs(t, err, usecase.ErrValidation, "overdue loans are returned, not renewed")
+
+	lost, err := uc.ReportLost(tenantCtx(), l.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanLost, lost.Status)
+	_, err = uc.Checkout(actorCtx("m-2"), domain.CheckoutInput{CopyID: c.ID})
+	assert.ErrorIs(t, err, domain.ErrCopyUnavailable, "a lost copy stays out of circulation")
+}
+
+func checkout(t *testing.T, uc usecase.LoanUsecase, copyID int64, member string) *domain.Loan {
+	t.Helper()
+	l, err := uc.Checkout(actorCtx(member), domain.CheckoutInput{CopyID: copyID})
+	require.NoError(t, err)
+	return l
+}
+
+func TestLoanUsecase_Holds_QueueInOrderAndAllocateOnReturn(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	l := checkout(t, uc, c.ID, "m-0")
+
+	var holds []*domain.Hold
+	for _, m := range []string{"m-1", "m-2", "m-3"} {
+		h, err := uc.PlaceHold(actorCtx(m), c.BookID)
+		require.NoError(t, err)
+		holds = append(holds, h)
+	}
+	assert.Equal(t, []int{1, 2, 3}, []int{holds[0].Position, holds[1].Position, holds[2].Position})
+
+	// Act
+	_, err := uc.Return(tenantCtx(), l.ID)
+	require.NoError(t, err)
+
+	// Assert
+	first, err := uc.GetHold(actorCtx("m-1"), c.BookID, holds[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldReady, first.Status)
+	assert.Zero(t, first.Position)
+	require.NotNil(t, first.CopyID)
+	assert.Equal(t, c.ID, *first.CopyID)
+	assert.Equal(t, "4 October 2025", first.PickupDate)
+
+	queued, err := uc.ListHolds(tenantCtx(), domain.ListHoldsQuery{BookID: c.BookID, ActiveOnly: true})
+	require.NoError(t, err)
+	require.Len(t, queued, 3)
+	assert.Equal(t, []int{0, 1, 2}, []int{queued[0].Position, queued[1].Position, queued[2].Position})
+
+	_, err = uc.Checkout(actorCtx("m-2"), domain.CheckoutInput{CopyID: c.ID})
+	assert.ErrorIs(t, err, domain.ErrCopyUnavailable, "the copy is set aside for m-1")
+
+	checkout(t, uc, c.ID, "m-1")
+	fulfilled, err := uc.GetHold(actorCtx("m-1"), c.BookID, holds[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldFulfilled, fulfilled.Status)
+}
+
+func TestLoanUsecase_Holds_ExpiryPassesTheCopyOn(t *testing.T) {
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	l := checkout(t, uc, c.ID, "m-0")
+	h1, err := uc.PlaceHold(actorCtx("m-1"), c.BookID)
+	require.NoError(t, err)
+	h2, err := uc.PlaceHold(actorCtx("m-2"), c.BookID)
+	require.NoError(t, err)
+	_, err = uc.Return(tenantCtx(), l.ID)
+	require.NoError(t, err)
+
+	n, err := uc.ExpireHolds(tenantCtx())
+	require.NoError(t, err)
+	assert.Zero(t, n, "still inside the pickup window")
+
+	now = now.Add(usecase.DefaultPickupWindow + time.Second)
+	n, err = uc.ExpireHolds(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	expired, err := uc.GetHold(actorCtx("m-1"), c.BookID, h1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldExpired, expired.Status)
+	next, err := uc.GetHold(actorCtx("m-2"), c.BookID, h2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldReady, next.Status)
+	require.NotNil(t, next.CopyID)
+	assert.Equal(t, c.ID, *next.CopyID)
+}
+
+func TestLoanUsecase_Holds_CancelReadyHoldPassesTheCopyOn(t *testing.T) {
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	h1, err := uc.PlaceHold(actorCtx("m-1"), c.BookID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldReady, h1.Status, "a free copy is set aside at once")
+	h2, err := uc.PlaceHold(actorCtx("m-2"), c.BookID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, h2.Position)
+
+	cancelled, err := uc.CancelHold(actorCtx("m-1"), c.BookID, h1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldCancelled, cancelled.Status)
+	_, err = uc.CancelHold(actorCtx("m-1"), c.BookID, h1.ID)
+	assert.ErrorIs(t, err, domain.ErrHoldClosed)
+
+	next, err := uc.GetHold(actorCtx("m-2"), c.BookID, h2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldReady, next.Status)
+}
+
+func TestLoanUsecase_Holds_Rejected(t *testing.T) {
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	checkout(t, uc, c.ID, "m-0")
+
+	_, err := uc.PlaceHold(tenantCtx(), c.BookID)
+	assert.ErrorIs(t, err, usecase.ErrForbidden, "holds are placed by members signed in")
+	_, err = uc.PlaceHold(actorCtx("m-1"), 99)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = uc.PlaceHold(actorCtx("m-1"), c.BookID)
+	require.NoError(t, err)
+	_, err = uc.PlaceHold(actorCtx("m-1"), c.BookID)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
+
+func TestLoanUsecase_Holds_AreTheMembersOwn(t *testing.T) {
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+	h, err := uc.PlaceHold(actorCtx("m-1"), c.BookID)
+	require.NoError(t, err)
+	assert.Equal(t, "m-1", h.MemberID)
+
+	_, err = uc.GetHold(actorCtx("m-2"), c.BookID, h.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = uc.CancelHold(actorCtx("m-2"), c.BookID, h.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	got, err := uc.GetHold(actorCtx("m-1"), c.BookID, h.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldReady, got.Status, "someone else's cancel leaves the hold alone")
+}
+
+func TestLoanUsecase_Checkout_StandardWithoutAMemberType(t *testing.T) {
+	now := time.Date(2025, 10, 1, 14, 30, 0, 0, time.UTC)
+	uc, c := newLoanUsecase(t, &now)
+
+	l, err := uc.Checkout(actorCtx("m-1"), domain.CheckoutInput{CopyID: c.ID})
+
+	require.NoError(t, err)
+	assert.Equal(t, "m-1", l.MemberID)
+	assert.Equal(t, domain.MemberStandard, l.MemberType)
+	assert.Equal(t, time.Date(2025, 10, 22, 23, 59, 59, 0, time.UTC), l.DueAt)
+}
+
+func TestLoanUsecase_Holds_ConcurrentReturnsAllocateEachCopyOnce(t *testing.T) {
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	uc, first := newLoanUsecase(t, &now)
+
+	const n = 10
+	loans := []*domain.Loan{checkout(t, uc, first.ID, "borrower-0")}
+	for i := 1; i < n; i++ {
+		c, err := uc.AddCopy(tenantCtx(), first.BookID, domain.CreateCopyInput{Barcode: fmt.Sprintf("DUNE-%03d", i+1)})
+		require.NoError(t, err)
+		loans = append(loans, checkout(t, uc, c.ID, fmt.Sprintf("borrower-%d", i)))
+	}
+	for i := 0; i < n+2; i++ {
+		_, err := uc.PlaceHold(actorCtx(fmt.Sprintf("m-%d", i)), first.BookID)
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range loans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.Return(tenantCtx(), l.ID)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	holds, err := uc.ListHolds(tenantCtx(), domain.ListHoldsQuery{BookID: first.BookID})
+	require.NoError(t, err)
+	copies := make(map[int64]bool)
+	for i, h := range holds {
+		if i < n {
+			assert.Equal(t, domain.HoldReady, h.Status, "hold %d", i)
+			require.NotNil(t, h.CopyID)
+			assert.False(t, copies[*h.CopyID], "copy %d set aside twice", *h.CopyID)
+			copies[*h.CopyID] = true
+			continue
+		}
+		assert.Equal(t, domain.HoldQueued, h.Status)
+		assert.Equal(t, i-n+1, h.Position)
+	}
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// Option configures optional collaborators of the book usecase.
+type Option func(*bookUsecase)
+
+// WithTransactor makes each mutation, together with everything recorded
+// about it, run in one transaction.
+func WithTransactor(tx domain.Transactor) Option {
+	return func(u *bookUsecase) {
+		u.tx = tx
+	}
+}
+
+// WithOutbox makes every mutation record its domain events in outbox.
+func WithOutbox(outbox domain.OutboxRepository) Option {
+	return func(u *bookUsecase) {
+		u.outbox = outbox
+	}
+}
+
+// WithAuditLog makes every mutation append an audit record with the actor
+// and request metadata found in the context.
+func WithAuditLog(audit domain.AuditRepository) Option {
+	return func(u *bookUsecase) {
+		u.audit = audit
+	}
+}
+
+// WithRevisions enables the revision endpoints, reading history from revs.
+// Without it they report domain.ErrNotFound.
+func WithRevisions(revs domain.BookRevisionRepository) Option {
+	return func(u *bookUsecase) {
+		u.revs = revs
+	}
+}
+
+// WithQuota makes creating and restoring books count against the quota of
+// the tenant in the context, and deleting them give it back. It needs a
+// Transactor to stay exact.
+func WithQuota(tenants domain.TenantRepository) Option {
+	return func(u *bookUsecase) {
+		u.quota = tenants
+	}
+}
+
+// WithCoverStore enables cover uploads, keeping the images in blobs.
+// Without it the cover methods report domain.ErrNotFound.
+func WithCoverStore(blobs domain.BlobStore) Option {
+	return func(u *bookUsecase) {
+		u.blobs = blobs
+	}
+}
+
+// WithDuplicates enables the duplicate report, reading it from dups.
+// Without it ListDuplicates reports domain.ErrNotFound.
+func WithDuplicates(dups domain.DuplicateRepository) Option {
+	return func(u *bookUsecase) {
+		u.dups = dups
+	}
+}
+
+// WithExporter enables ExportBooks, streaming the books from books.
+// Without it ExportBooks reports domain.ErrNotFound.
+func WithExporter(books domain.BookExporter) Option {
+	return func(u *bookUsecase) {
+		u.export = books
+	}
+}
+
+// WithSync enables SyncBooks, reading the change sequence from books.
+// Without it SyncBooks reports domain.ErrNotFound.
+func WithSync(books domain.BookSyncRepository, cfg SyncConfig) Option {
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultSyncRetention
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return func(u *bookUsecase) {
+		u.sync = books
+		u.syncCfg = cfg
+	}
+}
+
+// WithChangeStream publishes every committed book change to pub, for live
+// subscribers. taxonomy, if given, is read to tag each change with the
+// categories of its book so subscribers can filter on them.
+func WithChangeStream(pub domain.BookChangePublisher, taxonomy domain.CategoryRepository) Option {
+	return func(u *bookUsecase) {
+		u.changes = pub
+		u.taxonomy = taxonomy
+	}
+}
+
+// WithFieldRepository makes book views read only the selected fields from
+// books. Without it views read whole books and trim them.
+func WithFieldRepository(books domain.BookFieldRepository) Option {
+	return func(u *bookUsecase) {
+		u.fields = books
+	}
+}
+
+// WithIncludes lets book views embed the reviews and copies of each book.
+// Without it asking for either reports domain.ErrNotFound.
+func WithIncludes(reviews domain.ReviewRepository, copies domain.LoanRepository) Option {
+	return func(u *bookUsecase) {
+		u.reviews = reviews
+		u.copies = copies
+	}
+}
+
+// noTx is used when no Transactor is configured: fn simply runs.
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
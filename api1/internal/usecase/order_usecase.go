@@ -0,0 +1,339 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/unittest3/lib"
+)
+
+// maxLineQty caps the copies of one book in a cart.
+const maxLineQty = 1000
+
+// DefaultPaymentHold applies when OrderConfig.PaymentHold is zero.
+const DefaultPaymentHold = time.Hour
+
+// OrderConfig sets what orders are charged. DiscountPct applies to every
+// order without a coupon; Coupons maps coupon codes, compared without
+// regard to case, to the discount they give instead. TaxPct is charged on
+// what is left after the discount. PaymentHold is how long the copies of
+// a paid order stay reserved while its payment is pending; it should
+// outlast PaymentConfig.PendingTimeout, so that reconciliation voids the
+// payment before the copies go back on sale. Now is the clock orders are
+// placed by; it defaults to time.Now.
+type OrderConfig struct {
+	DiscountPct int
+	TaxPct      int
+	Coupons     map[string]int
+	PaymentHold time.Duration
+	Now         func() time.Time
+}
+
+type OrderUsecase interface {
+	GetInventory(ctx context.Context, bookID int64) (*domain.Inventory, error)
+	SetInventory(ctx context.Context, bookID int64, in domain.InventoryInput) (*domain.Inventory, error)
+
+	GetCart(ctx context.Context) (*domain.Cart, error)
+	SetCartLine(ctx context.Context, bookID int64, in domain.SetCartLineInput) (*domain.Cart, error)
+	ClearCart(ctx context.Context) error
+
+	PlaceOrder(ctx context.Context, in domain.PlaceOrderInput) (*domain.Order, error)
+	GetOrder(ctx context.Context, id int64) (*domain.Order, error)
+	ListOrders(ctx context.Context, limit, offset int) ([]*domain.Order, error)
+}
+
+type orderUsecase struct {
+	inventory domain.InventoryRepository
+	carts     domain.CartRepository
+	orders    domain.OrderRepository
+	stock     StockUsecase
+	payments  PaymentUsecase
+	books     domain.BookRepository
+	tx        domain.Transactor
+	cfg       OrderConfig
+}
+
+// NewOrderUsecase sells books from inventory, taking the copies from
+// stock. The customer is the actor of the request, so carts and orders
+// need one. tx should be given so that a checkout that fails part way
+// leaves no copies reserved. payments takes payment for orders placed
+// with a payment token; without it only unpaid orders can be placed.
+func NewOrderUsecase(inventory domain.InventoryRepository, carts domain.CartRepository, orders domain.OrderRepository, stock StockUsecase, payments PaymentUsecase, books domain.BookRepository, tx domain.Transactor, cfg OrderConfig) OrderUsecase {
+	coupons := make(map[string]int, len(cfg.Coupons))
+	for code, pct := range cfg.Coupons {
+		coupons[strings.ToUpper(strings.TrimSpace(code))] = pct
+	}
+	cfg.Coupons = coupons
+	if cfg.PaymentHold <= 0 {
+		cfg.PaymentHold = DefaultPaymentHold
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &orderUsecase{inventory: inventory, carts: carts, orders: orders, stock: stock, payments: payments, books: books, tx: tx, cfg: cfg}
+}
+
+func (u *orderUsecase) GetInventory(ctx context.Context, bookID int64) (*domain.Inventory, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return u.inventory.GetInventory(ctx, bookID)
+}
+
+// SetInventory puts a book on sale at a price, or changes the price. The
+// copies to sell are booked in through StockUsecase.
+func (u *orderUsecase) SetInventory(ctx context.Context, bookID int64, in domain.InventoryInput) (*domain.Inventory, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if in.PriceCents < 0 {
+		return nil, fmt.Errorf("%w: price cannot be negative", ErrValidation)
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return u.inventory.SetInventory(ctx, bookID, in, u.cfg.Now())
+}
+
+// GetCart returns the actor's cart priced as it stands. Lines for books no
+// longer on sale stay in the cart but count for nothing.
+func (u *orderUsecase) GetCart(ctx context.Context) (*domain.Cart, error) {
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	lines, err := u.carts.GetCartLines(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+	items, err := u.price(ctx, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Cart{
+		Customer: customer,
+		Lines:    lines,
+		Totals:   domain.NewOrderTotals(lib.CalculateTotals(items, u.cfg.DiscountPct, u.cfg.TaxPct)),
+	}, nil
+}
+
+// SetCartLine sets how many copies of a book are in the actor's cart; zero
+// takes the book out. Stock is only checked when the order is placed.
+func (u *orderUsecase) SetCartLine(ctx context.Context, bookID int64, in domain.SetCartLineInput) (*domain.Cart, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if in.Qty < 0 || in.Qty > maxLineQty {
+		return nil, fmt.Errorf("%w: qty must be between 0 and %d", ErrValidation, maxLineQty)
+	}
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	if in.Qty > 0 {
+		if _, err := u.books.GetByID(ctx, bookID); err != nil {
+			return nil, err
+		}
+		if _, err := u.inventory.GetInventory(ctx, bookID); errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("%w: book %d is not for sale", ErrValidation, bookID)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := u.carts.SetCartLine(ctx, customer, bookID, in.Qty); err != nil {
+		return nil, err
+	}
+	return u.GetCart(ctx)
+}
+
+func (u *orderUsecase) ClearCart(ctx context.Context) error {
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return err
+	}
+	return u.carts.ClearCart(ctx, customer)
+}
+
+// PlaceOrder checks out the actor's cart at current prices. Copies for
+// every line are reserved at once, or the order fails with
+// domain.ErrOutOfStock and nothing is reserved; the reservations are then
+// sold to the order, which records them on its lines. The totals are
+// worked out by lib.CalculateTotals and kept with the order as they were.
+//
+// An order placed with a payment token awaits payment instead: its copies
+// stay reserved for PaymentHold while the card is charged, once the order
+// is committed. The order is returned as the charge left it, paid or
+// cancelled, or still awaiting payment if the gateway has yet to say.
+// Since the order exists by then, a charge that fails part way does not
+// fail PlaceOrder: the payment is left for Reconcile to settle.
+func (u *orderUsecase) PlaceOrder(ctx context.Context, in domain.PlaceOrderInput) (*domain.Order, error) {
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(in.PaymentToken)
+	if token != "" && u.payments == nil {
+		return nil, fmt.Errorf("%w: payments are not taken", ErrValidation)
+	}
+	discount, coupon := u.cfg.DiscountPct, ""
+	if code := strings.ToUpper(strings.TrimSpace(in.Coupon)); code != "" {
+		pct, ok := u.cfg.Coupons[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown coupon %q", ErrValidation, in.Coupon)
+		}
+		discount, coupon = pct, code
+	}
+
+	var (
+		order   *domain.Order
+		payment *domain.Payment
+	)
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cart, err := u.carts.GetCartLines(ctx, customer)
+		if err != nil {
+			return err
+		}
+		if len(cart) == 0 {
+			return fmt.Errorf("%w: cart is empty", ErrValidation)
+		}
+		items, err := u.price(ctx, cart)
+		if err != nil {
+			return err
+		}
+
+		lines := make([]domain.OrderLine, len(cart))
+		want := make([]domain.StockLine, len(cart))
+		for i, l := range cart {
+			if l.Title == "" {
+				return fmt.Errorf("%w: book %d is no longer for sale", ErrValidation, l.BookID)
+			}
+			lines[i] = domain.OrderLine{BookID: l.BookID, Title: l.Title, Qty: l.Qty, UnitPriceCents: l.UnitPriceCents}
+			want[i] = domain.StockLine{BookID: l.BookID, Qty: l.Qty}
+		}
+		status, hold := domain.OrderPlaced, time.Duration(0)
+		if token != "" {
+			status, hold = domain.OrderAwaitingPayment, u.cfg.PaymentHold
+		}
+		reserved, err := u.stock.ReserveLines(ctx, want, "checkout:"+customer, hold)
+		if err != nil {
+			return err
+		}
+		for i, res := range reserved {
+			lines[i].ReservationID = res.ID
+		}
+
+		order, err = u.orders.CreateOrder(ctx, domain.Order{
+			Customer:    customer,
+			Status:      status,
+			Lines:       lines,
+			Coupon:      coupon,
+			DiscountPct: discount,
+			TaxPct:      u.cfg.TaxPct,
+			Totals:      domain.NewOrderTotals(lib.CalculateTotals(items, discount, u.cfg.TaxPct)),
+			PlacedAt:    u.cfg.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := u.carts.ClearCart(ctx, customer); err != nil {
+			return err
+		}
+		if token != "" {
+			payment, err = u.payments.Open(ctx, order)
+			return err
+		}
+		for _, l := range order.Lines {
+			if _, err := u.stock.SellReservation(ctx, l.BookID, l.ReservationID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return order, nil
+	}
+
+	if _, err := u.payments.Authorize(ctx, payment.ID, token); err == nil {
+		if charged, err := u.orders.GetOrder(ctx, order.ID); err == nil {
+			return charged, nil
+		}
+	}
+	return order, nil
+}
+
+// GetOrder returns one of the actor's orders. Other customers' orders are
+// not found.
+func (u *orderUsecase) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	if id <= 0 {
+		return nil, ErrValidation
+	}
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	o, err := u.orders.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Customer != customer {
+		return nil, domain.ErrNotFound
+	}
+	return o, nil
+}
+
+// ListOrders lists the actor's orders, newest first.
+func (u *orderUsecase) ListOrders(ctx context.Context, limit, offset int) ([]*domain.Order, error) {
+	if limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	return u.orders.ListOrders(ctx, domain.ListOrdersQuery{Customer: customer, Limit: limit, Offset: offset})
+}
+
+// price fills in the title and current price of each line and returns the
+// lines on sale as lib.Items. Lines whose book is gone or no longer on
+// sale are left without a title and are not among the items.
+func (u *orderUsecase) price(ctx context.Context, lines []domain.CartLine) ([]lib.Item, error) {
+	ids := make([]int64, len(lines))
+	for i, l := range lines {
+		ids[i] = l.BookID
+	}
+	inventory, err := u.inventory.GetInventories(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	books, err := u.books.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]lib.Item, 0, len(lines))
+	for i := range lines {
+		l := &lines[i]
+		inv, ok := inventory[l.BookID]
+		b, live := books[l.BookID]
+		if !ok || !live {
+			continue
+		}
+		l.Title = b.Title
+		l.UnitPriceCents = inv.PriceCents
+		items = append(items, lib.Item{Qty: l.Qty, UnitPriceCents: inv.PriceCents})
+	}
+	return items, nil
+}
@@ -0,0 +1,309 @@
+package usecase_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/infrastructure/payment"
+	"unit-test-demo/api1/internal/usecase"
+	"unit-test-demo/unittest3/lib"
+)
+
+type orderFixture struct {
+	uc           usecase.OrderUsecase
+	books        *memory.BookRepository
+	store        *memory.OrderRepository
+	stock        usecase.StockUsecase
+	payments     usecase.PaymentUsecase
+	paymentStore *memory.PaymentRepository
+	gateway      *payment.FakeGateway
+	dune         *domain.Book
+	emma         *domain.Book
+	placeAt      time.Time
+}
+
+// newOrderUsecase sells Dune at 19.99 with 5 in stock and Emma at 5.00
+// with 2, under 10% tax, a 5% standing discount and a 20% coupon. Cards
+// are charged through a fake gateway that answers within 200ms or not at
+// all.
+func newOrderUsecase(t *testing.T) *orderFixture {
+	t.Helper()
+	f := &orderFixture{
+		books:        memory.NewBookRepository(),
+		store:        memory.NewOrderRepository(),
+		paymentStore: memory.NewPaymentRepository(),
+		gateway:      payment.NewFakeGateway(payment.FakeConfig{}),
+		placeAt:      time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	srv := httptest.NewServer(f.gateway)
+	t.Cleanup(srv.Close)
+	client := payment.NewClient(payment.ClientConfig{BaseURL: srv.URL, Timeout: 200 * time.Millisecond}, srv.Client())
+
+	tx := memory.NewTransactor()
+	f.stock = usecase.NewStockUsecase(memory.NewStockRepository(), f.books, tx, nil, usecase.StockConfig{Now: fixedClock(&f.placeAt)})
+	f.payments = usecase.NewPaymentUsecase(f.paymentStore, f.store, f.stock, client, tx, usecase.PaymentConfig{Now: fixedClock(&f.placeAt)})
+	f.uc = usecase.NewOrderUsecase(f.store, f.store, f.store, f.stock, f.payments, f.books, tx, usecase.OrderConfig{
+		DiscountPct: 5,
+		TaxPct:      10,
+		Coupons:     map[string]int{"Summer20": 20},
+		Now:         fixedClock(&f.placeAt),
+	})
+
+	var err error
+	f.dune, err = f.books.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	f.emma, err = f.books.Create(tenantCtx(), domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	_, err = f.uc.SetInventory(tenantCtx(), f.dune.ID, domain.InventoryInput{PriceCents: 1999})
+	require.NoError(t, err)
+	_, err = f.stock.ReceiveStock(tenantCtx(), f.dune.ID, domain.StockInput{Qty: 5})
+	require.NoError(t, err)
+	_, err = f.uc.SetInventory(tenantCtx(), f.emma.ID, domain.InventoryInput{PriceCents: 500})
+	require.NoError(t, err)
+	_, err = f.stock.ReceiveStock(tenantCtx(), f.emma.ID, domain.StockInput{Qty: 2})
+	require.NoError(t, err)
+	return f
+}
+
+// onHand returns the copies of b on hand and how many of them are
+// reserved.
+func (f *orderFixture) onHand(t *testing.T, b *domain.Book) (onHand, reserved int64) {
+	t.Helper()
+	l, err := f.stock.GetStock(tenantCtx(), b.ID)
+	require.NoError(t, err)
+	return l.OnHand, l.Reserved
+}
+
+func TestOrderUsecase_Cart(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 2})
+	require.NoError(t, err)
+	cart, err := f.uc.SetCartLine(ctx, f.emma.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, "alice", cart.Customer)
+	assert.Equal(t, []domain.CartLine{
+		{BookID: f.dune.ID, Qty: 2, Title: "Dune", UnitPriceCents: 1999},
+		{BookID: f.emma.ID, Qty: 1, Title: "Emma", UnitPriceCents: 500},
+	}, cart.Lines)
+	want := lib.CalculateTotals([]lib.Item{{Qty: 2, UnitPriceCents: 1999}, {Qty: 1, UnitPriceCents: 500}}, 5, 10)
+	assert.Equal(t, domain.NewOrderTotals(want), cart.Totals)
+
+	// Carts are per customer, and a qty of zero takes the line out.
+	other, err := f.uc.GetCart(actorCtx("bob"))
+	require.NoError(t, err)
+	assert.Empty(t, other.Lines)
+	cart, err = f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 0})
+	require.NoError(t, err)
+	assert.Len(t, cart.Lines, 1)
+}
+
+func TestOrderUsecase_SetCartLine_Rejected(t *testing.T) {
+	f := newOrderUsecase(t)
+	unpriced, err := f.books.Create(tenantCtx(), domain.CreateBookInput{Title: "Beloved", Author: "Toni Morrison"})
+	require.NoError(t, err)
+
+	_, err = f.uc.SetCartLine(tenantCtx(), f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	assert.ErrorIs(t, err, usecase.ErrForbidden, "anonymous customers cannot shop")
+	_, err = f.uc.SetCartLine(actorCtx("alice"), f.dune.ID, domain.SetCartLineInput{Qty: -1})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = f.uc.SetCartLine(actorCtx("alice"), unpriced.ID, domain.SetCartLineInput{Qty: 1})
+	assert.ErrorIs(t, err, usecase.ErrValidation, "books without a price are not for sale")
+	_, err = f.uc.SetCartLine(actorCtx("alice"), 9999, domain.SetCartLineInput{Qty: 1})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestOrderUsecase_PlaceOrder(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 3})
+	require.NoError(t, err)
+	_, err = f.uc.SetCartLine(ctx, f.emma.ID, domain.SetCartLineInput{Qty: 2})
+	require.NoError(t, err)
+
+	order, err := f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{Coupon: " summer20 "})
+	require.NoError(t, err)
+
+	// 3 x 19.99 + 2 x 5.00 = 69.97; 20% off is 13.99; 10% tax on 55.98.
+	assert.Equal(t, domain.OrderTotals{SubtotalCents: 6997, DiscountCents: 1399, TaxCents: 560, TotalCents: 6158}, order.Totals)
+	assert.Equal(t, "SUMMER20", order.Coupon)
+	assert.Equal(t, 20, order.DiscountPct)
+	assert.Equal(t, 10, order.TaxPct)
+	assert.Equal(t, domain.OrderPlaced, order.Status)
+	assert.Equal(t, f.placeAt, order.PlacedAt)
+	require.Len(t, order.Lines, 2)
+	for i, want := range []domain.OrderLine{
+		{BookID: f.dune.ID, Title: "Dune", Qty: 3, UnitPriceCents: 1999},
+		{BookID: f.emma.ID, Title: "Emma", Qty: 2, UnitPriceCents: 500},
+	} {
+		got := order.Lines[i]
+		assert.NotZero(t, got.ReservationID)
+		got.ReservationID = 0
+		assert.Equal(t, want, got)
+
+		res, err := f.stock.GetReservation(tenantCtx(), want.BookID, order.Lines[i].ReservationID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReservationSold, res.Status)
+		assert.Equal(t, "checkout:alice", res.Ref)
+	}
+
+	// The reservations are sold: the copies are gone, not set aside.
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{2, 0}, [2]int64{onHand, reserved})
+	onHand, reserved = f.onHand(t, f.emma)
+	assert.Equal(t, [2]int64{0, 0}, [2]int64{onHand, reserved})
+	cart, err := f.uc.GetCart(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Lines, "checking out empties the cart")
+}
+
+// countingBooks counts the reads of books one at a time and in batches.
+type countingBooks struct {
+	*memory.BookRepository
+	gets, batches int
+}
+
+func (c *countingBooks) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	c.gets++
+	return c.BookRepository.GetByID(ctx, id)
+}
+
+func (c *countingBooks) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Book, error) {
+	c.batches++
+	return c.BookRepository.GetByIDs(ctx, ids)
+}
+
+func TestOrderUsecase_PricesTheCartInOneRead(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	_, err = f.uc.SetCartLine(ctx, f.emma.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	books := &countingBooks{BookRepository: f.books}
+	uc := usecase.NewOrderUsecase(f.store, f.store, f.store, f.stock, nil, books, memory.NewTransactor(), usecase.OrderConfig{})
+
+	cart, err := uc.GetCart(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, cart.Lines, 2)
+	assert.Equal(t, "Emma", cart.Lines[1].Title)
+	assert.Equal(t, 0, books.gets)
+	assert.Equal(t, 1, books.batches)
+}
+
+func TestOrderUsecase_PlaceOrder_KeepsTotals(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	placed, err := f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{})
+	require.NoError(t, err)
+
+	// Repricing and renaming the book does not touch the order.
+	_, err = f.uc.SetInventory(tenantCtx(), f.dune.ID, domain.InventoryInput{PriceCents: 2999})
+	require.NoError(t, err)
+	_, err = f.books.Update(tenantCtx(), f.dune.ID, domain.UpdateBookInput{Title: "Dune (2nd ed.)", Author: "Frank Herbert"})
+	require.NoError(t, err)
+
+	got, err := f.uc.GetOrder(ctx, placed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, placed, got)
+	assert.Equal(t, lib.Money(1999), got.Lines[0].UnitPriceCents)
+	assert.Equal(t, 5, got.DiscountPct, "the standing discount applies without a coupon")
+}
+
+func TestOrderUsecase_PlaceOrder_OutOfStockTakesNothing(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	_, err = f.uc.SetCartLine(ctx, f.emma.ID, domain.SetCartLineInput{Qty: 3})
+	require.NoError(t, err)
+
+	_, err = f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{})
+
+	assert.ErrorIs(t, err, domain.ErrOutOfStock)
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 0}, [2]int64{onHand, reserved})
+	onHand, reserved = f.onHand(t, f.emma)
+	assert.Equal(t, [2]int64{2, 0}, [2]int64{onHand, reserved})
+	cart, err := f.uc.GetCart(ctx)
+	require.NoError(t, err)
+	assert.Len(t, cart.Lines, 2, "the cart is kept for another try")
+}
+
+func TestOrderUsecase_PlaceOrder_Rejected(t *testing.T) {
+	f := newOrderUsecase(t)
+	ctx := actorCtx("alice")
+
+	_, err := f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{})
+	assert.ErrorIs(t, err, usecase.ErrValidation, "empty cart")
+
+	_, err = f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	_, err = f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{Coupon: "FREEBOOKS"})
+	assert.ErrorIs(t, err, usecase.ErrValidation, "unknown coupon")
+
+	require.NoError(t, f.books.Delete(tenantCtx(), f.dune.ID))
+	_, err = f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{})
+	assert.ErrorIs(t, err, usecase.ErrValidation, "deleted books cannot be ordered")
+}
+
+func TestOrderUsecase_ConcurrentOrdersDoNotOversell(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var won, lost int
+	for _, who := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		ctx := actorCtx(who)
+		_, err := f.uc.SetCartLine(ctx, f.emma.ID, domain.SetCartLineInput{Qty: 1})
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				won++
+			} else if assert.ErrorIs(t, err, domain.ErrOutOfStock) {
+				lost++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, won)
+	assert.Equal(t, 6, lost)
+	onHand, _ := f.onHand(t, f.emma)
+	assert.Equal(t, int64(0), onHand)
+}
+
+func TestOrderUsecase_OrdersArePrivate(t *testing.T) {
+	f := newOrderUsecase(t)
+	_, err := f.uc.SetCartLine(actorCtx("alice"), f.dune.ID, domain.SetCartLineInput{Qty: 1})
+	require.NoError(t, err)
+	order, err := f.uc.PlaceOrder(actorCtx("alice"), domain.PlaceOrderInput{})
+	require.NoError(t, err)
+
+	_, err = f.uc.GetOrder(actorCtx("bob"), order.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mine, err := f.uc.ListOrders(actorCtx("alice"), 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, mine, 1)
+	theirs, err := f.uc.ListOrders(actorCtx("bob"), 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, theirs)
+}
@@ -0,0 +1,469 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// DefaultReconcileAfter applies when PaymentConfig.ReconcileAfter is zero.
+const DefaultReconcileAfter = 5 * time.Minute
+
+// DefaultPendingTimeout applies when PaymentConfig.PendingTimeout is zero.
+const DefaultPendingTimeout = 30 * time.Minute
+
+// reconcileBatch is how many payments Reconcile looks up per round.
+const reconcileBatch = 100
+
+// PaymentConfig sets how Reconcile treats payments left part way.
+// ReconcileAfter is how long a payment may sit pending or authorized
+// before it is looked up at the gateway; PendingTimeout is how long after
+// it was opened a payment the gateway still has pending is voided. Now
+// defaults to time.Now.
+type PaymentConfig struct {
+	ReconcileAfter time.Duration
+	PendingTimeout time.Duration
+	Now            func() time.Time
+}
+
+type PaymentUsecase interface {
+	Open(ctx context.Context, order *domain.Order) (*domain.Payment, error)
+	Authorize(ctx context.Context, id int64, token string) (*domain.Payment, error)
+	GetOrderPayment(ctx context.Context, orderID int64) (*domain.Payment, error)
+
+	Refund(ctx context.Context, orderID int64) (*domain.Payment, error)
+	Void(ctx context.Context, orderID int64) (*domain.Payment, error)
+
+	ApplyGatewayPayment(ctx context.Context, gp domain.GatewayPayment) (*domain.Payment, error)
+	Reconcile(ctx context.Context) (int, error)
+}
+
+type paymentUsecase struct {
+	payments domain.PaymentRepository
+	orders   domain.OrderRepository
+	stock    StockUsecase
+	gateway  domain.PaymentGateway
+	tx       domain.Transactor
+	cfg      PaymentConfig
+}
+
+// NewPaymentUsecase takes payment for orders through gateway and keeps
+// each order in step with its payment: a captured payment sells the
+// order's reservations and makes it paid, a declined or voided one
+// releases them and cancels it, and a refunded one makes it refunded.
+//
+// Transitions are idempotent: asking for the status a payment is already
+// in changes nothing, so gateway answers, webhooks and reconciliation may
+// all report the same outcome.
+func NewPaymentUsecase(payments domain.PaymentRepository, orders domain.OrderRepository, stock StockUsecase, gateway domain.PaymentGateway, tx domain.Transactor, cfg PaymentConfig) PaymentUsecase {
+	if cfg.ReconcileAfter <= 0 {
+		cfg.ReconcileAfter = DefaultReconcileAfter
+	}
+	if cfg.PendingTimeout <= 0 {
+		cfg.PendingTimeout = DefaultPendingTimeout
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &paymentUsecase{payments: payments, orders: orders, stock: stock, gateway: gateway, tx: tx, cfg: cfg}
+}
+
+// Open starts a pending payment for the order's total. It runs inside the
+// caller's transaction, so that an order is never placed without one;
+// nothing is asked of the gateway until Authorize.
+func (u *paymentUsecase) Open(ctx context.Context, order *domain.Order) (*domain.Payment, error) {
+	return u.payments.CreatePayment(ctx, domain.Payment{
+		OrderID:     order.ID,
+		Status:      domain.PaymentPending,
+		AmountCents: order.Totals.TotalCents,
+		CreatedAt:   u.cfg.Now(),
+	})
+}
+
+// Authorize charges the card behind token for a pending payment: it is
+// authorized and then captured, or declined. A request the gateway
+// refuses, as it does a bad token, charges nothing and declines the
+// payment too. When the gateway cannot be reached, does not answer or
+// already has the payment in another state, the payment is left pending
+// for a webhook or Reconcile to settle; that is not an error. A payment
+// past pending is returned as it is.
+func (u *paymentUsecase) Authorize(ctx context.Context, id int64, token string) (*domain.Payment, error) {
+	p, err := u.payments.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PaymentPending {
+		return p, nil
+	}
+	if p.AmountCents == 0 {
+		return u.move(ctx, p.ID, domain.PaymentCaptured, "", "")
+	}
+	reference, err := paymentReference(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	gp, err := u.gateway.Authorize(ctx, domain.AuthorizeRequest{Reference: reference, AmountCents: p.AmountCents, Token: token})
+	switch {
+	case errors.Is(err, domain.ErrUnavailable), errors.Is(err, domain.ErrPaymentState):
+		return p, nil
+	case err != nil:
+		return u.move(ctx, p.ID, domain.PaymentDeclined, "", err.Error())
+	}
+	return u.follow(ctx, p.ID, gp)
+}
+
+// GetOrderPayment returns the payment of one of the actor's orders.
+func (u *paymentUsecase) GetOrderPayment(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	if orderID <= 0 {
+		return nil, ErrValidation
+	}
+	customer, err := actor(ctx, "shop")
+	if err != nil {
+		return nil, err
+	}
+	o, err := u.orders.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Customer != customer {
+		return nil, domain.ErrNotFound
+	}
+	return u.payments.GetPaymentByOrder(ctx, orderID)
+}
+
+// Refund gives back the whole of a captured payment.
+func (u *paymentUsecase) Refund(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	p, err := u.orderPayment(ctx, orderID, domain.PaymentRefunded)
+	if err != nil || p.Status == domain.PaymentRefunded {
+		return p, err
+	}
+	if p.GatewayRef == "" {
+		// Nothing was charged: the order came to nothing.
+		return u.move(ctx, p.ID, domain.PaymentRefunded, "", "")
+	}
+	gp, err := u.gateway.Refund(ctx, p.GatewayRef, p.AmountCents)
+	if err != nil {
+		return nil, err
+	}
+	return u.follow(ctx, p.ID, gp)
+}
+
+// Void drops a payment that has not been captured, cancelling its order.
+// A payment the gateway never heard of is voided here alone.
+func (u *paymentUsecase) Void(ctx context.Context, orderID int64) (*domain.Payment, error) {
+	p, err := u.orderPayment(ctx, orderID, domain.PaymentVoided)
+	if err != nil || p.Status == domain.PaymentVoided {
+		return p, err
+	}
+	ref := p.GatewayRef
+	if ref == "" {
+		reference, err := paymentReference(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		gp, err := u.gateway.Lookup(ctx, reference)
+		if errors.Is(err, domain.ErrNotFound) {
+			return u.move(ctx, p.ID, domain.PaymentVoided, "", "voided")
+		}
+		if err != nil {
+			return nil, err
+		}
+		ref = gp.Ref
+	}
+	gp, err := u.gateway.Void(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return u.follow(ctx, p.ID, gp)
+}
+
+// orderPayment returns the payment of an order of the tenant, failing
+// with domain.ErrPaymentState unless it can move to next or is there.
+func (u *paymentUsecase) orderPayment(ctx context.Context, orderID int64, next domain.PaymentStatus) (*domain.Payment, error) {
+	if orderID <= 0 {
+		return nil, ErrValidation
+	}
+	p, err := u.payments.GetPaymentByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != next && !p.Status.CanMove(next) {
+		return nil, fmt.Errorf("%w: payment is %s", domain.ErrPaymentState, p.Status)
+	}
+	return p, nil
+}
+
+// ApplyGatewayPayment brings a payment in line with what the gateway
+// reports of it, as a settlement webhook does. The tenant is not taken
+// from ctx but from gp.Reference, which Authorize made.
+func (u *paymentUsecase) ApplyGatewayPayment(ctx context.Context, gp domain.GatewayPayment) (*domain.Payment, error) {
+	tenant, id, err := parsePaymentReference(gp.Reference)
+	if err != nil {
+		return nil, err
+	}
+	return u.follow(tenancy.WithTenant(ctx, tenant), id, &gp)
+}
+
+// Reconcile looks up at the gateway every payment of the tenant in ctx
+// that has sat pending or authorized for longer than ReconcileAfter, and
+// brings it and its order in line. A payment the gateway never got is
+// voided, and so is one it has had pending for longer than
+// PendingTimeout; one it authorized is captured. A payment that cannot be
+// brought in line is skipped, so that it does not hold up the rest, and
+// reported in the error. It returns how many payments changed.
+func (u *paymentUsecase) Reconcile(ctx context.Context) (int, error) {
+	n := 0
+	var failed []error
+	q := domain.ListPaymentsQuery{
+		Statuses: []domain.PaymentStatus{domain.PaymentPending, domain.PaymentAuthorized},
+		Before:   u.cfg.Now().Add(-u.cfg.ReconcileAfter),
+		Limit:    reconcileBatch,
+	}
+	for {
+		open, err := u.payments.ListPayments(ctx, q)
+		if err != nil {
+			return n, errors.Join(append(failed, err)...)
+		}
+		for _, p := range open {
+			q.AfterID = p.ID
+			after, err := u.reconcile(ctx, p)
+			if ctx.Err() != nil {
+				return n, errors.Join(append(failed, ctx.Err())...)
+			}
+			if err != nil {
+				failed = append(failed, fmt.Errorf("payment %d: %w", p.ID, err))
+				continue
+			}
+			if after.Status != p.Status {
+				n++
+			}
+		}
+		if len(open) < reconcileBatch {
+			return n, errors.Join(failed...)
+		}
+	}
+}
+
+func (u *paymentUsecase) reconcile(ctx context.Context, p *domain.Payment) (*domain.Payment, error) {
+	reference, err := paymentReference(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	gp, err := u.gateway.Lookup(ctx, reference)
+	if errors.Is(err, domain.ErrNotFound) {
+		return u.move(ctx, p.ID, domain.PaymentVoided, "", "not received by the gateway")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if gp.Status == domain.PaymentPending && u.cfg.Now().Sub(p.CreatedAt) > u.cfg.PendingTimeout {
+		if gp, err = u.gateway.Void(ctx, gp.Ref); err != nil {
+			return nil, err
+		}
+	}
+	return u.follow(ctx, p.ID, gp)
+}
+
+// follow moves the payment to the status the gateway has it in. One the
+// gateway has authorized is captured straight away; if the capture gets
+// no answer it stays authorized for Reconcile to try again. A capture
+// whose order can no longer be filled is refunded.
+func (u *paymentUsecase) follow(ctx context.Context, id int64, gp *domain.GatewayPayment) (*domain.Payment, error) {
+	p, err := u.move(ctx, id, gp.Status, gp.Ref, gp.Reason)
+	if errors.Is(err, domain.ErrOutOfStock) {
+		return u.refundUnfilled(ctx, id, gp.Ref)
+	}
+	if err != nil || p.Status != domain.PaymentAuthorized {
+		return p, err
+	}
+
+	captured, err := u.gateway.Capture(ctx, p.GatewayRef, p.AmountCents)
+	if errors.Is(err, domain.ErrUnavailable) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	paid, err := u.move(ctx, id, captured.Status, captured.Ref, captured.Reason)
+	if errors.Is(err, domain.ErrOutOfStock) {
+		return u.refundUnfilled(ctx, id, captured.Ref)
+	}
+	return paid, err
+}
+
+// refundUnfilled gives back a payment the gateway captured for an order
+// whose reservations ran out first and whose copies have been sold since.
+// Until the refund goes through the payment stays as it was, so that
+// Reconcile finds the capture again and retries.
+func (u *paymentUsecase) refundUnfilled(ctx context.Context, id int64, ref string) (*domain.Payment, error) {
+	p, err := u.payments.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := u.gateway.Refund(ctx, ref, p.AmountCents)
+	if err != nil {
+		return nil, err
+	}
+	return u.move(ctx, id, refunded.Status, refunded.Ref, "out of stock")
+}
+
+// move is the payment state machine. It takes the payment to status,
+// together with what that does to its order, in one transaction; a
+// payment already in status is returned as it is, but for a gateway ref
+// it did not have yet.
+func (u *paymentUsecase) move(ctx context.Context, id int64, status domain.PaymentStatus, ref, reason string) (*domain.Payment, error) {
+	var p *domain.Payment
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		if p, err = u.payments.LockPayment(ctx, id); err != nil {
+			return err
+		}
+		if p.Status == status {
+			if ref == "" || p.GatewayRef != "" {
+				return nil
+			}
+			p.GatewayRef = ref
+			p, err = u.payments.UpdatePayment(ctx, *p)
+			return err
+		}
+		if !p.Status.CanMove(status) {
+			return fmt.Errorf("%w: payment is %s, not %s", domain.ErrPaymentState, p.Status, status)
+		}
+
+		p.Status = status
+		if ref != "" {
+			p.GatewayRef = ref
+		}
+		p.Reason = reason
+		p.UpdatedAt = u.cfg.Now()
+		if p, err = u.payments.UpdatePayment(ctx, *p); err != nil {
+			return err
+		}
+		return u.settleOrder(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// settleOrder makes the payment's order follow it.
+func (u *paymentUsecase) settleOrder(ctx context.Context, p *domain.Payment) error {
+	var status domain.OrderStatus
+	switch p.Status {
+	case domain.PaymentCaptured:
+		status = domain.OrderPaid
+	case domain.PaymentDeclined, domain.PaymentVoided:
+		status = domain.OrderCancelled
+	case domain.PaymentRefunded:
+		status = domain.OrderRefunded
+	default:
+		return nil
+	}
+
+	o, err := u.orders.GetOrder(ctx, p.OrderID)
+	if err != nil {
+		return err
+	}
+	if status == domain.OrderPaid {
+		err = u.sellLines(ctx, o)
+	} else {
+		err = u.releaseLines(ctx, o)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = u.orders.SetOrderStatus(ctx, o.ID, status)
+	return err
+}
+
+// sellLines sells the copies reserved for the order. Reservations that ran
+// out while the payment was pending have put their copies back; those are
+// reserved again first, all or none, so that if they have gone to someone
+// else it fails with domain.ErrOutOfStock before anything is sold.
+func (u *paymentUsecase) sellLines(ctx context.Context, o *domain.Order) error {
+	ids := make([]int64, len(o.Lines))
+	var lapsed []domain.StockLine
+	var at []int
+	for i, l := range o.Lines {
+		if l.ReservationID == 0 {
+			continue
+		}
+		res, err := u.stock.GetReservation(ctx, l.BookID, l.ReservationID)
+		if err != nil {
+			return err
+		}
+		ids[i] = res.ID
+		if res.Status != domain.ReservationActive {
+			lapsed = append(lapsed, domain.StockLine{BookID: l.BookID, Qty: l.Qty})
+			at = append(at, i)
+		}
+	}
+	if len(lapsed) > 0 {
+		renewed, err := u.stock.ReserveLines(ctx, lapsed, "checkout:"+o.Customer, 0)
+		if err != nil {
+			return err
+		}
+		for j, res := range renewed {
+			ids[at[j]] = res.ID
+		}
+	}
+
+	for i, l := range o.Lines {
+		if ids[i] == 0 {
+			continue
+		}
+		if _, err := u.stock.SellReservation(ctx, l.BookID, ids[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseLines puts the copies reserved for the order back on sale. A
+// reservation that ran out while the payment was pending, or was sold
+// before a refund, is left as it is.
+func (u *paymentUsecase) releaseLines(ctx context.Context, o *domain.Order) error {
+	for _, l := range o.Lines {
+		if l.ReservationID == 0 {
+			continue
+		}
+		_, err := u.stock.Release(ctx, l.BookID, l.ReservationID)
+		if err != nil && !errors.Is(err, domain.ErrReservationClosed) {
+			return err
+		}
+	}
+	return nil
+}
+
+// paymentReference is what the gateway knows a payment by: the tenant and
+// the payment ID, so that webhooks, which come without a tenant, can be
+// put back with theirs.
+func paymentReference(ctx context.Context, id int64) (string, error) {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tenant + ":" + strconv.FormatInt(id, 10), nil
+}
+
+func parsePaymentReference(reference string) (string, int64, error) {
+	i := strings.LastIndexByte(reference, ':')
+	if i <= 0 {
+		return "", 0, fmt.Errorf("%w: invalid payment reference %q", ErrValidation, reference)
+	}
+	id, err := strconv.ParseInt(reference[i+1:], 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("%w: invalid payment reference %q", ErrValidation, reference)
+	}
+	return reference[:i], id, nil
+}
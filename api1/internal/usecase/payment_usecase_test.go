@@ -0,0 +1,313 @@
+package usecase_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/infrastructure/payment"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// checkout places alice's order for two copies of Dune, paid with token.
+func (f *orderFixture) checkout(t *testing.T, token string) (*domain.Order, *domain.Payment) {
+	t.Helper()
+	ctx := actorCtx("alice")
+	_, err := f.uc.SetCartLine(ctx, f.dune.ID, domain.SetCartLineInput{Qty: 2})
+	require.NoError(t, err)
+	order, err := f.uc.PlaceOrder(ctx, domain.PlaceOrderInput{PaymentToken: token})
+	require.NoError(t, err)
+	p, err := f.payments.GetOrderPayment(ctx, order.ID)
+	require.NoError(t, err)
+	return order, p
+}
+
+func reference(p *domain.Payment) string {
+	return fmt.Sprintf("%s:%d", p.TenantID, p.ID)
+}
+
+func TestPaymentUsecase_PaidOrder(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	order, p := f.checkout(t, "tok_visa")
+
+	assert.Equal(t, domain.OrderPaid, order.Status)
+	assert.Equal(t, domain.PaymentCaptured, p.Status)
+	assert.Equal(t, order.Totals.TotalCents, p.AmountCents)
+	assert.NotEmpty(t, p.GatewayRef)
+	assert.Equal(t, domain.PaymentCaptured, f.gateway.Status(reference(p)))
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{3, 0}, [2]int64{onHand, reserved}, "paying sells the copies")
+}
+
+func TestPaymentUsecase_Declined(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	order, p := f.checkout(t, payment.TokenDecline)
+
+	assert.Equal(t, domain.OrderCancelled, order.Status)
+	assert.Equal(t, domain.PaymentDeclined, p.Status)
+	assert.Equal(t, "card_declined", p.Reason)
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 0}, [2]int64{onHand, reserved}, "a declined order gives its copies back")
+}
+
+func TestPaymentUsecase_RefusedToken(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	order, p := f.checkout(t, payment.TokenInvalid)
+
+	assert.Equal(t, domain.OrderCancelled, order.Status, "the order is placed and then cancelled, not failed")
+	assert.Equal(t, domain.PaymentDeclined, p.Status)
+	assert.Contains(t, p.Reason, "400 Bad Request")
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 0}, [2]int64{onHand, reserved}, "a refused order gives its copies back")
+	cart, err := f.uc.GetCart(actorCtx("alice"))
+	require.NoError(t, err)
+	assert.Empty(t, cart.Lines)
+}
+
+func TestPaymentUsecase_TimeoutIsReconciled(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	order, p := f.checkout(t, payment.TokenTimeout)
+
+	// The gateway authorized the card, but the answer never came.
+	assert.Equal(t, domain.OrderAwaitingPayment, order.Status)
+	assert.Equal(t, domain.PaymentPending, p.Status)
+	assert.Equal(t, domain.PaymentAuthorized, f.gateway.Status(reference(p)))
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 2}, [2]int64{onHand, reserved}, "the copies wait for the payment")
+
+	n, err := f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Zero(t, n, "payments are given time to settle")
+
+	f.placeAt = f.placeAt.Add(10 * time.Minute)
+	n, err = f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := f.uc.GetOrder(actorCtx("alice"), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderPaid, got.Status)
+	p, err = f.payments.GetOrderPayment(actorCtx("alice"), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentCaptured, p.Status)
+	assert.Equal(t, domain.PaymentCaptured, f.gateway.Status(reference(p)))
+	onHand, reserved = f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{3, 0}, [2]int64{onHand, reserved})
+
+	n, err = f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Zero(t, n, "nothing is left to fix")
+}
+
+func TestPaymentUsecase_AsyncSettlement(t *testing.T) {
+	f := newOrderUsecase(t)
+
+	order, p := f.checkout(t, payment.TokenAsync)
+	assert.Equal(t, domain.OrderAwaitingPayment, order.Status)
+	assert.Equal(t, domain.PaymentPending, p.Status)
+	assert.NotEmpty(t, p.GatewayRef, "the gateway ref is kept while pending")
+
+	// The settlement webhook, delivered twice as webhooks may be.
+	settled := domain.GatewayPayment{Ref: p.GatewayRef, Reference: reference(p), Status: domain.PaymentCaptured}
+	for range 2 {
+		got, err := f.payments.ApplyGatewayPayment(tenantCtx(), settled)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentCaptured, got.Status)
+	}
+
+	got, err := f.uc.GetOrder(actorCtx("alice"), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderPaid, got.Status)
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{3, 0}, [2]int64{onHand, reserved}, "the copies are sold once")
+
+	// A late decline cannot undo a capture.
+	_, err = f.payments.ApplyGatewayPayment(tenantCtx(), domain.GatewayPayment{Ref: p.GatewayRef, Reference: reference(p), Status: domain.PaymentDeclined})
+	assert.ErrorIs(t, err, domain.ErrPaymentState)
+	_, err = f.payments.ApplyGatewayPayment(tenantCtx(), domain.GatewayPayment{Reference: "no-tenant", Status: domain.PaymentCaptured})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
+
+func TestPaymentUsecase_CaptureAfterTheReservationRanOut(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, _ := f.checkout(t, payment.TokenTimeout)
+
+	// The payment hold passes before the gateway is heard from again.
+	f.placeAt = f.placeAt.Add(2 * time.Hour)
+	_, err := f.stock.ExpireReservations(tenantCtx())
+	require.NoError(t, err)
+	onHand, reserved := f.onHand(t, f.dune)
+	require.Equal(t, [2]int64{5, 0}, [2]int64{onHand, reserved})
+
+	n, err := f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := f.store.GetOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderPaid, got.Status, "the copies were still there to be sold")
+	onHand, reserved = f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{3, 0}, [2]int64{onHand, reserved})
+}
+
+func TestPaymentUsecase_CaptureOfAnOrderSoldOutIsRefunded(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, p := f.checkout(t, payment.TokenTimeout)
+	f.placeAt = f.placeAt.Add(2 * time.Hour)
+	_, err := f.stock.ExpireReservations(tenantCtx())
+	require.NoError(t, err)
+	// Meanwhile someone else takes every copy.
+	_, err = f.stock.Reserve(tenantCtx(), f.dune.ID, domain.ReserveStockInput{Qty: 5})
+	require.NoError(t, err)
+
+	n, err := f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	p, err = f.paymentStore.GetPaymentByOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRefunded, p.Status)
+	assert.Equal(t, "out of stock", p.Reason)
+	assert.Equal(t, domain.PaymentRefunded, f.gateway.Status(reference(p)))
+	got, err := f.store.GetOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderRefunded, got.Status)
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 5}, [2]int64{onHand, reserved}, "the other reservation keeps its copies")
+}
+
+func TestPaymentUsecase_ReconcileSkipsPaymentsItCannotFix(t *testing.T) {
+	f := newOrderUsecase(t)
+	// A payment whose order is gone cannot be settled...
+	broken, err := f.payments.Open(tenantCtx(), &domain.Order{ID: 999, Totals: domain.OrderTotals{TotalCents: 100}})
+	require.NoError(t, err)
+	// ...but must not keep this one from being.
+	order, _ := f.checkout(t, payment.TokenTimeout)
+
+	f.placeAt = f.placeAt.Add(10 * time.Minute)
+	n, err := f.payments.Reconcile(tenantCtx())
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorContains(t, err, fmt.Sprintf("payment %d", broken.ID))
+	assert.Equal(t, 1, n)
+	got, err := f.store.GetOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderPaid, got.Status)
+}
+
+func TestPaymentUsecase_PendingTooLongIsVoided(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, p := f.checkout(t, payment.TokenAsync)
+
+	f.placeAt = f.placeAt.Add(10 * time.Minute)
+	n, err := f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Zero(t, n, "still pending at the gateway")
+
+	f.placeAt = f.placeAt.Add(time.Hour)
+	n, err = f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := f.uc.GetOrder(actorCtx("alice"), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderCancelled, got.Status)
+	assert.Equal(t, domain.PaymentVoided, f.gateway.Status(reference(p)))
+	onHand, reserved := f.onHand(t, f.dune)
+	assert.Equal(t, [2]int64{5, 0}, [2]int64{onHand, reserved})
+}
+
+func TestPaymentUsecase_ReconcileVoidsPaymentsTheGatewayNeverGot(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, err := f.store.CreateOrder(tenantCtx(), domain.Order{Customer: "alice", Status: domain.OrderAwaitingPayment, PlacedAt: f.placeAt})
+	require.NoError(t, err)
+	_, err = f.payments.Open(tenantCtx(), order)
+	require.NoError(t, err)
+
+	f.placeAt = f.placeAt.Add(10 * time.Minute)
+	n, err := f.payments.Reconcile(tenantCtx())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	p, err := f.paymentStore.GetPaymentByOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentVoided, p.Status)
+	got, err := f.store.GetOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderCancelled, got.Status)
+}
+
+func TestPaymentUsecase_RefundAndVoid(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, p := f.checkout(t, "tok_visa")
+
+	_, err := f.payments.Void(tenantCtx(), order.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentState, "captured payments are refunded, not voided")
+
+	for range 2 {
+		p, err = f.payments.Refund(tenantCtx(), order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefunded, p.Status)
+	}
+	assert.Equal(t, domain.PaymentRefunded, f.gateway.Status(reference(p)))
+	got, err := f.store.GetOrder(tenantCtx(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderRefunded, got.Status)
+
+	async, _ := f.checkout(t, payment.TokenAsync)
+	p, err = f.payments.Void(tenantCtx(), async.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentVoided, p.Status)
+	got, err = f.store.GetOrder(tenantCtx(), async.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderCancelled, got.Status)
+	_, err = f.payments.Refund(tenantCtx(), async.ID)
+	assert.ErrorIs(t, err, domain.ErrPaymentState)
+}
+
+func TestPaymentUsecase_PaymentsArePrivate(t *testing.T) {
+	f := newOrderUsecase(t)
+	order, _ := f.checkout(t, "tok_visa")
+
+	_, err := f.payments.GetOrderPayment(actorCtx("bob"), order.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestOrderUsecase_PlaceOrder_WithoutPayments(t *testing.T) {
+	books := memory.NewBookRepository()
+	store := memory.NewOrderRepository()
+	stock := usecase.NewStockUsecase(memory.NewStockRepository(), books, nil, nil, usecase.StockConfig{})
+	uc := usecase.NewOrderUsecase(store, store, store, stock, nil, books, nil, usecase.OrderConfig{})
+
+	_, err := uc.PlaceOrder(actorCtx("alice"), domain.PlaceOrderInput{PaymentToken: "tok_visa"})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
+
+func TestPaymentStatus_CanMove(t *testing.T) {
+	tests := []struct {
+		from, to domain.PaymentStatus
+		want     bool
+	}{
+		{domain.PaymentPending, domain.PaymentAuthorized, true},
+		{domain.PaymentPending, domain.PaymentCaptured, true},
+		{domain.PaymentAuthorized, domain.PaymentCaptured, true},
+		{domain.PaymentAuthorized, domain.PaymentDeclined, false},
+		{domain.PaymentAuthorized, domain.PaymentRefunded, true},
+		{domain.PaymentCaptured, domain.PaymentRefunded, true},
+		{domain.PaymentCaptured, domain.PaymentVoided, false},
+		{domain.PaymentDeclined, domain.PaymentCaptured, false},
+		{domain.PaymentRefunded, domain.PaymentCaptured, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.from.CanMove(tt.to), "%s to %s", tt.from, tt.to)
+	}
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/requestmeta"
+)
+
+const maxReviewTextLen = 5000
+
+// ReviewCheck vets a review before it is stored. A non-nil error rejects
+// the review with ErrValidation and its message.
+type ReviewCheck func(in domain.ReviewInput) error
+
+// DenyWords is a ReviewCheck that rejects reviews containing any of words,
+// compared case-insensitively as whole words.
+func DenyWords(words ...string) ReviewCheck {
+	deny := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			deny[w] = true
+		}
+	}
+	return func(in domain.ReviewInput) error {
+		for _, w := range strings.FieldsFunc(strings.ToLower(in.Text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if deny[w] {
+				return errors.New("review text contains a blocked word")
+			}
+		}
+		return nil
+	}
+}
+
+type ReviewUsecase interface {
+	CreateReview(ctx context.Context, bookID int64, in domain.ReviewInput) (*domain.Review, error)
+	GetReview(ctx context.Context, bookID, id int64) (*domain.Review, error)
+	ListReviews(ctx context.Context, q domain.ListReviewsQuery) ([]*domain.Review, error)
+	UpdateReview(ctx context.Context, bookID, id int64, in domain.ReviewInput) (*domain.Review, error)
+	DeleteReview(ctx context.Context, bookID, id int64) error
+	MarkHelpful(ctx context.Context, bookID, id int64) (*domain.Review, error)
+}
+
+type reviewUsecase struct {
+	reviews domain.ReviewRepository
+	books   domain.BookRepository
+	tx      domain.Transactor
+	checks  []ReviewCheck
+}
+
+// NewReviewUsecase keeps the rating totals of books in step with their
+// reviews; tx should be given so both are written together. The reviewer
+// is the actor of the request, and anonymous requests may only read.
+func NewReviewUsecase(reviews domain.ReviewRepository, books domain.BookRepository, tx domain.Transactor, checks ...ReviewCheck) ReviewUsecase {
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &reviewUsecase{reviews: reviews, books: books, tx: tx, checks: checks}
+}
+
+func (u *reviewUsecase) CreateReview(ctx context.Context, bookID int64, in domain.ReviewInput) (*domain.Review, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if err := u.validate(in); err != nil {
+		return nil, err
+	}
+	reviewer, err := actor(ctx, "write reviews")
+	if err != nil {
+		return nil, err
+	}
+
+	var review *domain.Review
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := u.books.GetByID(ctx, bookID); err != nil {
+			return err
+		}
+		r, err := u.reviews.Create(ctx, bookID, reviewer, in)
+		if err != nil {
+			return err
+		}
+		review = r
+		_, err = u.books.AdjustRating(ctx, bookID, r.Rating, 1)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (u *reviewUsecase) GetReview(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	return u.reviews.Get(ctx, bookID, id)
+}
+
+func (u *reviewUsecase) ListReviews(ctx context.Context, q domain.ListReviewsQuery) ([]*domain.Review, error) {
+	if q.BookID <= 0 || q.Limit < 0 || q.Limit > maxListLimit || q.Offset < 0 {
+		return nil, ErrValidation
+	}
+	switch q.Sort {
+	case "":
+		q.Sort = domain.ReviewSortRecent
+	case domain.ReviewSortRecent, domain.ReviewSortHelpful:
+	default:
+		return nil, ErrValidation
+	}
+	// An unknown book is a 404, not an empty page.
+	if _, err := u.books.GetByID(ctx, q.BookID); err != nil {
+		return nil, err
+	}
+	return u.reviews.List(ctx, q)
+}
+
+// UpdateReview lets reviewers change their own review; the book's totals
+// move by the difference in rating.
+func (u *reviewUsecase) UpdateReview(ctx context.Context, bookID, id int64, in domain.ReviewInput) (*domain.Review, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	if err := u.validate(in); err != nil {
+		return nil, err
+	}
+
+	var review *domain.Review
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		old, err := u.owned(ctx, bookID, id)
+		if err != nil {
+			return err
+		}
+		r, err := u.reviews.Update(ctx, bookID, id, in)
+		if err != nil {
+			return err
+		}
+		review = r
+		if r.Rating == old.Rating {
+			return nil
+		}
+		_, err = u.books.AdjustRating(ctx, bookID, r.Rating-old.Rating, 0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (u *reviewUsecase) DeleteReview(ctx context.Context, bookID, id int64) error {
+	if bookID <= 0 || id <= 0 {
+		return ErrValidation
+	}
+
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		old, err := u.owned(ctx, bookID, id)
+		if err != nil {
+			return err
+		}
+		if err := u.reviews.Delete(ctx, bookID, id); err != nil {
+			return err
+		}
+		_, err = u.books.AdjustRating(ctx, bookID, -old.Rating, -1)
+		return err
+	})
+}
+
+// MarkHelpful records the actor's helpful vote. Voting again is a no-op,
+// and reviewers cannot vote for their own review.
+func (u *reviewUsecase) MarkHelpful(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	voter, err := actor(ctx, "write reviews")
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := u.reviews.Get(ctx, bookID, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Reviewer == voter {
+		return nil, fmt.Errorf("%w: cannot vote for your own review", ErrValidation)
+	}
+	return u.reviews.MarkHelpful(ctx, bookID, id, voter)
+}
+
+func (u *reviewUsecase) validate(in domain.ReviewInput) error {
+	if in.Rating < 1 || in.Rating > 5 {
+		return fmt.Errorf("%w: rating must be between 1 and 5", ErrValidation)
+	}
+	if utf8.RuneCountInString(in.Text) > maxReviewTextLen {
+		return fmt.Errorf("%w: text is longer than %d characters", ErrValidation, maxReviewTextLen)
+	}
+	for _, check := range u.checks {
+		if err := check(in); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+	return nil
+}
+
+// owned loads review id and checks that the actor wrote it.
+func (u *reviewUsecase) owned(ctx context.Context, bookID, id int64) (*domain.Review, error) {
+	who, err := actor(ctx, "write reviews")
+	if err != nil {
+		return nil, err
+	}
+	r, err := u.reviews.Get(ctx, bookID, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Reviewer != who {
+		return nil, fmt.Errorf("%w: not your review", ErrForbidden)
+	}
+	return r, nil
+}
+
+// actor returns who is making the request, for what needs a name: reviews
+// cannot be written anonymously, nor orders placed. what completes the
+// error message.
+func actor(ctx context.Context, what string) (string, error) {
+	who := requestmeta.FromContext(ctx).Actor
+	if who == requestmeta.AnonymousActor {
+		return "", fmt.Errorf("%w: sign in to %s", ErrForbidden, what)
+	}
+	return who, nil
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/requestmeta"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+// actorCtx is tenantCtx acting as who.
+func actorCtx(who string) context.Context {
+	return requestmeta.WithMeta(tenantCtx(), requestmeta.Meta{Actor: who})
+}
+
+func newReviewUsecase(t *testing.T, checks ...usecase.ReviewCheck) (usecase.ReviewUsecase, *memory.BookRepository, *memory.ReviewRepository, *domain.Book) {
+	t.Helper()
+	books := memory.NewBookRepository()
+	reviews := memory.NewReviewRepository()
+	book, err := books.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	return usecase.NewReviewUsecase(reviews, books, memory.NewTransactor(), checks...), books, reviews, book
+}
+
+func TestReviewUsecase_RatingTotalsFollowReviews(t *testing.T) {
+	// Arrange
+	uc, books, _, book := newReviewUsecase(t)
+	rating := func() (float64, int) {
+		b, err := books.GetByID(tenantCtx(), book.ID)
+		require.NoError(t, err)
+		return b.RatingAvg, b.RatingCount
+	}
+
+	// Act & Assert
+	alice, err := uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 5, Text: "Loved it"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.Reviewer)
+	_, err = uc.CreateReview(actorCtx("bob"), book.ID, domain.ReviewInput{Rating: 2})
+	require.NoError(t, err)
+	avg, n := rating()
+	assert.Equal(t, 3.5, avg)
+	assert.Equal(t, 2, n)
+
+	_, err = uc.UpdateReview(actorCtx("alice"), book.ID, alice.ID, domain.ReviewInput{Rating: 3, Text: "Fine"})
+	require.NoError(t, err)
+	avg, n = rating()
+	assert.Equal(t, 2.5, avg)
+	assert.Equal(t, 2, n)
+
+	require.NoError(t, uc.DeleteReview(actorCtx("alice"), book.ID, alice.ID))
+	avg, n = rating()
+	assert.Equal(t, 2.0, avg)
+	assert.Equal(t, 1, n)
+}
+
+func TestReviewUsecase_OneReviewPerReviewer(t *testing.T) {
+	uc, books, _, book := newReviewUsecase(t)
+	_, err := uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 5})
+	require.NoError(t, err)
+
+	_, err = uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 1})
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	b, _ := books.GetByID(tenantCtx(), book.ID)
+	assert.Equal(t, 1, b.RatingCount)
+}
+
+func TestReviewUsecase_OnlyTheReviewerMayChangeIt(t *testing.T) {
+	uc, _, _, book := newReviewUsecase(t)
+	r, err := uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 4})
+	require.NoError(t, err)
+
+	_, err = uc.UpdateReview(actorCtx("bob"), book.ID, r.ID, domain.ReviewInput{Rating: 1})
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	assert.ErrorIs(t, uc.DeleteReview(actorCtx("bob"), book.ID, r.ID), usecase.ErrForbidden)
+
+	_, err = uc.CreateReview(tenantCtx(), book.ID, domain.ReviewInput{Rating: 4})
+	assert.ErrorIs(t, err, usecase.ErrForbidden, "anonymous requests cannot review")
+}
+
+func TestReviewUsecase_Validation(t *testing.T) {
+	uc, _, _, book := newReviewUsecase(t, usecase.DenyWords("Darn"))
+
+	tests := []struct {
+		name string
+		in   domain.ReviewInput
+	}{
+		{"rating too low", domain.ReviewInput{Rating: 0}},
+		{"rating too high", domain.ReviewInput{Rating: 6}},
+		{"text too long", domain.ReviewInput{Rating: 3, Text: strings.Repeat("a", 5001)}},
+		{"blocked word", domain.ReviewInput{Rating: 3, Text: "What a darn shame."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.CreateReview(actorCtx("alice"), book.ID, tt.in)
+			assert.ErrorIs(t, err, usecase.ErrValidation)
+		})
+	}
+
+	_, err := uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 3, Text: "Darnedest thing"})
+	assert.NoError(t, err, "only whole words are blocked")
+}
+
+func TestReviewUsecase_UnknownBook(t *testing.T) {
+	uc, _, _, _ := newReviewUsecase(t)
+
+	_, err := uc.CreateReview(actorCtx("alice"), 42, domain.ReviewInput{Rating: 3})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = uc.ListReviews(tenantCtx(), domain.ListReviewsQuery{BookID: 42})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestReviewUsecase_ListSortedByHelpfulness(t *testing.T) {
+	uc, _, reviews, book := newReviewUsecase(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	reviews.SetClock(steppingClock(start))
+
+	older, err := uc.CreateReview(actorCtx("alice"), book.ID, domain.ReviewInput{Rating: 4})
+	require.NoError(t, err)
+	newer, err := uc.CreateReview(actorCtx("bob"), book.ID, domain.ReviewInput{Rating: 2})
+	require.NoError(t, err)
+
+	_, err = uc.MarkHelpful(actorCtx("carol"), book.ID, older.ID)
+	require.NoError(t, err)
+	voted, err := uc.MarkHelpful(actorCtx("carol"), book.ID, older.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, voted.HelpfulCount, "a second vote by the same voter does not count")
+
+	_, err = uc.MarkHelpful(actorCtx("alice"), book.ID, older.ID)
+	assert.ErrorIs(t, err, usecase.ErrValidation, "no voting for your own review")
+
+	recent, err := uc.ListReviews(tenantCtx(), domain.ListReviewsQuery{BookID: book.ID})
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, newer.ID, recent[0].ID)
+
+	helpful, err := uc.ListReviews(tenantCtx(), domain.ListReviewsQuery{BookID: book.ID, Sort: domain.ReviewSortHelpful})
+	require.NoError(t, err)
+	require.Len(t, helpful, 2)
+	assert.Equal(t, older.ID, helpful[0].ID)
+
+	_, err = uc.ListReviews(tenantCtx(), domain.ListReviewsQuery{BookID: book.ID, Sort: "stars"})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+}
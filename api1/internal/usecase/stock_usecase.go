@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// DefaultReservationTTL applies when StockConfig.ReservationTTL is zero.
+const DefaultReservationTTL = 15 * time.Minute
+
+// maxReservationTTL caps how long a reservation may ask to be held.
+const maxReservationTTL = 7 * 24 * time.Hour
+
+// expireBatch is how many expired reservations ExpireReservations lets go
+// of per round.
+const expireBatch = 100
+
+// StockConfig sets how long reservations are held when the caller does
+// not say. Now is the clock movements and expiry are judged by; it
+// defaults to time.Now.
+type StockConfig struct {
+	ReservationTTL time.Duration
+	Now            func() time.Time
+}
+
+type StockUsecase interface {
+	GetStock(ctx context.Context, bookID int64) (*domain.StockLevel, error)
+	ListMovements(ctx context.Context, bookID int64, limit, offset int) ([]*domain.StockMovement, error)
+	ReceiveStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error)
+	AdjustStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error)
+	SetLowStockThreshold(ctx context.Context, bookID int64, in domain.LowStockInput) (*domain.StockLevel, error)
+
+	Reserve(ctx context.Context, bookID int64, in domain.ReserveStockInput) (*domain.StockReservation, error)
+	ReserveLines(ctx context.Context, lines []domain.StockLine, ref string, ttl time.Duration) ([]*domain.StockReservation, error)
+	GetReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error)
+	Release(ctx context.Context, bookID, id int64) (*domain.StockReservation, error)
+	SellReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error)
+	ExpireReservations(ctx context.Context) (int, error)
+}
+
+type stockUsecase struct {
+	stock  domain.StockRepository
+	books  domain.BookRepository
+	tx     domain.Transactor
+	outbox domain.OutboxRepository
+	cfg    StockConfig
+}
+
+// NewStockUsecase keeps the stock ledgers of books. outbox, when given,
+// receives a domain.BookLowStock each time a book's available stock falls
+// to its threshold, in the same transaction as the movement that did it.
+func NewStockUsecase(stock domain.StockRepository, books domain.BookRepository, tx domain.Transactor, outbox domain.OutboxRepository, cfg StockConfig) StockUsecase {
+	if cfg.ReservationTTL <= 0 {
+		cfg.ReservationTTL = DefaultReservationTTL
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if tx == nil {
+		tx = noTx{}
+	}
+	return &stockUsecase{stock: stock, books: books, tx: tx, outbox: outbox, cfg: cfg}
+}
+
+func (u *stockUsecase) GetStock(ctx context.Context, bookID int64) (*domain.StockLevel, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return u.stock.GetStockLevel(ctx, bookID)
+}
+
+// ListMovements lists a book's ledger, newest first.
+func (u *stockUsecase) ListMovements(ctx context.Context, bookID int64, limit, offset int) ([]*domain.StockMovement, error) {
+	if bookID <= 0 || limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return u.stock.ListStockMovements(ctx, domain.ListStockMovementsQuery{BookID: bookID, Limit: limit, Offset: offset})
+}
+
+// ReceiveStock books copies in.
+func (u *stockUsecase) ReceiveStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error) {
+	if in.Qty <= 0 {
+		return nil, fmt.Errorf("%w: qty must be positive", ErrValidation)
+	}
+	return u.record(ctx, bookID, domain.StockReceived, in)
+}
+
+// AdjustStock corrects the stock on hand by in.Qty either way, as after a
+// count. It cannot take away copies that are reserved.
+func (u *stockUsecase) AdjustStock(ctx context.Context, bookID int64, in domain.StockInput) (*domain.StockLevel, error) {
+	if in.Qty == 0 {
+		return nil, fmt.Errorf("%w: qty cannot be zero", ErrValidation)
+	}
+	if strings.TrimSpace(in.Note) == "" {
+		return nil, fmt.Errorf("%w: adjustments need a note", ErrValidation)
+	}
+	return u.record(ctx, bookID, domain.StockAdjusted, in)
+}
+
+func (u *stockUsecase) record(ctx context.Context, bookID int64, kind domain.StockMovementKind, in domain.StockInput) (*domain.StockLevel, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	var l *domain.StockLevel
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		l, err = u.stock.RecordStock(ctx, domain.StockMovement{
+			BookID: bookID,
+			Kind:   kind,
+			Qty:    in.Qty,
+			Note:   strings.TrimSpace(in.Note),
+			At:     u.cfg.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return u.warnLow(ctx, l, -in.Qty)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetLowStockThreshold sets the available stock at or below which the
+// book counts as low; zero turns the warning off. A threshold that makes
+// the book low at once warns at once.
+func (u *stockUsecase) SetLowStockThreshold(ctx context.Context, bookID int64, in domain.LowStockInput) (*domain.StockLevel, error) {
+	if bookID <= 0 {
+		return nil, ErrValidation
+	}
+	if in.Threshold < 0 {
+		return nil, fmt.Errorf("%w: threshold cannot be negative", ErrValidation)
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	var l *domain.StockLevel
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := u.stock.GetStockLevel(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if l, err = u.stock.SetLowStockThreshold(ctx, bookID, in.Threshold); err != nil {
+			return err
+		}
+		if l.Low && !before.Low {
+			return u.emit(ctx, l)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reserve sets copies of one book aside for in.TTLSeconds, or the
+// configured TTL when that is zero.
+func (u *stockUsecase) Reserve(ctx context.Context, bookID int64, in domain.ReserveStockInput) (*domain.StockReservation, error) {
+	if bookID <= 0 || in.Qty <= 0 {
+		return nil, ErrValidation
+	}
+	ttl := time.Duration(in.TTLSeconds) * time.Second
+	if ttl < 0 || ttl > maxReservationTTL {
+		return nil, fmt.Errorf("%w: ttl_seconds must be between 0 and %d", ErrValidation, int(maxReservationTTL.Seconds()))
+	}
+	if ttl == 0 {
+		ttl = u.cfg.ReservationTTL
+	}
+	if _, err := u.books.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	res, err := u.reserve(ctx, []domain.StockLine{{BookID: bookID, Qty: in.Qty}}, strings.TrimSpace(in.Ref), ttl)
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+// ReserveLines sets copies aside for every line or, failing with
+// domain.ErrOutOfStock, for none, for ttl or the configured TTL when that
+// is zero. The books are taken to exist.
+func (u *stockUsecase) ReserveLines(ctx context.Context, lines []domain.StockLine, ref string, ttl time.Duration) ([]*domain.StockReservation, error) {
+	if len(lines) == 0 || ttl < 0 {
+		return nil, ErrValidation
+	}
+	if ttl == 0 {
+		ttl = u.cfg.ReservationTTL
+	}
+	for _, l := range lines {
+		if l.BookID <= 0 || l.Qty <= 0 {
+			return nil, ErrValidation
+		}
+	}
+	return u.reserve(ctx, lines, ref, ttl)
+}
+
+func (u *stockUsecase) reserve(ctx context.Context, lines []domain.StockLine, ref string, ttl time.Duration) ([]*domain.StockReservation, error) {
+	var out []*domain.StockReservation
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		now := u.cfg.Now()
+		if out, err = u.stock.Reserve(ctx, lines, ref, now, now.Add(ttl)); err != nil {
+			return err
+		}
+
+		taken := make(map[int64]int64)
+		var ids []int64
+		for _, l := range lines {
+			if _, ok := taken[l.BookID]; !ok {
+				ids = append(ids, l.BookID)
+			}
+			taken[l.BookID] += l.Qty
+		}
+		for _, id := range ids {
+			l, err := u.stock.GetStockLevel(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := u.warnLow(ctx, l, taken[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReservation returns a reservation of the book. Reservations of other
+// books are not found.
+func (u *stockUsecase) GetReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	if bookID <= 0 || id <= 0 {
+		return nil, ErrValidation
+	}
+	res, err := u.stock.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.BookID != bookID {
+		return nil, domain.ErrNotFound
+	}
+	return res, nil
+}
+
+// Release puts reserved copies back on sale.
+func (u *stockUsecase) Release(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	return u.close(ctx, bookID, id, domain.ReservationReleased)
+}
+
+// SellReservation hands reserved copies over to their buyer: they leave
+// the stock on hand for good.
+func (u *stockUsecase) SellReservation(ctx context.Context, bookID, id int64) (*domain.StockReservation, error) {
+	return u.close(ctx, bookID, id, domain.ReservationSold)
+}
+
+func (u *stockUsecase) close(ctx context.Context, bookID, id int64, status domain.ReservationStatus) (*domain.StockReservation, error) {
+	if _, err := u.GetReservation(ctx, bookID, id); err != nil {
+		return nil, err
+	}
+	return u.stock.CloseReservation(ctx, id, status, u.cfg.Now())
+}
+
+// ExpireReservations releases the active reservations of the tenant in ctx
+// that are past their expiry and returns how many it let go. One that is
+// sold or released while the sweep runs is left as it is.
+func (u *stockUsecase) ExpireReservations(ctx context.Context) (int, error) {
+	n := 0
+	for {
+		now := u.cfg.Now()
+		expired, err := u.stock.ExpiredReservations(ctx, now, expireBatch)
+		if err != nil {
+			return n, err
+		}
+		for _, res := range expired {
+			_, err := u.stock.CloseReservation(ctx, res.ID, domain.ReservationExpired, now)
+			if errors.Is(err, domain.ErrReservationClosed) {
+				continue
+			}
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+		if len(expired) < expireBatch {
+			return n, nil
+		}
+	}
+}
+
+// warnLow raises domain.BookLowStock if l is low but was not before its
+// available stock dropped by drop.
+func (u *stockUsecase) warnLow(ctx context.Context, l *domain.StockLevel, drop int64) error {
+	if !l.Low || drop <= 0 || l.Available+drop <= l.LowStockThreshold {
+		return nil
+	}
+	return u.emit(ctx, l)
+}
+
+func (u *stockUsecase) emit(ctx context.Context, l *domain.StockLevel) error {
+	if u.outbox == nil {
+		return nil
+	}
+	return u.outbox.Append(ctx, domain.BookLowStock{BookID: l.BookID, Available: l.Available, Threshold: l.LowStockThreshold})
+}
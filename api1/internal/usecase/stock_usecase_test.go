@@ -0,0 +1,236 @@
+package usecase_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+type stockFixture struct {
+	uc     usecase.StockUsecase
+	books  *memory.BookRepository
+	outbox *memory.OutboxRepository
+	dune   *domain.Book
+	now    time.Time
+}
+
+// newStockUsecase has Dune in the catalogue with nothing in stock, and
+// holds reservations for ten minutes.
+func newStockUsecase(t *testing.T) *stockFixture {
+	t.Helper()
+	f := &stockFixture{
+		books:  memory.NewBookRepository(),
+		outbox: memory.NewOutboxRepository(),
+		now:    time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.uc = usecase.NewStockUsecase(memory.NewStockRepository(), f.books, memory.NewTransactor(), f.outbox, usecase.StockConfig{
+		ReservationTTL: 10 * time.Minute,
+		Now:            fixedClock(&f.now),
+	})
+
+	var err error
+	f.dune, err = f.books.Create(tenantCtx(), domain.CreateBookInput{Title: "Dune", Author: "Frank Herbert"})
+	require.NoError(t, err)
+	return f
+}
+
+func (f *stockFixture) level(t *testing.T) *domain.StockLevel {
+	t.Helper()
+	l, err := f.uc.GetStock(tenantCtx(), f.dune.ID)
+	require.NoError(t, err)
+	return l
+}
+
+func TestStockUsecase_LevelsFollowTheLedger(t *testing.T) {
+	f := newStockUsecase(t)
+	ctx := tenantCtx()
+
+	_, err := f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 10, Note: "delivery"})
+	require.NoError(t, err)
+	_, err = f.uc.AdjustStock(ctx, f.dune.ID, domain.StockInput{Qty: -1, Note: "damaged"})
+	require.NoError(t, err)
+	sold, err := f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 3})
+	require.NoError(t, err)
+	released, err := f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 2, Ref: "till 1"})
+	require.NoError(t, err)
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 1})
+	require.NoError(t, err)
+	_, err = f.uc.SellReservation(ctx, f.dune.ID, sold.ID)
+	require.NoError(t, err)
+	_, err = f.uc.Release(ctx, f.dune.ID, released.ID)
+	require.NoError(t, err)
+
+	// 10 - 1 - 3 sold = 6 on hand, 1 of them still reserved.
+	assert.Equal(t, &domain.StockLevel{BookID: f.dune.ID, OnHand: 6, Reserved: 1, Available: 5}, f.level(t))
+
+	movements, err := f.uc.ListMovements(ctx, f.dune.ID, 0, 0)
+	require.NoError(t, err)
+	var kinds []domain.StockMovementKind
+	for _, m := range movements {
+		kinds = append(kinds, m.Kind)
+	}
+	assert.Equal(t, []domain.StockMovementKind{
+		domain.StockReleased, domain.StockSold, domain.StockReserved, domain.StockReserved,
+		domain.StockReserved, domain.StockAdjusted, domain.StockReceived,
+	}, kinds, "newest first")
+	assert.Equal(t, released.ID, movements[0].ReservationID)
+
+	_, err = f.uc.Release(ctx, f.dune.ID, sold.ID)
+	assert.ErrorIs(t, err, domain.ErrReservationClosed)
+	_, err = f.uc.GetReservation(ctx, f.dune.ID+1, sold.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "reservations belong to their book")
+}
+
+func TestStockUsecase_Rejected(t *testing.T) {
+	f := newStockUsecase(t)
+	ctx := tenantCtx()
+	_, err := f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 3})
+	require.NoError(t, err)
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 2})
+	require.NoError(t, err)
+
+	_, err = f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 0})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = f.uc.AdjustStock(ctx, f.dune.ID, domain.StockInput{Qty: -1})
+	assert.ErrorIs(t, err, usecase.ErrValidation, "adjustments need a note")
+	_, err = f.uc.AdjustStock(ctx, f.dune.ID, domain.StockInput{Qty: -2, Note: "recount"})
+	assert.ErrorIs(t, err, domain.ErrOutOfStock, "reserved copies cannot be counted away")
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 2})
+	assert.ErrorIs(t, err, domain.ErrOutOfStock)
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 1, TTLSeconds: -1})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = f.uc.SetLowStockThreshold(ctx, f.dune.ID, domain.LowStockInput{Threshold: -1})
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	_, err = f.uc.ReceiveStock(ctx, 9999, domain.StockInput{Qty: 1})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	assert.Equal(t, int64(1), f.level(t).Available)
+}
+
+func TestStockUsecase_ConcurrentReservationsDoNotOversell(t *testing.T) {
+	f := newStockUsecase(t)
+	_, err := f.uc.ReceiveStock(tenantCtx(), f.dune.ID, domain.StockInput{Qty: 5})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var won, lost int
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.uc.Reserve(tenantCtx(), f.dune.ID, domain.ReserveStockInput{Qty: 1})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				won++
+			} else if assert.ErrorIs(t, err, domain.ErrOutOfStock) {
+				lost++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, won)
+	assert.Equal(t, 15, lost)
+	assert.Equal(t, int64(0), f.level(t).Available)
+}
+
+func TestStockUsecase_ReserveLinesIsAllOrNothing(t *testing.T) {
+	f := newStockUsecase(t)
+	ctx := tenantCtx()
+	emma, err := f.books.Create(ctx, domain.CreateBookInput{Title: "Emma", Author: "Jane Austen"})
+	require.NoError(t, err)
+	_, err = f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 5})
+	require.NoError(t, err)
+	_, err = f.uc.ReceiveStock(ctx, emma.ID, domain.StockInput{Qty: 1})
+	require.NoError(t, err)
+
+	_, err = f.uc.ReserveLines(ctx, []domain.StockLine{{BookID: f.dune.ID, Qty: 2}, {BookID: emma.ID, Qty: 2}}, "checkout:alice", 0)
+	assert.ErrorIs(t, err, domain.ErrOutOfStock)
+	assert.Equal(t, int64(0), f.level(t).Reserved)
+
+	res, err := f.uc.ReserveLines(ctx, []domain.StockLine{{BookID: f.dune.ID, Qty: 2}, {BookID: emma.ID, Qty: 1}}, "checkout:alice", 0)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, emma.ID, res[1].BookID)
+	assert.Equal(t, f.now.Add(10*time.Minute), res[0].ExpiresAt)
+}
+
+func TestStockUsecase_ExpireReservations(t *testing.T) {
+	f := newStockUsecase(t)
+	ctx := tenantCtx()
+	_, err := f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 5})
+	require.NoError(t, err)
+	short, err := f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 2, TTLSeconds: 60})
+	require.NoError(t, err)
+	long, err := f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 1})
+	require.NoError(t, err)
+	sold, err := f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 1, TTLSeconds: 60})
+	require.NoError(t, err)
+	_, err = f.uc.SellReservation(ctx, f.dune.ID, sold.ID)
+	require.NoError(t, err)
+
+	f.now = f.now.Add(5 * time.Minute)
+	n, err := f.uc.ExpireReservations(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, n, "only the short reservation has run out; the sold one is done with")
+	got, err := f.uc.GetReservation(ctx, f.dune.ID, short.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReservationExpired, got.Status)
+	assert.Equal(t, f.now, *got.ClosedAt)
+	got, err = f.uc.GetReservation(ctx, f.dune.ID, long.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReservationActive, got.Status)
+	assert.Equal(t, &domain.StockLevel{BookID: f.dune.ID, OnHand: 4, Reserved: 1, Available: 3}, f.level(t))
+
+	n, err = f.uc.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestStockUsecase_LowStockRaisedOnceWhenCrossed(t *testing.T) {
+	f := newStockUsecase(t)
+	ctx := tenantCtx()
+	_, err := f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 5})
+	require.NoError(t, err)
+	l, err := f.uc.SetLowStockThreshold(ctx, f.dune.ID, domain.LowStockInput{Threshold: 2})
+	require.NoError(t, err)
+	assert.False(t, l.Low)
+
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 2})
+	require.NoError(t, err)
+	assert.Zero(t, f.outbox.Pending(), "3 available is still above the threshold")
+
+	_, err = f.uc.Reserve(ctx, f.dune.ID, domain.ReserveStockInput{Qty: 1})
+	require.NoError(t, err)
+	_, err = f.uc.AdjustStock(ctx, f.dune.ID, domain.StockInput{Qty: -1, Note: "damaged"})
+	require.NoError(t, err)
+	assert.True(t, f.level(t).Low)
+
+	msgs, err := f.outbox.ClaimPending(ctx, time.Now().Add(time.Second), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1, "already low stock does not warn again")
+	assert.Equal(t, domain.EventBookLowStock, msgs[0].EventType)
+	assert.Equal(t, f.dune.ID, msgs[0].AggregateID)
+	var payload domain.BookLowStock
+	require.NoError(t, json.Unmarshal(msgs[0].Payload, &payload))
+	assert.Equal(t, domain.BookLowStock{BookID: f.dune.ID, Available: 2, Threshold: 2}, payload)
+
+	// Restocking clears the warning; raising the threshold over the stock
+	// warns straight away.
+	_, err = f.uc.ReceiveStock(ctx, f.dune.ID, domain.StockInput{Qty: 10})
+	require.NoError(t, err)
+	_, err = f.uc.SetLowStockThreshold(ctx, f.dune.ID, domain.LowStockInput{Threshold: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.outbox.Pending())
+}
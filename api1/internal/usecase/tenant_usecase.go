@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// tenantIDPattern keeps tenant IDs safe to put in headers, tokens and cache
+// keys.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+type TenantUsecase interface {
+	CreateTenant(ctx context.Context, in domain.CreateTenantInput) (*domain.Tenant, error)
+	ListTenants(ctx context.Context, limit, offset int) ([]*domain.Tenant, error)
+	SuspendTenant(ctx context.Context, id string) (*domain.Tenant, error)
+	ResumeTenant(ctx context.Context, id string) (*domain.Tenant, error)
+	// ActiveTenant returns the tenant if requests may act for it, and
+	// domain.ErrTenantSuspended if it exists but is suspended.
+	ActiveTenant(ctx context.Context, id string) (*domain.Tenant, error)
+}
+
+type tenantUsecase struct {
+	repo domain.TenantRepository
+}
+
+func NewTenantUsecase(repo domain.TenantRepository) TenantUsecase {
+	return &tenantUsecase{repo: repo}
+}
+
+func (u *tenantUsecase) CreateTenant(ctx context.Context, in domain.CreateTenantInput) (*domain.Tenant, error) {
+	if !tenantIDPattern.MatchString(in.ID) || strings.TrimSpace(in.Name) == "" || in.BookQuota < 0 {
+		return nil, ErrValidation
+	}
+	return u.repo.Create(ctx, in)
+}
+
+func (u *tenantUsecase) ListTenants(ctx context.Context, limit, offset int) ([]*domain.Tenant, error) {
+	if limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	return u.repo.List(ctx, limit, offset)
+}
+
+// SuspendTenant stops every request acting for the tenant. Its books are
+// kept and come back with ResumeTenant.
+func (u *tenantUsecase) SuspendTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	return u.repo.SetSuspended(ctx, id, true)
+}
+
+func (u *tenantUsecase) ResumeTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	return u.repo.SetSuspended(ctx, id, false)
+}
+
+func (u *tenantUsecase) ActiveTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	t, err := u.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Suspended() {
+		return nil, domain.ErrTenantSuspended
+	}
+	return t, nil
+}
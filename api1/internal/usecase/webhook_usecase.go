@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+const minWebhookSecretLen = 16
+
+// subscribableEvents are the event types a webhook may ask for.
+var subscribableEvents = map[domain.EventType]bool{
+	domain.EventBookCreated:  true,
+	domain.EventBookUpdated:  true,
+	domain.EventBookDeleted:  true,
+	domain.EventBookRestored: true,
+	domain.EventBookLowStock: true,
+}
+
+type WebhookUsecase interface {
+	RegisterWebhook(ctx context.Context, in domain.CreateWebhookInput) (*domain.WebhookEndpoint, error)
+	ListWebhooks(ctx context.Context) ([]*domain.WebhookEndpoint, error)
+	EnableWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, endpointID int64, limit, offset int) ([]*domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, endpointID, deliveryID int64) (*domain.WebhookDelivery, error)
+}
+
+type webhookUsecase struct {
+	repo domain.WebhookRepository
+}
+
+func NewWebhookUsecase(repo domain.WebhookRepository) WebhookUsecase {
+	return &webhookUsecase{repo: repo}
+}
+
+// RegisterWebhook refuses URLs whose host is an address, or a name for the
+// local host, that domain.WebhookAddrAllowed rules out. Other names are
+// only resolved when a delivery is sent, and the sender checks the
+// addresses they resolve to then.
+func (u *webhookUsecase) RegisterWebhook(ctx context.Context, in domain.CreateWebhookInput) (*domain.WebhookEndpoint, error) {
+	target, err := url.Parse(in.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, ErrValidation
+	}
+	if !webhookHostAllowed(target.Hostname()) {
+		return nil, fmt.Errorf("%w: webhooks may not target internal addresses", ErrValidation)
+	}
+	if len(in.Secret) < minWebhookSecretLen || len(in.EventTypes) == 0 {
+		return nil, ErrValidation
+	}
+	for _, t := range in.EventTypes {
+		if !subscribableEvents[t] {
+			return nil, ErrValidation
+		}
+	}
+
+	return u.repo.CreateEndpoint(ctx, in)
+}
+
+func (u *webhookUsecase) ListWebhooks(ctx context.Context) ([]*domain.WebhookEndpoint, error) {
+	return u.repo.ListEndpoints(ctx)
+}
+
+// EnableWebhook re-activates an endpoint, typically after it was disabled
+// for failing too often, and clears its failure count.
+func (u *webhookUsecase) EnableWebhook(ctx context.Context, id int64) error {
+	return u.repo.SetEndpointActive(ctx, id, true)
+}
+
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, endpointID int64, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	if limit < 0 || limit > maxListLimit || offset < 0 {
+		return nil, ErrValidation
+	}
+	if _, err := u.repo.GetEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListDeliveries(ctx, endpointID, limit, offset)
+}
+
+// Redeliver queues a fresh copy of an earlier delivery. The original entry
+// stays in the log untouched.
+func (u *webhookUsecase) Redeliver(ctx context.Context, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	d, err := u.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.EndpointID != endpointID {
+		return nil, domain.ErrNotFound
+	}
+
+	return u.repo.EnqueueDelivery(ctx, domain.WebhookDelivery{
+		EndpointID:   d.EndpointID,
+		EventID:      d.EventID,
+		EventType:    d.EventType,
+		Payload:      d.Payload,
+		RedeliveryOf: &d.ID,
+	})
+}
+
+// webhookHostAllowed reports whether host may be registered for webhooks.
+func webhookHostAllowed(host string) bool {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return domain.WebhookAddrAllowed(ip)
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
@@ -0,0 +1,79 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/usecase"
+)
+
+func TestWebhookUsecase_RegisterWebhook_Validation(t *testing.T) {
+	valid := domain.CreateWebhookInput{
+		URL:        "https://example.com/hooks",
+		EventTypes: []domain.EventType{domain.EventBookCreated},
+		Secret:     "0123456789abcdef",
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(in *domain.CreateWebhookInput)
+	}{
+		{"relative url", func(in *domain.CreateWebhookInput) { in.URL = "/hooks" }},
+		{"unsupported scheme", func(in *domain.CreateWebhookInput) { in.URL = "ftp://example.com" }},
+		{"short secret", func(in *domain.CreateWebhookInput) { in.Secret = "short" }},
+		{"no event types", func(in *domain.CreateWebhookInput) { in.EventTypes = nil }},
+		{"unknown event type", func(in *domain.CreateWebhookInput) { in.EventTypes = []domain.EventType{"book.burned"} }},
+		{"loopback", func(in *domain.CreateWebhookInput) { in.URL = "http://127.0.0.1:8080/hooks" }},
+		{"loopback v6", func(in *domain.CreateWebhookInput) { in.URL = "http://[::1]/hooks" }},
+		{"localhost", func(in *domain.CreateWebhookInput) { in.URL = "http://LocalHost./hooks" }},
+		{"private range", func(in *domain.CreateWebhookInput) { in.URL = "https://10.1.2.3/hooks" }},
+		{"link-local", func(in *domain.CreateWebhookInput) { in.URL = "http://169.254.169.254/latest/meta-data" }},
+		{"mapped private", func(in *domain.CreateWebhookInput) { in.URL = "http://[::ffff:192.168.0.1]/hooks" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := usecase.NewWebhookUsecase(memory.NewWebhookRepository())
+			in := valid
+			tt.mutate(&in)
+
+			_, err := uc.RegisterWebhook(tenantCtx(), in)
+			assert.ErrorIs(t, err, usecase.ErrValidation)
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		uc := usecase.NewWebhookUsecase(memory.NewWebhookRepository())
+		got, err := uc.RegisterWebhook(tenantCtx(), valid)
+		require.NoError(t, err)
+		assert.True(t, got.Active)
+	})
+}
+
+func TestWebhookUsecase_Redeliver(t *testing.T) {
+	ctx := tenantCtx()
+	repo := memory.NewWebhookRepository()
+	uc := usecase.NewWebhookUsecase(repo)
+	e, _ := uc.RegisterWebhook(ctx, domain.CreateWebhookInput{
+		URL:        "https://example.com/hooks",
+		EventTypes: []domain.EventType{domain.EventBookCreated},
+		Secret:     "0123456789abcdef",
+	})
+	first, _ := repo.EnqueueDelivery(ctx, domain.WebhookDelivery{EndpointID: e.ID, EventID: 9, EventType: domain.EventBookCreated, Payload: []byte(`{}`)})
+
+	again, err := uc.Redeliver(ctx, e.ID, first.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, again.ID)
+	require.NotNil(t, again.RedeliveryOf)
+	assert.Equal(t, first.ID, *again.RedeliveryOf)
+
+	_, err = uc.Redeliver(ctx, e.ID+1, first.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	log, _ := uc.ListDeliveries(ctx, e.ID, 0, 0)
+	assert.Len(t, log, 2)
+}
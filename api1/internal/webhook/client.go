@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// ErrAddressRefused is returned for a delivery whose endpoint resolves to
+// an address domain.WebhookAddrAllowed rules out.
+var ErrAddressRefused = errors.New("webhook endpoint resolves to an internal address")
+
+// NewClient returns the client deliveries are sent with. Its dialer checks
+// the address every connection is about to be made to, after the name was
+// resolved, so an endpoint cannot reach internal hosts by pointing its
+// name there after registering, nor by redirecting. It takes no proxy
+// from the environment, so the address checked is the receiver's.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !domain.WebhookAddrAllowed(ap.Addr()) {
+				return ErrAddressRefused
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/tenancy"
+)
+
+// Envelope is the JSON body every webhook receives.
+type Envelope struct {
+	ID         int64            `json:"id"`
+	Type       domain.EventType `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       json.RawMessage  `json:"data"`
+}
+
+// Dispatcher is an outbox publisher that turns each event into one pending
+// delivery per subscribed endpoint of the event's tenant. The Sender does the actual HTTP calls,
+// so a slow receiver never holds up the outbox.
+type Dispatcher struct {
+	repo domain.WebhookRepository
+}
+
+func NewDispatcher(repo domain.WebhookRepository) *Dispatcher {
+	return &Dispatcher{repo: repo}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	ctx = tenancy.WithTenant(ctx, msg.TenantID)
+	endpoints, err := d.repo.ListEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Envelope{
+		ID:         msg.ID,
+		Type:       msg.EventType,
+		OccurredAt: msg.OccurredAt,
+		Data:       msg.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range endpoints {
+		if !e.Active || !e.Subscribes(msg.EventType) {
+			continue
+		}
+		_, err := d.repo.EnqueueDelivery(ctx, domain.WebhookDelivery{
+			EndpointID: e.ID,
+			EventID:    msg.ID,
+			EventType:  msg.EventType,
+			Payload:    payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"unit-test-demo/api1/internal/domain"
+)
+
+// SenderConfig tunes delivery. Zero values fall back to the defaults below.
+type SenderConfig struct {
+	BatchSize int
+	Timeout   time.Duration
+	// MaxAttempts is how often one delivery is tried before it is marked failed.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// DisableAfter consecutive failures of an endpoint deactivate it.
+	DisableAfter int
+}
+
+const (
+	defaultBatchSize    = 50
+	defaultTimeout      = 10 * time.Second
+	defaultMaxAttempts  = 8
+	defaultBaseBackoff  = 5 * time.Second
+	defaultMaxBackoff   = time.Hour
+	defaultDisableAfter = 20
+)
+
+// Sender POSTs pending deliveries to their endpoints, signing each request
+// and recording the outcome in the delivery log. It works for the tenant in
+// the context it is given, like the repository.
+type Sender struct {
+	repo   domain.WebhookRepository
+	client *http.Client
+	cfg    SenderConfig
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+// NewSender sends with client, or without one with NewClient.
+func NewSender(repo domain.WebhookRepository, client *http.Client, cfg SenderConfig) *Sender {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.DisableAfter <= 0 {
+		cfg.DisableAfter = defaultDisableAfter
+	}
+	if client == nil {
+		client = NewClient()
+	}
+	return &Sender{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+		now:    time.Now,
+		jitter: equalJitter,
+	}
+}
+
+// RunOnce sends one batch of the tenant's due deliveries and reports how
+// many succeeded.
+func (s *Sender) RunOnce(ctx context.Context) (int, error) {
+	// The batch is sent one delivery after another, each of which may take
+	// its full Timeout. The lease must outlive them all, with one to spare,
+	// so no other worker picks a delivery up while it waits its turn.
+	lease := time.Duration(s.cfg.BatchSize+1) * s.cfg.Timeout
+	due, err := s.repo.ClaimDueDeliveries(ctx, s.now(), lease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, d := range due {
+		ok, err := s.deliver(ctx, d)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *Sender) deliver(ctx context.Context, d *domain.WebhookDelivery) (bool, error) {
+	e, err := s.repo.GetEndpoint(ctx, d.EndpointID)
+	if err != nil {
+		return false, err
+	}
+
+	status, sendErr := s.send(ctx, e, d)
+	now := s.now()
+	d.Attempts++
+	d.LastStatusCode = status
+
+	if sendErr == nil {
+		d.Status = domain.DeliverySucceeded
+		d.LastError = ""
+		d.DeliveredAt = &now
+		if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+			return false, err
+		}
+		return true, s.repo.ResetEndpointFailures(ctx, e.ID)
+	}
+
+	d.LastError = sendErr.Error()
+	if d.Attempts >= s.cfg.MaxAttempts {
+		d.Status = domain.DeliveryFailed
+	} else {
+		d.NextAttemptAt = now.Add(s.backoff(d.Attempts))
+	}
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		return false, err
+	}
+
+	failures, err := s.repo.IncrementEndpointFailures(ctx, e.ID)
+	if err != nil {
+		return false, err
+	}
+	if failures >= s.cfg.DisableAfter {
+		log.Printf("webhook endpoint %d disabled after %d consecutive failures", e.ID, failures)
+		return false, s.repo.SetEndpointActive(ctx, e.ID, false)
+	}
+	return false, nil
+}
+
+// send makes one signed POST and returns the response status, if any.
+func (s *Sender) send(ctx context.Context, e *domain.WebhookEndpoint, d *domain.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	ts := s.now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(e.Secret, ts, d.Payload))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderEvent, string(d.EventType))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver answered %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// backoff grows exponentially with the attempt number, capped at
+// MaxBackoff, and is then jittered so endpoints recovering from an outage
+// are not hit by every retry at once.
+func (s *Sender) backoff(attempt int) time.Duration {
+	d := s.cfg.BaseBackoff
+	for i := 1; i < attempt && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.cfg.MaxBackoff {
+		d = s.cfg.MaxBackoff
+	}
+	return s.jitter(d)
+}
+
+// equalJitter picks a duration uniformly in [d/2, d].
+func equalJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
@@ -0,0 +1,209 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"unit-test-demo/api1/internal/domain"
+	"unit-test-demo/api1/internal/infrastructure/memory"
+	"unit-test-demo/api1/internal/tenancy"
+	"unit-test-demo/api1/internal/webhook"
+)
+
+const secret = "0123456789abcdef"
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":1}`)
+	sig := webhook.Sign(secret, now, body)
+	ts := "1760961600"
+
+	assert.NoError(t, webhook.Verify(secret, sig, ts, body, 5*time.Minute, now))
+	assert.ErrorIs(t, webhook.Verify("wrong-secret-value", sig, ts, body, 5*time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify(secret, sig, ts, []byte(`{"id":2}`), 5*time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify(secret, sig, ts, body, 5*time.Minute, now.Add(time.Hour)), webhook.ErrStaleTimestamp)
+}
+
+// receiver is an httptest.Server that verifies signatures and answers with
+// the status returned by respond.
+func receiver(t *testing.T, respond func(n int32) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		err := webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, time.Minute, time.Now())
+		assert.NoError(t, err)
+
+		var env webhook.Envelope
+		assert.NoError(t, json.Unmarshal(body, &env))
+		assert.Equal(t, domain.EventBookCreated, env.Type)
+
+		w.WriteHeader(respond(n))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// enqueue registers an endpoint and dispatches one book.created event to it.
+func enqueue(t *testing.T, repo *memory.WebhookRepository, url string) *domain.WebhookEndpoint {
+	t.Helper()
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	e, err := repo.CreateEndpoint(ctx, domain.CreateWebhookInput{
+		URL:        url,
+		EventTypes: []domain.EventType{domain.EventBookCreated},
+		Secret:     secret,
+	})
+	require.NoError(t, err)
+
+	err = webhook.NewDispatcher(repo).Publish(context.Background(), domain.OutboxMessage{
+		ID:        1,
+		TenantID:  "acme",
+		EventType: domain.EventBookCreated,
+		Payload:   json.RawMessage(`{"book":{"id":1,"title":"Dune"}}`),
+	})
+	require.NoError(t, err)
+	return e
+}
+
+func TestDispatcher_SkipsUnsubscribedAndDuplicates(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, "http://example.invalid")
+	d := webhook.NewDispatcher(repo)
+
+	// Relayed twice: still one delivery.
+	require.NoError(t, d.Publish(ctx, domain.OutboxMessage{ID: 1, TenantID: "acme", EventType: domain.EventBookCreated, Payload: json.RawMessage(`{}`)}))
+	// Not subscribed.
+	require.NoError(t, d.Publish(ctx, domain.OutboxMessage{ID: 2, TenantID: "acme", EventType: domain.EventBookUpdated, Payload: json.RawMessage(`{}`)}))
+
+	got, err := repo.ListDeliveries(ctx, e.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestDispatcher_OnlyTheEventsTenant(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, "http://example.invalid")
+	globex := tenancy.WithTenant(context.Background(), "globex")
+	other, err := repo.CreateEndpoint(globex, domain.CreateWebhookInput{
+		URL:        "http://example.invalid",
+		EventTypes: []domain.EventType{domain.EventBookCreated},
+		Secret:     secret,
+	})
+	require.NoError(t, err)
+
+	// globex's book: acme's endpoint must not hear of it.
+	require.NoError(t, webhook.NewDispatcher(repo).Publish(context.Background(),
+		domain.OutboxMessage{ID: 2, TenantID: "globex", EventType: domain.EventBookCreated, Payload: json.RawMessage(`{}`)}))
+
+	got, err := repo.ListDeliveries(ctx, e.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, got, 1, "only acme's own event")
+	got, err = repo.ListDeliveries(globex, other.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "globex", got[0].TenantID)
+
+	// Neither tenant sees the other's endpoints.
+	_, err = repo.GetEndpoint(globex, e.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	n, err := webhook.NewSender(repo, nil, webhook.SenderConfig{}).RunOnce(tenancy.WithTenant(context.Background(), "initech"))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestSender_DeliversSignedPayload(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	srv, calls := receiver(t, func(int32) int { return http.StatusNoContent })
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, srv.URL)
+
+	n, err := webhook.NewSender(repo, srv.Client(), webhook.SenderConfig{}).RunOnce(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int32(1), calls.Load())
+
+	log, _ := repo.ListDeliveries(ctx, e.ID, 10, 0)
+	require.Len(t, log, 1)
+	assert.Equal(t, domain.DeliverySucceeded, log[0].Status)
+	assert.Equal(t, http.StatusNoContent, log[0].LastStatusCode)
+	assert.NotNil(t, log[0].DeliveredAt)
+}
+
+func TestSender_RefusesInternalAddresses(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	srv, calls := receiver(t, func(int32) int { return http.StatusNoContent })
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, srv.URL)
+
+	// Without a client of its own the sender dials no loopback address.
+	n, err := webhook.NewSender(repo, nil, webhook.SenderConfig{}).RunOnce(ctx)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Zero(t, calls.Load())
+	log, _ := repo.ListDeliveries(ctx, e.ID, 10, 0)
+	require.Len(t, log, 1)
+	assert.Contains(t, log[0].LastError, webhook.ErrAddressRefused.Error())
+}
+
+func TestSender_RetriesThenMarksFailed(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	srv, calls := receiver(t, func(int32) int { return http.StatusInternalServerError })
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, srv.URL)
+	sender := webhook.NewSender(repo, srv.Client(), webhook.SenderConfig{
+		MaxAttempts: 2,
+		BaseBackoff: time.Nanosecond,
+		MaxBackoff:  time.Nanosecond,
+	})
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		_, err := sender.RunOnce(ctx)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), calls.Load())
+	log, _ := repo.ListDeliveries(ctx, e.ID, 10, 0)
+	require.Len(t, log, 1)
+	assert.Equal(t, domain.DeliveryFailed, log[0].Status)
+	assert.Equal(t, 2, log[0].Attempts)
+	assert.Equal(t, "receiver answered 500", log[0].LastError)
+}
+
+func TestSender_DisablesEndpointAfterConsecutiveFailures(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	srv, _ := receiver(t, func(int32) int { return http.StatusBadGateway })
+	repo := memory.NewWebhookRepository()
+	e := enqueue(t, repo, srv.URL)
+	sender := webhook.NewSender(repo, srv.Client(), webhook.SenderConfig{
+		DisableAfter: 1,
+		BaseBackoff:  time.Nanosecond,
+		MaxBackoff:   time.Nanosecond,
+	})
+
+	_, err := sender.RunOnce(ctx)
+	require.NoError(t, err)
+
+	got, _ := repo.GetEndpoint(ctx, e.ID)
+	assert.False(t, got.Active)
+
+	// Deliveries to a disabled endpoint are no longer picked up.
+	time.Sleep(time.Millisecond)
+	n, err := sender.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
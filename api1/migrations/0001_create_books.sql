@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS books (
+    id         BIGSERIAL PRIMARY KEY,
+    title      TEXT        NOT NULL,
+    author     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    aggregate_type  TEXT        NOT NULL,
+    aggregate_id    BIGINT      NOT NULL,
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    occurred_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    dispatched_at   TIMESTAMPTZ
+);
+
+-- The relay only ever scans undispatched rows, oldest first.
+CREATE INDEX IF NOT EXISTS outbox_pending_idx
+    ON outbox (aggregate_type, aggregate_id, id)
+    WHERE dispatched_at IS NULL;
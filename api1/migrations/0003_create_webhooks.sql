@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id                   BIGSERIAL PRIMARY KEY,
+    url                  TEXT        NOT NULL,
+    event_types          TEXT[]      NOT NULL,
+    secret               TEXT        NOT NULL,
+    active               BOOLEAN     NOT NULL DEFAULT TRUE,
+    consecutive_failures INT         NOT NULL DEFAULT 0,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    endpoint_id      BIGINT      NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_id         BIGINT      NOT NULL,
+    event_type       TEXT        NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INT         NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error       TEXT,
+    redelivery_of    BIGINT REFERENCES webhook_deliveries (id),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at     TIMESTAMPTZ
+);
+
+-- An outbox message relayed twice must not produce a second first delivery.
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_uniq
+    ON webhook_deliveries (endpoint_id, event_id)
+    WHERE redelivery_of IS NULL;
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
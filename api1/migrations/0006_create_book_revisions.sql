@@ -0,0 +1,20 @@
+-- books.revision is bumped by every write so concurrent writers to the same
+-- book get distinct revision numbers under the row lock.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS book_revisions (
+    book_id     BIGINT      NOT NULL REFERENCES books (id),
+    revision    INT         NOT NULL,
+    title       TEXT        NOT NULL,
+    author      TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    deleted     BOOLEAN     NOT NULL DEFAULT false,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (book_id, revision)
+);
+
+-- Books that existed before history was kept start with their current state.
+INSERT INTO book_revisions (book_id, revision, title, author, created_at, deleted, recorded_at)
+SELECT id, revision, title, author, created_at, deleted_at IS NOT NULL, COALESCE(deleted_at, created_at)
+FROM books
+ON CONFLICT DO NOTHING;
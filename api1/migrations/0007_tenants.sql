@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id           TEXT PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    book_quota   INT         NOT NULL DEFAULT 0 CHECK (book_quota >= 0),
+    book_count   INT         NOT NULL DEFAULT 0 CHECK (book_count >= 0),
+    suspended_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Books written before tenancy belong to a default tenant.
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT DO NOTHING;
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE books ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS books_tenant_idx ON books (tenant_id, id);
+
+ALTER TABLE book_revisions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE book_revisions ALTER COLUMN tenant_id DROP DEFAULT;
+
+UPDATE tenants t
+SET book_count = (SELECT count(*) FROM books b WHERE b.tenant_id = t.id AND b.deleted_at IS NULL);
+
+-- Defense in depth: even a query that forgets its tenant filter only sees
+-- rows of the tenant the repository set with set_config('app.tenant_id').
+-- FORCE applies the policies to the table owner as well.
+ALTER TABLE books ENABLE ROW LEVEL SECURITY;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS books_tenant_isolation ON books;
+CREATE POLICY books_tenant_isolation ON books
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE book_revisions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_revisions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_revisions_tenant_isolation ON book_revisions;
+CREATE POLICY book_revisions_tenant_isolation ON book_revisions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,26 @@
+-- The current cover's ETag is mirrored on books so listings can build the
+-- cover URL without a join.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS cover_etag TEXT;
+
+CREATE TABLE IF NOT EXISTS book_covers (
+    book_id            BIGINT PRIMARY KEY REFERENCES books (id),
+    tenant_id          TEXT        NOT NULL REFERENCES tenants (id),
+    content_type       TEXT        NOT NULL,
+    width              INT         NOT NULL,
+    height             INT         NOT NULL,
+    size               BIGINT      NOT NULL,
+    etag               TEXT        NOT NULL,
+    key                TEXT        NOT NULL,
+    thumb_content_type TEXT        NOT NULL,
+    thumb_size         BIGINT      NOT NULL,
+    thumb_etag         TEXT        NOT NULL,
+    thumb_key          TEXT        NOT NULL,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE book_covers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_covers FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_covers_tenant_isolation ON book_covers;
+CREATE POLICY book_covers_tenant_isolation ON book_covers
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
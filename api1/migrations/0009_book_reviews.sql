@@ -0,0 +1,43 @@
+-- Running totals of review ratings, kept by the review writes themselves so
+-- book reads never aggregate reviews.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS rating_sum   BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS rating_count INT    NOT NULL DEFAULT 0 CHECK (rating_count >= 0);
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id            BIGSERIAL PRIMARY KEY,
+    book_id       BIGINT      NOT NULL REFERENCES books (id),
+    tenant_id     TEXT        NOT NULL REFERENCES tenants (id),
+    reviewer      TEXT        NOT NULL,
+    rating        SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text          TEXT        NOT NULL DEFAULT '',
+    helpful_count INT         NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (book_id, reviewer)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_recent_idx ON reviews (book_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS reviews_helpful_idx ON reviews (book_id, helpful_count DESC, created_at DESC, id DESC);
+
+-- One row per voter keeps helpful votes idempotent.
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id  BIGINT      NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    tenant_id  TEXT        NOT NULL REFERENCES tenants (id),
+    voter      TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (review_id, voter)
+);
+
+ALTER TABLE reviews ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reviews FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS reviews_tenant_isolation ON reviews;
+CREATE POLICY reviews_tenant_isolation ON reviews
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE review_votes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE review_votes FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS review_votes_tenant_isolation ON review_votes;
+CREATE POLICY review_votes_tenant_isolation ON review_votes
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS copies (
+    id         BIGSERIAL PRIMARY KEY,
+    book_id    BIGINT      NOT NULL REFERENCES books (id),
+    tenant_id  TEXT        NOT NULL REFERENCES tenants (id),
+    barcode    TEXT        NOT NULL,
+    status     TEXT        NOT NULL DEFAULT 'available' CHECK (status IN ('available', 'on_loan', 'lost')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, barcode)
+);
+
+CREATE INDEX IF NOT EXISTS copies_book_idx ON copies (book_id);
+
+-- status only ever holds on_loan, returned or lost; overdue is derived
+-- from due_at when loans are read.
+CREATE TABLE IF NOT EXISTS loans (
+    id             BIGSERIAL PRIMARY KEY,
+    tenant_id      TEXT        NOT NULL REFERENCES tenants (id),
+    copy_id        BIGINT      NOT NULL REFERENCES copies (id),
+    book_id        BIGINT      NOT NULL REFERENCES books (id),
+    member_id      TEXT        NOT NULL,
+    member_type    TEXT        NOT NULL,
+    status         TEXT        NOT NULL DEFAULT 'on_loan' CHECK (status IN ('on_loan', 'returned', 'lost')),
+    renewals       INT         NOT NULL DEFAULT 0,
+    checked_out_at TIMESTAMPTZ NOT NULL,
+    due_at         TIMESTAMPTZ NOT NULL,
+    closed_at      TIMESTAMPTZ
+);
+
+-- Backstop for the row lock taken at checkout: a copy has at most one
+-- open loan.
+CREATE UNIQUE INDEX IF NOT EXISTS loans_one_open_per_copy ON loans (copy_id) WHERE status = 'on_loan';
+CREATE INDEX IF NOT EXISTS loans_member_idx ON loans (tenant_id, member_id, id);
+CREATE INDEX IF NOT EXISTS loans_due_idx ON loans (tenant_id, due_at) WHERE status = 'on_loan';
+
+ALTER TABLE copies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE copies FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS copies_tenant_isolation ON copies;
+CREATE POLICY copies_tenant_isolation ON copies
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE loans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE loans FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS loans_tenant_isolation ON loans;
+CREATE POLICY loans_tenant_isolation ON loans
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
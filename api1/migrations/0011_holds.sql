@@ -0,0 +1,33 @@
+ALTER TABLE copies DROP CONSTRAINT IF EXISTS copies_status_check;
+ALTER TABLE copies ADD CONSTRAINT copies_status_check
+    CHECK (status IN ('available', 'on_loan', 'on_hold', 'lost'));
+
+CREATE TABLE IF NOT EXISTS holds (
+    id        BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT        NOT NULL REFERENCES tenants (id),
+    book_id   BIGINT      NOT NULL REFERENCES books (id),
+    member_id TEXT        NOT NULL,
+    status    TEXT        NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'ready', 'fulfilled', 'expired', 'cancelled')),
+    copy_id   BIGINT REFERENCES copies (id),
+    placed_at TIMESTAMPTZ NOT NULL,
+    ready_at  TIMESTAMPTZ,
+    pickup_by TIMESTAMPTZ,
+    closed_at TIMESTAMPTZ
+);
+
+-- A member waits in a book's queue at most once.
+CREATE UNIQUE INDEX IF NOT EXISTS holds_one_active_per_member
+    ON holds (book_id, member_id) WHERE status IN ('queued', 'ready');
+-- A copy is set aside for at most one hold.
+CREATE UNIQUE INDEX IF NOT EXISTS holds_one_ready_per_copy
+    ON holds (copy_id) WHERE status = 'ready';
+CREATE INDEX IF NOT EXISTS holds_queue_idx ON holds (book_id, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS holds_pickup_idx ON holds (tenant_id, pickup_by) WHERE status = 'ready';
+
+ALTER TABLE holds ENABLE ROW LEVEL SECURITY;
+ALTER TABLE holds FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS holds_tenant_isolation ON holds;
+CREATE POLICY holds_tenant_isolation ON holds
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,55 @@
+-- Categories form a tree per tenant. path is the materialized path of IDs
+-- from the root, e.g. '/1/4/9/', so a subtree is every row whose path
+-- starts with its root's path.
+CREATE TABLE IF NOT EXISTS categories (
+    id         BIGSERIAL PRIMARY KEY,
+    tenant_id  TEXT        NOT NULL REFERENCES tenants (id),
+    parent_id  BIGINT REFERENCES categories (id),
+    slug       TEXT        NOT NULL,
+    name       TEXT        NOT NULL,
+    path       TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, slug)
+);
+
+CREATE INDEX IF NOT EXISTS categories_path_idx ON categories (tenant_id, path text_pattern_ops);
+CREATE INDEX IF NOT EXISTS categories_parent_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS book_categories (
+    book_id     BIGINT NOT NULL REFERENCES books (id),
+    category_id BIGINT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    tenant_id   TEXT   NOT NULL REFERENCES tenants (id),
+    PRIMARY KEY (book_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_categories_category_idx ON book_categories (category_id);
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id   BIGINT NOT NULL REFERENCES books (id),
+    tag       TEXT   NOT NULL,
+    tenant_id TEXT   NOT NULL REFERENCES tenants (id),
+    PRIMARY KEY (book_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS book_tags_tag_idx ON book_tags (tenant_id, tag);
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS categories_tenant_isolation ON categories;
+CREATE POLICY categories_tenant_isolation ON categories
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE book_categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_categories FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_categories_tenant_isolation ON book_categories;
+CREATE POLICY book_categories_tenant_isolation ON book_categories
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE book_tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_tags FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_tags_tenant_isolation ON book_tags;
+CREATE POLICY book_tags_tenant_isolation ON book_tags
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,11 @@
+-- norm_key is domain.BookKey(title, author). It is computed by the
+-- application, so rows from before this migration start out NULL and are
+-- filled in by the backfill run at startup.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS norm_key TEXT;
+-- allow_duplicate marks books an admin created despite a duplicate, and
+-- older duplicates found by the backfill. They are left out of the unique
+-- index so they neither block nor are blocked.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS allow_duplicate BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX IF NOT EXISTS books_norm_key_unique
+    ON books (tenant_id, norm_key) WHERE deleted_at IS NULL AND NOT allow_duplicate;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id               BIGSERIAL PRIMARY KEY,
+    tenant_id        TEXT        NOT NULL REFERENCES tenants (id),
+    format           TEXT        NOT NULL CHECK (format IN ('csv', 'jsonl')),
+    status           TEXT        NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    blob_key         TEXT        NOT NULL,
+    processed        INT         NOT NULL DEFAULT 0,
+    created          INT         NOT NULL DEFAULT 0,
+    failed           INT         NOT NULL DEFAULT 0,
+    error            TEXT        NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN     NOT NULL DEFAULT false,
+    created_by       TEXT        NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at       TIMESTAMPTZ,
+    heartbeat_at     TIMESTAMPTZ,
+    finished_at      TIMESTAMPTZ
+);
+
+-- Workers look for queued jobs, and for running ones whose heartbeat stopped.
+CREATE INDEX IF NOT EXISTS import_jobs_pending_idx
+    ON import_jobs (tenant_id, id) WHERE status IN ('queued', 'running');
+
+CREATE TABLE IF NOT EXISTS import_errors (
+    job_id    BIGINT NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+    tenant_id TEXT   NOT NULL REFERENCES tenants (id),
+    row_no    INT    NOT NULL,
+    title     TEXT   NOT NULL,
+    author    TEXT   NOT NULL,
+    message   TEXT   NOT NULL,
+    PRIMARY KEY (job_id, row_no)
+);
+
+ALTER TABLE import_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE import_jobs FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS import_jobs_tenant_isolation ON import_jobs;
+CREATE POLICY import_jobs_tenant_isolation ON import_jobs
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE import_errors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE import_errors FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS import_errors_tenant_isolation ON import_errors;
+CREATE POLICY import_errors_tenant_isolation ON import_errors
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,34 @@
+-- change_seq orders the writes to a tenant's books for offline sync: every
+-- insert or change of a synced column takes the tenant's next number from
+-- book_change_seqs. The counter row stays locked until the writing
+-- transaction ends, so numbers commit in the order they were taken and a
+-- reader never sees a number before an earlier one. Books written before
+-- this migration keep 0 until they next change.
+CREATE TABLE IF NOT EXISTS book_change_seqs (
+    tenant_id TEXT   PRIMARY KEY REFERENCES tenants (id),
+    seq       BIGINT NOT NULL
+);
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS books_change_seq_idx ON books (tenant_id, change_seq, id);
+
+CREATE OR REPLACE FUNCTION books_next_change_seq() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO book_change_seqs AS s (tenant_id, seq) VALUES (NEW.tenant_id, 1)
+    ON CONFLICT (tenant_id) DO UPDATE SET seq = s.seq + 1
+    RETURNING s.seq INTO NEW.change_seq;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_change_seq ON books;
+CREATE TRIGGER books_change_seq
+    BEFORE INSERT OR UPDATE OF title, author, deleted_at, cover_etag, rating_sum, rating_count ON books
+    FOR EACH ROW EXECUTE FUNCTION books_next_change_seq();
+
+ALTER TABLE book_change_seqs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_change_seqs FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_change_seqs_tenant_isolation ON book_change_seqs;
+CREATE POLICY book_change_seqs_tenant_isolation ON book_change_seqs
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,68 @@
+-- Storage of the event-sourced book repository (package eventsource). It
+-- shares nothing with the books table: book_streams holds the events,
+-- book_stream_snapshots the periodic snapshots, and book_stream_states the
+-- read model projected from them, which can be rebuilt from the events.
+CREATE SEQUENCE IF NOT EXISTS book_stream_ids;
+
+CREATE TABLE IF NOT EXISTS book_streams (
+    position  BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT        NOT NULL REFERENCES tenants (id),
+    book_id   BIGINT      NOT NULL,
+    version   INT         NOT NULL CHECK (version > 0),
+    kind      TEXT        NOT NULL,
+    data      JSONB       NOT NULL,
+    at        TIMESTAMPTZ NOT NULL,
+    -- Optimistic concurrency: two writers appending the same version of a
+    -- book cannot both commit.
+    CONSTRAINT book_streams_version_unique UNIQUE (book_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS book_streams_tenant_idx ON book_streams (tenant_id, position);
+
+CREATE TABLE IF NOT EXISTS book_stream_snapshots (
+    book_id   BIGINT PRIMARY KEY,
+    tenant_id TEXT        NOT NULL REFERENCES tenants (id),
+    version   INT         NOT NULL,
+    state     JSONB       NOT NULL,
+    at        TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS book_stream_states (
+    id           BIGINT PRIMARY KEY,
+    tenant_id    TEXT        NOT NULL REFERENCES tenants (id),
+    title        TEXT        NOT NULL,
+    author       TEXT        NOT NULL,
+    norm_key     TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    cover        JSONB,
+    rating_sum   INT         NOT NULL DEFAULT 0,
+    rating_count INT         NOT NULL DEFAULT 0,
+    deleted      BOOLEAN     NOT NULL DEFAULT false,
+    forced       BOOLEAN     NOT NULL DEFAULT false,
+    version      INT         NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS book_stream_states_tenant_idx ON book_stream_states (tenant_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS book_stream_states_norm_key_unique
+    ON book_stream_states (tenant_id, norm_key) WHERE NOT deleted AND NOT forced;
+
+ALTER TABLE book_streams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_streams FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_streams_tenant_isolation ON book_streams;
+CREATE POLICY book_streams_tenant_isolation ON book_streams
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE book_stream_snapshots ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_stream_snapshots FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_stream_snapshots_tenant_isolation ON book_stream_snapshots;
+CREATE POLICY book_stream_snapshots_tenant_isolation ON book_stream_snapshots
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE book_stream_states ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_stream_states FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_stream_states_tenant_isolation ON book_stream_states;
+CREATE POLICY book_stream_states_tenant_isolation ON book_stream_states
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
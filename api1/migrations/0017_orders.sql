@@ -0,0 +1,103 @@
+-- Prices are in cents, like lib.Money. The CHECK on stock is the backstop
+-- for the conditional decrement at checkout.
+CREATE TABLE IF NOT EXISTS book_inventory (
+    book_id     BIGINT      PRIMARY KEY REFERENCES books (id),
+    tenant_id   TEXT        NOT NULL REFERENCES tenants (id),
+    price_cents BIGINT      NOT NULL CHECK (price_cents >= 0),
+    stock       BIGINT      NOT NULL CHECK (stock >= 0),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS cart_lines (
+    tenant_id  TEXT        NOT NULL REFERENCES tenants (id),
+    customer   TEXT        NOT NULL,
+    book_id    BIGINT      NOT NULL REFERENCES books (id),
+    qty        BIGINT      NOT NULL CHECK (qty > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, customer, book_id)
+);
+
+-- The totals are what lib.CalculateTotals gave at checkout, kept as they
+-- were rather than worked out again from the lines.
+CREATE TABLE IF NOT EXISTS orders (
+    id             BIGSERIAL PRIMARY KEY,
+    tenant_id      TEXT        NOT NULL REFERENCES tenants (id),
+    customer       TEXT        NOT NULL,
+    status         TEXT        NOT NULL DEFAULT 'placed',
+    coupon         TEXT        NOT NULL DEFAULT '',
+    discount_pct   INT         NOT NULL,
+    tax_pct        INT         NOT NULL,
+    subtotal_cents BIGINT      NOT NULL,
+    discount_cents BIGINT      NOT NULL,
+    tax_cents      BIGINT      NOT NULL,
+    total_cents    BIGINT      NOT NULL,
+    placed_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS orders_customer_idx ON orders (tenant_id, customer, id DESC);
+
+CREATE TABLE IF NOT EXISTS order_lines (
+    order_id         BIGINT NOT NULL REFERENCES orders (id),
+    tenant_id        TEXT   NOT NULL REFERENCES tenants (id),
+    book_id          BIGINT NOT NULL REFERENCES books (id),
+    title            TEXT   NOT NULL,
+    qty              BIGINT NOT NULL CHECK (qty > 0),
+    unit_price_cents BIGINT NOT NULL,
+    PRIMARY KEY (order_id, book_id)
+);
+
+-- An order is a record of a sale: its lines and totals never change.
+CREATE OR REPLACE FUNCTION orders_keep_totals() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.customer, NEW.coupon, NEW.discount_pct, NEW.tax_pct,
+        NEW.subtotal_cents, NEW.discount_cents, NEW.tax_cents, NEW.total_cents, NEW.placed_at)
+       IS DISTINCT FROM
+       (OLD.customer, OLD.coupon, OLD.discount_pct, OLD.tax_pct,
+        OLD.subtotal_cents, OLD.discount_cents, OLD.tax_cents, OLD.total_cents, OLD.placed_at) THEN
+        RAISE EXCEPTION 'order % is immutable', OLD.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_immutable ON orders;
+CREATE TRIGGER orders_immutable BEFORE UPDATE ON orders
+    FOR EACH ROW EXECUTE FUNCTION orders_keep_totals();
+
+CREATE OR REPLACE FUNCTION order_lines_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'order lines are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS order_lines_immutable ON order_lines;
+CREATE TRIGGER order_lines_immutable BEFORE UPDATE OR DELETE ON order_lines
+    FOR EACH ROW EXECUTE FUNCTION order_lines_immutable();
+
+ALTER TABLE book_inventory ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_inventory FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_inventory_tenant_isolation ON book_inventory;
+CREATE POLICY book_inventory_tenant_isolation ON book_inventory
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE cart_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE cart_lines FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS cart_lines_tenant_isolation ON cart_lines;
+CREATE POLICY cart_lines_tenant_isolation ON cart_lines
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orders FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS orders_tenant_isolation ON orders;
+CREATE POLICY orders_tenant_isolation ON orders
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE order_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_lines FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_lines_tenant_isolation ON order_lines;
+CREATE POLICY order_lines_tenant_isolation ON order_lines
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,90 @@
+-- Stock is the sum of a book's ledger in stock_movements; book_stock holds
+-- the row every write to the ledger locks with SELECT ... FOR UPDATE
+-- first, so that checking the level and changing it are one step, and the
+-- book's low-stock threshold.
+CREATE TABLE IF NOT EXISTS book_stock (
+    book_id             BIGINT PRIMARY KEY REFERENCES books (id),
+    tenant_id           TEXT   NOT NULL REFERENCES tenants (id),
+    low_stock_threshold BIGINT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id         BIGSERIAL PRIMARY KEY,
+    tenant_id  TEXT        NOT NULL REFERENCES tenants (id),
+    book_id    BIGINT      NOT NULL REFERENCES books (id),
+    qty        BIGINT      NOT NULL CHECK (qty > 0),
+    status     TEXT        NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'sold', 'released', 'expired')),
+    ref        TEXT        NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    closed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservations_expiry_idx ON stock_reservations (tenant_id, expires_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id             BIGSERIAL PRIMARY KEY,
+    tenant_id      TEXT        NOT NULL REFERENCES tenants (id),
+    book_id        BIGINT      NOT NULL REFERENCES books (id),
+    kind           TEXT        NOT NULL CHECK (kind IN ('received', 'adjusted', 'reserved', 'released', 'sold')),
+    qty            BIGINT      NOT NULL CHECK (qty > 0 OR kind = 'adjusted'),
+    reservation_id BIGINT      REFERENCES stock_reservations (id),
+    note           TEXT        NOT NULL DEFAULT '',
+    at             TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_book_idx ON stock_movements (tenant_id, book_id, id);
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock movements are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+CREATE TRIGGER stock_movements_append_only BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+ALTER TABLE order_lines ADD COLUMN IF NOT EXISTS reservation_id BIGINT REFERENCES stock_reservations (id);
+
+ALTER TABLE book_stock ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_stock FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS book_stock_tenant_isolation ON book_stock;
+CREATE POLICY book_stock_tenant_isolation ON book_stock
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE stock_reservations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_reservations FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS stock_reservations_tenant_isolation ON stock_reservations;
+CREATE POLICY stock_reservations_tenant_isolation ON stock_reservations
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE stock_movements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_movements FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS stock_movements_tenant_isolation ON stock_movements;
+CREATE POLICY stock_movements_tenant_isolation ON stock_movements
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- The stock counted on book_inventory opens each book's ledger. The
+-- policies only show one tenant at a time, so go through them in turn.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'book_inventory' AND column_name = 'stock') THEN
+        FOR t IN SELECT id FROM tenants LOOP
+            PERFORM set_config('app.tenant_id', t, true);
+            INSERT INTO stock_movements (tenant_id, book_id, kind, qty, note, at)
+            SELECT tenant_id, book_id, 'received', stock, 'opening stock', now()
+            FROM book_inventory
+            WHERE tenant_id = t AND stock > 0;
+        END LOOP;
+        PERFORM set_config('app.tenant_id', '', true);
+        ALTER TABLE book_inventory DROP COLUMN stock;
+    END IF;
+END;
+$$;
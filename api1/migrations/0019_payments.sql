@@ -0,0 +1,23 @@
+-- A paid order has one payment. Amounts are in cents, like lib.Money.
+CREATE TABLE IF NOT EXISTS payments (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant_id    TEXT        NOT NULL REFERENCES tenants (id),
+    order_id     BIGINT      NOT NULL UNIQUE REFERENCES orders (id),
+    status       TEXT        NOT NULL CHECK (status IN ('pending', 'authorized', 'captured', 'declined', 'voided', 'refunded')),
+    amount_cents BIGINT      NOT NULL CHECK (amount_cents >= 0),
+    gateway_ref  TEXT        NOT NULL DEFAULT '',
+    reason       TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+-- Reconciliation looks for payments left part way.
+CREATE INDEX IF NOT EXISTS payments_open_idx ON payments (tenant_id, updated_at)
+    WHERE status IN ('pending', 'authorized');
+
+ALTER TABLE payments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payments FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS payments_tenant_isolation ON payments;
+CREATE POLICY payments_tenant_isolation ON payments
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
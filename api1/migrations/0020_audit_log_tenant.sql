@@ -0,0 +1,16 @@
+-- Audit records belong to the tenant whose book changed. Records written
+-- before tenancy belong to the default tenant, like their books. Adding the
+-- column rewrites no rows through the append-only trigger, which only
+-- fires on UPDATE and DELETE statements.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE audit_log ALTER COLUMN tenant_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS audit_log_entity_idx;
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (tenant_id, entity_type, entity_id, id);
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS audit_log_tenant_isolation ON audit_log;
+CREATE POLICY audit_log_tenant_isolation ON audit_log
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
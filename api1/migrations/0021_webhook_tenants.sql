@@ -0,0 +1,37 @@
+-- Webhooks belong to a tenant: each manages its own endpoints and only
+-- hears about its own books. The outbox records whose event it holds so
+-- the dispatcher can tell; the relay reads every tenant's messages, so the
+-- outbox itself stays without policies. Rows from before tenancy belong to
+-- the default tenant, like their books.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE outbox ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE webhook_endpoints ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS webhook_endpoints_tenant_idx ON webhook_endpoints (tenant_id, id);
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE webhook_deliveries ALTER COLUMN tenant_id DROP DEFAULT;
+UPDATE webhook_deliveries d SET tenant_id = e.tenant_id
+FROM webhook_endpoints e
+WHERE e.id = d.endpoint_id AND d.tenant_id <> e.tenant_id;
+
+-- The sender claims due deliveries a tenant at a time.
+DROP INDEX IF EXISTS webhook_deliveries_due_idx;
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries (tenant_id, next_attempt_at)
+    WHERE status = 'pending';
+
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhook_endpoints_tenant_isolation ON webhook_endpoints;
+CREATE POLICY webhook_endpoints_tenant_isolation ON webhook_endpoints
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhook_deliveries_tenant_isolation ON webhook_deliveries;
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,31 @@
+-- 0015 numbered a write as it was made, so the tenant's counter row stayed
+-- locked from then until the writing transaction ended, and every writer of
+-- a tenant queued behind the slowest one. The number is now taken when the
+-- transaction commits: a deferred trigger bumps the counter as the last
+-- thing before COMMIT, so the row is only locked while the transaction
+-- commits, and numbers still commit in the order they were taken.
+--
+-- A book written several times in one transaction takes several numbers,
+-- the last of which sticks. The gaps this leaves are harmless: sync clients
+-- only rely on the order.
+CREATE OR REPLACE FUNCTION books_number_change() RETURNS trigger AS $$
+DECLARE
+    next BIGINT;
+BEGIN
+    INSERT INTO book_change_seqs AS s (tenant_id, seq) VALUES (NEW.tenant_id, 1)
+    ON CONFLICT (tenant_id) DO UPDATE SET seq = s.seq + 1
+    RETURNING s.seq INTO next;
+    -- change_seq is not among the trigger's columns, so this does not fire
+    -- it again.
+    UPDATE books SET change_seq = next WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_change_seq ON books;
+CREATE CONSTRAINT TRIGGER books_change_seq
+    AFTER INSERT OR UPDATE OF title, author, deleted_at, cover_etag, rating_sum, rating_count ON books
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION books_number_change();
+
+DROP FUNCTION IF EXISTS books_next_change_seq();
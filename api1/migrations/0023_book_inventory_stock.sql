@@ -0,0 +1,87 @@
+-- 0018 dropped book_inventory.stock once the ledger had taken over; it is
+-- back, kept as the copies of each book available to sell, so that what
+-- reads the column still finds the stock as the ledger has it. Triggers
+-- keep it in step: a new inventory row starts from the ledger, and every
+-- movement moves it on by what it does to the available copies.
+ALTER TABLE book_inventory ADD COLUMN IF NOT EXISTS stock BIGINT NOT NULL DEFAULT 0 CHECK (stock >= 0);
+ALTER TABLE book_inventory ALTER COLUMN stock SET DEFAULT 0;
+
+-- Run again with the column back, 0018 would open the ledger of every
+-- book in stock a second time before dropping the column once more. A
+-- ledger is opened once: an opening movement for a book that has
+-- movements already is skipped, and the backfill below restores the
+-- column.
+CREATE OR REPLACE FUNCTION stock_movements_open_once() RETURNS trigger AS $$
+BEGIN
+    IF NEW.note = 'opening stock' AND EXISTS (
+        SELECT 1 FROM stock_movements
+        WHERE tenant_id = NEW.tenant_id AND book_id = NEW.book_id) THEN
+        RETURN NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_open_once ON stock_movements;
+CREATE TRIGGER stock_movements_open_once BEFORE INSERT ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_open_once();
+
+-- book_available_stock adds up the available copies of a book as
+-- domain.StockLevel does.
+CREATE OR REPLACE FUNCTION book_available_stock(t TEXT, book BIGINT) RETURNS BIGINT AS $$
+    SELECT coalesce(sum(CASE WHEN kind IN ('received', 'adjusted', 'released') THEN qty
+                             WHEN kind = 'reserved' THEN -qty ELSE 0 END), 0)::bigint
+    FROM stock_movements
+    WHERE tenant_id = t AND book_id = book;
+$$ LANGUAGE sql STABLE;
+
+-- A new inventory row takes the book's stock lock first, as writers to the
+-- ledger do, so that no movement slips in between the sum and the insert.
+CREATE OR REPLACE FUNCTION book_inventory_open_stock() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO book_stock (book_id, tenant_id) VALUES (NEW.book_id, NEW.tenant_id)
+    ON CONFLICT (book_id) DO NOTHING;
+    PERFORM 1 FROM book_stock WHERE book_id = NEW.book_id FOR UPDATE;
+    NEW.stock := book_available_stock(NEW.tenant_id, NEW.book_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS book_inventory_open_stock ON book_inventory;
+CREATE TRIGGER book_inventory_open_stock BEFORE INSERT ON book_inventory
+    FOR EACH ROW EXECUTE FUNCTION book_inventory_open_stock();
+
+-- A sale takes copies that were already reserved, so it leaves the
+-- available copies as they were.
+CREATE OR REPLACE FUNCTION book_inventory_follow_stock() RETURNS trigger AS $$
+DECLARE
+    delta BIGINT;
+BEGIN
+    delta := CASE WHEN NEW.kind IN ('received', 'adjusted', 'released') THEN NEW.qty
+                  WHEN NEW.kind = 'reserved' THEN -NEW.qty ELSE 0 END;
+    IF delta <> 0 THEN
+        UPDATE book_inventory SET stock = stock + delta
+        WHERE tenant_id = NEW.tenant_id AND book_id = NEW.book_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS book_inventory_follow_stock ON stock_movements;
+CREATE TRIGGER book_inventory_follow_stock AFTER INSERT ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION book_inventory_follow_stock();
+
+-- Bring rows from before the triggers into line with their ledgers, a
+-- tenant at a time as the policies allow.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOR t IN SELECT id FROM tenants LOOP
+        PERFORM set_config('app.tenant_id', t, true);
+        UPDATE book_inventory SET stock = book_available_stock(tenant_id, book_id)
+        WHERE tenant_id = t AND stock <> book_available_stock(tenant_id, book_id);
+    END LOOP;
+    PERFORM set_config('app.tenant_id', '', true);
+END;
+$$;